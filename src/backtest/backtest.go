@@ -0,0 +1,261 @@
+// go run ./src/backtest -rates rates.json -symbols RUB,EUR -out ./backtest-out
+// go run ./src/backtest -rates rates.json -search grid -ranges 4,6,8 -frame 3,5,8 -limit 20,50 -hidden 0,5,10
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction"
+	"pr.optima/src/repository"
+)
+
+var (
+	ratesPath = flag.String("rates", "", "JSON file with the array of exported rates")
+	symbols   = flag.String("symbols", "RUB,EUR,GBP,JPY,CNY,CHF", "comma separated list of the symbols")
+	trainType = flag.String("train", prediction.TTLbfgs, "train type, one of: "+strings.Join(trainTypes(), ", "))
+	netType   = flag.String("net", prediction.NTRegression, "network type: regression or classifier")
+	ranges    = flag.String("ranges", "6", "count of the range classes, comma separated values for search")
+	frame     = flag.String("frame", "5", "count of the class history inputs, comma separated values for search")
+	limit     = flag.String("limit", "20", "count of the rates used for training, comma separated values for search")
+	hidden    = flag.String("hidden", "", "size of the hidden layer (0 - no hidden layer), comma separated values for search, frame size if empty")
+	decay     = flag.String("decay", "", "weight decay, comma separated values for search, train type default if empty")
+	restarts  = flag.String("restarts", "", "count of the training restarts, comma separated values for search, train type default if empty")
+	horizons  = flag.String("horizons", "1,4,12,24", "comma separated steps ahead, empty value for one step only")
+	window    = flag.Int("window", 200, "count of the newest rates passed on each step (size of the rates repo)")
+	outDir    = flag.String("out", "", "directory for ResultData and Efficiency records in JSON, nothing is written if empty")
+	search    = flag.String("search", "", "hyperparameter search instead of the single backtest: grid or random")
+	samples   = flag.Int("samples", 20, "count of the candidates of the random search")
+	workers   = flag.Int("workers", 0, "count of the parallel evaluations of the search, count of CPU if 0")
+	top       = flag.Int("top", 10, "count of the best search results printed per symbol")
+)
+
+func main() {
+	flag.Parse()
+	if *ratesPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rates, err := loadRates(*ratesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	steps, err := parseInts(*horizons)
+	if err != nil {
+		log.Fatal(err)
+	}
+	space, err := parseSpace()
+	if err != nil {
+		log.Fatal(err)
+	}
+	rateRepo := repository.NewMemoryRateRepo(len(rates), false, rates)
+	log.Printf("Loaded rates: %d", rateRepo.Len())
+
+	var symbolList []string
+	for _, symbol := range strings.Split(*symbols, ",") {
+		symbolList = append(symbolList, strings.ToUpper(strings.TrimSpace(symbol)))
+	}
+
+	if *search != "" {
+		runSearch(rateRepo.GetAll(), symbolList, space, steps)
+		return
+	}
+
+	candidates, err := space.Grid()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(candidates) != 1 {
+		log.Fatal("single value of each hyperparameter required, use -search for the lists")
+	}
+	c := candidates[0]
+	for _, symbol := range symbolList {
+		engine, err := prediction.NewEngine(c.RangeCount, c.Frame, c.Limit, *trainType, *netType, symbol)
+		if err == nil {
+			engine, err = engine.WithHorizons(prediction.HMRecursive, steps...)
+		}
+		if err == nil {
+			engine, err = c.Apply(engine)
+		}
+		if err != nil {
+			log.Fatalf("%s create engine error: %v", symbol, err)
+		}
+
+		resultRepo := repository.NewMemoryResultDataRepo(engine.ResultsLimit(), true, engine.Model(), symbol)
+		effRepo := repository.NewMemoryEfficiencyRepo(engine.Model(), symbol, int32(c.RangeCount), int32(c.Limit), int32(c.Frame))
+		report, err := prediction.Backtest(engine, rateRepo.GetAll(), *window, resultRepo, effRepo, nil)
+		if err != nil {
+			log.Fatalf("%s backtest error: %v", symbol, err)
+		}
+		fmt.Println(report.ToString())
+
+		if *outDir != "" {
+			name := fmt.Sprintf("%s_%s", symbol, engine.Model())
+			if err := saveJSON(filepath.Join(*outDir, name+"_results.json"), resultRepo.History()); err != nil {
+				log.Fatal(err)
+			}
+			if err := saveJSON(filepath.Join(*outDir, name+"_efficiency.json"), effRepo.GetAll()); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+func runSearch(rates []entities.Rate, symbolList []string, space *prediction.SearchSpace, steps []int) {
+	var candidates []prediction.Candidate
+	var err error
+	switch *search {
+	case "grid":
+		candidates, err = space.Grid()
+	case "random":
+		candidates, err = space.Random(*samples, rand.New(rand.NewSource(time.Now().UnixNano())))
+	default:
+		err = fmt.Errorf("unknown search: '%s'", *search)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Search candidates: %d, symbols: %d", len(candidates), len(symbolList))
+
+	results := prediction.Search(rates, symbolList, candidates, prediction.SearchConfig{
+		TrainType: *trainType,
+		NetType:   *netType,
+		Horizons:  steps,
+		Window:    *window,
+		Workers:   *workers})
+	for _, symbol := range symbolList {
+		fmt.Printf("%s best candidates:\n", symbol)
+		printed := 0
+		for _, item := range results {
+			if item.Symbol == symbol && printed < *top {
+				fmt.Println(item.ToString())
+				printed++
+			}
+		}
+	}
+	if *outDir != "" {
+		if err := saveJSON(filepath.Join(*outDir, "search.json"), results); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// trainTypes return names of the networks, ensembles, predictors and baselines
+func trainTypes() []string {
+	result := append(prediction.TrainTypes(), prediction.EnsembleTypes()...)
+	result = append(result, prediction.Predictors()...)
+	return append(result, prediction.Baselines()...)
+}
+
+// parseSpace convert hyperparameter flags to the search space, empty flags are replaced by defaults
+func parseSpace() (*prediction.SearchSpace, error) {
+	var params prediction.TrainParams
+	var err error
+	if prediction.IsBaseline(*trainType) {
+		// baselines aren't trained, the model hyperparameters have no effect on them
+		if *hidden != "" || *decay != "" || *restarts != "" {
+			return nil, fmt.Errorf("baseline '%s' doesn't accept hidden, decay and restarts values", *trainType)
+		}
+	} else if params, err = prediction.DefaultTrainParams(*trainType); err != nil {
+		return nil, err
+	}
+	result := new(prediction.SearchSpace)
+	if result.RangeCounts, err = parseInts(*ranges); err != nil {
+		return nil, err
+	}
+	if result.Frames, err = parseInts(*frame); err != nil {
+		return nil, err
+	}
+	if result.Limits, err = parseInts(*limit); err != nil {
+		return nil, err
+	}
+	if result.Hidden, err = parseInts(*hidden); err != nil {
+		return nil, err
+	}
+	if result.Restarts, err = parseInts(*restarts); err != nil {
+		return nil, err
+	}
+	if result.Decays, err = parseFloats(*decay); err != nil {
+		return nil, err
+	}
+	if len(result.Hidden) == 0 {
+		// the network of the live job has hidden layer of the frame size
+		result.Hidden = []int{-1}
+	}
+	if len(result.Restarts) == 0 {
+		result.Restarts = []int{params.Network.Restarts}
+	}
+	if len(result.Decays) == 0 {
+		result.Decays = []float64{params.Network.Decay}
+	}
+	if err := result.Validate(*trainType); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func loadRates(path string) ([]entities.Rate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rates []entities.Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("rates file '%s' error: %v", path, err)
+	}
+	// the repo requires rates sorted by timestamp
+	prediction.SortRates(rates)
+	return rates, nil
+}
+
+func parseInts(value string) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		v, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("value '%s' error: %v", item, err)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func parseFloats(value string) ([]float64, error) {
+	var result []float64
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value '%s' error: %v", item, err)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func saveJSON(path string, value interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"fmt"
+)
+
+// CorrelationMatrix struct - correlation of the rate changes of the symbols over the window
+type CorrelationMatrix struct {
+	Window       int32       `json:"window"`
+	Method       string      `json:"method"`       // pearson or spearman
+	Coefficients [][]float64 `json:"coefficients"` // rows and columns are aligned with CorrelationResponse.Symbols
+	PValues      [][]float64 `json:"pValues"`      // two-tailed p-values of the zero correlation
+}
+
+// ToString method
+func (f *CorrelationMatrix) ToString() string {
+	return fmt.Sprintf("CorrelationMatrix { Window: %d; Method: %s; Coefficients: %v; PValues: %v }",
+		f.Window,
+		f.Method,
+		f.Coefficients,
+		f.PValues)
+}
+
+// CorrelationResponse struct - correlation matrices of the cross-currency rate changes
+type CorrelationResponse struct {
+	Timestamp int64               `json:"timestamp"` // timestamp of the latest rate
+	Symbols   []string            `json:"symbols"`
+	Matrices  []CorrelationMatrix `json:"matrices"`
+}
+
+// ToString method
+func (f *CorrelationResponse) ToString() string {
+	result := fmt.Sprintf("CorrelationResponse { Timestamp: %d; Symbols: %v; Matrices:", f.Timestamp, f.Symbols)
+	for i := range f.Matrices {
+		result += " " + f.Matrices[i].ToString()
+	}
+	return result + " }"
+}
@@ -15,17 +15,59 @@ type Efficiency struct {
 	Symbol      string  `datastore:"symbol,index" json:"symbol"`
 	LastSD      []int32 `datastore:"lastSD,noindex" json:"lastSD"`
 	Timestamp   int64   `datastore:"timestamp,index" json:"timestamp"`
+	// multi-step forecast scores, counters are aligned with Horizons
+	Horizons             []int32 `datastore:"horizons,noindex" json:"horizons"`
+	HorizonCount         []int32 `datastore:"horizonCount,noindex" json:"horizonCount"`
+	HorizonHits          []int32 `datastore:"horizonHits,noindex" json:"horizonHits"`
+	HorizonDirectionHits []int32 `datastore:"horizonDirectionHits,noindex" json:"horizonDirectionHits"`
+	// one step scores per market regime, counters are indexed by the regime
+	RegimeCount         []int32 `datastore:"regimeCount,noindex" json:"regimeCount"`
+	RegimeHits          []int32 `datastore:"regimeHits,noindex" json:"regimeHits"`
+	RegimeDirectionHits []int32 `datastore:"regimeDirectionHits,noindex" json:"regimeDirectionHits"`
+	// cumulative one step scores
+	Count         int32   `datastore:"count,noindex" json:"count"`
+	Hits          int32   `datastore:"hits,noindex" json:"hits"`
+	DirectionHits int32   `datastore:"directionHits,noindex" json:"directionHits"`
+	AbsErrorSum   int32   `datastore:"absErrorSum,noindex" json:"absErrorSum"`
+	Confusion     []int32 `datastore:"confusion,noindex" json:"confusion"`     // RangesCount x RangesCount, row - result class, column - predicted class
+	ResultCount   []int32 `datastore:"resultCount,noindex" json:"resultCount"` // count of the results per class, including the results of invalid predictions
+	LastResult    int32   `datastore:"lastResult,noindex" json:"lastResult"`
+	// probabilistic scores, updated when the prediction has class probabilities
+	BrierCount            int32     `datastore:"brierCount,noindex" json:"brierCount"`
+	BrierSum              float64   `datastore:"brierSum,noindex" json:"brierSum"`
+	CalibrationCount      []int32   `datastore:"calibrationCount,noindex" json:"calibrationCount"` // predictions per confidence bin
+	CalibrationHits       []int32   `datastore:"calibrationHits,noindex" json:"calibrationHits"`
+	CalibrationConfidence []float64 `datastore:"calibrationConfidence,noindex" json:"calibrationConfidence"` // sum of the confidence per bin
+	// naive baselines scores, counters are aligned with Baselines
+	Baselines             []string `datastore:"baselines,noindex" json:"baselines"`
+	BaselineHits          []int32  `datastore:"baselineHits,noindex" json:"baselineHits"`
+	BaselineDirectionHits []int32  `datastore:"baselineDirectionHits,noindex" json:"baselineDirectionHits"`
 }
 
+const (
+	// BLPersistence - naive baseline predicting the last result class
+	BLPersistence = "persistence"
+	// BLMajority - naive baseline predicting the most frequent result class
+	BLMajority = "majority"
+
+	calibrationBins = 10
+)
+
 // ToString method
 func (f *Efficiency) ToString() string {
-	return fmt.Sprintf("Efficiency {: Symbol: %s, Ranges: %d, Limit: %d, Frame: %d, DirectionRate10: %v, DirectionRate100: %v, Timestamp: %v }",
+	return fmt.Sprintf("Efficiency {: Symbol: %s, Ranges: %d, Limit: %d, Frame: %d, DirectionRate10: %v, DirectionRate100: %v, HitRate: %v, DirectionRate: %v, MAE: %v, Brier: %v, Persistence: %v, Majority: %v, Timestamp: %v }",
 		f.Symbol,
 		f.RangesCount,
 		f.Limit,
 		f.Frame,
 		f.GetDirectionRate10(),
 		f.GetDirectionRate100(),
+		f.GetHitRate(),
+		f.GetDirectionRate(),
+		f.GetMAE(),
+		f.GetBrierScore(),
+		f.GetBaselineDirectionRate(BLPersistence),
+		f.GetBaselineDirectionRate(BLMajority),
 		f.LastUpdate())
 }
 
@@ -55,11 +97,316 @@ func (f *Efficiency) GetDirectionRate100() float64 {
 	return math.NaN()
 }
 
+// AddResult update scores by the one step prediction and its result:
+// direction score is appended to LastSD (last 100 scores are kept), cumulative scores,
+// confusion matrix and baselines are updated, probabilities are optional
+func (f *Efficiency) AddResult(prediction, result int32, probabilities []float64) {
+	isMatch := IsDirectionMatch(prediction, result, f.RangesCount)
+	if isMatch {
+		f.LastSD = append(f.LastSD, 1)
+	} else {
+		f.LastSD = append(f.LastSD, 0)
+	}
+	if len(f.LastSD) > 100 {
+		f.LastSD = f.LastSD[len(f.LastSD)-100:]
+	}
+
+	// baselines are predicted by the scores before the result
+	if f.Count > 0 {
+		f.addBaselineResult(BLPersistence, f.LastResult, result)
+		f.addBaselineResult(BLMajority, f.majorityClass(), result)
+	}
+
+	f.Count++
+	if prediction == result {
+		f.Hits++
+	}
+	if isMatch {
+		f.DirectionHits++
+	}
+	if prediction > result {
+		f.AbsErrorSum += prediction - result
+	} else {
+		f.AbsErrorSum += result - prediction
+	}
+	rc := f.RangesCount
+	if len(f.Confusion) != int(rc*rc) {
+		f.Confusion = make([]int32, rc*rc)
+	}
+	if prediction >= 0 && prediction < rc && result >= 0 && result < rc {
+		f.Confusion[result*rc+prediction]++
+	}
+	if len(f.ResultCount) != int(rc) {
+		f.ResultCount = make([]int32, rc)
+	}
+	if result >= 0 && result < rc {
+		f.ResultCount[result]++
+	}
+	f.LastResult = result
+
+	if len(probabilities) > 0 && int(result) < len(probabilities) {
+		f.BrierCount++
+		for i, p := range probabilities {
+			if int32(i) == result {
+				p--
+			}
+			f.BrierSum += p * p
+		}
+		if len(f.CalibrationCount) != calibrationBins {
+			f.CalibrationCount = make([]int32, calibrationBins)
+			f.CalibrationHits = make([]int32, calibrationBins)
+			f.CalibrationConfidence = make([]float64, calibrationBins)
+		}
+		if prediction >= 0 && int(prediction) < len(probabilities) {
+			confidence := probabilities[prediction]
+			bin := int(confidence * calibrationBins)
+			if bin >= calibrationBins {
+				bin = calibrationBins - 1
+			} else if bin < 0 {
+				bin = 0
+			}
+			f.CalibrationCount[bin]++
+			f.CalibrationConfidence[bin] += confidence
+			if prediction == result {
+				f.CalibrationHits[bin]++
+			}
+		}
+	}
+}
+
+// GetHitRate - part of the predictions with exact class match
+func (f *Efficiency) GetHitRate() float64 {
+	if f.Count == 0 {
+		return math.NaN()
+	}
+	return float64(f.Hits) / float64(f.Count)
+}
+
+// GetDirectionRate - part of the predictions with direction match
+func (f *Efficiency) GetDirectionRate() float64 {
+	if f.Count == 0 {
+		return math.NaN()
+	}
+	return float64(f.DirectionHits) / float64(f.Count)
+}
+
+// GetMAE - mean absolute error in classes
+func (f *Efficiency) GetMAE() float64 {
+	if f.Count == 0 {
+		return math.NaN()
+	}
+	return float64(f.AbsErrorSum) / float64(f.Count)
+}
+
+// GetConfusion return count of the predictions of the class with the result class
+func (f *Efficiency) GetConfusion(result, prediction int32) int32 {
+	rc := f.RangesCount
+	if len(f.Confusion) != int(rc*rc) || result < 0 || result >= rc || prediction < 0 || prediction >= rc {
+		return 0
+	}
+	return f.Confusion[result*rc+prediction]
+}
+
+// GetPrecision - part of the predictions of the class that are correct
+func (f *Efficiency) GetPrecision(class int32) float64 {
+	var predicted int32
+	for result := int32(0); result < f.RangesCount; result++ {
+		predicted += f.GetConfusion(result, class)
+	}
+	if predicted == 0 {
+		return math.NaN()
+	}
+	return float64(f.GetConfusion(class, class)) / float64(predicted)
+}
+
+// GetRecall - part of the results of the class that are predicted
+func (f *Efficiency) GetRecall(class int32) float64 {
+	var actual int32
+	for prediction := int32(0); prediction < f.RangesCount; prediction++ {
+		actual += f.GetConfusion(class, prediction)
+	}
+	if actual == 0 {
+		return math.NaN()
+	}
+	return float64(f.GetConfusion(class, class)) / float64(actual)
+}
+
+// GetBrierScore - mean multi-class Brier score of the predictions with probabilities, 0 is perfect
+func (f *Efficiency) GetBrierScore() float64 {
+	if f.BrierCount == 0 {
+		return math.NaN()
+	}
+	return f.BrierSum / float64(f.BrierCount)
+}
+
+// GetCalibrationError - expected calibration error: weighted mean of |confidence - accuracy| of the confidence bins
+func (f *Efficiency) GetCalibrationError() float64 {
+	var total int32
+	var result float64
+	for i, cnt := range f.CalibrationCount {
+		if cnt == 0 {
+			continue
+		}
+		total += cnt
+		result += math.Abs(f.CalibrationConfidence[i] - float64(f.CalibrationHits[i]))
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	return result / float64(total)
+}
+
+// GetBaselineHitRate - exact hit rate of the naive baseline
+func (f *Efficiency) GetBaselineHitRate(baseline string) float64 {
+	idx := f.baselineIndex(baseline)
+	if idx < 0 || f.Count < 2 {
+		return math.NaN()
+	}
+	return float64(f.BaselineHits[idx]) / float64(f.Count-1)
+}
+
+// GetBaselineDirectionRate - direction rate of the naive baseline
+func (f *Efficiency) GetBaselineDirectionRate(baseline string) float64 {
+	idx := f.baselineIndex(baseline)
+	if idx < 0 || f.Count < 2 {
+		return math.NaN()
+	}
+	return float64(f.BaselineDirectionHits[idx]) / float64(f.Count-1)
+}
+
+// AddHorizonResult update scores of the horizon prediction
+func (f *Efficiency) AddHorizonResult(horizon, prediction, result int32) {
+	idx := f.horizonIndex(horizon)
+	if idx < 0 {
+		f.Horizons = append(f.Horizons, horizon)
+		f.HorizonCount = append(f.HorizonCount, 0)
+		f.HorizonHits = append(f.HorizonHits, 0)
+		f.HorizonDirectionHits = append(f.HorizonDirectionHits, 0)
+		idx = len(f.Horizons) - 1
+	}
+	f.HorizonCount[idx]++
+	if prediction == result {
+		f.HorizonHits[idx]++
+	}
+	if IsDirectionMatch(prediction, result, f.RangesCount) {
+		f.HorizonDirectionHits[idx]++
+	}
+}
+
+// GetHorizonHitRate method
+func (f *Efficiency) GetHorizonHitRate(horizon int32) float64 {
+	idx := f.horizonIndex(horizon)
+	if idx < 0 || f.HorizonCount[idx] == 0 {
+		return math.NaN()
+	}
+	return float64(f.HorizonHits[idx]) / float64(f.HorizonCount[idx])
+}
+
+// GetHorizonDirectionRate method
+func (f *Efficiency) GetHorizonDirectionRate(horizon int32) float64 {
+	idx := f.horizonIndex(horizon)
+	if idx < 0 || f.HorizonCount[idx] == 0 {
+		return math.NaN()
+	}
+	return float64(f.HorizonDirectionHits[idx]) / float64(f.HorizonCount[idx])
+}
+
+// AddRegimeResult update scores of the one step prediction made in the regime
+func (f *Efficiency) AddRegimeResult(regime, prediction, result int32) {
+	if regime < 0 {
+		return
+	}
+	for int(regime) >= len(f.RegimeCount) {
+		f.RegimeCount = append(f.RegimeCount, 0)
+		f.RegimeHits = append(f.RegimeHits, 0)
+		f.RegimeDirectionHits = append(f.RegimeDirectionHits, 0)
+	}
+	f.RegimeCount[regime]++
+	if prediction == result {
+		f.RegimeHits[regime]++
+	}
+	if IsDirectionMatch(prediction, result, f.RangesCount) {
+		f.RegimeDirectionHits[regime]++
+	}
+}
+
+// GetRegimeHitRate method
+func (f *Efficiency) GetRegimeHitRate(regime int32) float64 {
+	if regime < 0 || int(regime) >= len(f.RegimeCount) || f.RegimeCount[regime] == 0 {
+		return math.NaN()
+	}
+	return float64(f.RegimeHits[regime]) / float64(f.RegimeCount[regime])
+}
+
+// GetRegimeDirectionRate method
+func (f *Efficiency) GetRegimeDirectionRate(regime int32) float64 {
+	if regime < 0 || int(regime) >= len(f.RegimeCount) || f.RegimeCount[regime] == 0 {
+		return math.NaN()
+	}
+	return float64(f.RegimeDirectionHits[regime]) / float64(f.RegimeCount[regime])
+}
+
 // LastUpdate method
 func (f *Efficiency) LastUpdate() time.Time {
 	return time.Unix(f.Timestamp, 0).UTC()
 }
 
+func (f *Efficiency) addBaselineResult(baseline string, prediction, result int32) {
+	idx := f.baselineIndex(baseline)
+	if idx < 0 {
+		f.Baselines = append(f.Baselines, baseline)
+		f.BaselineHits = append(f.BaselineHits, 0)
+		f.BaselineDirectionHits = append(f.BaselineDirectionHits, 0)
+		idx = len(f.Baselines) - 1
+	}
+	if prediction == result {
+		f.BaselineHits[idx]++
+	}
+	if IsDirectionMatch(prediction, result, f.RangesCount) {
+		f.BaselineDirectionHits[idx]++
+	}
+}
+
+// majorityClass return the most frequent result class
+func (f *Efficiency) majorityClass() int32 {
+	var result, max int32
+	for class, cnt := range f.ResultCount {
+		if cnt > max {
+			result, max = int32(class), cnt
+		}
+	}
+	return result
+}
+
+func (f *Efficiency) baselineIndex(baseline string) int {
+	for i, item := range f.Baselines {
+		if item == baseline {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *Efficiency) horizonIndex(horizon int32) int {
+	for i, item := range f.Horizons {
+		if item == horizon {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsDirectionMatch - prediction and result completely match or lie on the same side of the middle class
+func IsDirectionMatch(prediction, result, rangesCount int32) bool {
+	if prediction == result {
+		return true
+	}
+	rcHalf := float32(rangesCount-1) / 2
+	fResult := float32(result)
+	fPrediction := float32(prediction)
+	return (rcHalf < fResult && rcHalf < fPrediction) || (rcHalf > fResult && rcHalf > fPrediction)
+}
+
 func intSumm(a []int32, cnt int) float64 {
 	if cnt <= 0 || cnt > len(a) {
 		return math.NaN()
@@ -68,6 +415,7 @@ func intSumm(a []int32, cnt int) float64 {
 	var result int32
 	for i := len(a) - 1; i > -1 && cnt > 0; i-- {
 		result += a[i]
+		cnt--
 	}
 	return float64(result)
 }
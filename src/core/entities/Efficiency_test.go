@@ -0,0 +1,72 @@
+package entities_test
+
+import (
+	"math"
+	"testing"
+
+	"pr.optima/src/core/entities"
+)
+
+func TestEfficiencyMetrics(t *testing.T) {
+	eff := entities.Efficiency{RangesCount: 4}
+	// prediction, result: exact hit, direction hit, miss, direction hit
+	eff.AddResult(0, 0, []float64{0.7, 0.1, 0.1, 0.1})
+	eff.AddResult(3, 2, []float64{0, 0, 0.4, 0.6})
+	eff.AddResult(1, 3, nil)
+	eff.AddResult(2, 3, nil)
+
+	if eff.GetHitRate() != 0.25 || eff.GetDirectionRate() != 0.75 || eff.GetMAE() != 1 {
+		t.Errorf("wrong rates: %s", eff.ToString())
+	}
+	if eff.GetConfusion(3, 1) != 1 || eff.GetPrecision(0) != 1 || eff.GetRecall(3) != 0 || !math.IsNaN(eff.GetRecall(1)) {
+		t.Errorf("wrong confusion matrix: %v", eff.Confusion)
+	}
+	// (0.09 + 0.01 * 3 + 0.36 + 0.36) / 2
+	if math.Abs(eff.GetBrierScore()-0.42) > 1e-9 {
+		t.Errorf("wrong brier score: %v", eff.GetBrierScore())
+	}
+	// bins: 0.7 - hit, 0.6 - miss
+	if math.Abs(eff.GetCalibrationError()-0.45) > 1e-9 {
+		t.Errorf("wrong calibration error: %v", eff.GetCalibrationError())
+	}
+	// persistence predicts 0, 2, 3 for results 2, 3, 3
+	if eff.GetBaselineHitRate(entities.BLPersistence) != 1.0/3 || eff.GetBaselineDirectionRate(entities.BLPersistence) != 2.0/3 {
+		t.Errorf("wrong persistence baseline: %v", eff.BaselineHits)
+	}
+
+	// the predictions out of the classes aren't in the confusion matrix, but their results are counted
+	majority := entities.Efficiency{RangesCount: 4}
+	majority.AddResult(-1, 2, nil)
+	majority.AddResult(-1, 2, nil)
+	majority.AddResult(1, 1, nil)
+	majority.AddResult(1, 2, nil)
+	// majority predicts 2, 2, 2 for results 2, 1, 2
+	if majority.GetBaselineHitRate(entities.BLMajority) != 2.0/3 || majority.ResultCount[2] != 3 {
+		t.Errorf("wrong majority baseline: %v, results: %v", majority.BaselineHits, majority.ResultCount)
+	}
+
+	for i := 0; i < 20; i++ {
+		eff.AddResult(0, 3, nil)
+	}
+	if eff.GetDirectionRate10() != 0 || len(eff.LastSD) != 24 {
+		t.Errorf("wrong direction rate 10: %v", eff.GetDirectionRate10())
+	}
+}
+
+func TestEfficiencyRegimes(t *testing.T) {
+	eff := entities.Efficiency{RangesCount: 4}
+	eff.AddRegimeResult(1, 3, 3)
+	eff.AddRegimeResult(1, 0, 3)
+	eff.AddRegimeResult(2, 2, 3)
+	eff.AddRegimeResult(-1, 2, 3)
+
+	if len(eff.RegimeCount) != 3 || eff.RegimeCount[0] != 0 || eff.RegimeCount[1] != 2 {
+		t.Fatalf("wrong regime counters: %v", eff.RegimeCount)
+	}
+	if eff.GetRegimeHitRate(1) != 0.5 || eff.GetRegimeDirectionRate(1) != 0.5 || eff.GetRegimeDirectionRate(2) != 1 {
+		t.Errorf("wrong regime rates: %v, %v", eff.RegimeHits, eff.RegimeDirectionHits)
+	}
+	if !math.IsNaN(eff.GetRegimeHitRate(0)) || !math.IsNaN(eff.GetRegimeDirectionRate(3)) {
+		t.Error("rate of the regime without results must be NaN")
+	}
+}
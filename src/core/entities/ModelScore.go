@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+)
+
+// ModelScore struct - efficiency of the model in the comparison of the network with baselines
+type ModelScore struct {
+	TrainType     string  `json:"trainType"`
+	Count         int32   `json:"count"` // count of the assessed predictions, metrics are zero when nothing assessed
+	HitRate       float64 `json:"hitRate"`
+	DirectionRate float64 `json:"directionRate"`
+	MAE           float64 `json:"mae"`
+	BrierScore    float64 `json:"brierScore"`
+}
+
+// NewModelScore create score from the efficiency, undefined metrics are replaced by zero
+func NewModelScore(eff Efficiency) ModelScore {
+	return ModelScore{
+		TrainType:     eff.TrainType,
+		Count:         eff.Count,
+		HitRate:       zeroNaN(eff.GetHitRate()),
+		DirectionRate: zeroNaN(eff.GetDirectionRate()),
+		MAE:           zeroNaN(eff.GetMAE()),
+		BrierScore:    zeroNaN(eff.GetBrierScore())}
+}
+
+// ToString method
+func (f *ModelScore) ToString() string {
+	return fmt.Sprintf("ModelScore { TrainType: %s; Count: %d; HitRate: %.4f; DirectionRate: %.4f; MAE: %.4f; BrierScore: %.4f }",
+		f.TrainType,
+		f.Count,
+		f.HitRate,
+		f.DirectionRate,
+		f.MAE,
+		f.BrierScore)
+}
+
+// CompareResponse struct - scores of the network and the baselines of the symbol
+type CompareResponse struct {
+	Symbol string       `json:"symbol"`
+	Models []ModelScore `json:"models"`
+}
+
+// ToString method
+func (f *CompareResponse) ToString() string {
+	result := fmt.Sprintf("CompareResponse { Symbol: %s; Models:", f.Symbol)
+	for i := range f.Models {
+		result += " " + f.Models[i].ToString()
+	}
+	return result + " }"
+}
+
+// zeroNaN replace NaN by zero, JSON has no NaN
+func zeroNaN(value float64) float64 {
+	if math.IsNaN(value) {
+		return 0
+	}
+	return value
+}
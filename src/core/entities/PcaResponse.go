@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"fmt"
+)
+
+// PcaComponent struct - principal component of the rate changes
+type PcaComponent struct {
+	Variance       float64   `json:"variance"`
+	ExplainedRatio float64   `json:"explainedRatio"` // part of the total variance
+	Loadings       []float64 `json:"loadings"`       // weights of the symbols, aligned with PcaResponse.Symbols
+}
+
+// ToString method
+func (f *PcaComponent) ToString() string {
+	return fmt.Sprintf("PcaComponent { Variance: %v; ExplainedRatio: %.4f; Loadings: %v }",
+		f.Variance,
+		f.ExplainedRatio,
+		f.Loadings)
+}
+
+// PcaResponse struct - principal components of the cross-currency rate changes over the window
+type PcaResponse struct {
+	Timestamp  int64          `json:"timestamp"` // timestamp of the latest rate
+	Window     int32          `json:"window"`
+	Symbols    []string       `json:"symbols"`
+	Components []PcaComponent `json:"components"` // in descending order of the variance
+}
+
+// ToString method
+func (f *PcaResponse) ToString() string {
+	result := fmt.Sprintf("PcaResponse { Timestamp: %d; Window: %d; Symbols: %v; Components:", f.Timestamp, f.Window, f.Symbols)
+	for i := range f.Components {
+		result += " " + f.Components[i].ToString()
+	}
+	return result + " }"
+}
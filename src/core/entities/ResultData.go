@@ -7,20 +7,29 @@ import (
 
 // ResultData struct
 type ResultData struct {
-	RangesCount int32   `datastore:"rangesCount,noindex" json:"rangesCount"`
-	Limit       int32   `datastore:"limit,noindex" json:"limit"`
-	TrainType   string  `datastore:"trainType,noindex" json:"trainType"`
-	Step        int32   `datastore:"step,noindex" json:"step"`
-	Symbol      string  `datastore:"symbol,index" json:"symbol"`
-	Timestamp   int64   `datastore:"timestamp,index" json:"timestamp"`
-	Source      []int32 `datastore:"source,noindex" json:"source"`
-	Prediction  int32   `datastore:"prediction,noindex" json:"prediction"`
-	Result      int32   `datastore:"result,noindex" json:"result"`
+	RangesCount   int32     `datastore:"rangesCount,noindex" json:"rangesCount"`
+	Limit         int32     `datastore:"limit,noindex" json:"limit"`
+	TrainType     string    `datastore:"trainType,index" json:"trainType"`
+	Step          int32     `datastore:"step,noindex" json:"step"`
+	Symbol        string    `datastore:"symbol,index" json:"symbol"`
+	Timestamp     int64     `datastore:"timestamp,index" json:"timestamp"`
+	Source        []int32   `datastore:"source,noindex" json:"source"`
+	Prediction    int32     `datastore:"prediction,noindex" json:"prediction"`
+	Probabilities []float64 `datastore:"probabilities,noindex" json:"probabilities"` // per range class, classifier networks only
+	Confidence    float64   `datastore:"confidence,noindex" json:"confidence"`       // probability of the predicted class
+	Result        int32     `datastore:"result,noindex" json:"result"`
+	// multi-step forecast: predicted and actual (-1 until known) classes for each horizon (steps ahead)
+	Horizons           []int32 `datastore:"horizons,noindex" json:"horizons"`
+	HorizonPredictions []int32 `datastore:"horizonPredictions,noindex" json:"horizonPredictions"`
+	HorizonResults     []int32 `datastore:"horizonResults,noindex" json:"horizonResults"`
+	// market regime at the prediction, RegimesCount is zero without regime detector
+	Regime       int32 `datastore:"regime,noindex" json:"regime"`
+	RegimesCount int32 `datastore:"regimesCount,noindex" json:"regimesCount"`
 }
 
 // ToString method
 func (f *ResultData) ToString() string {
-	return fmt.Sprintf("ResultData: Symbol: %s\nRanges: %d\nLimit: %d\nStep: %d\nDatetime: %v\nSource: %v\nPrediction: %d\nResult: %d.",
+	return fmt.Sprintf("ResultData: Symbol: %s\nRanges: %d\nLimit: %d\nStep: %d\nDatetime: %v\nSource: %v\nPrediction: %d\nConfidence: %v\nResult: %d.",
 		f.Symbol,
 		f.RangesCount,
 		f.Limit,
@@ -28,9 +37,20 @@ func (f *ResultData) ToString() string {
 		f.DateCreated(),
 		f.Source,
 		f.Prediction,
+		f.Confidence,
 		f.Result)
 }
 
+// HorizonIndex return index of the horizon in the Horizons, -1 if not found
+func (f *ResultData) HorizonIndex(horizon int32) int {
+	for i, item := range f.Horizons {
+		if item == horizon {
+			return i
+		}
+	}
+	return -1
+}
+
 // DateCreated method
 func (f *ResultData) DateCreated() time.Time {
 	return time.Unix(f.Timestamp, 0).UTC()
@@ -7,25 +7,36 @@ import (
 
 // Signal struct
 type Signal struct {
-	Score10     float32 `json:"score10"`
-	Score100    float32 `json:"score100"`
-	Timestamp   int64   `json:"timestamp"`
-	RangesCount int32   `json:"rangesCount"`
-	Prediction  int32   `json:"prediction"`
-	Symbol      string  `json:"symbol"`
+	Score10       float32   `json:"score10"`
+	Score100      float32   `json:"score100"`
+	Timestamp     int64     `json:"timestamp"`
+	RangesCount   int32     `json:"rangesCount"`
+	Prediction    int32     `json:"prediction"`
+	Probabilities []float64 `json:"probabilities"`
+	Confidence    float64   `json:"confidence"`
+	Abstain       bool      `json:"abstain"` // confidence of the prediction is too low to follow the signal
+	Symbol        string    `json:"symbol"`
 }
 
 // ToString method
 func (f *Signal) ToString() string {
-	return fmt.Sprintf("Signal { Symbol: %s; Ranges: %d; Datetime: %v; Prediction: %d; Score10: %v; Score100: %v }",
+	return fmt.Sprintf("Signal { Symbol: %s; Ranges: %d; Datetime: %v; Prediction: %d; Confidence: %v; Abstain: %t; Score10: %v; Score100: %v }",
 		f.Symbol,
 		f.RangesCount,
 		f.DateCreated(),
 		f.Prediction,
+		f.Confidence,
+		f.Abstain,
 		f.Score10,
 		f.Score100)
 }
 
+// UpdateAbstain mark signal as abstained when probabilities are known and
+// confidence of the prediction is less than minConfidence
+func (f *Signal) UpdateAbstain(minConfidence float64) {
+	f.Abstain = len(f.Probabilities) > 0 && f.Confidence < minConfidence
+}
+
 // DateCreated method
 func (f *Signal) DateCreated() time.Time {
 	return time.Unix(f.Timestamp, 0).UTC()
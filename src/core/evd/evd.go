@@ -0,0 +1,144 @@
+package evd
+
+import (
+	"math"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	// limit of the Jacobi sweeps
+	maxsweeps = 100
+)
+
+/*************************************************************************
+Finding the eigenvalues and eigenvectors of a symmetric matrix
+
+The algorithm finds eigen pairs of a symmetric matrix by reducing it to
+diagonal form using cyclic Jacobi rotations, i.e. SMatrixEVD with
+ZNeeded=1.
+
+Unlike SMatrixEVD (reduction to tridiagonal form and QL/QR iterations) the
+rotations are applied to the full matrix, which has the same high accuracy
+and is compact for the small matrices of the Fisher LDA.
+
+Input parameters:
+	A       -   symmetric matrix which is given by its upper or lower
+				triangular part.
+				Array whose indexes range within [0..N-1, 0..N-1].
+	N       -   size of matrix A.
+	IsUpper -   storage format.
+
+Output parameters:
+	D       -   eigenvalues in ascending order.
+				Array whose index ranges within [0..N-1].
+	Z       -   the matrix whose columns store the eigenvectors.
+				Array whose indexes range within [0..N-1, 0..N-1].
+
+Result:
+	True, if the algorithm has converged.
+	False, if the algorithm hasn't converged (rare case).
+*************************************************************************/
+func SMatrixEvd(a *[][]float64, n int, isupper bool, d *[]float64, z *[][]float64) bool {
+	b := utils.MakeMatrixFloat64(n, n)
+	for i := 0; i <= n-1; i++ {
+		for j := i; j <= n-1; j++ {
+			if isupper {
+				b[i][j] = (*a)[i][j]
+			} else {
+				b[i][j] = (*a)[j][i]
+			}
+			b[j][i] = b[i][j]
+		}
+	}
+	v := utils.MakeMatrixFloat64(n, n)
+	for i := 0; i <= n-1; i++ {
+		v[i][i] = 1
+	}
+	norm := 0.0
+	for i := 0; i <= n-1; i++ {
+		for j := 0; j <= n-1; j++ {
+			norm += b[i][j] * b[i][j]
+		}
+	}
+
+	converged := false
+	for sweep := 0; sweep < maxsweeps; sweep++ {
+		off := 0.0
+		for p := 0; p <= n-2; p++ {
+			for q := p + 1; q <= n-1; q++ {
+				off += b[p][q] * b[p][q]
+			}
+		}
+		if off <= utils.SqrFloat64(utils.MachineEpsilon)*norm {
+			converged = true
+			break
+		}
+		for p := 0; p <= n-2; p++ {
+			for q := p + 1; q <= n-1; q++ {
+				if b[p][q] == 0 {
+					continue
+				}
+
+				//
+				// Rotation zeroes out the element [P,Q]
+				//
+				theta := (b[q][q] - b[p][p]) / (2 * b[p][q])
+				var t float64
+				if math.Abs(theta) > 1E150 {
+					t = 1 / (2 * theta)
+				} else {
+					t = 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+					if theta < 0 {
+						t = -t
+					}
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k <= n-1; k++ {
+					bkp := b[k][p]
+					bkq := b[k][q]
+					b[k][p] = c*bkp - s*bkq
+					b[k][q] = s*bkp + c*bkq
+				}
+				for k := 0; k <= n-1; k++ {
+					bpk := b[p][k]
+					bqk := b[q][k]
+					b[p][k] = c*bpk - s*bqk
+					b[q][k] = s*bpk + c*bqk
+				}
+				for k := 0; k <= n-1; k++ {
+					vkp := v[k][p]
+					vkq := v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	if !converged {
+		return false
+	}
+
+	//
+	// Sort eigen pairs in ascending order of the eigenvalues
+	//
+	order := make([]int, n)
+	for i := 0; i <= n-1; i++ {
+		order[i] = i
+	}
+	for i := 1; i <= n-1; i++ {
+		for j := i; j > 0 && b[order[j]][order[j]] < b[order[j-1]][order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	*d = make([]float64, n)
+	*z = utils.MakeMatrixFloat64(n, n)
+	for k, j := range order {
+		(*d)[k] = b[j][j]
+		for i := 0; i <= n-1; i++ {
+			(*z)[i][k] = v[i][j]
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package evd_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/evd"
+)
+
+func TestDecomposition(t *testing.T) {
+	n := 5
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			a[i][j] = rand.Float64()*2 - 1
+			a[j][i] = a[i][j]
+		}
+	}
+	for _, isupper := range []bool{true, false} {
+		// the other triangle must be ignored
+		b := make([][]float64, n)
+		for i := range b {
+			b[i] = append([]float64{}, a[i]...)
+			for j := range b[i] {
+				if (isupper && j < i) || (!isupper && j > i) {
+					b[i][j] = 100
+				}
+			}
+		}
+		var d []float64
+		var z [][]float64
+		if !evd.SMatrixEvd(&b, n, isupper, &d, &z) {
+			t.Fatal("not converged")
+		}
+		for k := 0; k < n; k++ {
+			if k > 0 && d[k] < d[k-1] {
+				t.Fatalf("eigenvalues are not sorted: %v", d)
+			}
+			for i := 0; i < n; i++ {
+				var r float64
+				for j := 0; j < n; j++ {
+					r += a[i][j] * z[j][k]
+				}
+				if math.Abs(r-d[k]*z[i][k]) > 1e-12 {
+					t.Fatalf("A*z[%d] differs from the eigenvalue %v times z, upper: %v", k, d[k], isupper)
+				}
+			}
+		}
+	}
+}
+
+func TestDiagonal(t *testing.T) {
+	a := [][]float64{{3, 0, 0}, {0, -1, 0}, {0, 0, 2}}
+	var d []float64
+	var z [][]float64
+	if !evd.SMatrixEvd(&a, 3, true, &d, &z) {
+		t.Fatal("not converged")
+	}
+	if d[0] != -1 || d[1] != 2 || d[2] != 3 || z[1][0] != 1 || z[2][1] != 1 || z[0][2] != 1 {
+		t.Errorf("eigenvalues: %v, eigenvectors: %v", d, z)
+	}
+}
@@ -0,0 +1,1405 @@
+package forest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	innernodewidth    = 3
+	leafnodewidth     = 2
+	dfusestrongsplits = 1
+	dfuseevs          = 2
+	dfvnum            = 1
+	dffirstversion    = 0
+
+	maxrealnumber = 1E300
+	minrealnumber = 1E-300
+)
+
+/*************************************************************************
+Decision forest.
+
+RANDOM FOREST FORMAT of the Trees, trees are stored one after another
+from the offset 0:
+W[Offs]      -   size of sub-array
+	node info:
+W[K+0]       -   variable number        (-1 for leaf mode)
+W[K+1]       -   threshold              (class/value for leaf node)
+W[K+2]       -   ">=" branch index      (absent for leaf node)
+*************************************************************************/
+type DecisionForest struct {
+	NVars    int
+	NClasses int
+	NTrees   int
+	BufSize  int
+	Trees    []float64
+}
+
+func NewDecisionForest() *DecisionForest {
+	return &DecisionForest{Trees: []float64{}}
+}
+
+/*************************************************************************
+Training report:
+* RelClsError       -   training set relative classification error
+* AvgCE             -   training set average cross-entropy
+* RmsError          -   training set rms error
+* AvgError          -   training set average error
+* AvgRelError       -   training set average relative error
+* Oob*              -   the same out-of-bag estimates of generalization error
+*************************************************************************/
+type DfReport struct {
+	RelClsError    float64
+	AvgCE          float64
+	RmsError       float64
+	AvgError       float64
+	AvgRelError    float64
+	OobRelClsError float64
+	OobAvgCE       float64
+	OobRmsError    float64
+	OobAvgError    float64
+	OobAvgRelError float64
+}
+
+func NewDfReport() *DfReport {
+	return &DfReport{}
+}
+
+type dfinternalbuffers struct {
+	treebuf   []float64
+	idxbuf    []int
+	tmpbufr   []float64
+	tmpbufr2  []float64
+	tmpbufi   []int
+	classibuf []int
+	varpool   []int
+	evsbin    []bool
+	evssplits []float64
+}
+
+/*************************************************************************
+This subroutine builds random decision forest.
+
+INPUT PARAMETERS:
+	XY          -   training set
+	NPoints     -   training set size, NPoints>=1
+	NVars       -   number of independent variables, NVars>=1
+	NClasses    -   task type:
+					* NClasses=1 - regression task with one
+								   dependent variable
+					* NClasses>1 - classification task with
+								   NClasses classes.
+	NTrees      -   number of trees in a forest, NTrees>=1.
+					recommended values: 50-100.
+	R           -   percent of a training set used to build
+					individual trees. 0<R<=1.
+					recommended values: 0.1 <= R <= 0.66.
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -2, if there is a point with class number
+						  outside of [0..NClasses-1].
+					* -1, if incorrect parameters was passed
+						  (NPoints<1, NVars<1, NClasses<1, NTrees<1, R<=0
+						  or R>1).
+					*  1, if task has been solved
+	DF          -   model built
+	Rep         -   training report, contains error on a training set
+					and out-of-bag estimates of generalization error.
+
+  -- ALGLIB --
+	 Copyright 19.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfBuildRandomDecisionForest(xy *[][]float64, npoints, nvars, nclasses, ntrees int, r float64, info *int, df *DecisionForest, rep *DfReport) error {
+	*info = 0
+	if r <= 0 || r > 1 {
+		*info = -1
+		return nil
+	}
+	samplesize := utils.MaxInt(utils.RoundInt(r*float64(npoints)), 1)
+	return DfBuildInternal(xy, npoints, nvars, nclasses, ntrees, samplesize, utils.MaxInt(nvars/2, 1), dfusestrongsplits+dfuseevs, info, df, rep)
+}
+
+/*************************************************************************
+This subroutine builds random decision forest.
+This function gives ability to tune number of variables used when choosing
+best split.
+
+INPUT PARAMETERS:
+	XY          -   training set
+	NPoints     -   training set size, NPoints>=1
+	NVars       -   number of independent variables, NVars>=1
+	NClasses    -   task type:
+					* NClasses=1 - regression task with one
+								   dependent variable
+					* NClasses>1 - classification task with
+								   NClasses classes.
+	NTrees      -   number of trees in a forest, NTrees>=1.
+					recommended values: 50-100.
+	NRndVars    -   number of variables used when choosing best split
+	R           -   percent of a training set used to build
+					individual trees. 0<R<=1.
+					recommended values: 0.1 <= R <= 0.66.
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -2, if there is a point with class number
+						  outside of [0..NClasses-1].
+					* -1, if incorrect parameters was passed
+						  (NPoints<1, NVars<1, NClasses<1, NTrees<1, R<=0
+						  or R>1).
+					*  1, if task has been solved
+	DF          -   model built
+	Rep         -   training report, contains error on a training set
+					and out-of-bag estimates of generalization error.
+
+  -- ALGLIB --
+	 Copyright 19.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfBuildRandomDecisionForestX1(xy *[][]float64, npoints, nvars, nclasses, ntrees, nrndvars int, r float64, info *int, df *DecisionForest, rep *DfReport) error {
+	*info = 0
+	if r <= 0 || r > 1 {
+		*info = -1
+		return nil
+	}
+	if nrndvars <= 0 || nrndvars > nvars {
+		*info = -1
+		return nil
+	}
+	samplesize := utils.MaxInt(utils.RoundInt(r*float64(npoints)), 1)
+	return DfBuildInternal(xy, npoints, nvars, nclasses, ntrees, samplesize, nrndvars, dfusestrongsplits+dfuseevs, info, df, rep)
+}
+
+func DfBuildInternal(xy *[][]float64, npoints, nvars, nclasses, ntrees, samplesize, nfeatures, flags int, info *int, df *DecisionForest, rep *DfReport) error {
+	*info = 0
+
+	//
+	// Test for inputs
+	//
+	if npoints < 1 || samplesize < 1 || samplesize > npoints || nvars < 1 || nclasses < 1 || ntrees < 1 || nfeatures < 1 {
+		*info = -1
+		return nil
+	}
+	if len(*xy) < npoints {
+		return fmt.Errorf("DFBuildInternal: rows count %d less than NPoints %d", len(*xy), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*xy)[i]) < nvars+1 {
+			return fmt.Errorf("DFBuildInternal: row %d length %d less than NVars+1", i, len((*xy)[i]))
+		}
+	}
+	if nclasses > 1 {
+		for i := 0; i <= npoints-1; i++ {
+			if utils.RoundInt((*xy)[i][nvars]) < 0 || utils.RoundInt((*xy)[i][nvars]) >= nclasses {
+				*info = -2
+				return nil
+			}
+		}
+	}
+	*info = 1
+
+	//
+	// Flags
+	//
+	useevs := flags/dfuseevs%2 != 0
+
+	//
+	// Allocate data, prepare header
+	//
+	treesize := 1 + innernodewidth*(samplesize-1) + leafnodewidth*samplesize
+	permbuf := make([]int, npoints)
+	bufs := &dfinternalbuffers{
+		treebuf:   make([]float64, treesize),
+		idxbuf:    make([]int, npoints),
+		tmpbufr:   make([]float64, npoints),
+		tmpbufr2:  make([]float64, npoints),
+		tmpbufi:   make([]int, npoints),
+		varpool:   make([]int, nvars),
+		evsbin:    make([]bool, nvars),
+		evssplits: make([]float64, nvars),
+		classibuf: make([]int, 2*nclasses)}
+	oobbuf := make([]float64, nclasses*npoints)
+	oobcntbuf := make([]int, npoints)
+	df.Trees = make([]float64, ntrees*treesize)
+	xys := utils.MakeMatrixFloat64(samplesize, nvars+1)
+	x := make([]float64, nvars)
+	y := make([]float64, nclasses)
+	for i := 0; i <= npoints-1; i++ {
+		permbuf[i] = i
+	}
+
+	//
+	// Prepare variable pool and EVS (extended variable selection/splitting) buffers
+	// (whether EVS is turned on or not):
+	// 1. detect binary variables and pre-calculate splits for them
+	// 2. detect variables with non-distinct values and exclude them from pool
+	//
+	for i := 0; i <= nvars-1; i++ {
+		bufs.varpool[i] = i
+	}
+	nvarsinpool := nvars
+	if useevs {
+		for j := 0; j <= nvars-1; j++ {
+			vmin := (*xy)[0][j]
+			vmax := vmin
+			for i := 0; i <= npoints-1; i++ {
+				v := (*xy)[i][j]
+				vmin = math.Min(vmin, v)
+				vmax = math.Max(vmax, v)
+			}
+			if vmin == vmax {
+
+				//
+				// exclude variable from pool
+				//
+				bufs.varpool[j] = bufs.varpool[nvarsinpool-1]
+				bufs.varpool[nvarsinpool-1] = -1
+				nvarsinpool = nvarsinpool - 1
+				continue
+			}
+			bflag := false
+			for i := 0; i <= npoints-1; i++ {
+				v := (*xy)[i][j]
+				if v != vmin && v != vmax {
+					bflag = true
+					break
+				}
+			}
+			if bflag {
+
+				//
+				// non-binary variable
+				//
+				bufs.evsbin[j] = false
+			} else {
+
+				//
+				// Prepare
+				//
+				bufs.evsbin[j] = true
+				bufs.evssplits[j] = 0.5 * (vmin + vmax)
+				if bufs.evssplits[j] <= vmin {
+					bufs.evssplits[j] = vmax
+				}
+			}
+		}
+	}
+
+	df.NVars = nvars
+	df.NClasses = nclasses
+	df.NTrees = ntrees
+
+	//
+	// Build forest
+	//
+	offs := 0
+	for i := 0; i <= ntrees-1; i++ {
+
+		//
+		// Prepare sample
+		//
+		for k := 0; k <= samplesize-1; k++ {
+			j := k + rand.Intn(npoints-k)
+			permbuf[k], permbuf[j] = permbuf[j], permbuf[k]
+			copy(xys[k], (*xy)[permbuf[k]][:nvars+1])
+		}
+
+		//
+		// build tree, copy
+		//
+		if err := dfbuildtree(xys, samplesize, nvars, nclasses, nfeatures, nvarsinpool, flags, bufs); err != nil {
+			return err
+		}
+		j := utils.RoundInt(bufs.treebuf[0])
+		copy(df.Trees[offs:offs+j], bufs.treebuf[:j])
+		lasttreeoffs := offs
+		offs = offs + j
+
+		//
+		// OOB estimates
+		//
+		for k := samplesize; k <= npoints-1; k++ {
+			for j := 0; j <= nclasses-1; j++ {
+				y[j] = 0
+			}
+			j := permbuf[k]
+			copy(x, (*xy)[j][:nvars])
+			dfprocessinternal(df, lasttreeoffs, x, y)
+			for i_ := 0; i_ <= nclasses-1; i_++ {
+				oobbuf[j*nclasses+i_] += y[i_]
+			}
+			oobcntbuf[j] = oobcntbuf[j] + 1
+		}
+	}
+	df.BufSize = offs
+
+	//
+	// Normalize OOB results
+	//
+	for i := 0; i <= npoints-1; i++ {
+		if oobcntbuf[i] != 0 {
+			v := 1 / float64(oobcntbuf[i])
+			for i_ := i * nclasses; i_ <= i*nclasses+nclasses-1; i_++ {
+				oobbuf[i_] = v * oobbuf[i_]
+			}
+		}
+	}
+
+	//
+	// Calculate training set estimates
+	//
+	rep.RelClsError = DfRelClsError(df, xy, npoints)
+	rep.AvgCE = DfAvgce(df, xy, npoints)
+	rep.RmsError = DfRmsError(df, xy, npoints)
+	rep.AvgError = DfAvgError(df, xy, npoints)
+	rep.AvgRelError = DfAvgRelError(df, xy, npoints)
+
+	//
+	// Calculate OOB estimates.
+	//
+	rep.OobRelClsError = 0
+	rep.OobAvgCE = 0
+	rep.OobRmsError = 0
+	rep.OobAvgError = 0
+	rep.OobAvgRelError = 0
+	oobcnt := 0
+	oobrelcnt := 0
+	for i := 0; i <= npoints-1; i++ {
+		if oobcntbuf[i] != 0 {
+			ooboffs := i * nclasses
+			if nclasses > 1 {
+
+				//
+				// classification-specific code
+				//
+				k := utils.RoundInt((*xy)[i][nvars])
+				tmpi := 0
+				for j := 1; j <= nclasses-1; j++ {
+					if oobbuf[ooboffs+j] > oobbuf[ooboffs+tmpi] {
+						tmpi = j
+					}
+				}
+				if tmpi != k {
+					rep.OobRelClsError = rep.OobRelClsError + 1
+				}
+				if oobbuf[ooboffs+k] != 0 {
+					rep.OobAvgCE = rep.OobAvgCE - math.Log(oobbuf[ooboffs+k])
+				} else {
+					rep.OobAvgCE = rep.OobAvgCE - math.Log(minrealnumber)
+				}
+				for j := 0; j <= nclasses-1; j++ {
+					if j == k {
+						rep.OobRmsError = rep.OobRmsError + utils.SqrFloat64(oobbuf[ooboffs+j]-1)
+						rep.OobAvgError = rep.OobAvgError + math.Abs(oobbuf[ooboffs+j]-1)
+						rep.OobAvgRelError = rep.OobAvgRelError + math.Abs(oobbuf[ooboffs+j]-1)
+						oobrelcnt = oobrelcnt + 1
+					} else {
+						rep.OobRmsError = rep.OobRmsError + utils.SqrFloat64(oobbuf[ooboffs+j])
+						rep.OobAvgError = rep.OobAvgError + math.Abs(oobbuf[ooboffs+j])
+					}
+				}
+			} else {
+
+				//
+				// regression-specific code
+				//
+				rep.OobRmsError = rep.OobRmsError + utils.SqrFloat64(oobbuf[ooboffs]-(*xy)[i][nvars])
+				rep.OobAvgError = rep.OobAvgError + math.Abs(oobbuf[ooboffs]-(*xy)[i][nvars])
+				if (*xy)[i][nvars] != 0 {
+					rep.OobAvgRelError = rep.OobAvgRelError + math.Abs((oobbuf[ooboffs]-(*xy)[i][nvars])/(*xy)[i][nvars])
+					oobrelcnt = oobrelcnt + 1
+				}
+			}
+
+			//
+			// update OOB estimates count.
+			//
+			oobcnt = oobcnt + 1
+		}
+	}
+	if oobcnt > 0 {
+		rep.OobRelClsError = rep.OobRelClsError / float64(oobcnt)
+		rep.OobAvgCE = rep.OobAvgCE / float64(oobcnt)
+		rep.OobRmsError = math.Sqrt(rep.OobRmsError / float64(oobcnt*nclasses))
+		rep.OobAvgError = rep.OobAvgError / float64(oobcnt*nclasses)
+		if oobrelcnt > 0 {
+			rep.OobAvgRelError = rep.OobAvgRelError / float64(oobrelcnt)
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Procesing
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	X       -   input vector,  array[0..NVars-1].
+
+OUTPUT PARAMETERS:
+	Y       -   result. Regression estimate when solving regression  task,
+				vector of posterior probabilities for classification task.
+
+See also DFProcessI.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfProcess(df *DecisionForest, x, y *[]float64) {
+	if len(*y) < df.NClasses {
+		*y = make([]float64, df.NClasses)
+	}
+	offs := 0
+	for i := 0; i <= df.NClasses-1; i++ {
+		(*y)[i] = 0
+	}
+	for i := 0; i <= df.NTrees-1; i++ {
+
+		//
+		// Process basic tree
+		//
+		dfprocessinternal(df, offs, *x, *y)
+
+		//
+		// Next tree
+		//
+		offs = offs + utils.RoundInt(df.Trees[offs])
+	}
+	v := 1 / float64(df.NTrees)
+	for i := 0; i <= df.NClasses-1; i++ {
+		(*y)[i] = v * (*y)[i]
+	}
+}
+
+/*************************************************************************
+'interactive' variant of DFProcess for languages like Python which support
+constructs like "Y = DFProcessI(DF,X)" and interactive mode of interpreter
+
+This function allocates new array on each call,  so  it  is  significantly
+slower than its 'non-interactive' counterpart, but it is  more  convenient
+when you call it from command line.
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func DfProcessi(df *DecisionForest, x, y *[]float64) {
+	*y = []float64{}
+	DfProcess(df, x, y)
+}
+
+/*************************************************************************
+Relative classification error on the test set
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	percent of incorrectly classified cases.
+	Zero if model solves regression task.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfRelClsError(df *DecisionForest, xy *[][]float64, npoints int) float64 {
+	return float64(dfclserror(df, xy, npoints)) / float64(npoints)
+}
+
+/*************************************************************************
+Average cross-entropy (in bits per element) on the test set
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	CrossEntropy/(NPoints*LN(2)).
+	Zero if model solves regression task.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfAvgce(df *DecisionForest, xy *[][]float64, npoints int) float64 {
+	x := make([]float64, df.NVars)
+	y := make([]float64, df.NClasses)
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		copy(x, (*xy)[i][:df.NVars])
+		DfProcess(df, &x, &y)
+		if df.NClasses > 1 {
+
+			//
+			// classification-specific code
+			//
+			k := utils.RoundInt((*xy)[i][df.NVars])
+			if y[k] != 0 {
+				result = result - math.Log(y[k])
+			} else {
+				result = result - math.Log(minrealnumber)
+			}
+		}
+	}
+	return result / float64(npoints)
+}
+
+/*************************************************************************
+RMS error on the test set
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	root mean square error.
+	Its meaning for regression task is obvious. As for
+	classification task, RMS error means error when estimating posterior
+	probabilities.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfRmsError(df *DecisionForest, xy *[][]float64, npoints int) float64 {
+	x := make([]float64, df.NVars)
+	y := make([]float64, df.NClasses)
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		copy(x, (*xy)[i][:df.NVars])
+		DfProcess(df, &x, &y)
+		if df.NClasses > 1 {
+
+			//
+			// classification-specific code
+			//
+			k := utils.RoundInt((*xy)[i][df.NVars])
+			for j := 0; j <= df.NClasses-1; j++ {
+				if j == k {
+					result = result + utils.SqrFloat64(y[j]-1)
+				} else {
+					result = result + utils.SqrFloat64(y[j])
+				}
+			}
+		} else {
+
+			//
+			// regression-specific code
+			//
+			result = result + utils.SqrFloat64(y[0]-(*xy)[i][df.NVars])
+		}
+	}
+	return math.Sqrt(result / float64(npoints*df.NClasses))
+}
+
+/*************************************************************************
+Average error on the test set
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	Its meaning for regression task is obvious. As for
+	classification task, it means average error when estimating posterior
+	probabilities.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfAvgError(df *DecisionForest, xy *[][]float64, npoints int) float64 {
+	x := make([]float64, df.NVars)
+	y := make([]float64, df.NClasses)
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		copy(x, (*xy)[i][:df.NVars])
+		DfProcess(df, &x, &y)
+		if df.NClasses > 1 {
+
+			//
+			// classification-specific code
+			//
+			k := utils.RoundInt((*xy)[i][df.NVars])
+			for j := 0; j <= df.NClasses-1; j++ {
+				if j == k {
+					result = result + math.Abs(y[j]-1)
+				} else {
+					result = result + math.Abs(y[j])
+				}
+			}
+		} else {
+
+			//
+			// regression-specific code
+			//
+			result = result + math.Abs(y[0]-(*xy)[i][df.NVars])
+		}
+	}
+	return result / float64(npoints*df.NClasses)
+}
+
+/*************************************************************************
+Average relative error on the test set
+
+INPUT PARAMETERS:
+	DF      -   decision forest model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	Its meaning for regression task is obvious. As for
+	classification task, it means average relative error when estimating
+	posterior probability of belonging to the correct class.
+
+  -- ALGLIB --
+	 Copyright 16.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfAvgRelError(df *DecisionForest, xy *[][]float64, npoints int) float64 {
+	x := make([]float64, df.NVars)
+	y := make([]float64, df.NClasses)
+	result := 0.0
+	relcnt := 0
+	for i := 0; i <= npoints-1; i++ {
+		copy(x, (*xy)[i][:df.NVars])
+		DfProcess(df, &x, &y)
+		if df.NClasses > 1 {
+
+			//
+			// classification-specific code
+			//
+			k := utils.RoundInt((*xy)[i][df.NVars])
+			result = result + math.Abs(y[k]-1)
+			relcnt = relcnt + 1
+		} else {
+
+			//
+			// regression-specific code
+			//
+			if (*xy)[i][df.NVars] != 0 {
+				result = result + math.Abs((y[0]-(*xy)[i][df.NVars])/(*xy)[i][df.NVars])
+				relcnt = relcnt + 1
+			}
+		}
+	}
+	if relcnt > 0 {
+		result = result / float64(relcnt)
+	}
+	return result
+}
+
+/*************************************************************************
+Copying of DecisionForest strucure
+
+INPUT PARAMETERS:
+	DF1 -   original
+
+OUTPUT PARAMETERS:
+	DF2 -   copy
+
+  -- ALGLIB --
+	 Copyright 13.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfCopy(df1, df2 *DecisionForest) {
+	df2.NVars = df1.NVars
+	df2.NClasses = df1.NClasses
+	df2.NTrees = df1.NTrees
+	df2.BufSize = df1.BufSize
+	df2.Trees = utils.CloneArrayFloat64(df1.Trees[:df1.BufSize])
+}
+
+/*************************************************************************
+Serialization of DecisionForest strucure
+
+INPUT PARAMETERS:
+	DF      -   original
+
+OUTPUT PARAMETERS:
+	RA      -   array of real numbers which stores decision forest,
+				array[0..RLen-1]
+	RLen    -   RA lenght
+
+  -- ALGLIB --
+	 Copyright 13.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfSerialize(df *DecisionForest, ra *[]float64, rlen *int) {
+	//
+	//  RA format:
+	//      LEN         DESRC.
+	//      1           RLen
+	//      1           version (DFVNum)
+	//      1           NVars
+	//      1           NClasses
+	//      1           NTrees
+	//      1           BufSize
+	//      BufSize     Trees
+	//
+	*rlen = 6 + df.BufSize
+	*ra = make([]float64, *rlen)
+	(*ra)[0] = float64(*rlen)
+	(*ra)[1] = dfvnum
+	(*ra)[2] = float64(df.NVars)
+	(*ra)[3] = float64(df.NClasses)
+	(*ra)[4] = float64(df.NTrees)
+	(*ra)[5] = float64(df.BufSize)
+	copy((*ra)[6:], df.Trees[:df.BufSize])
+}
+
+/*************************************************************************
+Unserialization of DecisionForest strucure
+
+INPUT PARAMETERS:
+	RA      -   real array which stores decision forest
+
+OUTPUT PARAMETERS:
+	DF      -   restored structure
+
+  -- ALGLIB --
+	 Copyright 13.02.2009 by Bochkanov Sergey
+*************************************************************************/
+func DfUnserialize(ra []float64, df *DecisionForest) error {
+	if len(ra) < 6 || utils.RoundInt(ra[1]) != dfvnum || utils.RoundInt(ra[0]) != len(ra) {
+		return fmt.Errorf("DFUnserialize: incorrect array!")
+	}
+	nvars := utils.RoundInt(ra[2])
+	nclasses := utils.RoundInt(ra[3])
+	ntrees := utils.RoundInt(ra[4])
+	bufsize := utils.RoundInt(ra[5])
+	if nvars < 1 || nclasses < 1 || ntrees < 1 || bufsize != len(ra)-6 {
+		return fmt.Errorf("DFUnserialize: incorrect array!")
+	}
+
+	//
+	// Check that every tree of the buffer is navigated within its bounds,
+	// so the damaged array can't break DFProcess
+	//
+	trees := ra[6:]
+	offs := 0
+	for i := 0; i <= ntrees-1; i++ {
+		if offs >= bufsize {
+			return fmt.Errorf("DFUnserialize: incorrect array!")
+		}
+		size := utils.RoundInt(trees[offs])
+		if size < 1+leafnodewidth || offs+size > bufsize || !dfchecktree(trees[offs:offs+size], nvars, nclasses) {
+			return fmt.Errorf("DFUnserialize: incorrect array!")
+		}
+		offs = offs + size
+	}
+	if offs != bufsize {
+		return fmt.Errorf("DFUnserialize: incorrect array!")
+	}
+
+	df.NVars = nvars
+	df.NClasses = nclasses
+	df.NTrees = ntrees
+	df.BufSize = bufsize
+	df.Trees = utils.CloneArrayFloat64(trees)
+	return nil
+}
+
+/*************************************************************************
+Classification error
+*************************************************************************/
+func dfclserror(df *DecisionForest, xy *[][]float64, npoints int) int {
+	if df.NClasses <= 1 {
+		return 0
+	}
+	x := make([]float64, df.NVars)
+	y := make([]float64, df.NClasses)
+	result := 0
+	for i := 0; i <= npoints-1; i++ {
+		copy(x, (*xy)[i][:df.NVars])
+		DfProcess(df, &x, &y)
+		k := utils.RoundInt((*xy)[i][df.NVars])
+		tmpi := 0
+		for j := 1; j <= df.NClasses-1; j++ {
+			if y[j] > y[tmpi] {
+				tmpi = j
+			}
+		}
+		if tmpi != k {
+			result = result + 1
+		}
+	}
+	return result
+}
+
+/*************************************************************************
+Checks that nodes of the tree refer to the existing variables, classes
+and nodes
+*************************************************************************/
+func dfchecktree(tree []float64, nvars, nclasses int) bool {
+	k := 1
+	for k < len(tree) {
+		if tree[k] == -1 {
+			if k+leafnodewidth > len(tree) {
+				return false
+			}
+			if nclasses > 1 && (utils.RoundInt(tree[k+1]) < 0 || utils.RoundInt(tree[k+1]) >= nclasses) {
+				return false
+			}
+			k = k + leafnodewidth
+			continue
+		}
+		if k+innernodewidth > len(tree) || utils.RoundInt(tree[k]) < 0 || utils.RoundInt(tree[k]) >= nvars {
+			return false
+		}
+		// ">=" branch is always stored after the "<" one
+		if next := utils.RoundInt(tree[k+2]); next <= k || next >= len(tree) {
+			return false
+		}
+		k = k + innernodewidth
+	}
+	return k == len(tree)
+}
+
+/*************************************************************************
+Internal subroutine for processing one decision tree starting at Offs
+*************************************************************************/
+func dfprocessinternal(df *DecisionForest, offs int, x, y []float64) {
+
+	//
+	// Set pointer to the root
+	//
+	k := offs + 1
+
+	//
+	// Navigate through the tree
+	//
+	for {
+		if df.Trees[k] == -1 {
+			if df.NClasses == 1 {
+				y[0] = y[0] + df.Trees[k+1]
+			} else {
+				idx := utils.RoundInt(df.Trees[k+1])
+				y[idx] = y[idx] + 1
+			}
+			break
+		}
+		if x[utils.RoundInt(df.Trees[k])] < df.Trees[k+1] {
+			k = k + innernodewidth
+		} else {
+			k = offs + utils.RoundInt(df.Trees[k+2])
+		}
+	}
+}
+
+/*************************************************************************
+Builds one decision tree. Just a wrapper for the DFBuildTreeRec.
+*************************************************************************/
+func dfbuildtree(xy [][]float64, npoints, nvars, nclasses, nfeatures, nvarsinpool, flags int, bufs *dfinternalbuffers) error {
+	if npoints <= 0 {
+		return fmt.Errorf("DFBuildTree: NPoints<=0")
+	}
+
+	//
+	// Prepare IdxBuf. It stores indices of the training set elements.
+	// When training set is being split, contents of IdxBuf is
+	// correspondingly reordered so we can know which elements belong
+	// to which branch of decision tree.
+	//
+	for i := 0; i <= npoints-1; i++ {
+		bufs.idxbuf[i] = i
+	}
+
+	//
+	// Recursive procedure
+	//
+	numprocessed := 1
+	if err := dfbuildtreerec(xy, npoints, nvars, nclasses, nfeatures, nvarsinpool, flags, &numprocessed, 0, npoints-1, bufs); err != nil {
+		return err
+	}
+	bufs.treebuf[0] = float64(numprocessed)
+	return nil
+}
+
+/*************************************************************************
+Builds one decision tree (internal recursive subroutine)
+
+Parameters:
+	TreeBuf     -   large enough array, at least TreeSize
+	IdxBuf      -   at least NPoints elements
+	TmpBufR     -   at least NPoints
+	TmpBufR2    -   at least NPoints
+	TmpBufI     -   at least NPoints
+*************************************************************************/
+func dfbuildtreerec(xy [][]float64, npoints, nvars, nclasses, nfeatures, nvarsinpool, flags int, numprocessed *int, idx1, idx2 int, bufs *dfinternalbuffers) error {
+	if npoints <= 0 || idx2 < idx1 {
+		return fmt.Errorf("DFBuildTreeRec: incorrect partition [%d..%d]", idx1, idx2)
+	}
+	useevs := flags/dfuseevs%2 != 0
+
+	//
+	// Leaf node
+	//
+	if idx2 == idx1 {
+		bufs.treebuf[*numprocessed] = -1
+		bufs.treebuf[*numprocessed+1] = xy[bufs.idxbuf[idx1]][nvars]
+		*numprocessed = *numprocessed + leafnodewidth
+		return nil
+	}
+
+	//
+	// Non-leaf node.
+	// Select random variable, prepare split:
+	// 1. prepare default solution - no splitting, class at random
+	// 2. investigate possible splits, compare with default/best
+	//
+	idxbest := -1
+	tbest := 0.0
+	ebest := 0.0
+	if nclasses > 1 {
+
+		//
+		// default solution for classification
+		//
+		for i := 0; i <= nclasses-1; i++ {
+			bufs.classibuf[i] = 0
+		}
+		s := float64(idx2 - idx1 + 1)
+		for i := idx1; i <= idx2; i++ {
+			j := utils.RoundInt(xy[bufs.idxbuf[i]][nvars])
+			bufs.classibuf[j] = bufs.classibuf[j] + 1
+		}
+		for i := 0; i <= nclasses-1; i++ {
+			w := float64(bufs.classibuf[i])
+			ebest = ebest + w*utils.SqrFloat64(1-w/s) + (s-w)*utils.SqrFloat64(w/s)
+		}
+		ebest = math.Sqrt(ebest / float64(nclasses*(idx2-idx1+1)))
+	} else {
+
+		//
+		// default solution for regression
+		//
+		v := 0.0
+		for i := idx1; i <= idx2; i++ {
+			v = v + xy[bufs.idxbuf[i]][nvars]
+		}
+		v = v / float64(idx2-idx1+1)
+		for i := idx1; i <= idx2; i++ {
+			ebest = ebest + utils.SqrFloat64(xy[bufs.idxbuf[i]][nvars]-v)
+		}
+		ebest = math.Sqrt(ebest / float64(idx2-idx1+1))
+	}
+	i := 0
+	for i <= utils.MinInt(nfeatures, nvarsinpool)-1 {
+
+		//
+		// select variables from pool
+		//
+		j := i + rand.Intn(nvarsinpool-i)
+		bufs.varpool[i], bufs.varpool[j] = bufs.varpool[j], bufs.varpool[i]
+		varcur := bufs.varpool[i]
+
+		//
+		// load variable values to working array
+		//
+		// apply EVS preprocessing: if all variable values are same,
+		// variable is excluded from pool.
+		//
+		// This is necessary for binary pre-splits (see later) to work.
+		//
+		for j := idx1; j <= idx2; j++ {
+			bufs.tmpbufr[j-idx1] = xy[bufs.idxbuf[j]][varcur]
+		}
+		if useevs {
+			bflag := false
+			v := bufs.tmpbufr[0]
+			for j := 0; j <= idx2-idx1; j++ {
+				if bufs.tmpbufr[j] != v {
+					bflag = true
+					break
+				}
+			}
+			if !bflag {
+
+				//
+				// exclude variable from pool,
+				// go to the next iteration.
+				// I is not increased.
+				//
+				bufs.varpool[i], bufs.varpool[nvarsinpool-1] = bufs.varpool[nvarsinpool-1], bufs.varpool[i]
+				nvarsinpool = nvarsinpool - 1
+				continue
+			}
+		}
+
+		//
+		// load labels to working array
+		//
+		if nclasses > 1 {
+			for j := idx1; j <= idx2; j++ {
+				bufs.tmpbufi[j-idx1] = utils.RoundInt(xy[bufs.idxbuf[j]][nvars])
+			}
+		} else {
+			for j := idx1; j <= idx2; j++ {
+				bufs.tmpbufr2[j-idx1] = xy[bufs.idxbuf[j]][nvars]
+			}
+		}
+
+		//
+		// calculate split
+		//
+		info := 0
+		threshold := 0.0
+		currms := 0.0
+		if useevs && bufs.evsbin[varcur] {
+
+			//
+			// Pre-calculated splits for binary variables.
+			// Threshold is already known, just calculate RMS error
+			//
+			threshold = bufs.evssplits[varcur]
+			sl := 0.0
+			sr := 0.0
+			if nclasses > 1 {
+
+				//
+				// classification-specific code
+				//
+				for j := 0; j <= 2*nclasses-1; j++ {
+					bufs.classibuf[j] = 0
+				}
+				for j := 0; j <= idx2-idx1; j++ {
+					k := bufs.tmpbufi[j]
+					if bufs.tmpbufr[j] < threshold {
+						bufs.classibuf[k] = bufs.classibuf[k] + 1
+						sl = sl + 1
+					} else {
+						bufs.classibuf[k+nclasses] = bufs.classibuf[k+nclasses] + 1
+						sr = sr + 1
+					}
+				}
+				if sl == 0 || sr == 0 {
+					return fmt.Errorf("DFBuildTreeRec: something strange!")
+				}
+				for j := 0; j <= nclasses-1; j++ {
+					w := float64(bufs.classibuf[j])
+					currms = currms + w*utils.SqrFloat64(w/sl-1)
+					currms = currms + (sl-w)*utils.SqrFloat64(w/sl)
+					w = float64(bufs.classibuf[nclasses+j])
+					currms = currms + w*utils.SqrFloat64(w/sr-1)
+					currms = currms + (sr-w)*utils.SqrFloat64(w/sr)
+				}
+				currms = math.Sqrt(currms / float64(nclasses*(idx2-idx1+1)))
+			} else {
+
+				//
+				// regression-specific code
+				//
+				v1 := 0.0
+				v2 := 0.0
+				for j := 0; j <= idx2-idx1; j++ {
+					if bufs.tmpbufr[j] < threshold {
+						v1 = v1 + bufs.tmpbufr2[j]
+						sl = sl + 1
+					} else {
+						v2 = v2 + bufs.tmpbufr2[j]
+						sr = sr + 1
+					}
+				}
+				if sl == 0 || sr == 0 {
+					return fmt.Errorf("DFBuildTreeRec: something strange!")
+				}
+				v1 = v1 / sl
+				v2 = v2 / sr
+				for j := 0; j <= idx2-idx1; j++ {
+					if bufs.tmpbufr[j] < threshold {
+						currms = currms + utils.SqrFloat64(v1-bufs.tmpbufr2[j])
+					} else {
+						currms = currms + utils.SqrFloat64(v2-bufs.tmpbufr2[j])
+					}
+				}
+				currms = math.Sqrt(currms / float64(idx2-idx1+1))
+			}
+			info = 1
+		} else {
+
+			//
+			// Generic splits
+			//
+			var err error
+			if nclasses > 1 {
+				err = dfsplitc(bufs.tmpbufr, bufs.tmpbufi, bufs.classibuf, idx2-idx1+1, nclasses, dfusestrongsplits, &info, &threshold, &currms)
+			} else {
+				err = dfsplitr(bufs.tmpbufr, bufs.tmpbufr2, idx2-idx1+1, dfusestrongsplits, &info, &threshold, &currms)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if info > 0 {
+			if currms <= ebest {
+				ebest = currms
+				idxbest = varcur
+				tbest = threshold
+			}
+		}
+
+		//
+		// Next iteration
+		//
+		i = i + 1
+	}
+
+	//
+	// to split or not to split
+	//
+	if idxbest < 0 {
+
+		//
+		// All values are same, cannot split.
+		//
+		bufs.treebuf[*numprocessed] = -1
+		if nclasses > 1 {
+
+			//
+			// Select random class label (randomness allows us to
+			// approximate distribution of the classes)
+			//
+			bufs.treebuf[*numprocessed+1] = float64(utils.RoundInt(xy[bufs.idxbuf[idx1+rand.Intn(idx2-idx1+1)]][nvars]))
+		} else {
+
+			//
+			// Select average (for regression task).
+			//
+			v := 0.0
+			for i := idx1; i <= idx2; i++ {
+				v = v + xy[bufs.idxbuf[i]][nvars]/float64(idx2-idx1+1)
+			}
+			bufs.treebuf[*numprocessed+1] = v
+		}
+		*numprocessed = *numprocessed + leafnodewidth
+		return nil
+	}
+
+	//
+	// we can split
+	//
+	bufs.treebuf[*numprocessed] = float64(idxbest)
+	bufs.treebuf[*numprocessed+1] = tbest
+	i1 := idx1
+	i2 := idx2
+	for i1 <= i2 {
+
+		//
+		// Reorder indices so that left partition is in [Idx1..I1-1],
+		// and right partition is in [I2+1..Idx2]
+		//
+		if xy[bufs.idxbuf[i1]][idxbest] < tbest {
+			i1 = i1 + 1
+			continue
+		}
+		if xy[bufs.idxbuf[i2]][idxbest] >= tbest {
+			i2 = i2 - 1
+			continue
+		}
+		bufs.idxbuf[i1], bufs.idxbuf[i2] = bufs.idxbuf[i2], bufs.idxbuf[i1]
+		i1 = i1 + 1
+		i2 = i2 - 1
+	}
+	oldnp := *numprocessed
+	*numprocessed = *numprocessed + innernodewidth
+	if err := dfbuildtreerec(xy, npoints, nvars, nclasses, nfeatures, nvarsinpool, flags, numprocessed, idx1, i1-1, bufs); err != nil {
+		return err
+	}
+	bufs.treebuf[oldnp+2] = float64(*numprocessed)
+	return dfbuildtreerec(xy, npoints, nvars, nclasses, nfeatures, nvarsinpool, flags, numprocessed, i2+1, idx2, bufs)
+}
+
+/*************************************************************************
+Makes split on attribute
+*************************************************************************/
+func dfsplitc(x []float64, c []int, cntbuf []int, n, nc, flags int, info *int, threshold, e *float64) error {
+	tagsortfasti(x, c, n)
+	*e = maxrealnumber
+	*threshold = 0.5 * (x[0] + x[n-1])
+	*info = -3
+	qcnt, qmin, qmax := dfsplitquantiles(flags)
+	for q := qmin; q <= qmax; q++ {
+		nleft, cursplit, ok, err := dfsplitat(x, n, x[n*q/qcnt])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		*info = 1
+		for i := 0; i <= 2*nc-1; i++ {
+			cntbuf[i] = 0
+		}
+		for i := 0; i <= nleft-1; i++ {
+			cntbuf[c[i]] = cntbuf[c[i]] + 1
+		}
+		for i := nleft; i <= n-1; i++ {
+			cntbuf[nc+c[i]] = cntbuf[nc+c[i]] + 1
+		}
+		sl := float64(nleft)
+		sr := float64(n - nleft)
+		v := 0.0
+		for i := 0; i <= nc-1; i++ {
+			w := float64(cntbuf[i])
+			v = v + w*utils.SqrFloat64(w/sl-1)
+			v = v + (sl-w)*utils.SqrFloat64(w/sl)
+			w = float64(cntbuf[nc+i])
+			v = v + w*utils.SqrFloat64(w/sr-1)
+			v = v + (sr-w)*utils.SqrFloat64(w/sr)
+		}
+		cure := math.Sqrt(v / float64(nc*n))
+		if cure < *e {
+			*threshold = cursplit
+			*e = cure
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Makes split on attribute
+*************************************************************************/
+func dfsplitr(x, y []float64, n, flags int, info *int, threshold, e *float64) error {
+	tagsortfastr(x, y, n)
+	*e = maxrealnumber
+	*threshold = 0.5 * (x[0] + x[n-1])
+	*info = -3
+	qcnt, qmin, qmax := dfsplitquantiles(flags)
+	for q := qmin; q <= qmax; q++ {
+		nleft, cursplit, ok, err := dfsplitat(x, n, x[n*q/qcnt])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		*info = 1
+		cure := 0.0
+		v := 0.0
+		for i := 0; i <= nleft-1; i++ {
+			v = v + y[i]
+		}
+		v = v / float64(nleft)
+		for i := 0; i <= nleft-1; i++ {
+			cure = cure + utils.SqrFloat64(y[i]-v)
+		}
+		v = 0
+		for i := nleft; i <= n-1; i++ {
+			v = v + y[i]
+		}
+		v = v / float64(n-nleft)
+		for i := nleft; i <= n-1; i++ {
+			cure = cure + utils.SqrFloat64(y[i]-v)
+		}
+		cure = math.Sqrt(cure / float64(n))
+		if cure < *e {
+			*threshold = cursplit
+			*e = cure
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Quantiles tried by the split: weak splits split at half, strong splits
+choose best quartile
+*************************************************************************/
+func dfsplitquantiles(flags int) (qcnt, qmin, qmax int) {
+	if flags/dfusestrongsplits%2 == 0 {
+		return 2, 1, 1
+	}
+	return 4, 1, 3
+}
+
+/*************************************************************************
+Threshold of the split of the sorted X near CurSplit and size of the left
+partition, OK is false if all values are the same.
+
+Threshold is set between two partitions, with some tweaking to avoid
+problems with floating point arithmetics.
+
+The problem is that when you calculates C = 0.5*(A+B) there can be no C
+which lies strictly between A and B (for example, there is no floating
+point number which is greater than 1 and less than 1+eps). In such
+situations we choose right side as theshold (remember that points which
+lie on threshold falls to the right side).
+*************************************************************************/
+func dfsplitat(x []float64, n int, cursplit float64) (nleft int, threshold float64, ok bool, err error) {
+	neq := 0
+	nless := 0
+	ngreater := 0
+	for i := 0; i <= n-1; i++ {
+		if x[i] < cursplit {
+			nless = nless + 1
+		}
+		if x[i] == cursplit {
+			neq = neq + 1
+		}
+		if x[i] > cursplit {
+			ngreater = ngreater + 1
+		}
+	}
+	if neq == 0 {
+		return 0, 0, false, fmt.Errorf("DFSplitR: NEq=0, something strange!!!")
+	}
+	if nless == 0 && ngreater == 0 {
+		return 0, 0, false, nil
+	}
+	if nless < ngreater {
+		threshold = 0.5 * (x[nless+neq-1] + x[nless+neq])
+		nleft = nless + neq
+		if threshold <= x[nless+neq-1] {
+			threshold = x[nless+neq]
+		}
+	} else {
+		threshold = 0.5 * (x[nless-1] + x[nless])
+		nleft = nless
+		if threshold <= x[nless-1] {
+			threshold = x[nless]
+		}
+	}
+	return nleft, threshold, true, nil
+}
+
+type tagsorti struct {
+	a []float64
+	b []int
+}
+
+func (s tagsorti) Len() int           { return len(s.a) }
+func (s tagsorti) Less(i, j int) bool { return s.a[i] < s.a[j] }
+func (s tagsorti) Swap(i, j int) {
+	s.a[i], s.a[j] = s.a[j], s.a[i]
+	s.b[i], s.b[j] = s.b[j], s.b[i]
+}
+
+type tagsortr struct {
+	a []float64
+	b []float64
+}
+
+func (s tagsortr) Len() int           { return len(s.a) }
+func (s tagsortr) Less(i, j int) bool { return s.a[i] < s.a[j] }
+func (s tagsortr) Swap(i, j int) {
+	s.a[i], s.a[j] = s.a[j], s.a[i]
+	s.b[i], s.b[j] = s.b[j], s.b[i]
+}
+
+/*************************************************************************
+Sorts A[0..N-1] in ascending order, integer tags B[0..N-1] are reordered
+together with A. Order of the equal keys is not preserved, the splits
+don't depend on it.
+*************************************************************************/
+func tagsortfasti(a []float64, b []int, n int) {
+	sort.Sort(tagsorti{a: a[:n], b: b[:n]})
+}
+
+/*************************************************************************
+Same as TagSortFastI, but the tags are real.
+*************************************************************************/
+func tagsortfastr(a []float64, b []float64, n int) {
+	sort.Sort(tagsortr{a: a[:n], b: b[:n]})
+}
@@ -0,0 +1,134 @@
+package forest_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/forest"
+)
+
+// classificationSet - class is the quadrant of the point, the third variable is noise
+func classificationSet(npoints int, seed int64) [][]float64 {
+	rnd := rand.New(rand.NewSource(seed))
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		x0, x1 := rnd.Float64()*2-1, rnd.Float64()*2-1
+		class := 0.0
+		if x0 >= 0 {
+			class++
+		}
+		if x1 >= 0 {
+			class += 2
+		}
+		xy[i] = []float64{x0, x1, rnd.Float64(), class}
+	}
+	return xy
+}
+
+func TestClassification(t *testing.T) {
+	xy := classificationSet(400, 1)
+	df := forest.NewDecisionForest()
+	rep := forest.NewDfReport()
+	info := 0
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 3, 4, 50, 0.5, &info, df, rep); err != nil {
+		t.Fatal(err)
+	}
+	if info != 1 {
+		t.Fatalf("info: %d", info)
+	}
+	if rep.OobRelClsError > 0.1 || rep.RelClsError > rep.OobRelClsError+1e-12 {
+		t.Errorf("classification error: %v, out-of-bag: %v", rep.RelClsError, rep.OobRelClsError)
+	}
+
+	test := classificationSet(100, 2)
+	if e := forest.DfRelClsError(df, &test, len(test)); e > 0.1 {
+		t.Errorf("test set classification error: %v", e)
+	}
+	var y []float64
+	x := []float64{0.5, -0.5, 0.5}
+	forest.DfProcessi(df, &x, &y)
+	if len(y) != 4 || math.Abs(y[0]+y[1]+y[2]+y[3]-1) > 1e-12 || y[1] < 0.5 {
+		t.Errorf("posterior probabilities: %v", y)
+	}
+}
+
+func TestRegression(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	xy := make([][]float64, 300)
+	for i := range xy {
+		x := rnd.Float64() * 4
+		xy[i] = []float64{x, math.Sin(x)}
+	}
+	df := forest.NewDecisionForest()
+	rep := forest.NewDfReport()
+	info := 0
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 1, 1, 50, 0.66, &info, df, rep); err != nil {
+		t.Fatal(err)
+	}
+	if info != 1 || rep.OobRmsError > 0.1 || rep.AvgCE != 0 {
+		t.Errorf("info: %d, report: %+v", info, rep)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	xy := classificationSet(20, 4)
+	df := forest.NewDecisionForest()
+	rep := forest.NewDfReport()
+	info := 0
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 3, 4, 10, 0, &info, df, rep); err != nil || info != -1 {
+		t.Errorf("zero sample ratio, info: %d, error: %v", info, err)
+	}
+	if err := forest.DfBuildRandomDecisionForestX1(&xy, len(xy), 3, 4, 10, 4, 0.5, &info, df, rep); err != nil || info != -1 {
+		t.Errorf("more random variables than variables, info: %d, error: %v", info, err)
+	}
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 3, 3, 10, 0.5, &info, df, rep); err != nil || info != -2 {
+		t.Errorf("class out of range, info: %d, error: %v", info, err)
+	}
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 4, 4, 10, 0.5, &info, df, rep); err == nil {
+		t.Error("short rows accepted")
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	xy := classificationSet(100, 5)
+	df := forest.NewDecisionForest()
+	info := 0
+	if err := forest.DfBuildRandomDecisionForest(&xy, len(xy), 3, 4, 10, 0.5, &info, df, forest.NewDfReport()); err != nil {
+		t.Fatal(err)
+	}
+	var ra []float64
+	rlen := 0
+	forest.DfSerialize(df, &ra, &rlen)
+	if rlen != len(ra) {
+		t.Fatalf("RLen: %d, array length: %d", rlen, len(ra))
+	}
+	restored := forest.NewDecisionForest()
+	if err := forest.DfUnserialize(ra, restored); err != nil {
+		t.Fatal(err)
+	}
+	copied := forest.NewDecisionForest()
+	forest.DfCopy(df, copied)
+	var expected, actual, other []float64
+	for _, row := range xy {
+		x := row[:3]
+		forest.DfProcessi(df, &x, &expected)
+		forest.DfProcessi(restored, &x, &actual)
+		forest.DfProcessi(copied, &x, &other)
+		for j := range expected {
+			if expected[j] != actual[j] || expected[j] != other[j] {
+				t.Fatalf("outputs differ: %v, restored %v, copied %v", expected, actual, other)
+			}
+		}
+	}
+
+	if err := forest.DfUnserialize(ra[:len(ra)-1], restored); err == nil {
+		t.Error("truncated array accepted")
+	}
+	damaged := append([]float64{}, ra...)
+	// variable of the root of the first tree
+	damaged[7] = 10
+	if err := forest.DfUnserialize(damaged, restored); err == nil {
+		t.Error("damaged array accepted")
+	}
+}
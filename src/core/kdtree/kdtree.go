@@ -0,0 +1,1202 @@
+package kdtree
+
+import (
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	splitnodesize = 6
+
+	// maximum count of the points in the leaf
+	maxleafsize = 8
+)
+
+/*************************************************************************
+KD-tree.
+
+NODES FORMAT, nodes are stored one after another from the offset 0:
+	leaf node:
+Nodes[K+0]   -   number of points in the leaf (>0)
+Nodes[K+1]   -   index of the first point in XY
+	split node:
+Nodes[K+0]   -   0
+Nodes[K+1]   -   dimension to split
+Nodes[K+2]   -   index of the split position in Splits
+Nodes[K+3]   -   offset of the "<=" child
+Nodes[K+4]   -   offset of the ">" child
+
+XY stores NX X columns which are used by the search, followed by the
+copy of the NX X columns and NY Y columns which are returned as results.
+*************************************************************************/
+type KdTree struct {
+	N        int
+	NX       int
+	NY       int
+	NormType int
+
+	xy     [][]float64
+	tags   []int
+	boxmin []float64
+	boxmax []float64
+	nodes  []int
+	splits []float64
+
+	// query state
+	x         []float64
+	kneeded   int
+	rneeded   float64
+	selfmatch bool
+	approxf   float64
+	kcur      int
+	idx       []int
+	r         []float64
+	buf       []float64
+	curboxmin []float64
+	curboxmax []float64
+	curdist   float64
+}
+
+func NewKdTree() *KdTree {
+	return &KdTree{}
+}
+
+/*************************************************************************
+KD-tree creation
+
+This subroutine creates KD-tree from set of X-values and optional Y-values
+
+INPUT PARAMETERS
+	XY      -   dataset, array[0..N-1,0..NX+NY-1].
+				one row corresponds to one point.
+				first NX columns contain X-values, next NY (NY may be zero)
+				columns may contain associated Y-values
+	N       -   number of points, N>=0.
+	NX      -   space dimension, NX>=1.
+	NY      -   number of optional Y-values, NY>=0.
+	NormType-   norm type:
+				* 0 denotes infinity-norm
+				* 1 denotes 1-norm
+				* 2 denotes 2-norm (Euclidean norm)
+
+OUTPUT PARAMETERS
+	KDT     -   KD-tree
+
+NOTES
+
+1. KD-tree  creation  have O(N*logN) complexity and O(N*(2*NX+NY))  memory
+   requirements.
+2. Although KD-trees may be used with any combination of N  and  NX,  they
+   are more efficient than brute-force search only when N >> 4^NX. So they
+   are most useful in low-dimensional tasks (NX=2, NX=3). NX=1  is another
+   inefficient case, because  simple  binary  search  (without  additional
+   structures) is much more efficient in such tasks than KD-trees.
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeBuild(xy *[][]float64, n, nx, ny, normtype int, kdt *KdTree) error {
+	tags := make([]int, n)
+	return KdTreeBuildTagged(xy, tags, n, nx, ny, normtype, kdt)
+}
+
+/*************************************************************************
+KD-tree creation
+
+This  subroutine  creates  KD-tree  from set of X-values, integer tags and
+optional Y-values
+
+INPUT PARAMETERS
+	XY      -   dataset, array[0..N-1,0..NX+NY-1].
+				one row corresponds to one point.
+				first NX columns contain X-values, next NY (NY may be zero)
+				columns may contain associated Y-values
+	Tags    -   tags, array[0..N-1], contains integer tags associated
+				with points.
+	N       -   number of points, N>=0
+	NX      -   space dimension, NX>=1.
+	NY      -   number of optional Y-values, NY>=0.
+	NormType-   norm type:
+				* 0 denotes infinity-norm
+				* 1 denotes 1-norm
+				* 2 denotes 2-norm (Euclidean norm)
+
+OUTPUT PARAMETERS
+	KDT     -   KD-tree
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeBuildTagged(xy *[][]float64, tags []int, n, nx, ny, normtype int, kdt *KdTree) error {
+	if !(n >= 0) {
+		return fmt.Errorf("KDTreeBuildTagged: N<0")
+	}
+	if !(nx >= 1) {
+		return fmt.Errorf("KDTreeBuildTagged: NX<1")
+	}
+	if !(ny >= 0) {
+		return fmt.Errorf("KDTreeBuildTagged: NY<0")
+	}
+	if !(normtype >= 0 && normtype <= 2) {
+		return fmt.Errorf("KDTreeBuildTagged: incorrect NormType")
+	}
+	if !(len(*xy) >= n) {
+		return fmt.Errorf("KDTreeBuildTagged: rows(X)<N")
+	}
+	if !(len(tags) >= n) {
+		return fmt.Errorf("KDTreeBuildTagged: length(Tags)<N")
+	}
+	for i := 0; i <= n-1; i++ {
+		if !(len((*xy)[i]) >= nx+ny) {
+			return fmt.Errorf("KDTreeBuildTagged: row %d length %d less than NX+NY", i, len((*xy)[i]))
+		}
+		if res, _ := utils.IsFiniteVector((*xy)[i], nx+ny); !res {
+			return fmt.Errorf("KDTreeBuildTagged: XY contains infinite or NaN values")
+		}
+	}
+
+	//
+	// initialize
+	//
+	kdt.N = n
+	kdt.NX = nx
+	kdt.NY = ny
+	kdt.NormType = normtype
+	kdt.kcur = 0
+
+	//
+	// N=0 => quick exit
+	//
+	if n == 0 {
+		return nil
+	}
+
+	//
+	// Allocate
+	//
+	kdtreeallocdatasetindependent(kdt, nx, ny)
+	kdtreeallocdatasetdependent(kdt, n, nx, ny)
+
+	//
+	// Initial fill
+	//
+	for i := 0; i <= n-1; i++ {
+		for i_ := 0; i_ <= nx-1; i_++ {
+			kdt.xy[i][i_] = (*xy)[i][i_]
+		}
+		for i_ := 0; i_ <= nx+ny-1; i_++ {
+			kdt.xy[i][nx+i_] = (*xy)[i][i_]
+		}
+		kdt.tags[i] = tags[i]
+	}
+
+	//
+	// Determine bounding box
+	//
+	for i_ := 0; i_ <= nx-1; i_++ {
+		kdt.boxmin[i_] = kdt.xy[0][i_]
+		kdt.boxmax[i_] = kdt.xy[0][i_]
+	}
+	for i := 1; i <= n-1; i++ {
+		for j := 0; j <= nx-1; j++ {
+			kdt.boxmin[j] = math.Min(kdt.boxmin[j], kdt.xy[i][j])
+			kdt.boxmax[j] = math.Max(kdt.boxmax[j], kdt.xy[i][j])
+		}
+	}
+
+	//
+	// prepare tree structure
+	// * MaxNodes=N because we guarantee no trivial splits, i.e.
+	//   every split will generate two non-empty boxes
+	//
+	nodesoffs := 0
+	splitsoffs := 0
+	copy(kdt.curboxmin, kdt.boxmin)
+	copy(kdt.curboxmax, kdt.boxmax)
+	kdtreegeneratetreerec(kdt, &nodesoffs, &splitsoffs, 0, n, maxleafsize)
+	return nil
+}
+
+/*************************************************************************
+K-NN query: K nearest neighbors
+
+INPUT PARAMETERS
+	KDT         -   KD-tree
+	X           -   point, array[0..NX-1].
+	K           -   number of neighbors to return, K>=1
+	SelfMatch   -   whether self-matches are allowed:
+					* if True, nearest neighbor may be the point itself
+					  (if it exists in original dataset)
+					* if False, then only points with non-zero distance
+					  are returned
+
+RESULT
+	number of actual neighbors found (either K or N, if K>N).
+
+This  subroutine  performs  query  and  stores  its result in the internal
+structures of the KD-tree. You can use  following  subroutines  to  obtain
+these results:
+* KDTreeQueryResultsX() to get X-values
+* KDTreeQueryResultsXY() to get X- and Y-values
+* KDTreeQueryResultsTags() to get tag values
+* KDTreeQueryResultsDistances() to get distances
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryKnn(kdt *KdTree, x []float64, k int, selfmatch bool) (int, error) {
+	return KdTreeQueryAknn(kdt, x, k, selfmatch, 0.0)
+}
+
+/*************************************************************************
+R-NN query: all points within R-sphere centered at X
+
+INPUT PARAMETERS
+	KDT         -   KD-tree
+	X           -   point, array[0..NX-1].
+	R           -   radius of sphere (in corresponding norm), R>0
+	SelfMatch   -   whether self-matches are allowed:
+					* if True, nearest neighbor may be the point itself
+					  (if it exists in original dataset)
+					* if False, then only points with non-zero distance
+					  are returned
+
+RESULT
+	number of neighbors found, >=0
+
+This  subroutine  performs  query  and  stores  its result in the internal
+structures of the KD-tree. You can use  following  subroutines  to  obtain
+actual results:
+* KDTreeQueryResultsX() to get X-values
+* KDTreeQueryResultsXY() to get X- and Y-values
+* KDTreeQueryResultsTags() to get tag values
+* KDTreeQueryResultsDistances() to get distances
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryRnn(kdt *KdTree, x []float64, r float64, selfmatch bool) (int, error) {
+	if !(r > 0) {
+		return 0, fmt.Errorf("KDTreeQueryRNN: incorrect R!")
+	}
+	if !(len(x) >= kdt.NX) {
+		return 0, fmt.Errorf("KDTreeQueryRNN: Length(X)<NX!")
+	}
+	if res, _ := utils.IsFiniteVector(x, kdt.NX); !res {
+		return 0, fmt.Errorf("KDTreeQueryRNN: X contains infinite or NaN values!")
+	}
+
+	//
+	// Handle special case: KDT.N=0
+	//
+	if kdt.N == 0 {
+		kdt.kcur = 0
+		return 0, nil
+	}
+
+	//
+	// Prepare parameters
+	//
+	kdt.kneeded = 0
+	if kdt.NormType != 2 {
+		kdt.rneeded = r
+	} else {
+		kdt.rneeded = utils.SqrFloat64(r)
+	}
+	kdt.selfmatch = selfmatch
+	kdt.approxf = 1
+	kdt.kcur = 0
+
+	//
+	// calculate distance from point to current bounding box
+	//
+	kdtreeinitbox(kdt, x)
+
+	//
+	// call recursive search
+	// results are returned as heap
+	//
+	kdtreequerynnrec(kdt, 0)
+
+	//
+	// pop from heap to generate ordered representation
+	//
+	// last element is non pop'ed because it is already in
+	// its place
+	//
+	result := kdt.kcur
+	j := kdt.kcur
+	for i := kdt.kcur; i >= 2; i-- {
+		tagheappopi(kdt.r, kdt.idx, &j)
+	}
+	return result, nil
+}
+
+/*************************************************************************
+K-NN query: approximate K nearest neighbors
+
+INPUT PARAMETERS
+	KDT         -   KD-tree
+	X           -   point, array[0..NX-1].
+	K           -   number of neighbors to return, K>=1
+	SelfMatch   -   whether self-matches are allowed:
+					* if True, nearest neighbor may be the point itself
+					  (if it exists in original dataset)
+					* if False, then only points with non-zero distance
+					  are returned
+	Eps         -   approximation factor, Eps>=0. eps-approximate  nearest
+					neighbor  is  a  neighbor  whose distance from X is at
+					most (1+eps) times distance of true nearest neighbor.
+
+RESULT
+	number of actual neighbors found (either K or N, if K>N).
+
+NOTES
+	significant performance gain may be achieved only when Eps  is  is  on
+	the order of magnitude of 1 or larger.
+
+This  subroutine  performs  query  and  stores  its result in the internal
+structures of the KD-tree. You can use  following  subroutines  to  obtain
+these results:
+* KDTreeQueryResultsX() to get X-values
+* KDTreeQueryResultsXY() to get X- and Y-values
+* KDTreeQueryResultsTags() to get tag values
+* KDTreeQueryResultsDistances() to get distances
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryAknn(kdt *KdTree, x []float64, k int, selfmatch bool, eps float64) (int, error) {
+	if !(k > 0) {
+		return 0, fmt.Errorf("KDTreeQueryAKNN: incorrect K!")
+	}
+	if !(eps >= 0) {
+		return 0, fmt.Errorf("KDTreeQueryAKNN: incorrect Eps!")
+	}
+	if !(len(x) >= kdt.NX) {
+		return 0, fmt.Errorf("KDTreeQueryAKNN: Length(X)<NX!")
+	}
+	if res, _ := utils.IsFiniteVector(x, kdt.NX); !res {
+		return 0, fmt.Errorf("KDTreeQueryAKNN: X contains infinite or NaN values!")
+	}
+
+	//
+	// Handle special case: KDT.N=0
+	//
+	if kdt.N == 0 {
+		kdt.kcur = 0
+		return 0, nil
+	}
+
+	//
+	// Prepare parameters
+	//
+	k = utils.MinInt(k, kdt.N)
+	kdt.kneeded = k
+	kdt.rneeded = 0
+	kdt.selfmatch = selfmatch
+	if kdt.NormType == 2 {
+		kdt.approxf = 1 / utils.SqrFloat64(1+eps)
+	} else {
+		kdt.approxf = 1 / (1 + eps)
+	}
+	kdt.kcur = 0
+
+	//
+	// calculate distance from point to current bounding box
+	//
+	kdtreeinitbox(kdt, x)
+
+	//
+	// call recursive search
+	// results are returned as heap
+	//
+	kdtreequerynnrec(kdt, 0)
+
+	//
+	// pop from heap to generate ordered representation
+	//
+	// last element is non pop'ed because it is already in
+	// its place
+	//
+	result := kdt.kcur
+	j := kdt.kcur
+	for i := kdt.kcur; i >= 2; i-- {
+		tagheappopi(kdt.r, kdt.idx, &j)
+	}
+	return result, nil
+}
+
+/*************************************************************************
+X-values from last query
+
+INPUT PARAMETERS
+	KDT     -   KD-tree
+
+OUTPUT PARAMETERS
+	X       -   rows are filled with X-values, array[0..K-1,0..NX-1]
+				K is the number of neighbors found by the last query
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryResultsX(kdt *KdTree, x *[][]float64) {
+	*x = utils.MakeMatrixFloat64(kdt.kcur, kdt.NX)
+	for i := 0; i <= kdt.kcur-1; i++ {
+		for i_ := 0; i_ <= kdt.NX-1; i_++ {
+			(*x)[i][i_] = kdt.xy[kdt.idx[i]][kdt.NX+i_]
+		}
+	}
+}
+
+/*************************************************************************
+X- and Y-values from last query
+
+INPUT PARAMETERS
+	KDT     -   KD-tree
+
+OUTPUT PARAMETERS
+	XY      -   rows are filled with points: first NX columns with
+				X-values, next NY columns - with Y-values,
+				array[0..K-1,0..NX+NY-1]
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryResultsXY(kdt *KdTree, xy *[][]float64) {
+	*xy = utils.MakeMatrixFloat64(kdt.kcur, kdt.NX+kdt.NY)
+	for i := 0; i <= kdt.kcur-1; i++ {
+		for i_ := 0; i_ <= kdt.NX+kdt.NY-1; i_++ {
+			(*xy)[i][i_] = kdt.xy[kdt.idx[i]][kdt.NX+i_]
+		}
+	}
+}
+
+/*************************************************************************
+Tags from last query
+
+INPUT PARAMETERS
+	KDT     -   KD-tree
+
+OUTPUT PARAMETERS
+	Tags    -   filled with tags associated with points,
+				array[0..K-1]
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryResultsTags(kdt *KdTree, tags *[]int) {
+	*tags = make([]int, kdt.kcur)
+	for i := 0; i <= kdt.kcur-1; i++ {
+		(*tags)[i] = kdt.tags[kdt.idx[i]]
+	}
+}
+
+/*************************************************************************
+Distances from last query
+
+INPUT PARAMETERS
+	KDT     -   KD-tree
+
+OUTPUT PARAMETERS
+	R       -   filled with distances (in corresponding norm),
+				array[0..K-1]
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func KdTreeQueryResultsDistances(kdt *KdTree, r *[]float64) {
+	*r = make([]float64, kdt.kcur)
+
+	//
+	// unload norms
+	//
+	// Abs() call is used to handle cases with negative norms
+	// (generated during KFN requests)
+	//
+	if kdt.NormType == 0 || kdt.NormType == 1 {
+		for i := 0; i <= kdt.kcur-1; i++ {
+			(*r)[i] = math.Abs(kdt.r[i])
+		}
+	}
+	if kdt.NormType == 2 {
+		for i := 0; i <= kdt.kcur-1; i++ {
+			(*r)[i] = math.Sqrt(math.Abs(kdt.r[i]))
+		}
+	}
+}
+
+/*************************************************************************
+Rearranges nodes [I1,I2) using partition in D-th dimension with S as threshold.
+Returns split position I3: [I1,I3) and [I3,I2) are created as result.
+
+This subroutine doesn't create tree structures, just rearranges nodes.
+*************************************************************************/
+func kdtreesplit(kdt *KdTree, i1, i2, d int, s float64, i3 *int) {
+	//
+	// split XY/Tags in two parts:
+	// * [ILeft,IRight] is non-processed part of XY/Tags
+	//
+	// After cycle is done, we have Ileft=IRight. We deal with
+	// this element separately.
+	//
+	// After this, [I1,ILeft) contains left part, and [ILeft,I2)
+	// contains right part.
+	//
+	ileft := i1
+	iright := i2 - 1
+	for ileft < iright {
+		if kdt.xy[ileft][d] <= s {
+			//
+			// XY[ILeft] is on its place.
+			// Advance ILeft.
+			//
+			ileft = ileft + 1
+		} else {
+			//
+			// XY[ILeft,..] must be at IRight.
+			// Swap and advance IRight.
+			//
+			kdt.xy[ileft], kdt.xy[iright] = kdt.xy[iright], kdt.xy[ileft]
+			kdt.tags[ileft], kdt.tags[iright] = kdt.tags[iright], kdt.tags[ileft]
+			iright = iright - 1
+		}
+	}
+	if kdt.xy[ileft][d] <= s {
+		ileft = ileft + 1
+	}
+	*i3 = ileft
+}
+
+/*************************************************************************
+Recursive kd-tree generation subroutine.
+
+PARAMETERS
+	KDT         tree
+	NodesOffs   unused part of Nodes[] which must be filled by tree
+	SplitsOffs  unused part of Splits[]
+	I1, I2      points from [I1,I2) are processed
+
+NodesOffs[] and SplitsOffs[] must be large enough.
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func kdtreegeneratetreerec(kdt *KdTree, nodesoffs, splitsoffs *int, i1, i2, maxleafsize int) {
+	var i3 int
+
+	//
+	// Generate leaf if needed
+	//
+	if i2-i1 <= maxleafsize {
+		kdt.nodes[*nodesoffs+0] = i2 - i1
+		kdt.nodes[*nodesoffs+1] = i1
+		*nodesoffs = *nodesoffs + 2
+		return
+	}
+
+	//
+	// Load values for easier access
+	//
+	nx := kdt.NX
+
+	//
+	// Select dimension to split:
+	// * D is a dimension number
+	// In case bounding box has zero size, we enforce creation of the leaf node.
+	//
+	d := 0
+	ds := kdt.curboxmax[0] - kdt.curboxmin[0]
+	for i := 1; i <= nx-1; i++ {
+		v := kdt.curboxmax[i] - kdt.curboxmin[i]
+		if v > ds {
+			ds = v
+			d = i
+		}
+	}
+	if ds == 0 {
+		kdt.nodes[*nodesoffs+0] = i2 - i1
+		kdt.nodes[*nodesoffs+1] = i1
+		*nodesoffs = *nodesoffs + 2
+		return
+	}
+
+	//
+	// Select split position S using sliding midpoint rule,
+	// rearrange points into [I1,I3) and [I3,I2).
+	//
+	s := kdt.curboxmin[d] + 0.5*ds
+	for i_ := 0; i_ <= i2-i1-1; i_++ {
+		kdt.buf[i_] = kdt.xy[i1+i_][d]
+	}
+	n := i2 - i1
+	cntless := 0
+	cntgreater := 0
+	minv := kdt.buf[0]
+	maxv := kdt.buf[0]
+	minidx := i1
+	maxidx := i1
+	for i := 0; i <= n-1; i++ {
+		v := kdt.buf[i]
+		if v < minv {
+			minv = v
+			minidx = i1 + i
+		}
+		if v > maxv {
+			maxv = v
+			maxidx = i1 + i
+		}
+		if v < s {
+			cntless = cntless + 1
+		}
+		if v > s {
+			cntgreater = cntgreater + 1
+		}
+	}
+	if minv == maxv {
+		//
+		// In case all points has same value of D-th component
+		// (MinV=MaxV) we enforce D-th dimension of bounding
+		// box to become exactly zero and repeat tree construction.
+		//
+		v0 := kdt.curboxmin[d]
+		v1 := kdt.curboxmax[d]
+		kdt.curboxmin[d] = minv
+		kdt.curboxmax[d] = maxv
+		kdtreegeneratetreerec(kdt, nodesoffs, splitsoffs, i1, i2, maxleafsize)
+		kdt.curboxmin[d] = v0
+		kdt.curboxmax[d] = v1
+		return
+	}
+	if cntless > 0 && cntgreater > 0 {
+		//
+		// normal midpoint split
+		//
+		kdtreesplit(kdt, i1, i2, d, s, &i3)
+	} else {
+		//
+		// sliding midpoint
+		//
+		if cntless == 0 {
+			//
+			// 1. move split to MinV,
+			// 2. place one point to the left bin (move to I1),
+			//    others - to the right bin
+			//
+			s = minv
+			if minidx != i1 {
+				kdt.xy[minidx], kdt.xy[i1] = kdt.xy[i1], kdt.xy[minidx]
+				kdt.tags[minidx], kdt.tags[i1] = kdt.tags[i1], kdt.tags[minidx]
+			}
+			i3 = i1 + 1
+		} else {
+			//
+			// 1. move split to MaxV,
+			// 2. place one point to the right bin (move to I2-1),
+			//    others - to the left bin
+			//
+			s = maxv
+			if maxidx != i2-1 {
+				kdt.xy[maxidx], kdt.xy[i2-1] = kdt.xy[i2-1], kdt.xy[maxidx]
+				kdt.tags[maxidx], kdt.tags[i2-1] = kdt.tags[i2-1], kdt.tags[maxidx]
+			}
+			i3 = i2 - 1
+		}
+	}
+
+	//
+	// Generate 'split' node
+	//
+	kdt.nodes[*nodesoffs+0] = 0
+	kdt.nodes[*nodesoffs+1] = d
+	kdt.nodes[*nodesoffs+2] = *splitsoffs
+	kdt.splits[*splitsoffs+0] = s
+	oldoffs := *nodesoffs
+	*nodesoffs = *nodesoffs + splitnodesize
+	*splitsoffs = *splitsoffs + 1
+
+	//
+	// Recursive generation:
+	// * update CurBox
+	// * call subroutine
+	// * restore CurBox
+	//
+	kdt.nodes[oldoffs+3] = *nodesoffs
+	v := kdt.curboxmax[d]
+	kdt.curboxmax[d] = s
+	kdtreegeneratetreerec(kdt, nodesoffs, splitsoffs, i1, i3, maxleafsize)
+	kdt.curboxmax[d] = v
+	kdt.nodes[oldoffs+4] = *nodesoffs
+	v = kdt.curboxmin[d]
+	kdt.curboxmin[d] = s
+	kdtreegeneratetreerec(kdt, nodesoffs, splitsoffs, i3, i2, maxleafsize)
+	kdt.curboxmin[d] = v
+}
+
+/*************************************************************************
+Recursive subroutine for NN queries.
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func kdtreequerynnrec(kdt *KdTree, offs int) {
+	//
+	// Leaf node.
+	// Process points.
+	//
+	if kdt.nodes[offs] > 0 {
+		i1 := kdt.nodes[offs+1]
+		i2 := i1 + kdt.nodes[offs]
+		for i := i1; i <= i2-1; i++ {
+			//
+			// Calculate distance
+			//
+			ptdist := 0.0
+			nx := kdt.NX
+			if kdt.NormType == 0 {
+				for j := 0; j <= nx-1; j++ {
+					ptdist = math.Max(ptdist, math.Abs(kdt.xy[i][j]-kdt.x[j]))
+				}
+			}
+			if kdt.NormType == 1 {
+				for j := 0; j <= nx-1; j++ {
+					ptdist = ptdist + math.Abs(kdt.xy[i][j]-kdt.x[j])
+				}
+			}
+			if kdt.NormType == 2 {
+				for j := 0; j <= nx-1; j++ {
+					ptdist = ptdist + utils.SqrFloat64(kdt.xy[i][j]-kdt.x[j])
+				}
+			}
+
+			//
+			// Skip points with zero distance if self-matches are turned off
+			//
+			if ptdist == 0 && !kdt.selfmatch {
+				continue
+			}
+
+			//
+			// We CAN'T process point if R-criterion isn't satisfied,
+			// i.e. (RNeeded<>0) AND (PtDist>R).
+			//
+			if kdt.rneeded == 0 || ptdist <= kdt.rneeded {
+				//
+				// R-criterion is satisfied, we must either:
+				// * replace worst point, if (KNeeded<>0) AND (KCur=KNeeded)
+				//   (or skip, if worst point is better)
+				// * add point without replacement otherwise
+				//
+				if kdt.kcur < kdt.kneeded || kdt.kneeded == 0 {
+					//
+					// add current point to heap without replacement
+					//
+					tagheappushi(kdt.r, kdt.idx, &kdt.kcur, ptdist, i)
+				} else {
+					//
+					// New points are added or not, depending on their distance.
+					// If added, they replace element at the top of the heap
+					//
+					if ptdist < kdt.r[0] {
+						if kdt.kneeded == 1 {
+							kdt.idx[0] = i
+							kdt.r[0] = ptdist
+						} else {
+							tagheapreplacetopi(kdt.r, kdt.idx, kdt.kneeded, ptdist, i)
+						}
+					}
+				}
+			}
+		}
+		return
+	}
+
+	//
+	// Simple split
+	//
+	if kdt.nodes[offs] == 0 {
+		//
+		// Load:
+		// * D  dimension to split
+		// * S  split position
+		//
+		d := kdt.nodes[offs+1]
+		s := kdt.splits[kdt.nodes[offs+2]]
+
+		//
+		// Calculate:
+		// * ChildBestOffs      child box with best chances
+		// * ChildWorstOffs     child box with worst chances
+		//
+		var childbestoffs, childworstoffs int
+		var bestisleft bool
+		if kdt.x[d] <= s {
+			childbestoffs = kdt.nodes[offs+3]
+			childworstoffs = kdt.nodes[offs+4]
+			bestisleft = true
+		} else {
+			childbestoffs = kdt.nodes[offs+4]
+			childworstoffs = kdt.nodes[offs+3]
+			bestisleft = false
+		}
+
+		//
+		// Navigate through childs
+		//
+		for i := 0; i <= 1; i++ {
+			//
+			// Select child to process:
+			// * ChildOffs      current child offset in Nodes[]
+			// * UpdateMin      whether minimum or maximum value
+			//                  of bounding box is changed on update
+			//
+			var childoffs int
+			var updatemin bool
+			if i == 0 {
+				childoffs = childbestoffs
+				updatemin = !bestisleft
+			} else {
+				updatemin = bestisleft
+				childoffs = childworstoffs
+			}
+
+			//
+			// Update bounding box and current distance
+			//
+			var v float64
+			prevdist := kdt.curdist
+			t1 := kdt.x[d]
+			if updatemin {
+				v = kdt.curboxmin[d]
+				if t1 <= s {
+					if kdt.NormType == 0 {
+						kdt.curdist = math.Max(kdt.curdist, s-t1)
+					}
+					if kdt.NormType == 1 {
+						kdt.curdist = kdt.curdist - math.Max(v-t1, 0) + s - t1
+					}
+					if kdt.NormType == 2 {
+						kdt.curdist = kdt.curdist - utils.SqrFloat64(math.Max(v-t1, 0)) + utils.SqrFloat64(s-t1)
+					}
+				}
+				kdt.curboxmin[d] = s
+			} else {
+				v = kdt.curboxmax[d]
+				if t1 >= s {
+					if kdt.NormType == 0 {
+						kdt.curdist = math.Max(kdt.curdist, t1-s)
+					}
+					if kdt.NormType == 1 {
+						kdt.curdist = kdt.curdist - math.Max(t1-v, 0) + t1 - s
+					}
+					if kdt.NormType == 2 {
+						kdt.curdist = kdt.curdist - utils.SqrFloat64(math.Max(t1-v, 0)) + utils.SqrFloat64(t1-s)
+					}
+				}
+				kdt.curboxmax[d] = s
+			}
+
+			//
+			// Decide: to dive into cell or not to dive
+			//
+			var todive bool
+			if kdt.rneeded != 0 && kdt.curdist > kdt.rneeded {
+				todive = false
+			} else {
+				if kdt.kcur < kdt.kneeded || kdt.kneeded == 0 {
+					//
+					// KCur<KNeeded (i.e. not all points are found)
+					//
+					todive = true
+				} else {
+					//
+					// KCur=KNeeded, decide to dive or not to dive
+					// using point position relative to bounding box.
+					//
+					todive = kdt.curdist <= kdt.r[0]*kdt.approxf
+				}
+			}
+			if todive {
+				kdtreequerynnrec(kdt, childoffs)
+			}
+
+			//
+			// Restore bounding box and distance
+			//
+			if updatemin {
+				kdt.curboxmin[d] = v
+			} else {
+				kdt.curboxmax[d] = v
+			}
+			kdt.curdist = prevdist
+		}
+		return
+	}
+}
+
+/*************************************************************************
+Copies X[] to KDT.X[]
+Loads distance from X[] to bounding box.
+Initializes CurBox[].
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func kdtreeinitbox(kdt *KdTree, x []float64) {
+	//
+	// calculate distance from point to current bounding box
+	//
+	kdt.curdist = 0
+	for i := 0; i <= kdt.NX-1; i++ {
+		vx := x[i]
+		vmin := kdt.boxmin[i]
+		vmax := kdt.boxmax[i]
+		kdt.x[i] = vx
+		kdt.curboxmin[i] = vmin
+		kdt.curboxmax[i] = vmax
+		var t float64
+		if vx < vmin {
+			t = vmin - vx
+		} else if vx > vmax {
+			t = vx - vmax
+		}
+		if kdt.NormType == 0 {
+			kdt.curdist = math.Max(kdt.curdist, t)
+		}
+		if kdt.NormType == 1 {
+			kdt.curdist = kdt.curdist + t
+		}
+		if kdt.NormType == 2 {
+			kdt.curdist = kdt.curdist + utils.SqrFloat64(t)
+		}
+	}
+}
+
+/*************************************************************************
+This function allocates all dataset-independent array  fields  of  KDTree,
+i.e.  such  array  fields  that  their dimensions do not depend on dataset
+size.
+
+This function do not sets KDT.NX or KDT.NY - it just allocates arrays
+
+  -- ALGLIB --
+	 Copyright 14.03.2011 by Bochkanov Sergey
+*************************************************************************/
+func kdtreeallocdatasetindependent(kdt *KdTree, nx, ny int) {
+	kdt.x = make([]float64, nx)
+	kdt.boxmin = make([]float64, nx)
+	kdt.boxmax = make([]float64, nx)
+	kdt.curboxmin = make([]float64, nx)
+	kdt.curboxmax = make([]float64, nx)
+}
+
+/*************************************************************************
+This function allocates all dataset-dependent array fields of KDTree, i.e.
+such array fields that their dimensions depend on dataset size.
+
+This function do not sets KDT.N, KDT.NX or KDT.NY -
+it just allocates arrays.
+
+  -- ALGLIB --
+	 Copyright 14.03.2011 by Bochkanov Sergey
+*************************************************************************/
+func kdtreeallocdatasetdependent(kdt *KdTree, n, nx, ny int) {
+	kdt.xy = utils.MakeMatrixFloat64(n, 2*nx+ny)
+	kdt.tags = make([]int, n)
+	kdt.idx = make([]int, n)
+	kdt.r = make([]float64, n)
+	kdt.buf = make([]float64, utils.MaxInt(n, nx))
+	kdt.nodes = make([]int, splitnodesize*2*n)
+	kdt.splits = make([]float64, 2*n)
+}
+
+/*************************************************************************
+Heap operations: adds element to the heap
+
+PARAMETERS:
+	A       -   heap itself, must be at least array[0..N]
+	B       -   array of integer tags, which are updated according to
+				permutations in the heap
+	N       -   size of the heap (without new element).
+				updated on output
+	VA      -   value of the element being added
+	VB      -   value of the tag
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func tagheappushi(a []float64, b []int, n *int, va float64, vb int) {
+	if *n < 0 {
+		return
+	}
+
+	//
+	// N=0 is a special case
+	//
+	if *n == 0 {
+		a[0] = va
+		b[0] = vb
+		*n = *n + 1
+		return
+	}
+
+	//
+	// add current point to the heap
+	// (add to the bottom, then move up)
+	//
+	// we don't write point to the heap
+	// until its final position is determined
+	// (it allow us to reduce number of array access operations)
+	//
+	j := *n
+	*n = *n + 1
+	for j > 0 {
+		k := (j - 1) / 2
+		v := a[k]
+		if v < va {
+			//
+			// swap with higher element
+			//
+			a[j] = v
+			b[j] = b[k]
+			j = k
+		} else {
+			//
+			// element in its place. terminate.
+			//
+			break
+		}
+	}
+	a[j] = va
+	b[j] = vb
+}
+
+/*************************************************************************
+Heap operations: replaces top element with new element
+(which is moved down)
+
+PARAMETERS:
+	A       -   heap itself, must be at least array[0..N-1]
+	B       -   array of integer tags, which are updated according to
+				permutations in the heap
+	N       -   size of the heap
+	VA      -   value of the element which replaces top element
+	VB      -   value of the tag
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func tagheapreplacetopi(a []float64, b []int, n int, va float64, vb int) {
+	if n < 1 {
+		return
+	}
+
+	//
+	// N=1 is a special case
+	//
+	if n == 1 {
+		a[0] = va
+		b[0] = vb
+		return
+	}
+
+	//
+	// move down through heap:
+	// * J  -   current element
+	// * K1 -   first child (always exists)
+	// * K2 -   second child (may not exists)
+	//
+	// we don't write point to the heap
+	// until its final position is determined
+	// (it allow us to reduce number of array access operations)
+	//
+	j := 0
+	k1 := 1
+	k2 := 2
+	for k1 < n {
+		if k2 >= n {
+			//
+			// only one child.
+			//
+			// swap and terminate (because this child
+			// have no siblings due to heap structure)
+			//
+			v := a[k1]
+			if v > va {
+				a[j] = v
+				b[j] = b[k1]
+				j = k1
+			}
+			break
+		} else {
+			//
+			// two childs
+			//
+			v1 := a[k1]
+			v2 := a[k2]
+			if v1 > v2 {
+				if va < v1 {
+					a[j] = v1
+					b[j] = b[k1]
+					j = k1
+				} else {
+					break
+				}
+			} else {
+				if va < v2 {
+					a[j] = v2
+					b[j] = b[k2]
+					j = k2
+				} else {
+					break
+				}
+			}
+			k1 = 2*j + 1
+			k2 = 2*j + 2
+		}
+	}
+	a[j] = va
+	b[j] = vb
+}
+
+/*************************************************************************
+Heap operations: pops top element from the heap
+
+PARAMETERS:
+	A       -   heap itself, must be at least array[0..N-1]
+	B       -   array of integer tags, which are updated according to
+				permutations in the heap
+	N       -   size of the heap, N>=1
+
+On output top element is moved to A[N-1], B[N-1], heap is reordered, N is
+decreased by 1.
+
+  -- ALGLIB --
+	 Copyright 28.02.2010 by Bochkanov Sergey
+*************************************************************************/
+func tagheappopi(a []float64, b []int, n *int) {
+	if *n < 1 {
+		return
+	}
+
+	//
+	// N=1 is a special case
+	//
+	if *n == 1 {
+		*n = 0
+		return
+	}
+
+	//
+	// swap top element and last element,
+	// then reorder heap
+	//
+	va := a[*n-1]
+	vb := b[*n-1]
+	a[*n-1] = a[0]
+	b[*n-1] = b[0]
+	*n = *n - 1
+	tagheapreplacetopi(a, b, *n, va, vb)
+}
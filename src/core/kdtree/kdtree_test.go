@@ -0,0 +1,134 @@
+package kdtree_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"pr.optima/src/core/kdtree"
+)
+
+func distance(a, b []float64, normtype int) float64 {
+	var result float64
+	for i := range a {
+		d := math.Abs(a[i] - b[i])
+		switch normtype {
+		case 0:
+			result = math.Max(result, d)
+		case 1:
+			result += d
+		case 2:
+			result += d * d
+		}
+	}
+	if normtype == 2 {
+		result = math.Sqrt(result)
+	}
+	return result
+}
+
+func TestQueryKnn(t *testing.T) {
+	n, nx := 200, 3
+	xy := make([][]float64, n)
+	tags := make([]int, n)
+	for i := range xy {
+		// rounded values produce duplicates and equal coordinates
+		xy[i] = []float64{float64(rand.Intn(10)), rand.Float64(), rand.NormFloat64(), float64(i)}
+		tags[i] = i * 10
+	}
+	for normtype := 0; normtype <= 2; normtype++ {
+		kdt := kdtree.NewKdTree()
+		if err := kdtree.KdTreeBuildTagged(&xy, tags, n, nx, 1, normtype, kdt); err != nil {
+			t.Fatal(err)
+		}
+		for q := 0; q < 20; q++ {
+			x := []float64{rand.Float64() * 10, rand.Float64(), rand.NormFloat64()}
+			expected := make([]float64, n)
+			for i := range xy {
+				expected[i] = distance(xy[i][:nx], x, normtype)
+			}
+			sort.Float64s(expected)
+
+			k, err := kdtree.KdTreeQueryKnn(kdt, x, 5, true)
+			if err != nil || k != 5 {
+				t.Fatalf("found: %d, error: %v", k, err)
+			}
+			var r []float64
+			var result [][]float64
+			var found []int
+			kdtree.KdTreeQueryResultsDistances(kdt, &r)
+			kdtree.KdTreeQueryResultsXY(kdt, &result)
+			kdtree.KdTreeQueryResultsTags(kdt, &found)
+			for i := 0; i < k; i++ {
+				if math.Abs(r[i]-expected[i]) > 1e-12 {
+					t.Fatalf("norm %d, distances: %v, expected: %v", normtype, r, expected[:k])
+				}
+				row := int(result[i][nx])
+				if found[i] != row*10 || math.Abs(distance(xy[row][:nx], x, normtype)-r[i]) > 1e-12 {
+					t.Fatalf("norm %d, neighbour %d: %v, tag: %d", normtype, i, result[i], found[i])
+				}
+			}
+
+			radius := expected[10] + 1e-9
+			if k, err = kdtree.KdTreeQueryRnn(kdt, x, radius, true); err != nil || k < 11 || (k < n && expected[k] <= radius) {
+				t.Fatalf("norm %d, found in radius: %d, error: %v", normtype, k, err)
+			}
+			kdtree.KdTreeQueryResultsDistances(kdt, &r)
+			for i := 1; i < k; i++ {
+				if r[i] < r[i-1] || r[i] > radius {
+					t.Fatalf("norm %d, distances in radius: %v", normtype, r)
+				}
+			}
+		}
+	}
+}
+
+func TestSelfMatch(t *testing.T) {
+	xy := [][]float64{{0, 0}, {1, 0}, {0, 2}, {0, 0}}
+	kdt := kdtree.NewKdTree()
+	if err := kdtree.KdTreeBuild(&xy, len(xy), 2, 0, 2, kdt); err != nil {
+		t.Fatal(err)
+	}
+	var x [][]float64
+	if k, err := kdtree.KdTreeQueryKnn(kdt, []float64{0, 0}, 2, false); err != nil || k != 2 {
+		t.Fatalf("found: %d, error: %v", k, err)
+	}
+	kdtree.KdTreeQueryResultsX(kdt, &x)
+	if x[0][0] != 1 || x[1][1] != 2 {
+		t.Errorf("neighbours without self-matches: %v", x)
+	}
+	if k, err := kdtree.KdTreeQueryKnn(kdt, []float64{0, 0}, 10, true); err != nil || k != 4 {
+		t.Errorf("found: %d of 4 points, error: %v", k, err)
+	}
+	if k, err := kdtree.KdTreeQueryAknn(kdt, []float64{0.9, 0}, 1, true, 1); err != nil || k != 1 {
+		t.Errorf("approximate neighbours found: %d, error: %v", k, err)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	kdt := kdtree.NewKdTree()
+	xy := [][]float64{{0, 1}, {1}}
+	if err := kdtree.KdTreeBuild(&xy, 2, 2, 0, 2, kdt); err == nil {
+		t.Error("short row accepted")
+	}
+	if err := kdtree.KdTreeBuild(&xy, 1, 2, 0, 3, kdt); err == nil {
+		t.Error("unknown norm accepted")
+	}
+	xy[0][1] = math.NaN()
+	if err := kdtree.KdTreeBuild(&xy, 1, 2, 0, 2, kdt); err == nil {
+		t.Error("NaN accepted")
+	}
+	if err := kdtree.KdTreeBuild(&xy, 0, 2, 0, 2, kdt); err != nil {
+		t.Fatal(err)
+	}
+	if k, err := kdtree.KdTreeQueryKnn(kdt, []float64{0, 0}, 1, true); err != nil || k != 0 {
+		t.Errorf("empty tree found: %d, error: %v", k, err)
+	}
+	if _, err := kdtree.KdTreeQueryKnn(kdt, []float64{0, 0}, 0, true); err == nil {
+		t.Error("zero neighbours accepted")
+	}
+	if _, err := kdtree.KdTreeQueryRnn(kdt, []float64{0}, 1, true); err == nil {
+		t.Error("short point accepted")
+	}
+}
@@ -0,0 +1,274 @@
+package kmeans
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+/*************************************************************************
+k-means++ clusterization
+
+INPUT PARAMETERS:
+	XY          -   dataset, array [0..NPoints-1,0..NVars-1].
+	NPoints     -   dataset size, NPoints>=K
+	NVars       -   number of variables, NVars>=1
+	K           -   desired number of clusters, K>=1
+	Restarts    -   number of restarts, Restarts>=1
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -3, if task is degenerate (number of distinct points is
+						  less than K)
+					* -1, if incorrect NPoints/NFeatures/K/Restarts was passed
+					*  1, if subroutine finished successfully
+	C           -   array[0..NVars-1,0..K-1].matrix whose columns store
+					cluster's centers
+	XYC         -   array[NPoints], which contains cluster indexes
+
+  -- ALGLIB --
+	 Copyright 21.03.2009 by Bochkanov Sergey
+*************************************************************************/
+func KMeansGenerate(xy *[][]float64, npoints, nvars, k, restarts int, info *int, c *[][]float64, xyc *[]int) error {
+	*info = 0
+	*c = [][]float64{}
+	*xyc = []int{}
+
+	//
+	// Test parameters
+	//
+	if npoints < k || nvars < 1 || k < 1 || restarts < 1 {
+		*info = -1
+		return nil
+	}
+	if len(*xy) < npoints {
+		return fmt.Errorf("KMeansGenerate: rows count %d less than NPoints %d", len(*xy), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*xy)[i]) < nvars {
+			return fmt.Errorf("KMeansGenerate: row %d length %d less than NVars", i, len((*xy)[i]))
+		}
+	}
+
+	//
+	// TODO: special case K=1
+	// TODO: special case K=NPoints
+	//
+	*info = 1
+
+	//
+	// Multiple passes of k-means++ algorithm
+	//
+	ct := utils.MakeMatrixFloat64(k, nvars)
+	ctbest := utils.MakeMatrixFloat64(k, nvars)
+	*xyc = make([]int, npoints)
+	xycbest := make([]int, npoints)
+	d2 := make([]float64, npoints)
+	p := make([]float64, npoints)
+	tmp := make([]float64, nvars)
+	csizes := make([]int, k)
+	cbusy := make([]bool, k)
+	ebest := math.MaxFloat64
+	for pass := 1; pass <= restarts; pass++ {
+
+		//
+		// Select initial centers  using k-means++ algorithm
+		// 1. Choose first center at random
+		// 2. Choose next centers using their distance from centers already chosen
+		//
+		// Note that for performance reasons centers are stored in ROWS of CT, not
+		// in columns. We'll transpose CT in the end and store it in the C.
+		//
+		i := rand.Intn(npoints)
+		copy(ct[0], (*xy)[i][:nvars])
+		cbusy[0] = true
+		for i = 1; i <= k-1; i++ {
+			cbusy[i] = false
+		}
+		if !selectcenterpp(*xy, npoints, nvars, ct, cbusy, k, d2, p, tmp) {
+			*info = -3
+			return nil
+		}
+
+		//
+		// Update centers:
+		// 2. update center positions
+		//
+		for i = 0; i <= npoints-1; i++ {
+			(*xyc)[i] = -1
+		}
+		for {
+
+			//
+			// fill XYC with center numbers
+			//
+			waschanges := false
+			for i = 0; i <= npoints-1; i++ {
+				cclosest := -1
+				dclosest := math.MaxFloat64
+				for j := 0; j <= k-1; j++ {
+					v := distance2((*xy)[i], ct[j], nvars)
+					if v < dclosest {
+						cclosest = j
+						dclosest = v
+					}
+				}
+				if (*xyc)[i] != cclosest {
+					waschanges = true
+				}
+				(*xyc)[i] = cclosest
+			}
+
+			//
+			// Update centers
+			//
+			for j := 0; j <= k-1; j++ {
+				csizes[j] = 0
+			}
+			for i = 0; i <= k-1; i++ {
+				for j := 0; j <= nvars-1; j++ {
+					ct[i][j] = 0
+				}
+			}
+			for i = 0; i <= npoints-1; i++ {
+				csizes[(*xyc)[i]] = csizes[(*xyc)[i]] + 1
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					ct[(*xyc)[i]][i_] = ct[(*xyc)[i]][i_] + (*xy)[i][i_]
+				}
+			}
+			zerosizeclusters := false
+			for i = 0; i <= k-1; i++ {
+				cbusy[i] = csizes[i] != 0
+				zerosizeclusters = zerosizeclusters || csizes[i] == 0
+			}
+			if zerosizeclusters {
+
+				//
+				// Some clusters have zero size - rare, but possible.
+				// We'll choose new centers for such clusters using k-means++ rule
+				// and restart algorithm
+				//
+				if !selectcenterpp(*xy, npoints, nvars, ct, cbusy, k, d2, p, tmp) {
+					*info = -3
+					return nil
+				}
+				continue
+			}
+			for j := 0; j <= k-1; j++ {
+				v := 1 / float64(csizes[j])
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					ct[j][i_] = v * ct[j][i_]
+				}
+			}
+
+			//
+			// if nothing has changed during iteration
+			//
+			if !waschanges {
+				break
+			}
+		}
+
+		//
+		// 3. Calculate E, compare with best centers found so far
+		//
+		e := 0.0
+		for i = 0; i <= npoints-1; i++ {
+			e = e + distance2((*xy)[i], ct[(*xyc)[i]], nvars)
+		}
+		if e < ebest {
+
+			//
+			// store partition.
+			//
+			ebest = e
+			for i = 0; i <= k-1; i++ {
+				copy(ctbest[i], ct[i])
+			}
+			copy(xycbest, *xyc)
+		}
+	}
+
+	//
+	// Copy and transpose
+	//
+	*c = utils.MakeMatrixFloat64(nvars, k)
+	for i := 0; i <= k-1; i++ {
+		for j := 0; j <= nvars-1; j++ {
+			(*c)[j][i] = ctbest[i][j]
+		}
+	}
+	copy(*xyc, xycbest)
+	return nil
+}
+
+/*************************************************************************
+Select center for a new cluster using k-means++ rule
+*************************************************************************/
+func selectcenterpp(xy [][]float64, npoints, nvars int, centers [][]float64, busycenters []bool, ccnt int, d2, p, tmp []float64) bool {
+	busycenters = append([]bool{}, busycenters...)
+
+	for cc := 0; cc <= ccnt-1; cc++ {
+		if !busycenters[cc] {
+
+			//
+			// fill D2
+			//
+			for i := 0; i <= npoints-1; i++ {
+				d2[i] = math.MaxFloat64
+				for j := 0; j <= ccnt-1; j++ {
+					if busycenters[j] {
+						if v := distance2(xy[i], centers[j], nvars); v < d2[i] {
+							d2[i] = v
+						}
+					}
+				}
+			}
+
+			//
+			// calculate P (non-cumulative)
+			//
+			s := 0.0
+			for i := 0; i <= npoints-1; i++ {
+				s = s + d2[i]
+			}
+			if s == 0 {
+				return false
+			}
+			s = 1 / s
+			for i_ := 0; i_ <= npoints-1; i_++ {
+				p[i_] = s * d2[i_]
+			}
+
+			//
+			// choose one of points with probability P
+			// random number within (0,1) is generated and
+			// inverse empirical CDF is used to randomly choose a point.
+			//
+			s = 0
+			v := rand.Float64()
+			for i := 0; i <= npoints-1; i++ {
+				s = s + p[i]
+				if v <= s || i == npoints-1 {
+					copy(centers[cc], xy[i][:nvars])
+					busycenters[cc] = true
+					break
+				}
+			}
+		}
+	}
+	return true
+}
+
+/*************************************************************************
+Squared euclidean distance between the first NVars values of X and Y
+*************************************************************************/
+func distance2(x, y []float64, nvars int) float64 {
+	result := 0.0
+	for i_ := 0; i_ <= nvars-1; i_++ {
+		result += (x[i_] - y[i_]) * (x[i_] - y[i_])
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package kmeans_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/kmeans"
+)
+
+func TestGenerate(t *testing.T) {
+	// three separated blobs around (0, 0), (10, 0) and (0, 10)
+	centers := [][]float64{{0, 0}, {10, 0}, {0, 10}}
+	rnd := rand.New(rand.NewSource(1))
+	xy := make([][]float64, 300)
+	for i := range xy {
+		center := centers[i%3]
+		xy[i] = []float64{center[0] + rnd.Float64() - 0.5, center[1] + rnd.Float64() - 0.5}
+	}
+	var c [][]float64
+	var xyc []int
+	info := 0
+	if err := kmeans.KMeansGenerate(&xy, len(xy), 2, 3, 5, &info, &c, &xyc); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	if len(c) != 2 || len(c[0]) != 3 || len(xyc) != len(xy) {
+		t.Fatalf("centers: %v, indexes: %d", c, len(xyc))
+	}
+	for i, center := range centers {
+		cluster := xyc[i]
+		if math.Abs(c[0][cluster]-center[0]) > 0.2 || math.Abs(c[1][cluster]-center[1]) > 0.2 {
+			t.Errorf("cluster %d center: (%v, %v), expected %v", cluster, c[0][cluster], c[1][cluster], center)
+		}
+	}
+	for i := range xy {
+		if xyc[i] != xyc[i%3] {
+			t.Fatalf("point %d in cluster %d, expected %d", i, xyc[i], xyc[i%3])
+		}
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	xy := [][]float64{{1}, {1}, {1}, {2}}
+	var c [][]float64
+	var xyc []int
+	info := 0
+	if err := kmeans.KMeansGenerate(&xy, len(xy), 1, 2, 1, &info, &c, &xyc); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	if xyc[0] != xyc[2] || xyc[0] == xyc[3] {
+		t.Errorf("clusters: %v", xyc)
+	}
+	if err := kmeans.KMeansGenerate(&xy, len(xy), 1, 3, 1, &info, &c, &xyc); err != nil || info != -3 {
+		t.Errorf("less distinct points than clusters, info: %d, error: %v", info, err)
+	}
+	if err := kmeans.KMeansGenerate(&xy, len(xy), 1, 5, 1, &info, &c, &xyc); err != nil || info != -1 {
+		t.Errorf("more clusters than points, info: %d, error: %v", info, err)
+	}
+	if err := kmeans.KMeansGenerate(&xy, len(xy), 2, 2, 1, &info, &c, &xyc); err == nil {
+		t.Error("short rows accepted")
+	}
+}
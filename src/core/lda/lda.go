@@ -0,0 +1,368 @@
+package lda
+
+import (
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/evd"
+	"pr.optima/src/core/neural/utils"
+)
+
+/*************************************************************************
+Multiclass Fisher LDA
+
+Subroutine finds coefficients of linear combination which optimally separates
+training set on classes.
+
+INPUT PARAMETERS:
+	XY          -   training set, array[0..NPoints-1,0..NVars].
+					First NVars columns store values of independent
+					variables, next column stores number of class (from 0
+					to NClasses-1) which dataset element belongs to. Fractional
+					values are rounded to nearest integer.
+	NPoints     -   training set size, NPoints>=0
+	NVars       -   number of independent variables, NVars>=1
+	NClasses    -   number of classes, NClasses>=2
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -4, if internal EVD subroutine hasn't converged
+					* -2, if there is a point with class number
+						  outside of [0..NClasses-1].
+					* -1, if incorrect parameters was passed (NPoints<0,
+						  NVars<1, NClasses<2)
+					*  1, if task has been solved
+					*  2, if there was a multicollinearity in training set,
+						  but task has been solved.
+	W           -   linear combination coefficients, array[0..NVars-1]
+
+  -- ALGLIB --
+	 Copyright 31.05.2008 by Bochkanov Sergey
+*************************************************************************/
+func FisherLda(xy *[][]float64, npoints, nvars, nclasses int, info *int, w *[]float64) error {
+	var w2 [][]float64
+
+	*info = 0
+	*w = []float64{}
+	if err := FisherLdaN(xy, npoints, nvars, nclasses, info, &w2); err != nil {
+		return err
+	}
+	if *info > 0 {
+		*w = make([]float64, nvars)
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			(*w)[i_] = w2[i_][0]
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+N-dimensional multiclass Fisher LDA
+
+Subroutine finds coefficients of linear combinations which optimally separates
+training set on classes. It returns N-dimensional basis whose vector are sorted
+by quality of training set separation (in descending order).
+
+INPUT PARAMETERS:
+	XY          -   training set, array[0..NPoints-1,0..NVars].
+					First NVars columns store values of independent
+					variables, next column stores number of class (from 0
+					to NClasses-1) which dataset element belongs to. Fractional
+					values are rounded to nearest integer.
+	NPoints     -   training set size, NPoints>=0
+	NVars       -   number of independent variables, NVars>=1
+	NClasses    -   number of classes, NClasses>=2
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -4, if internal EVD subroutine hasn't converged
+					* -2, if there is a point with class number
+						  outside of [0..NClasses-1].
+					* -1, if incorrect parameters was passed (NPoints<0,
+						  NVars<1, NClasses<2)
+					*  1, if task has been solved
+					*  2, if there was a multicollinearity in training set,
+						  but task has been solved.
+	W           -   basis, array[0..NVars-1,0..NVars-1]
+					columns of matrix stores basis vectors, sorted by
+					quality of training set separation (in descending order)
+
+  -- ALGLIB --
+	 Copyright 31.05.2008 by Bochkanov Sergey
+*************************************************************************/
+func FisherLdaN(xy *[][]float64, npoints, nvars, nclasses int, info *int, w *[][]float64) error {
+	var d, d2 []float64
+	var z, z2, wproj [][]float64
+
+	*info = 0
+	*w = [][]float64{}
+
+	//
+	// Test data
+	//
+	if npoints < 0 || nvars < 1 || nclasses < 2 {
+		*info = -1
+		return nil
+	}
+	if len(*xy) < npoints {
+		return fmt.Errorf("FisherLDAN: rows count %d less than NPoints %d", len(*xy), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*xy)[i]) < nvars+1 {
+			return fmt.Errorf("FisherLDAN: row %d length %d less than NVars+1", i, len((*xy)[i]))
+		}
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if utils.RoundInt((*xy)[i][nvars]) < 0 || utils.RoundInt((*xy)[i][nvars]) >= nclasses {
+			*info = -2
+			return nil
+		}
+	}
+	*info = 1
+
+	//
+	// Special case: NPoints<=1
+	// Degenerate task.
+	//
+	if npoints <= 1 {
+		*info = 2
+		*w = utils.MakeMatrixFloat64(nvars, nvars)
+		for i := 0; i <= nvars-1; i++ {
+			(*w)[i][i] = 1
+		}
+		return nil
+	}
+
+	//
+	// Prepare temporaries
+	//
+	tf := make([]float64, nvars)
+
+	//
+	// Convert class labels from reals to integers (just for convenience)
+	//
+	c := make([]int, npoints)
+	for i := 0; i <= npoints-1; i++ {
+		c[i] = utils.RoundInt((*xy)[i][nvars])
+	}
+
+	//
+	// Calculate class sizes and means
+	//
+	mu := make([]float64, nvars)
+	muc := utils.MakeMatrixFloat64(nclasses, nvars)
+	nc := make([]int, nclasses)
+	for i := 0; i <= npoints-1; i++ {
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			mu[i_] = mu[i_] + (*xy)[i][i_]
+		}
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			muc[c[i]][i_] = muc[c[i]][i_] + (*xy)[i][i_]
+		}
+		nc[c[i]] = nc[c[i]] + 1
+	}
+	for i := 0; i <= nclasses-1; i++ {
+
+		//
+		// the empty class has no mean, its row is kept zero
+		//
+		if nc[i] == 0 {
+			continue
+		}
+		v := 1 / float64(nc[i])
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			muc[i][i_] = v * muc[i][i_]
+		}
+	}
+	v := 1 / float64(npoints)
+	for i_ := 0; i_ <= nvars-1; i_++ {
+		mu[i_] = v * mu[i_]
+	}
+
+	//
+	// Create ST matrix
+	//
+	st := utils.MakeMatrixFloat64(nvars, nvars)
+	for k := 0; k <= npoints-1; k++ {
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			tf[i_] = (*xy)[k][i_] - mu[i_]
+		}
+		for i := 0; i <= nvars-1; i++ {
+			v = tf[i]
+			for i_ := 0; i_ <= nvars-1; i_++ {
+				st[i][i_] = st[i][i_] + v*tf[i_]
+			}
+		}
+	}
+
+	//
+	// Create SW matrix
+	//
+	sw := utils.MakeMatrixFloat64(nvars, nvars)
+	for k := 0; k <= npoints-1; k++ {
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			tf[i_] = (*xy)[k][i_] - muc[c[k]][i_]
+		}
+		for i := 0; i <= nvars-1; i++ {
+			v = tf[i]
+			for i_ := 0; i_ <= nvars-1; i_++ {
+				sw[i][i_] = sw[i][i_] + v*tf[i_]
+			}
+		}
+	}
+
+	//
+	// Maximize ratio J=(w'*ST*w)/(w'*SW*w).
+	//
+	// First, make transition from w to v such that w'*ST*w becomes v'*v:
+	//    v  = root(ST)*w = R*w
+	//    R  = root(D)*Z'
+	//    w  = (root(ST)^-1)*v = RI*v
+	//    RI = Z*inv(root(D))
+	//    J  = (v'*v)/(v'*(RI'*SW*RI)*v)
+	//    ST = Z*D*Z'
+	//
+	//    so we have
+	//
+	//    J = (v'*v) / (v'*(inv(root(D))*Z'*SW*Z*inv(root(D)))*v)  =
+	//      = (v'*v) / (v'*A*v)
+	//
+	if !evd.SMatrixEvd(&st, nvars, true, &d, &z) {
+		*info = -4
+		return nil
+	}
+	*w = utils.MakeMatrixFloat64(nvars, nvars)
+	if d[nvars-1] <= 0 || d[0] <= 1000*utils.MachineEpsilon*d[nvars-1] {
+
+		//
+		// Special case: D[NVars-1]<=0
+		// Degenerate task (all variables takes the same value).
+		//
+		if d[nvars-1] <= 0 {
+			*info = 2
+			for i := 0; i <= nvars-1; i++ {
+				(*w)[i][i] = 1
+			}
+			return nil
+		}
+
+		//
+		// Special case: degenerate ST matrix, multicollinearity found.
+		// Since we know ST eigenvalues/vectors we can translate task to
+		// non-degenerate form.
+		//
+		// Let WG is orthogonal basis of the non zero variance subspace
+		// of the ST and let WZ is orthogonal basis of the zero variance
+		// subspace.
+		//
+		// Projection on WG allows us to use LDA on reduced M-dimensional
+		// subspace, N-M vectors of WZ allows us to update reduced LDA
+		// factors to full N-dimensional subspace.
+		//
+		m := 0
+		for k := 0; k <= nvars-1; k++ {
+			if d[k] <= 1000*utils.MachineEpsilon*d[nvars-1] {
+				m = k + 1
+			}
+		}
+		if m == 0 {
+			return fmt.Errorf("FisherLDAN: internal error #1")
+		}
+		xyproj := utils.MakeMatrixFloat64(npoints, nvars-m+1)
+		for i := 0; i <= npoints-1; i++ {
+			for j := 0; j <= nvars-m-1; j++ {
+				v = 0.0
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					v += (*xy)[i][i_] * z[i_][m+j]
+				}
+				xyproj[i][j] = v
+			}
+			xyproj[i][nvars-m] = (*xy)[i][nvars]
+		}
+		if err := FisherLdaN(&xyproj, npoints, nvars-m, nclasses, info, &wproj); err != nil || *info < 0 {
+			return err
+		}
+		for i := 0; i <= nvars-1; i++ {
+			for j := 0; j <= nvars-m-1; j++ {
+				v = 0.0
+				for i_ := 0; i_ <= nvars-m-1; i_++ {
+					v += z[i][m+i_] * wproj[i_][j]
+				}
+				(*w)[i][j] = v
+			}
+		}
+		for k := nvars - m; k <= nvars-1; k++ {
+			for i_ := 0; i_ <= nvars-1; i_++ {
+				(*w)[i_][k] = z[i_][k-(nvars-m)]
+			}
+		}
+		*info = 2
+	} else {
+
+		//
+		// General case: no multicollinearity
+		//
+		tm := utils.MakeMatrixFloat64(nvars, nvars)
+		a := utils.MakeMatrixFloat64(nvars, nvars)
+		for i := 0; i <= nvars-1; i++ {
+			for j := 0; j <= nvars-1; j++ {
+				v = 0.0
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					v += sw[i][i_] * z[i_][j]
+				}
+				tm[i][j] = v
+			}
+		}
+		for i := 0; i <= nvars-1; i++ {
+			for j := 0; j <= nvars-1; j++ {
+				v = 0.0
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					v += z[i_][i] * tm[i_][j]
+				}
+				a[i][j] = v / math.Sqrt(d[i]*d[j])
+			}
+		}
+		if !evd.SMatrixEvd(&a, nvars, true, &d2, &z2) {
+			*info = -4
+			return nil
+		}
+		for k := 0; k <= nvars-1; k++ {
+			for i := 0; i <= nvars-1; i++ {
+				tf[i] = z2[i][k] / math.Sqrt(d[i])
+			}
+			for i := 0; i <= nvars-1; i++ {
+				v = 0.0
+				for i_ := 0; i_ <= nvars-1; i_++ {
+					v += z[i][i_] * tf[i_]
+				}
+				(*w)[i][k] = v
+			}
+		}
+	}
+
+	//
+	// Post-processing:
+	// * normalization
+	// * converting to non-negative form, if possible
+	//
+	for k := 0; k <= nvars-1; k++ {
+		v = 0.0
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			v += (*w)[i_][k] * (*w)[i_][k]
+		}
+		v = 1 / math.Sqrt(v)
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			(*w)[i_][k] = v * (*w)[i_][k]
+		}
+		v = 0
+		for i := 0; i <= nvars-1; i++ {
+			v = v + (*w)[i][k]
+		}
+		if v < 0 {
+			for i_ := 0; i_ <= nvars-1; i_++ {
+				(*w)[i_][k] = -1 * (*w)[i_][k]
+			}
+		}
+	}
+	return nil
+}
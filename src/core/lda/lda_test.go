@@ -0,0 +1,86 @@
+package lda_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/lda"
+)
+
+// separationSet - classes differ by x0 - x1 only, x0 + x1 has large variance in both classes
+func separationSet(npoints int) [][]float64 {
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		class := float64(i % 2)
+		common := rand.NormFloat64() * 10
+		diff := class*2 - 1 + rand.NormFloat64()*0.3
+		xy[i] = []float64{(common + diff) / 2, (common - diff) / 2, class}
+	}
+	return xy
+}
+
+func TestFisherLda(t *testing.T) {
+	xy := separationSet(400)
+	var w []float64
+	info := 0
+	if err := lda.FisherLda(&xy, len(xy), 2, 2, &info, &w); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	// the direction of x0 - x1, the sign is not defined since the sum of the coefficients is zero
+	if math.Abs(math.Abs(w[0])-1/math.Sqrt(2)) > 0.02 || math.Abs(w[0]+w[1]) > 0.03 {
+		t.Errorf("direction: %v", w)
+	}
+
+	var basis [][]float64
+	if err := lda.FisherLdaN(&xy, len(xy), 2, 2, &info, &basis); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	if basis[0][0] != w[0] || basis[1][0] != w[1] {
+		t.Errorf("first basis vector: %v, %v, expected: %v", basis[0][0], basis[1][0], w)
+	}
+	for k := 0; k < 2; k++ {
+		if norm := basis[0][k]*basis[0][k] + basis[1][k]*basis[1][k]; math.Abs(norm-1) > 1e-12 {
+			t.Errorf("basis vector %d norm: %v", k, norm)
+		}
+	}
+}
+
+func TestMulticollinearity(t *testing.T) {
+	xy := separationSet(100)
+	// the third variable duplicates the first one
+	for i := range xy {
+		xy[i] = []float64{xy[i][0], xy[i][1], xy[i][0], xy[i][2]}
+	}
+	var basis [][]float64
+	info := 0
+	if err := lda.FisherLdaN(&xy, len(xy), 3, 2, &info, &basis); err != nil || info != 2 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	// the separating direction has zero projection on the duplicated variables difference
+	if math.Abs(basis[0][0]-basis[2][0]) > 1e-6 || math.Abs(basis[0][0]+basis[1][0]+basis[2][0]) > 0.1 {
+		t.Errorf("first basis vector: %v, %v, %v", basis[0][0], basis[1][0], basis[2][0])
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	var basis [][]float64
+	info := 0
+	xy := [][]float64{{1, 1, 0}, {1, 1, 1}, {1, 1, 1}}
+	if err := lda.FisherLdaN(&xy, len(xy), 2, 2, &info, &basis); err != nil || info != 2 || basis[0][0] != 1 || basis[1][1] != 1 {
+		t.Errorf("same values, info: %d, basis: %v, error: %v", info, basis, err)
+	}
+	if err := lda.FisherLdaN(&xy, 1, 2, 2, &info, &basis); err != nil || info != 2 {
+		t.Errorf("single point, info: %d, error: %v", info, err)
+	}
+	if err := lda.FisherLdaN(&xy, len(xy), 2, 1, &info, &basis); err != nil || info != -1 {
+		t.Errorf("single class, info: %d, error: %v", info, err)
+	}
+	xy[1][2] = 2
+	if err := lda.FisherLdaN(&xy, len(xy), 2, 2, &info, &basis); err != nil || info != -2 {
+		t.Errorf("class out of range, info: %d, error: %v", info, err)
+	}
+	if err := lda.FisherLdaN(&xy, len(xy), 3, 3, &info, &basis); err == nil {
+		t.Error("short rows accepted")
+	}
+}
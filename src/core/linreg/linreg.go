@@ -0,0 +1,920 @@
+package linreg
+
+import (
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/neural/utils"
+	"pr.optima/src/core/svd"
+)
+
+const lrvnum = 5
+
+/*************************************************************************
+Linear model.
+
+W FORMAT:
+W[0]         -   size of the array
+W[1]         -   version (LRVNum)
+W[2]         -   NVars
+W[3]         -   Offs, offset of the coefficients
+W[Offs..]    -   coefficients, NVars+1 values, the last one is the constant
+				 term (intercept)
+*************************************************************************/
+type LinearModel struct {
+	W []float64
+}
+
+func NewLinearModel() *LinearModel {
+	return &LinearModel{W: []float64{}}
+}
+
+/*************************************************************************
+LRReport structure contains additional information about linear model:
+* C             -   covariation matrix,  array[0..NVars,0..NVars].
+					C[i,j] = Cov(A[i],A[j])
+* RmsError      -   root mean square error on a training set
+* AvgError      -   average error on a training set
+* AvgRelError   -   average relative error on a training set (excluding
+					observations with zero function value).
+* CvRmsError    -   leave-one-out cross-validation estimate of
+					generalization error. Calculated using fast algorithm
+					with O(NVars*NPoints) complexity.
+* CvAvgError    -   cross-validation estimate of average error
+* CvAvgRelError -   cross-validation estimate of average relative error
+
+All other fields of the structure are intended for internal use and should
+not be used outside ALGLIB.
+*************************************************************************/
+type LrReport struct {
+	C             [][]float64
+	RmsError      float64
+	AvgError      float64
+	AvgRelError   float64
+	CvRmsError    float64
+	CvAvgError    float64
+	CvAvgRelError float64
+	NCvDefects    int
+	CvDefects     []int
+}
+
+func NewLrReport() *LrReport {
+	return &LrReport{C: [][]float64{}, CvDefects: []int{}}
+}
+
+/*************************************************************************
+Linear regression
+
+Subroutine builds model:
+
+	Y = A(0)*X[0] + ... + A(N-1)*X[N-1] + A(N)
+
+and model found in ALGLIB format, covariation matrix, training set  errors
+(rms,  average,  average  relative)   and  leave-one-out  cross-validation
+estimate of the generalization error. CV  estimate calculated  using  fast
+algorithm with O(NPoints*NVars) complexity.
+
+When  covariation  matrix  is  calculated  standard deviations of function
+values are assumed to be equal to RMS error on the training set.
+
+INPUT PARAMETERS:
+	XY          -   training set, array [0..NPoints-1,0..NVars]:
+					* NVars columns - independent variables
+					* last column - dependent variable
+	NPoints     -   training set size, NPoints>NVars+1
+	NVars       -   number of independent variables
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -255, in case of unknown internal error
+					* -4, if internal SVD subroutine haven't converged
+					* -1, if incorrect parameters was passed (NPoints<NVars+2, NVars<1).
+					*  1, if subroutine successfully finished
+	LM          -   linear model in the ALGLIB format. Use subroutines of
+					this unit to work with the model.
+	AR          -   additional results
+
+  -- ALGLIB --
+	 Copyright 02.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrBuild(xy *[][]float64, npoints, nvars int, info *int, lm *LinearModel, ar *LrReport) error {
+	*info = 0
+	if npoints <= nvars+1 || nvars < 1 {
+		*info = -1
+		return nil
+	}
+	s := make([]float64, npoints)
+	for i := 0; i <= npoints-1; i++ {
+		s[i] = 1
+	}
+	if err := LrBuildS(xy, &s, npoints, nvars, info, lm, ar); err != nil || *info < 0 {
+		return err
+	}
+	sigma2 := utils.SqrFloat64(ar.RmsError) * float64(npoints) / float64(npoints-nvars-1)
+	for i := 0; i <= nvars; i++ {
+		for j := 0; j <= nvars; j++ {
+			ar.C[i][j] = sigma2 * ar.C[i][j]
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Linear regression
+
+Variant of LRBuild which uses vector of standatd deviations (errors in
+function values).
+
+INPUT PARAMETERS:
+	XY          -   training set, array [0..NPoints-1,0..NVars]:
+					* NVars columns - independent variables
+					* last column - dependent variable
+	S           -   standard deviations (errors in function values)
+					array[0..NPoints-1], S[i]>0.
+	NPoints     -   training set size, NPoints>NVars+1
+	NVars       -   number of independent variables
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -255, in case of unknown internal error
+					* -4, if internal SVD subroutine haven't converged
+					* -1, if incorrect parameters was passed (NPoints<NVars+2, NVars<1).
+					* -2, if S[I]<=0
+					*  1, if subroutine successfully finished
+	LM          -   linear model in the ALGLIB format. Use subroutines of
+					this unit to work with the model.
+	AR          -   additional results
+
+  -- ALGLIB --
+	 Copyright 02.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrBuildS(xy *[][]float64, s *[]float64, npoints, nvars int, info *int, lm *LinearModel, ar *LrReport) error {
+	*info = 0
+
+	//
+	// Test parameters
+	//
+	if npoints <= nvars+1 || nvars < 1 {
+		*info = -1
+		return nil
+	}
+	if err := checkdataset("LRBuildS", xy, s, npoints, nvars); err != nil {
+		return err
+	}
+
+	//
+	// Copy data, add one more column (constant term)
+	//
+	xyi := utils.MakeMatrixFloat64(npoints, nvars+2)
+	for i := 0; i <= npoints-1; i++ {
+		copy(xyi[i], (*xy)[i][:nvars])
+		xyi[i][nvars] = 1
+		xyi[i][nvars+1] = (*xy)[i][nvars]
+	}
+
+	//
+	// Standartization
+	//
+	x := make([]float64, npoints)
+	means := make([]float64, nvars)
+	sigmas := make([]float64, nvars)
+	mean := 0.0
+	variance := 0.0
+	for j := 0; j <= nvars-1; j++ {
+		for i := 0; i <= npoints-1; i++ {
+			x[i] = (*xy)[i][j]
+		}
+		samplemoments(x, npoints, &mean, &variance)
+		means[j] = mean
+		sigmas[j] = math.Sqrt(variance)
+		if sigmas[j] == 0 {
+			sigmas[j] = 1
+		}
+		for i := 0; i <= npoints-1; i++ {
+			xyi[i][j] = (xyi[i][j] - means[j]) / sigmas[j]
+		}
+	}
+
+	//
+	// Internal processing
+	//
+	lrinternal(xyi, *s, npoints, nvars+1, info, lm, ar)
+	if *info < 0 {
+		return nil
+	}
+
+	//
+	// Un-standartization
+	//
+	offs := utils.RoundInt(lm.W[3])
+	for j := 0; j <= nvars-1; j++ {
+
+		//
+		// Constant term is updated (and its covariance too,
+		// since it gets some variance from J-th component)
+		//
+		lm.W[offs+nvars] = lm.W[offs+nvars] - lm.W[offs+j]*means[j]/sigmas[j]
+		v := means[j] / sigmas[j]
+		for i := 0; i <= nvars; i++ {
+			ar.C[nvars][i] = ar.C[nvars][i] - v*ar.C[j][i]
+		}
+		for i := 0; i <= nvars; i++ {
+			ar.C[i][nvars] = ar.C[i][nvars] - v*ar.C[i][j]
+		}
+
+		//
+		// J-th term is updated
+		//
+		lm.W[offs+j] = lm.W[offs+j] / sigmas[j]
+		v = 1 / sigmas[j]
+		for i := 0; i <= nvars; i++ {
+			ar.C[j][i] = v * ar.C[j][i]
+		}
+		for i := 0; i <= nvars; i++ {
+			ar.C[i][j] = v * ar.C[i][j]
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Like LRBuildS, but builds model
+
+	Y = A(0)*X[0] + ... + A(N-1)*X[N-1]
+
+i.e. with zero constant term.
+
+  -- ALGLIB --
+	 Copyright 30.10.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrBuildZS(xy *[][]float64, s *[]float64, npoints, nvars int, info *int, lm *LinearModel, ar *LrReport) error {
+	*info = 0
+
+	//
+	// Test parameters
+	//
+	if npoints <= nvars+1 || nvars < 1 {
+		*info = -1
+		return nil
+	}
+	if err := checkdataset("LRBuildZS", xy, s, npoints, nvars); err != nil {
+		return err
+	}
+
+	//
+	// Copy data, add one more column (constant term)
+	//
+	xyi := utils.MakeMatrixFloat64(npoints, nvars+2)
+	for i := 0; i <= npoints-1; i++ {
+		copy(xyi[i], (*xy)[i][:nvars])
+		xyi[i][nvars] = 0
+		xyi[i][nvars+1] = (*xy)[i][nvars]
+	}
+
+	//
+	// Standartization: unusual scaling
+	//
+	x := make([]float64, npoints)
+	c := make([]float64, nvars)
+	mean := 0.0
+	variance := 0.0
+	for j := 0; j <= nvars-1; j++ {
+		for i := 0; i <= npoints-1; i++ {
+			x[i] = (*xy)[i][j]
+		}
+		samplemoments(x, npoints, &mean, &variance)
+		if math.Abs(mean) > math.Sqrt(variance) {
+
+			//
+			// variation is relatively small, it is better to
+			// bring mean value to 1
+			//
+			c[j] = mean
+		} else {
+
+			//
+			// variation is large, it is better to bring variance to 1
+			//
+			if variance == 0 {
+				variance = 1
+			}
+			c[j] = math.Sqrt(variance)
+		}
+		for i := 0; i <= npoints-1; i++ {
+			xyi[i][j] = xyi[i][j] / c[j]
+		}
+	}
+
+	//
+	// Internal processing
+	//
+	lrinternal(xyi, *s, npoints, nvars+1, info, lm, ar)
+	if *info < 0 {
+		return nil
+	}
+
+	//
+	// Un-standartization
+	//
+	offs := utils.RoundInt(lm.W[3])
+	for j := 0; j <= nvars-1; j++ {
+
+		//
+		// J-th term is updated
+		//
+		lm.W[offs+j] = lm.W[offs+j] / c[j]
+		v := 1 / c[j]
+		for i := 0; i <= nvars; i++ {
+			ar.C[j][i] = v * ar.C[j][i]
+		}
+		for i := 0; i <= nvars; i++ {
+			ar.C[i][j] = v * ar.C[i][j]
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Like LRBuild but builds model
+
+	Y = A(0)*X[0] + ... + A(N-1)*X[N-1]
+
+i.e. with zero constant term.
+
+  -- ALGLIB --
+	 Copyright 30.10.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrBuildZ(xy *[][]float64, npoints, nvars int, info *int, lm *LinearModel, ar *LrReport) error {
+	*info = 0
+	if npoints <= nvars+1 || nvars < 1 {
+		*info = -1
+		return nil
+	}
+	s := make([]float64, npoints)
+	for i := 0; i <= npoints-1; i++ {
+		s[i] = 1
+	}
+	if err := LrBuildZS(xy, &s, npoints, nvars, info, lm, ar); err != nil || *info < 0 {
+		return err
+	}
+	sigma2 := utils.SqrFloat64(ar.RmsError) * float64(npoints) / float64(npoints-nvars-1)
+	for i := 0; i <= nvars; i++ {
+		for j := 0; j <= nvars; j++ {
+			ar.C[i][j] = sigma2 * ar.C[i][j]
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Unpacks coefficients of linear model.
+
+INPUT PARAMETERS:
+	LM          -   linear model in ALGLIB format
+
+OUTPUT PARAMETERS:
+	V           -   coefficients, array[0..NVars]
+					constant term (intercept) is stored in the V[NVars].
+	NVars       -   number of independent variables (one less than number
+					of coefficients)
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrUnpack(lm *LinearModel, v *[]float64, nvars *int) {
+	*nvars = utils.RoundInt(lm.W[2])
+	offs := utils.RoundInt(lm.W[3])
+	*v = utils.CloneArrayFloat64(lm.W[offs : offs+*nvars+1])
+}
+
+/*************************************************************************
+"Packs" coefficients and creates linear model in ALGLIB format (LRUnpack
+reversed).
+
+INPUT PARAMETERS:
+	V           -   coefficients, array[0..NVars]
+	NVars       -   number of independent variables
+
+OUTPUT PAREMETERS:
+	LM          -   linear model.
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrPack(v *[]float64, nvars int, lm *LinearModel) error {
+	if nvars < 0 || len(*v) < nvars+1 {
+		return fmt.Errorf("LRPack: coefficients count %d less than NVars+1", len(*v))
+	}
+	offs := 4
+	lm.W = make([]float64, 4+nvars+1)
+	lm.W[0] = float64(4 + nvars + 1)
+	lm.W[1] = lrvnum
+	lm.W[2] = float64(nvars)
+	lm.W[3] = float64(offs)
+	copy(lm.W[offs:], (*v)[:nvars+1])
+	return nil
+}
+
+/*************************************************************************
+Procesing
+
+INPUT PARAMETERS:
+	LM      -   linear model
+	X       -   input vector,  array[0..NVars-1].
+
+Result:
+	value of linear model regression estimate
+
+  -- ALGLIB --
+	 Copyright 03.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrProcess(lm *LinearModel, x *[]float64) float64 {
+	nvars := utils.RoundInt(lm.W[2])
+	offs := utils.RoundInt(lm.W[3])
+	v := 0.0
+	for i := 0; i <= nvars-1; i++ {
+		v += (*x)[i] * lm.W[offs+i]
+	}
+	return v + lm.W[offs+nvars]
+}
+
+/*************************************************************************
+RMS error on the test set
+
+INPUT PARAMETERS:
+	LM      -   linear model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	root mean square error.
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrRmsError(lm *LinearModel, xy *[][]float64, npoints int) float64 {
+	nvars := utils.RoundInt(lm.W[2])
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		x := (*xy)[i]
+		result = result + utils.SqrFloat64(LrProcess(lm, &x)-x[nvars])
+	}
+	return math.Sqrt(result / float64(npoints))
+}
+
+/*************************************************************************
+Average error on the test set
+
+INPUT PARAMETERS:
+	LM      -   linear model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	average error.
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrAvgError(lm *LinearModel, xy *[][]float64, npoints int) float64 {
+	nvars := utils.RoundInt(lm.W[2])
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		x := (*xy)[i]
+		result = result + math.Abs(LrProcess(lm, &x)-x[nvars])
+	}
+	return result / float64(npoints)
+}
+
+/*************************************************************************
+RMS error on the test set
+
+INPUT PARAMETERS:
+	LM      -   linear model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	average relative error.
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func LrAvgRelError(lm *LinearModel, xy *[][]float64, npoints int) float64 {
+	nvars := utils.RoundInt(lm.W[2])
+	result := 0.0
+	k := 0
+	for i := 0; i <= npoints-1; i++ {
+		x := (*xy)[i]
+		if x[nvars] != 0 {
+			result = result + math.Abs((LrProcess(lm, &x)-x[nvars])/x[nvars])
+			k = k + 1
+		}
+	}
+	if k != 0 {
+		result = result / float64(k)
+	}
+	return result
+}
+
+/*************************************************************************
+Copying of LinearModel strucure
+
+INPUT PARAMETERS:
+	LM1 -   original
+
+OUTPUT PARAMETERS:
+	LM2 -   copy
+
+  -- ALGLIB --
+	 Copyright 15.03.2009 by Bochkanov Sergey
+*************************************************************************/
+func LrCopy(lm1, lm2 *LinearModel) {
+	k := utils.RoundInt(lm1.W[0])
+	lm2.W = utils.CloneArrayFloat64(lm1.W[:k])
+}
+
+/*************************************************************************
+Standard errors of the coefficients: square roots of the diagonal of the
+covariation matrix, array[0..NVars], the last one is the error of the
+constant term.
+*************************************************************************/
+func LrStdErrors(ar *LrReport) []float64 {
+	result := make([]float64, len(ar.C))
+	for i := range ar.C {
+		result[i] = math.Sqrt(math.Max(ar.C[i][i], 0))
+	}
+	return result
+}
+
+/*************************************************************************
+Check that the training set has NPoints rows of NVars+1 values and NPoints
+standard deviations.
+*************************************************************************/
+func checkdataset(name string, xy *[][]float64, s *[]float64, npoints, nvars int) error {
+	if len(*xy) < npoints || len(*s) < npoints {
+		return fmt.Errorf("%s: rows count %d or deviations count %d less than NPoints %d", name, len(*xy), len(*s), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*xy)[i]) < nvars+1 {
+			return fmt.Errorf("%s: row %d length %d less than NVars+1", name, i, len((*xy)[i]))
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Internal linear regression subroutine
+*************************************************************************/
+func lrinternal(xy [][]float64, s []float64, npoints, nvars int, info *int, lm *LinearModel, ar *LrReport) {
+	var sv []float64
+	var u, vt [][]float64
+	epstol := 1000.0
+
+	*info = 0
+
+	//
+	// Check for errors in data
+	//
+	if npoints < nvars || nvars < 1 {
+		*info = -1
+		return
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if s[i] <= 0 {
+			*info = -2
+			return
+		}
+	}
+	*info = 1
+
+	//
+	// Create design matrix
+	//
+	a := utils.MakeMatrixFloat64(npoints, nvars)
+	b := make([]float64, npoints)
+	for i := 0; i <= npoints-1; i++ {
+		r := 1 / s[i]
+		for j := 0; j <= nvars-1; j++ {
+			a[i][j] = r * xy[i][j]
+		}
+		b[i] = xy[i][nvars] / s[i]
+	}
+
+	//
+	// Allocate W:
+	// W[0]     array size
+	// W[1]     version number, 0
+	// W[2]     NVars (minus 1, to be compatible with external representation)
+	// W[3]     coefficients offset
+	//
+	offs := 4
+	lm.W = make([]float64, 4+nvars)
+	lm.W[0] = float64(4 + nvars)
+	lm.W[1] = lrvnum
+	lm.W[2] = float64(nvars - 1)
+	lm.W[3] = float64(offs)
+
+	//
+	// Solve problem using SVD:
+	//
+	// 0. check for degeneracy (different types)
+	// 1. A = U*diag(sv)*V'
+	// 2. T = b'*U
+	// 3. w = SUM((T[i]/sv[i])*V[..,i])
+	// 4. cov(wi,wj) = SUM(Vji*Vjk/sv[i]^2,K=1..M)
+	//
+	// see $15.4 of "Numerical Recipes in C" for more information
+	//
+	t := make([]float64, nvars)
+	svi := make([]float64, nvars)
+	ar.C = utils.MakeMatrixFloat64(nvars, nvars)
+	vm := utils.MakeMatrixFloat64(nvars, nvars)
+	if !svd.RMatrixSvd(&a, npoints, nvars, &sv, &u, &vt) {
+		*info = -4
+		return
+	}
+	if sv[0] <= 0 {
+
+		//
+		// Degenerate case: zero design matrix.
+		//
+		for i := offs; i <= offs+nvars-1; i++ {
+			lm.W[i] = 0
+		}
+		ar.RmsError = LrRmsError(lm, &xy, npoints)
+		ar.AvgError = LrAvgError(lm, &xy, npoints)
+		ar.AvgRelError = LrAvgRelError(lm, &xy, npoints)
+		ar.CvRmsError = ar.RmsError
+		ar.CvAvgError = ar.AvgError
+		ar.CvAvgRelError = ar.AvgRelError
+		ar.NCvDefects = 0
+		ar.CvDefects = make([]int, nvars)
+		ar.C = utils.MakeMatrixFloat64(nvars, nvars)
+		return
+	}
+	if sv[nvars-1] <= epstol*utils.MachineEpsilon*sv[0] {
+
+		//
+		// Degenerate case, non-zero design matrix.
+		//
+		// We can leave it and solve task in SVD least squares fashion.
+		// Solution and covariance matrix will be obtained correctly,
+		// but CV error estimates - will not. It is better to reduce
+		// it to non-degenerate task and to obtain correct CV estimates.
+		//
+		for k := nvars; k >= 1; k-- {
+			if sv[k-1] > epstol*utils.MachineEpsilon*sv[0] {
+
+				//
+				// Reduce
+				//
+				xym := utils.MakeMatrixFloat64(npoints, k+1)
+				for i := 0; i <= npoints-1; i++ {
+					for j := 0; j <= k-1; j++ {
+						r := 0.0
+						for i_ := 0; i_ <= nvars-1; i_++ {
+							r += xy[i][i_] * vt[j][i_]
+						}
+						xym[i][j] = r
+					}
+					xym[i][k] = xy[i][nvars]
+				}
+
+				//
+				// Solve
+				//
+				tlm := NewLinearModel()
+				ar2 := NewLrReport()
+				lrinternal(xym, s, npoints, k, info, tlm, ar2)
+				if *info != 1 {
+					return
+				}
+
+				//
+				// Convert back to un-reduced format
+				//
+				for j := 0; j <= nvars-1; j++ {
+					lm.W[offs+j] = 0
+				}
+				for j := 0; j <= k-1; j++ {
+					r := tlm.W[offs+j]
+					for i_ := 0; i_ <= nvars-1; i_++ {
+						lm.W[offs+i_] = lm.W[offs+i_] + r*vt[j][i_]
+					}
+				}
+				ar.RmsError = ar2.RmsError
+				ar.AvgError = ar2.AvgError
+				ar.AvgRelError = ar2.AvgRelError
+				ar.CvRmsError = ar2.CvRmsError
+				ar.CvAvgError = ar2.CvAvgError
+				ar.CvAvgRelError = ar2.CvAvgRelError
+				ar.NCvDefects = ar2.NCvDefects
+				ar.CvDefects = make([]int, nvars)
+				for j := 0; j <= ar.NCvDefects-1; j++ {
+					ar.CvDefects[j] = ar2.CvDefects[j]
+				}
+
+				//
+				// C = VT[0..K-1]' * C2 * VT[0..K-1]
+				//
+				for i := 0; i <= k-1; i++ {
+					for j := 0; j <= nvars-1; j++ {
+						r := 0.0
+						for i_ := 0; i_ <= k-1; i_++ {
+							r += ar2.C[i][i_] * vt[i_][j]
+						}
+						vm[i][j] = r
+					}
+				}
+				ar.C = utils.MakeMatrixFloat64(nvars, nvars)
+				for i := 0; i <= nvars-1; i++ {
+					for j := 0; j <= nvars-1; j++ {
+						r := 0.0
+						for i_ := 0; i_ <= k-1; i_++ {
+							r += vt[i_][i] * vm[i_][j]
+						}
+						ar.C[i][j] = r
+					}
+				}
+				return
+			}
+		}
+		*info = -255
+		return
+	}
+	for i := 0; i <= nvars-1; i++ {
+		if sv[i] > epstol*utils.MachineEpsilon*sv[0] {
+			svi[i] = 1 / sv[i]
+		} else {
+			svi[i] = 0
+		}
+	}
+	for i := 0; i <= npoints-1; i++ {
+		r := b[i]
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			t[i_] = t[i_] + r*u[i][i_]
+		}
+	}
+	for i := 0; i <= nvars-1; i++ {
+		lm.W[offs+i] = 0
+	}
+	for i := 0; i <= nvars-1; i++ {
+		r := t[i] * svi[i]
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			lm.W[offs+i_] = lm.W[offs+i_] + r*vt[i][i_]
+		}
+	}
+	for j := 0; j <= nvars-1; j++ {
+		r := svi[j]
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			vm[i_][j] = r * vt[j][i_]
+		}
+	}
+	for i := 0; i <= nvars-1; i++ {
+		for j := i; j <= nvars-1; j++ {
+			r := 0.0
+			for i_ := 0; i_ <= nvars-1; i_++ {
+				r += vm[i][i_] * vm[j][i_]
+			}
+			ar.C[i][j] = r
+			ar.C[j][i] = r
+		}
+	}
+
+	//
+	// Leave-1-out cross-validation error.
+	//
+	// NOTATIONS:
+	// A            design matrix
+	// A*x = b      original linear least squares task
+	// U*S*V'       SVD of A
+	// ai           i-th row of the A
+	// bi           i-th element of the b
+	// xf           solution of the original LLS task
+	//
+	// Cross-validation error of i-th element from a sample is
+	// calculated using following formula:
+	//
+	//     ERRi = ai*xf - (ai*xf-bi*(ui*ui'))/(1-ui*ui')     (1)
+	//
+	// This formula can be derived from normal equations of the
+	// original task
+	//
+	//     (A'*A)x = A'*b                                    (2)
+	//
+	// by applying modification (zeroing out i-th row of A) to (2):
+	//
+	//     (A-ai)'*(A-ai) = (A-ai)'*b
+	//
+	// and using Sherman-Morrison formula for updating matrix inverse
+	//
+	// NOTE 1: b is not zeroed out since it is much simpler and
+	// does not influence final result.
+	//
+	// NOTE 2: some design matrices A have such ui that 1-ui*ui'=0.
+	// Formula (1) can't be applied for such cases and they are skipped
+	// from CV calculation (which distorts resulting CV estimate).
+	// But from the properties of U we can conclude that there can
+	// be no more than NVars such vectors. Usually
+	// NVars << NPoints, so in a normal case it only slightly
+	// influences result.
+	//
+	ncv := 0
+	na := 0
+	nacv := 0
+	ar.RmsError = 0
+	ar.AvgError = 0
+	ar.AvgRelError = 0
+	ar.CvRmsError = 0
+	ar.CvAvgError = 0
+	ar.CvAvgRelError = 0
+	ar.NCvDefects = 0
+	ar.CvDefects = make([]int, nvars)
+	for i := 0; i <= npoints-1; i++ {
+
+		//
+		// Error on a training set
+		//
+		r := 0.0
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			r += xy[i][i_] * lm.W[offs+i_]
+		}
+		ar.RmsError = ar.RmsError + utils.SqrFloat64(r-xy[i][nvars])
+		ar.AvgError = ar.AvgError + math.Abs(r-xy[i][nvars])
+		if xy[i][nvars] != 0 {
+			ar.AvgRelError = ar.AvgRelError + math.Abs((r-xy[i][nvars])/xy[i][nvars])
+			na = na + 1
+		}
+
+		//
+		// Error using fast leave-one-out cross-validation
+		//
+		p := 0.0
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			p += u[i][i_] * u[i][i_]
+		}
+		if p > 1-epstol*utils.MachineEpsilon {
+			ar.CvDefects[ar.NCvDefects] = i
+			ar.NCvDefects = ar.NCvDefects + 1
+			continue
+		}
+		r = s[i] * (r/s[i] - b[i]*p) / (1 - p)
+		ar.CvRmsError = ar.CvRmsError + utils.SqrFloat64(r-xy[i][nvars])
+		ar.CvAvgError = ar.CvAvgError + math.Abs(r-xy[i][nvars])
+		if xy[i][nvars] != 0 {
+			ar.CvAvgRelError = ar.CvAvgRelError + math.Abs((r-xy[i][nvars])/xy[i][nvars])
+			nacv = nacv + 1
+		}
+		ncv = ncv + 1
+	}
+	if ncv == 0 {
+
+		//
+		// Something strange: ALL ui are degenerate.
+		// Unexpected...
+		//
+		*info = -255
+		return
+	}
+	ar.RmsError = math.Sqrt(ar.RmsError / float64(npoints))
+	ar.AvgError = ar.AvgError / float64(npoints)
+	if na != 0 {
+		ar.AvgRelError = ar.AvgRelError / float64(na)
+	}
+	ar.CvRmsError = math.Sqrt(ar.CvRmsError / float64(ncv))
+	ar.CvAvgError = ar.CvAvgError / float64(ncv)
+	if nacv != 0 {
+		ar.CvAvgRelError = ar.CvAvgRelError / float64(nacv)
+	}
+}
+
+/*************************************************************************
+Mean and unbiased variance of the sample X[0..N-1], the part of the
+SampleMoments (BaseStat unit) used by the regression.
+
+  -- ALGLIB --
+	 Copyright 06.09.2006 by Bochkanov Sergey
+*************************************************************************/
+func samplemoments(x []float64, n int, mean, variance *float64) {
+	*mean = 0
+	*variance = 0
+	for i := 0; i <= n-1; i++ {
+		*mean = *mean + x[i]
+	}
+	*mean = *mean / float64(n)
+	if n == 1 {
+		return
+	}
+	v1 := 0.0
+	v2 := 0.0
+	for i := 0; i <= n-1; i++ {
+		v1 = v1 + utils.SqrFloat64(x[i]-*mean)
+		v2 = v2 + (x[i] - *mean)
+	}
+	v2 = utils.SqrFloat64(v2) / float64(n)
+	*variance = (v1 - v2) / float64(n-1)
+	if *variance < 0 {
+		*variance = 0
+	}
+}
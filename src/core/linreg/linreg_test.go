@@ -0,0 +1,146 @@
+package linreg_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/linreg"
+)
+
+// regressionSet - y = 2*x0 - 3*x1 + 5 with the uniform noise of the given amplitude
+func regressionSet(npoints int, noise float64, seed int64) [][]float64 {
+	rnd := rand.New(rand.NewSource(seed))
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		x0, x1 := rnd.Float64()*10, rnd.Float64()*2-1
+		xy[i] = []float64{x0, x1, 2*x0 - 3*x1 + 5 + noise*(rnd.Float64()*2-1)}
+	}
+	return xy
+}
+
+func TestBuild(t *testing.T) {
+	xy := regressionSet(200, 0.1, 1)
+	lm := linreg.NewLinearModel()
+	ar := linreg.NewLrReport()
+	info := 0
+	if err := linreg.LrBuild(&xy, len(xy), 2, &info, lm, ar); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	var v []float64
+	nvars := 0
+	linreg.LrUnpack(lm, &v, &nvars)
+	expected := []float64{2, -3, 5}
+	errors := linreg.LrStdErrors(ar)
+	if nvars != 2 || len(v) != 3 || len(errors) != 3 {
+		t.Fatalf("coefficients: %v, standard errors: %v", v, errors)
+	}
+	for i := range expected {
+		if errors[i] <= 0 || math.Abs(v[i]-expected[i]) > 4*errors[i]+1e-3 {
+			t.Errorf("coefficient %d: %v, standard error: %v, expected %v", i, v[i], errors[i], expected[i])
+		}
+	}
+	// uniform noise of amplitude 0.1 has rms 0.1/sqrt(3)
+	if ar.RmsError < 0.04 || ar.RmsError > 0.08 || ar.CvRmsError < ar.RmsError || ar.CvRmsError > 1.2*ar.RmsError {
+		t.Errorf("rms error: %v, cross-validation: %v", ar.RmsError, ar.CvRmsError)
+	}
+	if ar.AvgError > ar.RmsError || ar.CvAvgError > ar.CvRmsError || ar.NCvDefects != 0 {
+		t.Errorf("report: %+v", ar)
+	}
+	test := regressionSet(100, 0.1, 2)
+	if e := linreg.LrRmsError(lm, &test, len(test)); e > 0.08 {
+		t.Errorf("test set rms error: %v", e)
+	}
+	if e := linreg.LrAvgRelError(lm, &test, len(test)); e > 0.05 {
+		t.Errorf("test set average relative error: %v", e)
+	}
+	x := []float64{1, 0}
+	if y := linreg.LrProcess(lm, &x); math.Abs(y-7) > 0.05 {
+		t.Errorf("estimate: %v, expected 7", y)
+	}
+}
+
+// TestExact - covariation of the exact fit is zero, CV error is zero too
+func TestExact(t *testing.T) {
+	xy := regressionSet(20, 0, 3)
+	lm := linreg.NewLinearModel()
+	ar := linreg.NewLrReport()
+	info := 0
+	if err := linreg.LrBuild(&xy, len(xy), 2, &info, lm, ar); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	if ar.RmsError > 1e-9 || ar.CvRmsError > 1e-9 {
+		t.Errorf("rms error: %v, cross-validation: %v", ar.RmsError, ar.CvRmsError)
+	}
+	for i, e := range linreg.LrStdErrors(ar) {
+		if e > 1e-6 {
+			t.Errorf("standard error %d: %v", i, e)
+		}
+	}
+}
+
+// TestDegenerate - the second variable duplicates the first one, the solution has the minimal norm
+func TestDegenerate(t *testing.T) {
+	xy := make([][]float64, 30)
+	for i := range xy {
+		x := float64(i)
+		xy[i] = []float64{x, x, 4*x + 1}
+	}
+	lm := linreg.NewLinearModel()
+	ar := linreg.NewLrReport()
+	info := 0
+	if err := linreg.LrBuild(&xy, len(xy), 2, &info, lm, ar); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	var v []float64
+	nvars := 0
+	linreg.LrUnpack(lm, &v, &nvars)
+	if math.Abs(v[0]-2) > 1e-6 || math.Abs(v[1]-2) > 1e-6 || math.Abs(v[2]-1) > 1e-6 {
+		t.Errorf("coefficients: %v", v)
+	}
+
+	if err := linreg.LrBuild(&xy, 3, 2, &info, lm, ar); err != nil || info != -1 {
+		t.Errorf("too small set, info: %d, error: %v", info, err)
+	}
+	s := make([]float64, len(xy))
+	if err := linreg.LrBuildS(&xy, &s, len(xy), 2, &info, lm, ar); err != nil || info != -2 {
+		t.Errorf("zero deviations, info: %d, error: %v", info, err)
+	}
+	if err := linreg.LrBuild(&xy, len(xy), 3, &info, lm, ar); err == nil {
+		t.Error("short rows accepted")
+	}
+}
+
+func TestBuildZ(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	xy := make([][]float64, 50)
+	for i := range xy {
+		x0, x1 := rnd.Float64()*10, rnd.Float64()*2-1
+		xy[i] = []float64{x0, x1, 0.5*x0 + 2*x1 + 0.01*(rnd.Float64()*2-1)}
+	}
+	lm := linreg.NewLinearModel()
+	ar := linreg.NewLrReport()
+	info := 0
+	if err := linreg.LrBuildZ(&xy, len(xy), 2, &info, lm, ar); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	var v []float64
+	nvars := 0
+	linreg.LrUnpack(lm, &v, &nvars)
+	if math.Abs(v[0]-0.5) > 0.01 || math.Abs(v[1]-2) > 0.01 || v[2] != 0 {
+		t.Errorf("coefficients: %v", v)
+	}
+
+	packed := linreg.NewLinearModel()
+	if err := linreg.LrPack(&v, nvars, packed); err != nil {
+		t.Fatal(err)
+	}
+	copied := linreg.NewLinearModel()
+	linreg.LrCopy(lm, copied)
+	for _, row := range xy {
+		x := row[:2]
+		if y := linreg.LrProcess(lm, &x); y != linreg.LrProcess(packed, &x) || y != linreg.LrProcess(copied, &x) {
+			t.Fatalf("estimates differ on %v", x)
+		}
+	}
+}
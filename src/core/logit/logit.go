@@ -0,0 +1,1354 @@
+package logit
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"pr.optima/src/core/neural/mlpbase"
+	"pr.optima/src/core/neural/mlptrain"
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	xtol      = 100 * utils.MachineEpsilon
+	ftol      = 0.0001
+	gtol      = 0.3
+	maxfev    = 20
+	stpmin    = 1.0E-2
+	stpmax    = 1.0E5
+	logitvnum = 6
+
+	maxrealnumber  = 1E300
+	minrealnumber  = 1E-300
+)
+
+/*************************************************************************
+Multinomial logit model.
+
+W FORMAT:
+W[0]         -   size of the array
+W[1]         -   version (LogitVNum)
+W[2]         -   NVars
+W[3]         -   NClasses
+W[4]         -   Offs, offset of the coefficients
+W[Offs..]    -   coefficients, (NVars+1)*(NClasses-1) values, see MNLUnpack
+W[Offs+..]   -   NClasses service values (exponents of the linear outputs)
+*************************************************************************/
+type LogitModel struct {
+	W []float64
+}
+
+func NewLogitModel() *LogitModel {
+	return &LogitModel{W: []float64{}}
+}
+
+/*************************************************************************
+MNLReport structure contains information about training process:
+* NGrad     -   number of gradient calculations
+* NHess     -   number of Hessian calculations
+*************************************************************************/
+type MnlReport struct {
+	NGrad int
+	NHess int
+}
+
+func NewMnlReport() *MnlReport {
+	return &MnlReport{}
+}
+
+type logitmcstate struct {
+	brackt bool
+	stage1 bool
+	infoc  int
+	dg     float64
+	dgm    float64
+	dginit float64
+	dgtest float64
+	dgx    float64
+	dgxm   float64
+	dgy    float64
+	dgym   float64
+	finit  float64
+	ftest1 float64
+	fm     float64
+	fx     float64
+	fxm    float64
+	fy     float64
+	fym    float64
+	stx    float64
+	sty    float64
+	stmin  float64
+	stmax  float64
+	width  float64
+	width1 float64
+	xtrapf float64
+}
+
+/*************************************************************************
+This subroutine trains logit model.
+
+INPUT PARAMETERS:
+	XY          -   training set, array[0..NPoints-1,0..NVars]
+					First NVars columns store values of independent
+					variables, next column stores number of class (from 0
+					to NClasses-1) which dataset element belongs to. Fractional
+					values are rounded to nearest integer.
+	NPoints     -   training set size, NPoints>=1
+	NVars       -   number of independent variables, NVars>=1
+	NClasses    -   number of classes, NClasses>=2
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -2, if there is a point with class number
+						  outside of [0..NClasses-1].
+					* -1, if incorrect parameters was passed
+						  (NPoints<NVars+2, NVars<1, NClasses<2).
+					*  1, if task has been solved
+	LM          -   model built
+	Rep         -   training report
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlTrainH(xy *[][]float64, npoints, nvars, nclasses int, info *int, lm *LogitModel, rep *MnlReport) error {
+	nin := 0
+	nout := 0
+	wcount := 0
+	e := 0.0
+	wstep := 0.0
+	mcstage := 0
+	mcinfo := 0
+	mcnfev := 0
+	solverinfo := 0
+	mcstate := new(logitmcstate)
+
+	*info = 0
+	decay := 0.001
+
+	//
+	// Test for inputs
+	//
+	if npoints < nvars+2 || nvars < 1 || nclasses < 2 {
+		*info = -1
+		return nil
+	}
+	if len(*xy) < npoints {
+		return fmt.Errorf("MNLTrainH: rows count %d less than NPoints %d", len(*xy), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*xy)[i]) < nvars+1 {
+			return fmt.Errorf("MNLTrainH: row %d length %d less than NVars+1", i, len((*xy)[i]))
+		}
+		if utils.RoundInt((*xy)[i][nvars]) < 0 || utils.RoundInt((*xy)[i][nvars]) >= nclasses {
+			*info = -2
+			return nil
+		}
+	}
+	*info = 1
+
+	//
+	// Initialize data
+	//
+	rep.NGrad = 0
+	rep.NHess = 0
+
+	//
+	// Allocate array
+	//
+	offs := 5
+	ssize := 5 + (nvars+1)*(nclasses-1) + nclasses
+	lm.W = make([]float64, ssize)
+	lm.W[0] = float64(ssize)
+	lm.W[1] = logitvnum
+	lm.W[2] = float64(nvars)
+	lm.W[3] = float64(nclasses)
+	lm.W[4] = float64(offs)
+
+	//
+	// Degenerate case: all outputs are equal
+	//
+	allsame := true
+	for i := 1; i <= npoints-1; i++ {
+		if utils.RoundInt((*xy)[i][nvars]) != utils.RoundInt((*xy)[i-1][nvars]) {
+			allsame = false
+		}
+	}
+	if allsame {
+		v := -(2 * math.Log(minrealnumber))
+		k := utils.RoundInt((*xy)[0][nvars])
+		for i := 0; i <= nclasses-2; i++ {
+			if k == nclasses-1 {
+				lm.W[offs+i*(nvars+1)+nvars] = -v
+			} else if i == k {
+				lm.W[offs+i*(nvars+1)+nvars] = v
+			}
+		}
+		return nil
+	}
+
+	//
+	// General case.
+	// Prepare task and network. Allocate space.
+	//
+	network := mlpbase.NewMlp()
+	if err := mlpbase.MlpCreatec0(nvars, nclasses, network); err != nil {
+		return err
+	}
+	mlpbase.MlpInitPreprocessor(network, *xy, npoints)
+	mlpbase.MlpProperties(network, &nin, &nout, &wcount)
+	for i := 0; i <= wcount-1; i++ {
+		network.Weights[i] = (2*rand.Float64() - 1) / float64(nvars)
+	}
+	g := make([]float64, wcount)
+	h := utils.MakeMatrixFloat64(wcount, wcount)
+	wdir := make([]float64, wcount)
+	work := make([]float64, wcount)
+
+	//
+	// E/G of the network with the weight decay
+	//
+	calcgrad := func() error {
+		if err := mlpbase.MlpGradNBatch(network, *xy, npoints, &e, &g); err != nil {
+			return err
+		}
+		v := 0.0
+		for i := 0; i <= wcount-1; i++ {
+			v += network.Weights[i] * network.Weights[i]
+		}
+		e = e + 0.5*decay*v
+		for i := 0; i <= wcount-1; i++ {
+			g[i] = g[i] + decay*network.Weights[i]
+		}
+		rep.NGrad = rep.NGrad + 1
+		return nil
+	}
+
+	//
+	// Optimize in WDir direction
+	//
+	linesearch := func() error {
+		v := 0.0
+		for i := 0; i <= wcount-1; i++ {
+			v += wdir[i] * wdir[i]
+		}
+		wstep = math.Sqrt(v)
+		v = 1 / math.Sqrt(v)
+		for i := 0; i <= wcount-1; i++ {
+			wdir[i] = v * wdir[i]
+		}
+		mcstage = 0
+		mnlmcsrch(wcount, &network.Weights, &e, &g, wdir, &wstep, &mcinfo, &mcnfev, &work, mcstate, &mcstage)
+		for mcstage != 0 {
+			if err := calcgrad(); err != nil {
+				return err
+			}
+			mnlmcsrch(wcount, &network.Weights, &e, &g, wdir, &wstep, &mcinfo, &mcnfev, &work, mcstate, &mcstage)
+		}
+		return nil
+	}
+
+	//
+	// First stage: optimize in gradient direction.
+	//
+	for k := 0; k <= wcount/3+10; k++ {
+
+		//
+		// Calculate gradient in starting point
+		//
+		if err := calcgrad(); err != nil {
+			return err
+		}
+
+		//
+		// Setup optimization scheme
+		//
+		for i := 0; i <= wcount-1; i++ {
+			wdir[i] = -g[i]
+		}
+		if err := linesearch(); err != nil {
+			return err
+		}
+	}
+
+	//
+	// Second stage: use Hessian when we are close to the minimum
+	//
+	for {
+
+		//
+		// Calculate and update E/G/H
+		//
+		if err := mlpbase.MlpHessianNBatch(network, xy, npoints, &e, &g, &h); err != nil {
+			return err
+		}
+		v := 0.0
+		for i := 0; i <= wcount-1; i++ {
+			v += network.Weights[i] * network.Weights[i]
+		}
+		e = e + 0.5*decay*v
+		for i := 0; i <= wcount-1; i++ {
+			g[i] = g[i] + decay*network.Weights[i]
+		}
+		for k := 0; k <= wcount-1; k++ {
+			h[k][k] = h[k][k] + decay
+		}
+		rep.NHess = rep.NHess + 1
+
+		//
+		// Select step direction
+		// NOTE: it is important to use lower-triangle Cholesky
+		// factorization since it is much faster than higher-triangle version.
+		//
+		spd := mlptrain.SpdMatrixCholesky(&h, wcount, false)
+		if spd {
+			mlptrain.SpdMatrixCholeskySolve(&h, wcount, false, &g, &solverinfo, &wdir)
+			spd = solverinfo > 0
+		}
+		if spd {
+
+			//
+			// H is positive definite.
+			// Step in Newton direction.
+			//
+			for i := 0; i <= wcount-1; i++ {
+				wdir[i] = -wdir[i]
+			}
+		} else {
+
+			//
+			// H is indefinite.
+			// Step in gradient direction.
+			//
+			wdir = make([]float64, wcount)
+			for i := 0; i <= wcount-1; i++ {
+				wdir[i] = -g[i]
+			}
+		}
+		if err := linesearch(); err != nil {
+			return err
+		}
+		if spd && (mcinfo == 2 || mcinfo == 4 || mcinfo == 6) {
+			break
+		}
+	}
+
+	//
+	// Convert from NN format to MNL format
+	//
+	copy(lm.W[offs:offs+wcount], network.Weights[:wcount])
+	for k := 0; k <= nvars-1; k++ {
+		for i := 0; i <= nclasses-2; i++ {
+			s := network.ColumnSigmas[k]
+			if s == 0 {
+				s = 1
+			}
+			j := offs + (nvars+1)*i
+			v := lm.W[j+k]
+			lm.W[j+k] = v / s
+			lm.W[j+nvars] = lm.W[j+nvars] + v*network.ColumnMeans[k]/s
+		}
+	}
+	for k := 0; k <= nclasses-2; k++ {
+		lm.W[offs+(nvars+1)*k+nvars] = -lm.W[offs+(nvars+1)*k+nvars]
+	}
+	return nil
+}
+
+/*************************************************************************
+Procesing
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	X       -   input vector,  array[0..NVars-1].
+	Y       -   (possibly) preallocated buffer; if size of Y is less than
+				NClasses, it will be reallocated.If it is large enough, it
+				is NOT reallocated, so we can save some time on reallocation.
+
+OUTPUT PARAMETERS:
+	Y       -   result, array[0..NClasses-1]
+				Vector of posterior probabilities for classification task.
+
+Unlike ALGLIB the exponents are calculated in Y instead of the service part
+of LM.W, so the model may be processed concurrently.
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlProcess(lm *LogitModel, x, y *[]float64) {
+	nclasses := utils.RoundInt(lm.W[3])
+	if len(*y) < nclasses {
+		*y = make([]float64, nclasses)
+	}
+	mnliexp(lm.W, *x, *y)
+	s := 0.0
+	for i := 0; i <= nclasses-1; i++ {
+		s = s + (*y)[i]
+	}
+	for i := 0; i <= nclasses-1; i++ {
+		(*y)[i] = (*y)[i] / s
+	}
+}
+
+/*************************************************************************
+'interactive'  variant  of  MNLProcess  for  languages  like  Python which
+support constructs like "Y = MNLProcess(LM,X)" and interactive mode of the
+interpreter
+
+This function allocates new array on each call,  so  it  is  significantly
+slower than its 'non-interactive' counterpart, but it is  more  convenient
+when you call it from command line.
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlProcessi(lm *LogitModel, x, y *[]float64) {
+	*y = []float64{}
+	MnlProcess(lm, x, y)
+}
+
+/*************************************************************************
+Unpacks coefficients of logit model. Logit model have form:
+
+	P(class=i) = S(i) / (S(0) + S(1) + ... +S(M-1))
+		  S(i) = Exp(A[i,0]*X[0] + ... + A[i,N-1]*X[N-1] + A[i,N]), when i<M-1
+		S(M-1) = 1
+
+INPUT PARAMETERS:
+	LM          -   logit model in ALGLIB format
+
+OUTPUT PARAMETERS:
+	V           -   coefficients, array[0..NClasses-2,0..NVars]
+	NVars       -   number of independent variables
+	NClasses    -   number of classes
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlUnpack(lm *LogitModel, a *[][]float64, nvars, nclasses *int) {
+	*nvars = utils.RoundInt(lm.W[2])
+	*nclasses = utils.RoundInt(lm.W[3])
+	offs := utils.RoundInt(lm.W[4])
+	*a = utils.MakeMatrixFloat64(*nclasses-1, *nvars+1)
+	for i := 0; i <= *nclasses-2; i++ {
+		copy((*a)[i], lm.W[offs+i*(*nvars+1):offs+(i+1)*(*nvars+1)])
+	}
+}
+
+/*************************************************************************
+"Packs" coefficients and creates logit model in ALGLIB format (MNLUnpack
+reversed).
+
+INPUT PARAMETERS:
+	A           -   model (see MNLUnpack)
+	NVars       -   number of independent variables
+	NClasses    -   number of classes
+
+OUTPUT PARAMETERS:
+	LM          -   logit model.
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlPack(a *[][]float64, nvars, nclasses int, lm *LogitModel) error {
+	if nvars < 1 || nclasses < 2 || len(*a) < nclasses-1 {
+		return fmt.Errorf("MNLPack: incorrect parameters!")
+	}
+	offs := 5
+	ssize := 5 + (nvars+1)*(nclasses-1) + nclasses
+	lm.W = make([]float64, ssize)
+	lm.W[0] = float64(ssize)
+	lm.W[1] = logitvnum
+	lm.W[2] = float64(nvars)
+	lm.W[3] = float64(nclasses)
+	lm.W[4] = float64(offs)
+	for i := 0; i <= nclasses-2; i++ {
+		if len((*a)[i]) < nvars+1 {
+			return fmt.Errorf("MNLPack: row %d length %d less than NVars+1", i, len((*a)[i]))
+		}
+		copy(lm.W[offs+i*(nvars+1):offs+(i+1)*(nvars+1)], (*a)[i])
+	}
+	return nil
+}
+
+/*************************************************************************
+Copying of LogitModel strucure
+
+INPUT PARAMETERS:
+	LM1 -   original
+
+OUTPUT PARAMETERS:
+	LM2 -   copy
+
+  -- ALGLIB --
+	 Copyright 15.03.2009 by Bochkanov Sergey
+*************************************************************************/
+func MnlCopy(lm1, lm2 *LogitModel) {
+	k := utils.RoundInt(lm1.W[0])
+	lm2.W = utils.CloneArrayFloat64(lm1.W[:k])
+}
+
+/*************************************************************************
+Serialization of LogitModel strucure, the array is the copy of LM.W
+
+OUTPUT PARAMETERS:
+	RA      -   array of real numbers which stores logit model,
+				array[0..RLen-1]
+	RLen    -   RA lenght
+*************************************************************************/
+func MnlSerialize(lm *LogitModel, ra *[]float64, rlen *int) {
+	*rlen = utils.RoundInt(lm.W[0])
+	*ra = utils.CloneArrayFloat64(lm.W[:*rlen])
+}
+
+/*************************************************************************
+Unserialization of LogitModel strucure, header of the array is checked to
+describe the model of its length and the coefficients must be finite.
+*************************************************************************/
+func MnlUnserialize(ra []float64, lm *LogitModel) error {
+	if len(ra) < 5 || utils.RoundInt(ra[0]) != len(ra) || utils.RoundInt(ra[1]) != logitvnum {
+		return fmt.Errorf("MNLUnserialize: incorrect array!")
+	}
+	nvars := utils.RoundInt(ra[2])
+	nclasses := utils.RoundInt(ra[3])
+	offs := utils.RoundInt(ra[4])
+	if nvars < 1 || nclasses < 2 || offs != 5 || len(ra) != 5+(nvars+1)*(nclasses-1)+nclasses {
+		return fmt.Errorf("MNLUnserialize: incorrect array!")
+	}
+	if ok, _ := utils.IsFiniteVector(ra[offs:], (nvars+1)*(nclasses-1)); !ok {
+		return fmt.Errorf("MNLUnserialize: incorrect array!")
+	}
+	lm.W = utils.CloneArrayFloat64(ra)
+	return nil
+}
+
+/*************************************************************************
+Average cross-entropy (in bits per element) on the test set
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	CrossEntropy/(NPoints*ln(2)).
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlAvgce(lm *LogitModel, xy *[][]float64, npoints int) float64 {
+	nvars := utils.RoundInt(lm.W[2])
+	worky := make([]float64, utils.RoundInt(lm.W[3]))
+	result := 0.0
+	for i := 0; i <= npoints-1; i++ {
+		workx := (*xy)[i][:nvars]
+		MnlProcess(lm, &workx, &worky)
+		if p := worky[utils.RoundInt((*xy)[i][nvars])]; p > 0 {
+			result = result - math.Log(p)
+		} else {
+			result = result - math.Log(minrealnumber)
+		}
+	}
+	return result / (float64(npoints) * math.Log(2))
+}
+
+/*************************************************************************
+Relative classification error on the test set
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	percent of incorrectly classified cases.
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlRelClsError(lm *LogitModel, xy *[][]float64, npoints int) float64 {
+	return float64(MnlClsError(lm, xy, npoints)) / float64(npoints)
+}
+
+/*************************************************************************
+RMS error on the test set
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	root mean square error (error when estimating posterior probabilities).
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlRmsError(lm *LogitModel, xy *[][]float64, npoints int) float64 {
+	_, _, rms, _, _ := mnlallerrors(lm, xy, npoints)
+	return rms
+}
+
+/*************************************************************************
+Average error on the test set
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	average error (error when estimating posterior probabilities).
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlAvgError(lm *LogitModel, xy *[][]float64, npoints int) float64 {
+	_, _, _, avg, _ := mnlallerrors(lm, xy, npoints)
+	return avg
+}
+
+/*************************************************************************
+Average relative error on the test set
+
+INPUT PARAMETERS:
+	LM      -   logit model
+	XY      -   test set
+	NPoints -   test set size
+
+RESULT:
+	average relative error (error when estimating posterior probabilities).
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlAvgRelError(lm *LogitModel, xy *[][]float64, npoints int) float64 {
+	_, _, _, _, avgrel := mnlallerrors(lm, xy, npoints)
+	return avgrel
+}
+
+/*************************************************************************
+Classification error on test set = MNLRelClsError*NPoints
+
+  -- ALGLIB --
+	 Copyright 10.09.2008 by Bochkanov Sergey
+*************************************************************************/
+func MnlClsError(lm *LogitModel, xy *[][]float64, npoints int) int {
+	nvars := utils.RoundInt(lm.W[2])
+	nclasses := utils.RoundInt(lm.W[3])
+	worky := make([]float64, nclasses)
+	result := 0
+	for i := 0; i <= npoints-1; i++ {
+
+		//
+		// Process
+		//
+		workx := (*xy)[i][:nvars]
+		MnlProcess(lm, &workx, &worky)
+
+		//
+		// Logit version of the answer
+		//
+		nmax := 0
+		for j := 0; j <= nclasses-1; j++ {
+			if worky[j] > worky[nmax] {
+				nmax = j
+			}
+		}
+
+		//
+		// compare
+		//
+		if nmax != utils.RoundInt((*xy)[i][nvars]) {
+			result = result + 1
+		}
+	}
+	return result
+}
+
+/*************************************************************************
+Internal subroutine. Places exponents of the anti-overflow shifted
+internal linear outputs into Y[0..NClasses-1].
+*************************************************************************/
+func mnliexp(w, x, y []float64) {
+	nvars := utils.RoundInt(w[2])
+	nclasses := utils.RoundInt(w[3])
+	offs := utils.RoundInt(w[4])
+	for i := 0; i <= nclasses-2; i++ {
+		i1 := offs + i*(nvars+1)
+		v := 0.0
+		for j := 0; j <= nvars-1; j++ {
+			v += w[i1+j] * x[j]
+		}
+		y[i] = v + w[i1+nvars]
+	}
+	y[nclasses-1] = 0
+	mx := 0.0
+	for i := 0; i <= nclasses-1; i++ {
+		mx = math.Max(mx, y[i])
+	}
+	for i := 0; i <= nclasses-1; i++ {
+		y[i] = math.Exp(y[i] - mx)
+	}
+}
+
+/*************************************************************************
+Calculation of all types of errors
+
+  -- ALGLIB --
+	 Copyright 30.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func mnlallerrors(lm *LogitModel, xy *[][]float64, npoints int) (relcls, avgce, rms, avg, avgrel float64) {
+	nvars := utils.RoundInt(lm.W[2])
+	nclasses := utils.RoundInt(lm.W[3])
+	y := make([]float64, nclasses)
+	dy := make([]float64, 1)
+	var buf []float64
+	dserrallocate(nclasses, &buf)
+	for i := 0; i <= npoints-1; i++ {
+		workx := (*xy)[i][:nvars]
+		MnlProcess(lm, &workx, &y)
+		dy[0] = (*xy)[i][nvars]
+		dserraccumulate(&buf, &y, &dy)
+	}
+	dserrfinish(&buf)
+	return buf[0], buf[1], buf[2], buf[3], buf[4]
+}
+
+/*************************************************************************
+Error buffer of the classifier (BDSS unit), see DSErrAllocate:
+buf[0..4] are relcls, avgce, rms, avg and avgrel errors,
+buf[5] is NClasses, buf[6] and buf[7] are the counters.
+
+  -- ALGLIB --
+	 Copyright 11.01.2009 by Bochkanov Sergey
+*************************************************************************/
+func dserrallocate(nclasses int, buf *[]float64) {
+	*buf = make([]float64, 8)
+	(*buf)[5] = float64(nclasses)
+}
+
+/*************************************************************************
+See DSErrAllocate for comments on this routine.
+
+  -- ALGLIB --
+	 Copyright 11.01.2009 by Bochkanov Sergey
+*************************************************************************/
+func dserraccumulate(buf, y, desiredy *[]float64) {
+	b := *buf
+	offs := 5
+	nclasses := utils.RoundInt(b[offs])
+	rmax := utils.RoundInt((*desiredy)[0])
+	mmax := 0
+	for j := 1; j <= nclasses-1; j++ {
+		if (*y)[j] > (*y)[mmax] {
+			mmax = j
+		}
+	}
+	if mmax != rmax {
+		b[0] = b[0] + 1
+	}
+	if (*y)[rmax] > 0 {
+		b[1] = b[1] - math.Log((*y)[rmax])
+	} else {
+		b[1] = b[1] + math.Log(maxrealnumber)
+	}
+	for j := 0; j <= nclasses-1; j++ {
+		v := (*y)[j]
+		ev := 0.0
+		if j == rmax {
+			ev = 1
+		}
+		b[2] = b[2] + utils.SqrFloat64(v-ev)
+		b[3] = b[3] + math.Abs(v-ev)
+		if ev != 0 {
+			b[4] = b[4] + math.Abs((v-ev)/ev)
+			b[offs+2] = b[offs+2] + 1
+		}
+	}
+	b[offs+1] = b[offs+1] + 1
+}
+
+/*************************************************************************
+See DSErrAllocate for comments on this routine.
+
+  -- ALGLIB --
+	 Copyright 11.01.2009 by Bochkanov Sergey
+*************************************************************************/
+func dserrfinish(buf *[]float64) {
+	b := *buf
+	offs := 5
+	nout := math.Abs(float64(utils.RoundInt(b[offs])))
+	if b[offs+1] != 0 {
+		b[0] = b[0] / b[offs+1]
+		b[1] = b[1] / b[offs+1]
+		b[2] = math.Sqrt(b[2] / (nout * b[offs+1]))
+		b[3] = b[3] / (nout * b[offs+1])
+	}
+	if b[offs+2] != 0 {
+		b[4] = b[4] / b[offs+2]
+	}
+}
+
+/*************************************************************************
+THE  PURPOSE  OF  MCSRCH  IS  TO  FIND A STEP WHICH SATISFIES A SUFFICIENT
+DECREASE CONDITION AND A CURVATURE CONDITION, see MCSRCH of the MLPTrain
+unit for the full description. This version uses the LOGIT tolerances
+and STPMIN/STPMAX bounds.
+
+INFO IS AN INTEGER OUTPUT VARIABLE SET AS FOLLOWS:
+	INFO = 0  IMPROPER INPUT PARAMETERS.
+	INFO = 1  THE SUFFICIENT DECREASE CONDITION AND THE
+			  DIRECTIONAL DERIVATIVE CONDITION HOLD.
+	INFO = 2  RELATIVE WIDTH OF THE INTERVAL OF UNCERTAINTY
+			  IS AT MOST XTOL.
+	INFO = 3  NUMBER OF CALLS TO FCN HAS REACHED MAXFEV.
+	INFO = 4  THE STEP IS AT THE LOWER BOUND STPMIN.
+	INFO = 5  THE STEP IS AT THE UPPER BOUND STPMAX.
+	INFO = 6  ROUNDING ERRORS PREVENT FURTHER PROGRESS.
+
+ARGONNE NATIONAL LABORATORY. MINPACK PROJECT. JUNE 1983
+JORGE J. MORE', DAVID J. THUENTE
+*************************************************************************/
+func mnlmcsrch(n int, x *[]float64, f *float64, g *[]float64, s []float64, stp *float64, info, nfev *int, wa *[]float64, state *logitmcstate, stage *int) {
+	p5 := 0.5
+	p66 := 0.66
+	state.xtrapf = 4.0
+
+	//
+	// Main cycle
+	//
+	for {
+		if *stage == 0 {
+			//
+			// NEXT
+			//
+			*stage = 2
+			continue
+		}
+		if *stage == 2 {
+			state.infoc = 1
+			*info = 0
+
+			//
+			//     CHECK THE INPUT PARAMETERS FOR ERRORS.
+			//
+			if n <= 0 || *stp <= 0 {
+				*stage = 0
+				return
+			}
+
+			//
+			//     COMPUTE THE INITIAL GRADIENT IN THE SEARCH DIRECTION
+			//     AND CHECK THAT S IS A DESCENT DIRECTION.
+			//
+			v := 0.0
+			for i := 0; i <= n-1; i++ {
+				v += (*g)[i] * s[i]
+			}
+			state.dginit = v
+			if state.dginit >= 0 {
+				*stage = 0
+				return
+			}
+
+			//
+			//     INITIALIZE LOCAL VARIABLES.
+			//
+			state.brackt = false
+			state.stage1 = true
+			*nfev = 0
+			state.finit = *f
+			state.dgtest = ftol * state.dginit
+			state.width = stpmax - stpmin
+			state.width1 = state.width / p5
+			copy((*wa)[:n], (*x)[:n])
+
+			//
+			//     THE VARIABLES STX, FX, DGX CONTAIN THE VALUES OF THE STEP,
+			//     FUNCTION, AND DIRECTIONAL DERIVATIVE AT THE BEST STEP.
+			//     THE VARIABLES STY, FY, DGY CONTAIN THE VALUE OF THE STEP,
+			//     FUNCTION, AND DERIVATIVE AT THE OTHER ENDPOINT OF
+			//     THE INTERVAL OF UNCERTAINTY.
+			//     THE VARIABLES STP, F, DG CONTAIN THE VALUES OF THE STEP,
+			//     FUNCTION, AND DERIVATIVE AT THE CURRENT STEP.
+			//
+			state.stx = 0
+			state.fx = state.finit
+			state.dgx = state.dginit
+			state.sty = 0
+			state.fy = state.finit
+			state.dgy = state.dginit
+
+			//
+			// NEXT
+			//
+			*stage = 3
+			continue
+		}
+		if *stage == 3 {
+			//
+			//     START OF ITERATION.
+			//
+			//     SET THE MINIMUM AND MAXIMUM STEPS TO CORRESPOND
+			//     TO THE PRESENT INTERVAL OF UNCERTAINTY.
+			//
+			if state.brackt {
+				if state.stx < state.sty {
+					state.stmin = state.stx
+					state.stmax = state.sty
+				} else {
+					state.stmin = state.sty
+					state.stmax = state.stx
+				}
+			} else {
+				state.stmin = state.stx
+				state.stmax = *stp + state.xtrapf*(*stp-state.stx)
+			}
+
+			//
+			//        FORCE THE STEP TO BE WITHIN THE BOUNDS STPMAX AND STPMIN.
+			//
+			if *stp > stpmax {
+				*stp = stpmax
+			}
+			if *stp < stpmin {
+				*stp = stpmin
+			}
+
+			//
+			//        IF AN UNUSUAL TERMINATION IS TO OCCUR THEN LET
+			//        STP BE THE LOWEST POINT OBTAINED SO FAR.
+			//
+			if (state.brackt && (*stp <= state.stmin || *stp >= state.stmax)) || *nfev >= maxfev-1 || state.infoc == 0 || (state.brackt && state.stmax-state.stmin <= xtol*state.stmax) {
+				*stp = state.stx
+			}
+
+			//
+			//        EVALUATE THE FUNCTION AND GRADIENT AT STP
+			//        AND COMPUTE THE DIRECTIONAL DERIVATIVE.
+			//
+			for i := 0; i <= n-1; i++ {
+				(*x)[i] = (*wa)[i] + *stp*s[i]
+			}
+
+			//
+			// NEXT
+			//
+			*stage = 4
+			return
+		}
+		if *stage == 4 {
+			*info = 0
+			*nfev = *nfev + 1
+			v := 0.0
+			for i := 0; i <= n-1; i++ {
+				v += (*g)[i] * s[i]
+			}
+			state.dg = v
+			state.ftest1 = state.finit + *stp*state.dgtest
+
+			//
+			//        TEST FOR CONVERGENCE.
+			//
+			if (state.brackt && (*stp <= state.stmin || *stp >= state.stmax)) || state.infoc == 0 {
+				*info = 6
+			}
+			if *stp == stpmax && *f <= state.ftest1 && state.dg <= state.dgtest {
+				*info = 5
+			}
+			if *stp == stpmin && (*f > state.ftest1 || state.dg >= state.dgtest) {
+				*info = 4
+			}
+			if *nfev >= maxfev {
+				*info = 3
+			}
+			if state.brackt && state.stmax-state.stmin <= xtol*state.stmax {
+				*info = 2
+			}
+			if *f <= state.ftest1 && math.Abs(state.dg) <= -(gtol*state.dginit) {
+				*info = 1
+			}
+
+			//
+			//        CHECK FOR TERMINATION.
+			//
+			if *info != 0 {
+				*stage = 0
+				return
+			}
+
+			//
+			//        IN THE FIRST STAGE WE SEEK A STEP FOR WHICH THE MODIFIED
+			//        FUNCTION HAS A NONPOSITIVE VALUE AND NONNEGATIVE DERIVATIVE.
+			//
+			if state.stage1 && *f <= state.ftest1 && state.dg >= math.Min(ftol, gtol)*state.dginit {
+				state.stage1 = false
+			}
+
+			//
+			//        A MODIFIED FUNCTION IS USED TO PREDICT THE STEP ONLY IF
+			//        WE HAVE NOT OBTAINED A STEP FOR WHICH THE MODIFIED
+			//        FUNCTION HAS A NONPOSITIVE FUNCTION VALUE AND NONNEGATIVE
+			//        DERIVATIVE, AND IF A LOWER FUNCTION VALUE HAS BEEN
+			//        OBTAINED BUT THE DECREASE IS NOT SUFFICIENT.
+			//
+			if state.stage1 && *f <= state.fx && *f > state.ftest1 {
+				//
+				//           DEFINE THE MODIFIED FUNCTION AND DERIVATIVE VALUES.
+				//
+				state.fm = *f - *stp*state.dgtest
+				state.fxm = state.fx - state.stx*state.dgtest
+				state.fym = state.fy - state.sty*state.dgtest
+				state.dgm = state.dg - state.dgtest
+				state.dgxm = state.dgx - state.dgtest
+				state.dgym = state.dgy - state.dgtest
+
+				//
+				//           CALL CSTEP TO UPDATE THE INTERVAL OF UNCERTAINTY
+				//           AND TO COMPUTE THE NEW STEP.
+				//
+				mnlmcstep(&state.stx, &state.fxm, &state.dgxm, &state.sty, &state.fym, &state.dgym, stp, state.fm, state.dgm, &state.brackt, state.stmin, state.stmax, &state.infoc)
+
+				//
+				//           RESET THE FUNCTION AND GRADIENT VALUES FOR F.
+				//
+				state.fx = state.fxm + state.stx*state.dgtest
+				state.fy = state.fym + state.sty*state.dgtest
+				state.dgx = state.dgxm + state.dgtest
+				state.dgy = state.dgym + state.dgtest
+			} else {
+				//
+				//           CALL MCSTEP TO UPDATE THE INTERVAL OF UNCERTAINTY
+				//           AND TO COMPUTE THE NEW STEP.
+				//
+				mnlmcstep(&state.stx, &state.fx, &state.dgx, &state.sty, &state.fy, &state.dgy, stp, *f, state.dg, &state.brackt, state.stmin, state.stmax, &state.infoc)
+			}
+
+			//
+			//        FORCE A SUFFICIENT DECREASE IN THE SIZE OF THE
+			//        INTERVAL OF UNCERTAINTY.
+			//
+			if state.brackt {
+				if math.Abs(state.sty-state.stx) >= p66*state.width1 {
+					*stp = state.stx + p5*(state.sty-state.stx)
+				}
+				state.width1 = state.width
+				state.width = math.Abs(state.sty - state.stx)
+			}
+
+			//
+			//  NEXT.
+			//
+			*stage = 3
+			continue
+		}
+	}
+}
+
+func mnlmcstep(stx, fx, dx, sty, fy, dy, stp *float64, fp, dp float64, brackt *bool, stmin, stmax float64, info *int) {
+	var bound bool
+	gamma := 0.0
+	p := 0.0
+	q := 0.0
+	r := 0.0
+	s := 0.0
+	sgnd := 0.0
+	stpc := 0.0
+	stpf := 0.0
+	stpq := 0.0
+	theta := 0.0
+
+	*info = 0
+
+	//
+	//     CHECK THE INPUT PARAMETERS FOR ERRORS.
+	//
+	if ((*brackt && (*stp <= (math.Min(*stx, *sty)) || *stp >= (math.Max(*stx, *sty)))) || (*dx * (*stp - *stx)) >= 0) || stmax < stmin {
+		return
+	}
+
+	//
+	//     DETERMINE IF THE DERIVATIVES HAVE OPPOSITE SIGN.
+	//
+	sgnd = dp * (*dx / math.Abs(*dx))
+
+	//
+	//     FIRST CASE. A HIGHER FUNCTION VALUE.
+	//     THE MINIMUM IS BRACKETED. IF THE CUBIC STEP IS CLOSER
+	//     TO STX THAN THE QUADRATIC STEP, THE CUBIC STEP IS TAKEN,
+	//     ELSE THE AVERAGE OF THE CUBIC AND QUADRATIC STEPS IS TAKEN.
+	//
+	if fp > *fx {
+		*info = 1
+		bound = true
+		theta = 3 * (*fx - fp) / (*stp - *stx) + *dx + dp
+		s = math.Max(math.Abs(theta), math.Max(math.Abs(*dx), math.Abs(dp)))
+		_s := theta / s
+		gamma = s * math.Sqrt((_s * _s) - *dx / s * (dp / s))
+		if *stp < *stx {
+			gamma = -gamma
+		}
+		p = gamma - *dx + theta
+		q = gamma - *dx + gamma + dp
+		r = p / q
+		stpc = *stx + r * (*stp - *stx)
+		stpq = *stx + *dx / ((*fx - fp) / (*stp - *stx) + *dx) / 2 * (*stp - *stx)
+		if math.Abs(stpc - *stx) < math.Abs(stpq - *stx) {
+			stpf = stpc
+		}else {
+			stpf = stpc + (stpq - stpc) / 2
+		}
+		*brackt = true
+	}else {
+		if sgnd < 0 {
+			//
+			//     SECOND CASE. A LOWER FUNCTION VALUE AND DERIVATIVES OF
+			//     OPPOSITE SIGN. THE MINIMUM IS BRACKETED. IF THE CUBIC
+			//     STEP IS CLOSER TO STX THAN THE QUADRATIC (SECANT) STEP,
+			//     THE CUBIC STEP IS TAKEN, ELSE THE QUADRATIC STEP IS TAKEN.
+			//
+			*info = 2
+			bound = false
+			theta = 3 * (*fx - fp) / (*stp - *stx) + *dx + dp
+			s = math.Max(math.Abs(theta), math.Max(math.Abs(*dx), math.Abs(dp)))
+			_s := theta / s
+			gamma = s * math.Sqrt((_s * _s) - *dx / s * (dp / s))
+			if *stp > *stx {
+				gamma = -gamma
+			}
+			p = gamma - dp + theta
+			q = gamma - dp + gamma + *dx
+			r = p / q
+			stpc = *stp + r * (*stx - *stp)
+			stpq = *stp + dp / (dp - *dx) * (*stx - *stp)
+			if math.Abs(stpc - *stp) > math.Abs(stpq - *stp) {
+				stpf = stpc
+			}else {
+				stpf = stpq
+			}
+			*brackt = true
+		}else {
+			if math.Abs(dp) < math.Abs(*dx) {
+				//
+				//     THIRD CASE. A LOWER FUNCTION VALUE, DERIVATIVES OF THE
+				//     SAME SIGN, AND THE MAGNITUDE OF THE DERIVATIVE DECREASES.
+				//     THE CUBIC STEP IS ONLY USED IF THE CUBIC TENDS TO INFINITY
+				//     IN THE DIRECTION OF THE STEP OR IF THE MINIMUM OF THE CUBIC
+				//     IS BEYOND STP. OTHERWISE THE CUBIC STEP IS DEFINED TO BE
+				//     EITHER STPMIN OR STPMAX. THE QUADRATIC (SECANT) STEP IS ALSO
+				//     COMPUTED AND IF THE MINIMUM IS BRACKETED THEN THE THE STEP
+				//     CLOSEST TO STX IS TAKEN, ELSE THE STEP FARTHEST AWAY IS TAKEN.
+				//
+				*info = 3
+				bound = true
+				theta = 3 * (*fx - fp) / (*stp - *stx) + *dx + dp
+				s = math.Max(math.Abs(theta), math.Max(math.Abs(*dx), math.Abs(dp)))
+
+				//
+				//        THE CASE GAMMA = 0 ONLY ARISES IF THE CUBIC DOES NOT TEND
+				//        TO INFINITY IN THE DIRECTION OF THE STEP.
+				//
+				_s := theta / s
+				gamma = s * math.Sqrt(math.Max(0, (_s * _s) - *dx / s * (dp / s)))
+				if *stp > *stx {
+					gamma = -gamma
+				}
+				p = gamma - dp + theta
+				q = gamma + (*dx - dp) + gamma
+				r = p / q
+				if r < 0 && gamma != 0 {
+					stpc = *stp + r * (*stx - *stp)
+				}else {
+					if *stp > *stx {
+						stpc = stmax
+					}else {
+						stpc = stmin
+					}
+				}
+				stpq = *stp + dp / (dp - *dx) * (*stx - *stp)
+				if *brackt {
+					if math.Abs(*stp - stpc) < math.Abs(*stp - stpq) {
+						stpf = stpc
+					}else {
+						stpf = stpq
+					}
+				}else {
+					if math.Abs(*stp - stpc) > math.Abs(*stp - stpq) {
+						stpf = stpc
+					}else {
+						stpf = stpq
+					}
+				}
+			}else {
+				//
+				//     FOURTH CASE. A LOWER FUNCTION VALUE, DERIVATIVES OF THE
+				//     SAME SIGN, AND THE MAGNITUDE OF THE DERIVATIVE DOES
+				//     NOT DECREASE. IF THE MINIMUM IS NOT BRACKETED, THE STEP
+				//     IS EITHER STPMIN OR STPMAX, ELSE THE CUBIC STEP IS TAKEN.
+				//
+				*info = 4
+				bound = false
+				if *brackt {
+					theta = 3 * (fp - *fy) / (*sty - *stp) + *dy + dp
+					s = math.Max(math.Abs(theta), math.Max(math.Abs(*dy), math.Abs(dp)))
+					_s := theta / s
+					gamma = s * math.Sqrt((_s * _s) - *dy / s * (dp / s))
+					if *stp > *sty {
+						gamma = -gamma
+					}
+					p = gamma - dp + theta
+					q = gamma - dp + gamma + *dy
+					r = p / q
+					stpc = *stp + r * (*sty - *stp)
+					stpf = stpc
+				}else {
+					if *stp > *stx {
+						stpf = stmax
+					}else {
+						stpf = stmin
+					}
+				}
+			}
+		}
+	}
+
+	//
+	//     UPDATE THE INTERVAL OF UNCERTAINTY. THIS UPDATE DOES NOT
+	//     DEPEND ON THE NEW STEP OR THE CASE ANALYSIS ABOVE.
+	//
+	if fp > *fx {
+		*sty = *stp
+		*fy = fp
+		*dy = dp
+	}else {
+		if sgnd < 0.0 {
+			*sty = *stx
+			*fy = *fx
+			*dy = *dx
+		}
+		*stx = *stp
+		*fx = fp
+		*dx = dp
+	}
+
+	//
+	//     COMPUTE THE NEW STEP AND SAFEGUARD IT.
+	//
+	stpf = math.Min(stmax, stpf)
+	stpf = math.Max(stmin, stpf)
+	*stp = stpf
+	if *brackt && bound {
+		if *sty > *stx {
+			*stp = math.Min(*stx + 0.66 * (*sty - *stx), *stp)
+		}else {
+			*stp = math.Max(*stx + 0.66 * (*sty - *stx), *stp)
+		}
+	}
+}
+
+/*************************************************************************
+THE  PURPOSE  OF  MCSRCH  IS  TO  FIND A STEP WHICH SATISFIES A SUFFICIENT
+DECREASE CONDITION AND A CURVATURE CONDITION.
+
+AT EACH STAGE THE SUBROUTINE  UPDATES  AN  INTERVAL  OF  UNCERTAINTY  WITH
+ENDPOINTS  STX  AND  STY.  THE INTERVAL OF UNCERTAINTY IS INITIALLY CHOSEN
+SO THAT IT CONTAINS A MINIMIZER OF THE MODIFIED FUNCTION
+
+	F(X+STP*S) - F(X) - FTOL*STP*(GRADF(X)'S).
+
+IF  A STEP  IS OBTAINED FOR  WHICH THE MODIFIED FUNCTION HAS A NONPOSITIVE
+FUNCTION  VALUE  AND  NONNEGATIVE  DERIVATIVE,   THEN   THE   INTERVAL  OF
+UNCERTAINTY IS CHOSEN SO THAT IT CONTAINS A MINIMIZER OF F(X+STP*S).
+
+THE  ALGORITHM  IS  DESIGNED TO FIND A STEP WHICH SATISFIES THE SUFFICIENT
+DECREASE CONDITION
+
+	F(X+STP*S) .LE. F(X) + FTOL*STP*(GRADF(X)'S),
+
+AND THE CURVATURE CONDITION
+
+	ABS(GRADF(X+STP*S)'S)) .LE. GTOL*ABS(GRADF(X)'S).
+
+IF  FTOL  IS  LESS  THAN GTOL AND IF, FOR EXAMPLE, THE FUNCTION IS BOUNDED
+BELOW,  THEN  THERE  IS  ALWAYS  A  STEP  WHICH SATISFIES BOTH CONDITIONS.
+IF  NO  STEP  CAN BE FOUND  WHICH  SATISFIES  BOTH  CONDITIONS,  THEN  THE
+ALGORITHM  USUALLY STOPS  WHEN  ROUNDING ERRORS  PREVENT FURTHER PROGRESS.
+IN THIS CASE STP ONLY SATISFIES THE SUFFICIENT DECREASE CONDITION.
+
+
+:::::::::::::IMPORTANT NOTES:::::::::::::
+
+NOTE 1:
+
+This routine  guarantees that it will stop at the last point where function
+value was calculated. It won't make several additional function evaluations
+after finding good point. So if you store function evaluations requested by
+this routine, you can be sure that last one is the point where we've stopped.
+
+NOTE 2:
+
+when 0<StpMax<StpMin, algorithm will terminate with INFO=5 and Stp=0.0
+:::::::::::::::::::::::::::::::::::::::::
+
+
+PARAMETERS DESCRIPRION
+
+STAGE IS ZERO ON FIRST CALL, ZERO ON FINAL EXIT
+
+N IS A POSITIVE INTEGER INPUT VARIABLE SET TO THE NUMBER OF VARIABLES.
+
+X IS  AN  ARRAY  OF  LENGTH N. ON INPUT IT MUST CONTAIN THE BASE POINT FOR
+THE LINE SEARCH. ON OUTPUT IT CONTAINS X+STP*S.
+
+F IS  A  VARIABLE. ON INPUT IT MUST CONTAIN THE VALUE OF F AT X. ON OUTPUT
+IT CONTAINS THE VALUE OF F AT X + STP*S.
+
+G IS AN ARRAY OF LENGTH N. ON INPUT IT MUST CONTAIN THE GRADIENT OF F AT X.
+ON OUTPUT IT CONTAINS THE GRADIENT OF F AT X + STP*S.
+
+S IS AN INPUT ARRAY OF LENGTH N WHICH SPECIFIES THE SEARCH DIRECTION.
+
+STP  IS  A NONNEGATIVE VARIABLE. ON INPUT STP CONTAINS AN INITIAL ESTIMATE
+OF A SATISFACTORY STEP. ON OUTPUT STP CONTAINS THE FINAL ESTIMATE.
+
+FTOL AND GTOL ARE NONNEGATIVE INPUT VARIABLES. TERMINATION OCCURS WHEN THE
+SUFFICIENT DECREASE CONDITION AND THE DIRECTIONAL DERIVATIVE CONDITION ARE
+SATISFIED.
+
+XTOL IS A NONNEGATIVE INPUT VARIABLE. TERMINATION OCCURS WHEN THE RELATIVE
+WIDTH OF THE INTERVAL OF UNCERTAINTY IS AT MOST XTOL.
+
+STPMIN AND STPMAX ARE NONNEGATIVE INPUT VARIABLES WHICH SPECIFY LOWER  AND
+UPPER BOUNDS FOR THE STEP.
+
+MAXFEV IS A POSITIVE INTEGER INPUT VARIABLE. TERMINATION OCCURS WHEN THE
+NUMBER OF CALLS TO FCN IS AT LEAST MAXFEV BY THE END OF AN ITERATION.
+
+INFO IS AN INTEGER OUTPUT VARIABLE SET AS FOLLOWS:
+	INFO = 0  IMPROPER INPUT PARAMETERS.
+
+	INFO = 1  THE SUFFICIENT DECREASE CONDITION AND THE
+			  DIRECTIONAL DERIVATIVE CONDITION HOLD.
+
+	INFO = 2  RELATIVE WIDTH OF THE INTERVAL OF UNCERTAINTY
+			  IS AT MOST XTOL.
+
+	INFO = 3  NUMBER OF CALLS TO FCN HAS REACHED MAXFEV.
+
+	INFO = 4  THE STEP IS AT THE LOWER BOUND STPMIN.
+
+	INFO = 5  THE STEP IS AT THE UPPER BOUND STPMAX.
+
+	INFO = 6  ROUNDING ERRORS PREVENT FURTHER PROGRESS.
+			  THERE MAY NOT BE A STEP WHICH SATISFIES THE
+			  SUFFICIENT DECREASE AND CURVATURE CONDITIONS.
+			  TOLERANCES MAY BE TOO SMALL.
+
+NFEV IS AN INTEGER OUTPUT VARIABLE SET TO THE NUMBER OF CALLS TO FCN.
+
+WA IS A WORK ARRAY OF LENGTH N.
+
+ARGONNE NATIONAL LABORATORY. MINPACK PROJECT. JUNE 1983
+JORGE J. MORE', DAVID J. THUENTE
+*************************************************************************/
@@ -0,0 +1,181 @@
+package logit_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/logit"
+	"pr.optima/src/core/neural/mlpbase"
+)
+
+// sectorSet - class is the sector of the linear score with the maximum value, the classes are
+// linearly separable as the logit model assumes, the third variable is noise
+func sectorSet(npoints int, seed int64) [][]float64 {
+	rnd := rand.New(rand.NewSource(seed))
+	weights := [][]float64{{4, 0}, {-2, 3.5}, {-2, -3.5}}
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		x0, x1 := rnd.Float64()*2-1, rnd.Float64()*2-1
+		class, best := 0, math.Inf(-1)
+		for k, w := range weights {
+			if score := w[0]*x0 + w[1]*x1; score > best {
+				class, best = k, score
+			}
+		}
+		xy[i] = []float64{x0, x1, rnd.Float64(), float64(class)}
+	}
+	return xy
+}
+
+func TestTrain(t *testing.T) {
+	xy := sectorSet(400, 1)
+	lm := logit.NewLogitModel()
+	rep := logit.NewMnlReport()
+	info := 0
+	if err := logit.MnlTrainH(&xy, len(xy), 3, 3, &info, lm, rep); err != nil {
+		t.Fatal(err)
+	}
+	if info != 1 || rep.NGrad == 0 || rep.NHess == 0 {
+		t.Fatalf("info: %d, report: %+v", info, rep)
+	}
+
+	test := sectorSet(200, 2)
+	if e := logit.MnlRelClsError(lm, &test, len(test)); e > 0.1 {
+		t.Errorf("test set classification error: %v", e)
+	}
+	if e := logit.MnlAvgce(lm, &test, len(test)); e <= 0 || e > 1 {
+		t.Errorf("test set cross-entropy: %v", e)
+	}
+	rms := logit.MnlRmsError(lm, &test, len(test))
+	if avg := logit.MnlAvgError(lm, &test, len(test)); rms <= 0 || avg <= 0 || avg > rms {
+		t.Errorf("rms error: %v, average error: %v", rms, avg)
+	}
+	var y []float64
+	x := []float64{0.5, -0.5, 0.5}
+	logit.MnlProcessi(lm, &x, &y)
+	if len(y) != 3 || math.Abs(y[0]+y[1]+y[2]-1) > 1e-12 || y[0] < 0.5 {
+		t.Errorf("posterior probabilities: %v", y)
+	}
+}
+
+// TestMetricsMatchNetwork - metrics have the same meaning as the MLP ones: the network with
+// the coefficients of the model (no hidden layers, SOFTMAX outputs) gives the same errors
+func TestMetricsMatchNetwork(t *testing.T) {
+	a := [][]float64{{1, -2, 0.5}, {-1, 0.5, 0}}
+	lm := logit.NewLogitModel()
+	if err := logit.MnlPack(&a, 2, 3, lm); err != nil {
+		t.Fatal(err)
+	}
+	network := mlpbase.NewMlp()
+	if err := mlpbase.MlpCreatec0(2, 3, network); err != nil {
+		t.Fatal(err)
+	}
+	// the network subtracts the bias
+	copy(network.Weights, []float64{1, -2, -0.5, -1, 0.5, 0})
+	rnd := rand.New(rand.NewSource(3))
+	xy := make([][]float64, 100)
+	for i := range xy {
+		xy[i] = []float64{rnd.Float64()*4 - 2, rnd.Float64()*4 - 2, float64(rnd.Intn(3))}
+	}
+	var expected, actual []float64
+	x := xy[0][:2]
+	mlpbase.MlpProcessi(network, &x, &expected)
+	logit.MnlProcessi(lm, &x, &actual)
+	for j := range expected {
+		if math.Abs(expected[j]-actual[j]) > 1e-12 {
+			t.Fatalf("network: %v, model: %v", expected, actual)
+		}
+	}
+	avgce, err := mlpbase.MlpAvgce(network, &xy, len(xy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := logit.MnlAvgce(lm, &xy, len(xy)); math.Abs(e-avgce) > 1e-9 {
+		t.Errorf("cross-entropy: %v, network: %v", e, avgce)
+	}
+	relcls, err := mlpbase.MlpRelClsError(network, &xy, len(xy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := logit.MnlRelClsError(lm, &xy, len(xy)); e != relcls {
+		t.Errorf("classification error: %v, network: %v", e, relcls)
+	}
+	rms, err := mlpbase.MlpRmsError(network, &xy, len(xy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := logit.MnlRmsError(lm, &xy, len(xy)); math.Abs(e-rms) > 1e-9 {
+		t.Errorf("rms error: %v, network: %v", e, rms)
+	}
+}
+
+func TestDegenerate(t *testing.T) {
+	xy := [][]float64{{0, 1}, {1, 1}, {2, 1}, {3, 1}}
+	lm := logit.NewLogitModel()
+	info := 0
+	if err := logit.MnlTrainH(&xy, len(xy), 1, 3, &info, lm, logit.NewMnlReport()); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	if e := logit.MnlRelClsError(lm, &xy, len(xy)); e != 0 {
+		t.Errorf("classification error: %v", e)
+	}
+
+	if err := logit.MnlTrainH(&xy, len(xy), 1, 1, &info, lm, logit.NewMnlReport()); err != nil || info != -1 {
+		t.Errorf("single class, info: %d, error: %v", info, err)
+	}
+	if err := logit.MnlTrainH(&xy, len(xy), 1, 2, &info, lm, logit.NewMnlReport()); err != nil || info != 1 {
+		t.Errorf("info: %d, error: %v", info, err)
+	}
+	xy[2][1] = 2
+	if err := logit.MnlTrainH(&xy, len(xy), 1, 2, &info, lm, logit.NewMnlReport()); err != nil || info != -2 {
+		t.Errorf("class out of range, info: %d, error: %v", info, err)
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	xy := sectorSet(100, 4)
+	lm := logit.NewLogitModel()
+	info := 0
+	if err := logit.MnlTrainH(&xy, len(xy), 3, 3, &info, lm, logit.NewMnlReport()); err != nil {
+		t.Fatal(err)
+	}
+	var ra []float64
+	rlen := 0
+	logit.MnlSerialize(lm, &ra, &rlen)
+	restored := logit.NewLogitModel()
+	if err := logit.MnlUnserialize(ra, restored); err != nil {
+		t.Fatal(err)
+	}
+	copied := logit.NewLogitModel()
+	logit.MnlCopy(lm, copied)
+	var a [][]float64
+	nvars, nclasses := 0, 0
+	logit.MnlUnpack(lm, &a, &nvars, &nclasses)
+	packed := logit.NewLogitModel()
+	if err := logit.MnlPack(&a, nvars, nclasses, packed); err != nil {
+		t.Fatal(err)
+	}
+	var expected, actual, other, unpacked []float64
+	for _, row := range xy {
+		x := row[:3]
+		logit.MnlProcessi(lm, &x, &expected)
+		logit.MnlProcessi(restored, &x, &actual)
+		logit.MnlProcessi(copied, &x, &other)
+		logit.MnlProcessi(packed, &x, &unpacked)
+		for j := range expected {
+			if expected[j] != actual[j] || expected[j] != other[j] || expected[j] != unpacked[j] {
+				t.Fatalf("outputs differ: %v, restored %v, copied %v, packed %v", expected, actual, other, unpacked)
+			}
+		}
+	}
+
+	if err := logit.MnlUnserialize(ra[:len(ra)-1], restored); err == nil {
+		t.Error("truncated array accepted")
+	}
+	damaged := append([]float64{}, ra...)
+	damaged[6] = math.NaN()
+	if err := logit.MnlUnserialize(damaged, restored); err == nil {
+		t.Error("damaged array accepted")
+	}
+}
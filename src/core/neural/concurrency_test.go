@@ -0,0 +1,112 @@
+package neural_test
+
+import (
+	"sync"
+	"testing"
+
+	"pr.optima/src/core/neural"
+)
+
+const (
+	concurrencyGoroutines = 8
+	concurrencyRepeats    = 50
+)
+
+// runConcurrently calls process for every row of xy by several goroutines at once
+// and compares outputs with the ones calculated before by the single goroutine
+func runConcurrently(t *testing.T, xy [][]float64, nin int, process func(x *[]float64) (*[]float64, error)) {
+	expected := make([][]float64, len(xy))
+	for i, row := range xy {
+		x := append([]float64(nil), row[:nin]...)
+		y, err := process(&x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected[i] = *y
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, concurrencyGoroutines)
+	for g := 0; g < concurrencyGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for r := 0; r < concurrencyRepeats; r++ {
+				i := (g + r) % len(xy)
+				x := append([]float64(nil), xy[i][:nin]...)
+				y, err := process(&x)
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+				for j := range expected[i] {
+					if (*y)[j] != expected[i][j] {
+						errs <- "concurrent processing result differs from the sequential one"
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestMlpProcessConcurrent(t *testing.T) {
+	xy := regressionSet(40)
+	mlp, err := neural.MlpCreate1(2, 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := neural.MlpTrainLbfgs(mlp, &xy, len(xy), 0.001, 2, 0.01, 50); err != nil {
+		t.Fatal(err)
+	}
+	runConcurrently(t, xy, 2, func(x *[]float64) (*[]float64, error) {
+		return neural.MlpProcess(mlp, x)
+	})
+}
+
+func TestMlpErrorConcurrent(t *testing.T) {
+	xy := classifierSet(300)
+	mlp, err := neural.MlpCreateC1(2, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := neural.MlpRelClsError(mlp, &xy, len(xy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]float64, concurrencyGoroutines)
+	for g := range results {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			// mixed read-only calls on the one network
+			neural.MlpProcess(mlp, &[]float64{1, 0})
+			neural.MlpAvgRelError(mlp, &xy, len(xy))
+			results[g], _ = neural.MlpRelClsError(mlp, &xy, len(xy))
+		}(g)
+	}
+	wg.Wait()
+	for g, item := range results {
+		if item != expected {
+			t.Errorf("goroutine %d: classification error %v, expected %v", g, item, expected)
+		}
+	}
+}
+
+func TestMlpeProcessConcurrent(t *testing.T) {
+	xy := classifierSet(40)
+	ensemble, err := neural.MlpeCreateC1(2, 4, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runConcurrently(t, xy, 2, func(x *[]float64) (*[]float64, error) {
+		return neural.MlpeProcess(ensemble, x)
+	})
+}
@@ -0,0 +1,495 @@
+package neural
+
+import (
+	"pr.optima/src/core/neural/mlpe"
+)
+
+/*
+************************************************************************
+Neural networks ensemble. Output of the ensemble is the mean output of the
+networks with the same geometry.
+************************************************************************
+*/
+type MlpEnsemble struct {
+	innerobj *mlpe.Mlpensemble
+}
+
+func NewMlpEnsemble() *MlpEnsemble {
+	return &MlpEnsemble{
+		innerobj: mlpe.NewMlpe()}
+}
+
+/*
+************************************************************************
+Like MLPCreate0, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreate0(nin, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreate0", nin, nout, false); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreate0", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreate0(nin, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreate0", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Like MLPCreate1, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreate1(nin, nhid, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreate1", nin, nout, false, nhid); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreate1", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreate1(nin, nhid, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreate1", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Like MLPCreate2, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreate2(nin, nhid1, nhid2, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreate2", nin, nout, false, nhid1, nhid2); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreate2", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreate2(nin, nhid1, nhid2, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreate2", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Like MLPCreateC0, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreateC0(nin, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreateC0", nin, nout, true); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreateC0", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreateC0(nin, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreateC0", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Like MLPCreateC1, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreateC1(nin, nhid, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreateC1", nin, nout, true, nhid); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreateC1", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreateC1(nin, nhid, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreateC1", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Like MLPCreateC2, but for ensembles.
+
+	  -- ALGLIB --
+		 Copyright 18.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreateC2(nin, nhid1, nhid2, nout, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkGeometry("MlpeCreateC2", nin, nout, true, nhid1, nhid2); err != nil {
+		return nil, err
+	}
+	if err := checkEnsembleSize("MlpeCreateC2", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreateC2(nin, nhid1, nhid2, nout, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreateC2", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Creates ensemble from network. Only network geometry is copied.
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCreateFromNetwork(network *MultiLayerPerceptron, ensemblesize int) (*MlpEnsemble, error) {
+	if err := checkEnsembleSize("MlpeCreateFromNetwork", ensemblesize); err != nil {
+		return nil, err
+	}
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeCreateFromNetwork(network.innerobj, ensemblesize, ensemble.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpeCreateFromNetwork", Msg: err.Error()}
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Copying of MLPEnsemble strucure
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeCopy(ensemble *MlpEnsemble) *MlpEnsemble {
+	result := NewMlpEnsemble()
+	mlpe.MlpeCopy(ensemble.innerobj, result.innerobj)
+	return result
+}
+
+/*
+************************************************************************
+Serialization of MLPEnsemble strucure to the array of real numbers
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeSerialize(ensemble *MlpEnsemble) []float64 {
+	ra := make([]float64, 0)
+	rlen := 0
+	mlpe.MlpeSerialize(ensemble.innerobj, &ra, &rlen)
+	return ra
+}
+
+/*
+************************************************************************
+Unserialization of MLPEnsemble strucure
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeUnserialize(ra []float64) (*MlpEnsemble, error) {
+	ensemble := NewMlpEnsemble()
+	if err := mlpe.MlpeUnserialize(ra, ensemble.innerobj); err != nil {
+		return nil, err
+	}
+	return ensemble, nil
+}
+
+/*
+************************************************************************
+Randomization of MLP ensemble
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeRandomize(ensemble *MlpEnsemble) {
+	mlpe.MlpeRandomize(ensemble.innerobj)
+}
+
+/*
+************************************************************************
+Return ensemble properties (number of inputs and outputs).
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeProperties(ensemble *MlpEnsemble) (nin, nout int) {
+	mlpe.MlpeProperties(ensemble.innerobj, &nin, &nout)
+	return
+}
+
+/*
+************************************************************************
+Return normalization type (whether ensemble is SOFTMAX-normalized or not).
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeIsSoftMax(ensemble *MlpEnsemble) bool {
+	return mlpe.MlpeIsSoftMax(ensemble.innerobj)
+}
+
+/*
+************************************************************************
+Return ensemble size (number of the networks).
+************************************************************************
+*/
+func MlpeSize(ensemble *MlpEnsemble) int {
+	return mlpe.MlpeSize(ensemble.innerobj)
+}
+
+/*
+************************************************************************
+Procesing
+
+INPUT PARAMETERS:
+
+	Ensemble-   neural networks ensemble
+	X       -   input vector,  array[0..NIn-1].
+
+RESULT:
+
+	Regression estimate when solving regression  task, vector of posterior
+	probabilities for classification task.
+
+Ensemble is only read, so it can be processed by the several goroutines at
+once as long as nobody trains it.
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeProcess(ensemble *MlpEnsemble, x *[]float64) (*[]float64, error) {
+	nin, _ := MlpeProperties(ensemble)
+	if err := checkVector("MlpeProcess", x, nin); err != nil {
+		return nil, err
+	}
+	y := make([]float64, 0)
+	if err := mlpe.MlpeProcess(ensemble.innerobj, x, &y); err != nil {
+		return nil, err
+	}
+	return &y, nil
+}
+
+/*
+************************************************************************
+Relative classification error on the test set
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeRelClsError(ensemble *MlpEnsemble, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkEnsembleDataset("MlpeRelClsError", ensemble, xy, npoints, 1); err != nil {
+		return 0, err
+	}
+	return mlpe.MlpeRelclsError(ensemble.innerobj, xy, npoints)
+}
+
+/*
+************************************************************************
+Average cross-entropy (in bits per element) on the test set
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeAvgce(ensemble *MlpEnsemble, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkEnsembleDataset("MlpeAvgce", ensemble, xy, npoints, 1); err != nil {
+		return 0, err
+	}
+	return mlpe.MlpeAvgce(ensemble.innerobj, xy, npoints)
+}
+
+/*
+************************************************************************
+RMS error on the test set
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeRmsError(ensemble *MlpEnsemble, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkEnsembleDataset("MlpeRmsError", ensemble, xy, npoints, 1); err != nil {
+		return 0, err
+	}
+	return mlpe.MlpeRmsError(ensemble.innerobj, xy, npoints)
+}
+
+/*
+************************************************************************
+Average error on the test set
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeAvgError(ensemble *MlpEnsemble, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkEnsembleDataset("MlpeAvgError", ensemble, xy, npoints, 1); err != nil {
+		return 0, err
+	}
+	return mlpe.MlpeAvgError(ensemble.innerobj, xy, npoints)
+}
+
+/*
+************************************************************************
+Average relative error on the test set
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeAvgRelError(ensemble *MlpEnsemble, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkEnsembleDataset("MlpeAvgRelError", ensemble, xy, npoints, 1); err != nil {
+		return 0, err
+	}
+	return mlpe.MlpeAvgrelError(ensemble.innerobj, xy, npoints)
+}
+
+/*
+************************************************************************
+Training neural networks ensemble using  bootstrap  aggregating (bagging).
+Modified Levenberg-Marquardt algorithm is used as base training method.
+
+OUTPUT PARAMETERS:
+
+		Rep         -   training report, termination reason is
+						TerminationConverged if task has been solved.
+		OOBErrors   -   out-of-bag generalization error estimate
+		Err         -   same as in MLPTrainLM.
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeBaggingLm(ensemble *MlpEnsemble, xy *[][]float64, npoints int, decay float64, restarts int) (*MlpReport, *MlpCvReport, error) {
+	if err := checkEnsembleDataset("MlpeBaggingLm", ensemble, xy, npoints, 1); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTrainParams("MlpeBaggingLm", decay, restarts); err != nil {
+		return nil, nil, err
+	}
+	info := 0
+	rep := NewMlpReport()
+	ooberrors := NewMlpCvReport()
+	if err := mlpe.MlpeBaggingLm(ensemble.innerobj, xy, npoints, decay, restarts, &info, rep.innerObj, ooberrors.innerObj); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rep.terminated("MlpeBaggingLm", info); err != nil {
+		return nil, nil, err
+	}
+	return rep, ooberrors, nil
+}
+
+/*
+************************************************************************
+Training neural networks ensemble using  bootstrap  aggregating (bagging).
+L-BFGS algorithm is used as base training method.
+
+OUTPUT PARAMETERS:
+
+		Rep         -   training report, termination reason is
+						TerminationConverged if task has been solved.
+		OOBErrors   -   out-of-bag generalization error estimate
+		Err         -   same as in MLPTrainLBFGS.
+
+	  -- ALGLIB --
+		 Copyright 17.02.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeBaggingLbfgs(ensemble *MlpEnsemble, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int) (*MlpReport, *MlpCvReport, error) {
+	if err := checkEnsembleDataset("MlpeBaggingLbfgs", ensemble, xy, npoints, 1); err != nil {
+		return nil, nil, err
+	}
+	if err := checkTrainParams("MlpeBaggingLbfgs", decay, restarts); err != nil {
+		return nil, nil, err
+	}
+	if err := checkLbfgsParams("MlpeBaggingLbfgs", wstep, maxits); err != nil {
+		return nil, nil, err
+	}
+	info := 0
+	rep := NewMlpReport()
+	ooberrors := NewMlpCvReport()
+	if err := mlpe.MlpeBaggingLbfgs(ensemble.innerobj, xy, npoints, decay, restarts, wstep, maxits, &info, rep.innerObj, ooberrors.innerObj); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rep.terminated("MlpeBaggingLbfgs", info); err != nil {
+		return nil, nil, err
+	}
+	return rep, ooberrors, nil
+}
+
+/*
+************************************************************************
+Training neural networks ensemble using early stopping, each network is
+trained on the random split of the set.
+
+OUTPUT PARAMETERS:
+
+		Rep         -   training report, termination reason is
+						TerminationEarlyStopping if task has been solved.
+		Err         -   same as in MLPTrainES, NPoints must be at least 2.
+
+	  -- ALGLIB --
+		 Copyright 10.03.2009 by Bochkanov Sergey
+
+************************************************************************
+*/
+func MlpeTrainEs(ensemble *MlpEnsemble, xy *[][]float64, npoints int, decay float64, restarts int) (*MlpReport, error) {
+	if err := checkEnsembleDataset("MlpeTrainEs", ensemble, xy, npoints, 2); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpeTrainEs", decay, restarts); err != nil {
+		return nil, err
+	}
+	info := 0
+	rep := NewMlpReport()
+	if err := mlpe.MlpeTraines(ensemble.innerobj, xy, npoints, decay, restarts, &info, rep.innerObj); err != nil {
+		return nil, err
+	}
+	return rep.terminated("MlpeTrainEs", info)
+}
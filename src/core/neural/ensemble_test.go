@@ -0,0 +1,143 @@
+package neural_test
+
+import (
+	"math"
+	"testing"
+
+	"pr.optima/src/core/neural"
+)
+
+// linear regression set y = (x0 + x1) / 2
+func regressionSet(npoints int) [][]float64 {
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		x0 := float64(i%7) / 7
+		x1 := float64(i%5) / 5
+		xy[i] = []float64{x0, x1, (x0 + x1) / 2}
+	}
+	return xy
+}
+
+// two classes split by the sign of x0 - x1
+func classifierSet(npoints int) [][]float64 {
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		x0 := float64(i%7) - 3
+		x1 := float64(i%5) - 2
+		class := 0.0
+		if x0 > x1 {
+			class = 1
+		}
+		xy[i] = []float64{x0, x1, class}
+	}
+	return xy
+}
+
+func TestEnsembleBagging(t *testing.T) {
+	xy := regressionSet(40)
+	ensemble, err := neural.MlpeCreate1(2, 3, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, oob, err := neural.MlpeBaggingLbfgs(ensemble, &xy, len(xy), 0.001, 2, 0.01, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.GetTerminationReason() != neural.TerminationConverged {
+		t.Fatalf("termination reason %v", rep.GetTerminationReason())
+	}
+	if rep.GetNGrad() == 0 {
+		t.Error("gradients not calculated")
+	}
+	if math.IsNaN(oob.GetRmsError()) {
+		t.Error("out-of-bag rms error is NaN")
+	}
+	rms, err := neural.MlpeRmsError(ensemble, &xy, len(xy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rms > 0.1 {
+		t.Errorf("rms error %v too large", rms)
+	}
+
+	if _, _, err = neural.MlpeBaggingLm(ensemble, &xy, len(xy), 0.001, 1); err != nil {
+		t.Fatalf("MlpeBaggingLm error %v", err)
+	}
+
+	// zero stopping criteria are rejected
+	if _, _, err = neural.MlpeBaggingLbfgs(ensemble, &xy, len(xy), 0.001, 2, 0, 0); err == nil {
+		t.Error("zero stopping criteria accepted")
+	} else if _, ok := err.(*neural.ArgumentError); !ok {
+		t.Errorf("error %v, expected ArgumentError", err)
+	}
+}
+
+func TestEnsembleEarlyStopping(t *testing.T) {
+	xy := classifierSet(60)
+	ensemble, err := neural.MlpeCreateC1(2, 3, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := neural.MlpeTrainEs(ensemble, &xy, len(xy), 0.001, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := rep.GetTerminationReason(); reason != neural.TerminationEarlyStopping && reason != neural.TerminationConverged {
+		t.Fatalf("termination reason %v", reason)
+	}
+	x := []float64{3, -2}
+	y, err := neural.MlpeProcess(ensemble, &x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*y) != 2 || math.Abs((*y)[0]+(*y)[1]-1) > 1e-9 {
+		t.Errorf("probabilities %v", *y)
+	}
+
+	// class numbers outside of the range
+	xy[0][2] = 5
+	if _, err = neural.MlpeTrainEs(ensemble, &xy, len(xy), 0.001, 2); err == nil {
+		t.Error("class number outside of the range accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+}
+
+func TestEnsembleSerialization(t *testing.T) {
+	if _, err := neural.MlpeCreate0(2, 1, 0); err == nil {
+		t.Error("zero ensemble size accepted")
+	}
+
+	xy := regressionSet(20)
+	ensemble, err := neural.MlpeCreate1(2, 2, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := neural.MlpeBaggingLbfgs(ensemble, &xy, len(xy), 0.001, 1, 0.01, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	ra := neural.MlpeSerialize(ensemble)
+	restored, err := neural.MlpeUnserialize(ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := neural.MlpeCopy(ensemble)
+	if nin, nout := neural.MlpeProperties(restored); nin != 2 || nout != 1 || neural.MlpeSize(restored) != 3 || neural.MlpeIsSoftMax(restored) {
+		t.Errorf("restored properties nin: %d, nout: %d, size: %d", nin, nout, neural.MlpeSize(restored))
+	}
+	for _, row := range xy {
+		x := row[:2]
+		expected, _ := neural.MlpeProcess(ensemble, &x)
+		actual, _ := neural.MlpeProcess(restored, &x)
+		copiedY, _ := neural.MlpeProcess(copied, &x)
+		if (*expected)[0] != (*actual)[0] || (*expected)[0] != (*copiedY)[0] {
+			t.Fatalf("outputs differ: %v, restored %v, copied %v", *expected, *actual, *copiedY)
+		}
+	}
+
+	ra[1] = 0
+	if _, err := neural.MlpeUnserialize(ra); err == nil {
+		t.Error("wrong version accepted")
+	}
+}
@@ -0,0 +1,247 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+)
+
+// ShapeError - size of the data doesn't match the network: short vector, missing columns,
+// not enough rows, class number or index out of range
+type ShapeError struct {
+	Op  string // name of the failed function
+	Msg string
+}
+
+func (e *ShapeError) Error() string {
+	return fmt.Sprintf("neural: %s: invalid shape: %s", e.Op, e.Msg)
+}
+
+// NonFiniteError - data contains NaN or infinite value
+type NonFiniteError struct {
+	Op  string
+	Row int // row of the dataset, -1 for the vector
+	Col int
+}
+
+func (e *NonFiniteError) Error() string {
+	if e.Row < 0 {
+		return fmt.Sprintf("neural: %s: non-finite value at [%d]", e.Op, e.Col)
+	}
+	return fmt.Sprintf("neural: %s: non-finite value at [%d][%d]", e.Op, e.Row, e.Col)
+}
+
+// ArgumentError - invalid value of the parameter, e.g. negative decay or zero restarts count
+type ArgumentError struct {
+	Op  string
+	Msg string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("neural: %s: invalid argument: %s", e.Op, e.Msg)
+}
+
+// ConvergenceError - training has not converged to the solution
+type ConvergenceError struct {
+	Op   string
+	Info int // ALGLIB return code
+	Msg  string
+}
+
+func (e *ConvergenceError) Error() string {
+	return fmt.Sprintf("neural: %s: did not converge: %s (info %d)", e.Op, e.Msg, e.Info)
+}
+
+// TerminationReason - why the training has been finished, values match ALGLIB success return codes
+type TerminationReason int
+
+const (
+	// TerminationNone - training has not been finished
+	TerminationNone TerminationReason = 0
+	// TerminationCompleted - all the folds of the cross-validation are processed
+	TerminationCompleted TerminationReason = 1
+	// TerminationConverged - step became smaller than the threshold or iterations limit is reached
+	TerminationConverged TerminationReason = 2
+	// TerminationEarlyStopping - validation set error started to increase
+	TerminationEarlyStopping TerminationReason = 6
+	// TerminationStopped - training is cancelled or stopped by the progress callback
+	TerminationStopped TerminationReason = 8
+)
+
+func (r TerminationReason) String() string {
+	switch r {
+	case TerminationNone:
+		return "none"
+	case TerminationCompleted:
+		return "completed"
+	case TerminationConverged:
+		return "converged"
+	case TerminationEarlyStopping:
+		return "early stopping"
+	case TerminationStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("TerminationReason(%d)", int(r))
+}
+
+// infoResult convert ALGLIB return code of the training to the termination reason or error
+func infoResult(op string, info int) (TerminationReason, error) {
+	switch info {
+	case 1, 2, 6, 8:
+		return TerminationReason(info), nil
+	case -1:
+		return TerminationNone, &ArgumentError{Op: op, Msg: "wrong parameters"}
+	case -2:
+		return TerminationNone, &ShapeError{Op: op, Msg: "class number outside of [0..NOut-1]"}
+	case -8:
+		return TerminationNone, &ArgumentError{Op: op, Msg: "both WStep and MaxIts are zero"}
+	case -9:
+		return TerminationNone, &ConvergenceError{Op: op, Info: info, Msg: "internal matrix inverse failed"}
+	}
+	return TerminationNone, &ConvergenceError{Op: op, Info: info, Msg: "unexpected return code"}
+}
+
+// checkGeometry check sizes of the layers, classifier requires at least two outputs
+func checkGeometry(op string, nin, nout int, softmax bool, hidden ...int) error {
+	if nin < 1 {
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("inputs count %d, must be positive", nin)}
+	}
+	if nout < 1 || (softmax && nout < 2) {
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("outputs count %d is too small", nout)}
+	}
+	for _, item := range hidden {
+		if item < 1 {
+			return &ShapeError{Op: op, Msg: fmt.Sprintf("hidden layer size %d, must be positive", item)}
+		}
+	}
+	return nil
+}
+
+// checkVector check that x contains at least n finite values
+func checkVector(op string, x *[]float64, n int) error {
+	if x == nil || len(*x) < n {
+		length := 0
+		if x != nil {
+			length = len(*x)
+		}
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("vector length %d, required %d", length, n)}
+	}
+	for i := 0; i < n; i++ {
+		if math.IsNaN((*x)[i]) || math.IsInf((*x)[i], 0) {
+			return &NonFiniteError{Op: op, Row: -1, Col: i}
+		}
+	}
+	return nil
+}
+
+// checkDataset check first npoints rows of xy: at least minpoints rows of finite values,
+// nin inputs followed by nout outputs or by the class number in [0..nout-1] for classifier
+func checkDataset(op string, xy *[][]float64, npoints, minpoints, nin, nout int, softmax bool) error {
+	if npoints < minpoints {
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("%d points, required at least %d", npoints, minpoints)}
+	}
+	if npoints == 0 {
+		return nil
+	}
+	if xy == nil || len(*xy) < npoints {
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("dataset has less than %d rows", npoints)}
+	}
+	ncols := nin + nout
+	if softmax {
+		ncols = nin + 1
+	}
+	for i := 0; i < npoints; i++ {
+		row := (*xy)[i]
+		if len(row) < ncols {
+			return &ShapeError{Op: op, Msg: fmt.Sprintf("row %d has %d columns, required %d", i, len(row), ncols)}
+		}
+		for j := 0; j < ncols; j++ {
+			if math.IsNaN(row[j]) || math.IsInf(row[j], 0) {
+				return &NonFiniteError{Op: op, Row: i, Col: j}
+			}
+		}
+		if softmax {
+			if class := int(math.Floor(row[nin] + .5)); class < 0 || class >= nout {
+				return &ShapeError{Op: op, Msg: fmt.Sprintf("row %d: class number %d outside of [0..%d]", i, class, nout-1)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkTrainParams check common hyperparameters of the training algorithms
+func checkTrainParams(op string, decay float64, restarts int) error {
+	if math.IsNaN(decay) || math.IsInf(decay, 0) || decay < 0 {
+		return &ArgumentError{Op: op, Msg: fmt.Sprintf("decay %v, must be non-negative", decay)}
+	}
+	if restarts < 1 {
+		return &ArgumentError{Op: op, Msg: fmt.Sprintf("restarts %d, must be positive", restarts)}
+	}
+	return nil
+}
+
+// checkLbfgsParams check L-BFGS stopping criteria
+func checkLbfgsParams(op string, wstep float64, maxits int) error {
+	if math.IsNaN(wstep) || math.IsInf(wstep, 0) || wstep < 0 || maxits < 0 {
+		return &ArgumentError{Op: op, Msg: fmt.Sprintf("WStep %v and MaxIts %d must be non-negative", wstep, maxits)}
+	}
+	if wstep == 0 && maxits == 0 {
+		return &ArgumentError{Op: op, Msg: "both WStep and MaxIts are zero"}
+	}
+	return nil
+}
+
+// checkIndex check that index of the input or output is in [0..n-1]
+func checkIndex(op, what string, i, n int) error {
+	if i < 0 || i >= n {
+		return &ShapeError{Op: op, Msg: fmt.Sprintf("%s index %d outside of [0..%d]", what, i, n-1)}
+	}
+	return nil
+}
+
+// checkLayer check that the network has layer k
+func checkLayer(op string, network *MultiLayerPerceptron, k int) error {
+	return checkIndex(op, "layer", k, MlpGetLayersCount(network))
+}
+
+// checkNeuron check that the network has neuron i in the layer k
+func checkNeuron(op string, network *MultiLayerPerceptron, k, i int) error {
+	if err := checkLayer(op, network, k); err != nil {
+		return err
+	}
+	size, _ := MlpGetLayerSize(network, k)
+	return checkIndex(op, "neuron", i, size)
+}
+
+// checkNetworkDataset check dataset against the inputs and outputs of the network
+func checkNetworkDataset(op string, network *MultiLayerPerceptron, xy *[][]float64, npoints, minpoints int) error {
+	nin, nout, _ := MlpProperties(network)
+	return checkDataset(op, xy, npoints, minpoints, nin, nout, MlpIsSoftMax(network))
+}
+
+// checkKfold check dataset and parameters of the cross-validation, every fold must contain a point
+func checkKfold(op string, network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int) error {
+	if err := checkNetworkDataset(op, network, xy, npoints, 2); err != nil {
+		return err
+	}
+	if err := checkTrainParams(op, decay, restarts); err != nil {
+		return err
+	}
+	if foldscount < 2 || foldscount > npoints {
+		return &ArgumentError{Op: op, Msg: fmt.Sprintf("folds count %d outside of [2..%d]", foldscount, npoints)}
+	}
+	return nil
+}
+
+// checkEnsembleSize check count of the networks in the ensemble
+func checkEnsembleSize(op string, ensemblesize int) error {
+	if ensemblesize < 1 {
+		return &ArgumentError{Op: op, Msg: fmt.Sprintf("ensemble size %d, must be positive", ensemblesize)}
+	}
+	return nil
+}
+
+// checkEnsembleDataset check dataset against the inputs and outputs of the ensemble
+func checkEnsembleDataset(op string, ensemble *MlpEnsemble, xy *[][]float64, npoints, minpoints int) error {
+	nin, nout := MlpeProperties(ensemble)
+	return checkDataset(op, xy, npoints, minpoints, nin, nout, MlpeIsSoftMax(ensemble))
+}
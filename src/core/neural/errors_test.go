@@ -0,0 +1,103 @@
+package neural_test
+
+import (
+	"math"
+	"testing"
+
+	"pr.optima/src/core/neural"
+)
+
+func TestShapeError(t *testing.T) {
+	if _, err := neural.MlpCreateC1(2, 3, 1); err == nil {
+		t.Error("classifier with one output accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := neural.MlpProcess(mlp, &[]float64{1}); err == nil {
+		t.Error("short input accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+	if _, err := neural.MlpGetLayerSize(mlp, 3); err == nil {
+		t.Error("nonexistent layer accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+
+	xy := [][]float64{{1, 2, 3}, {1, 2}}
+	if _, err := neural.MlpRmsError(mlp, &xy, len(xy)); err == nil {
+		t.Error("short row accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+	if _, err := neural.MlpUnserialize(neural.MlpSerialize(mlp)[1:]); err == nil {
+		t.Error("truncated array accepted")
+	} else if _, ok := err.(*neural.ShapeError); !ok {
+		t.Errorf("error %v, expected ShapeError", err)
+	}
+}
+
+func TestNonFiniteError(t *testing.T) {
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := neural.MlpProcess(mlp, &[]float64{1, math.NaN()}); err == nil {
+		t.Error("NaN input accepted")
+	} else if e, ok := err.(*neural.NonFiniteError); !ok || e.Row != -1 || e.Col != 1 {
+		t.Errorf("error %v, expected NonFiniteError at [1]", err)
+	}
+
+	xy := regressionSet(20)
+	xy[5][2] = math.Inf(1)
+	if _, err := neural.MlpTrainLm(mlp, &xy, len(xy), 0.001, 1); err == nil {
+		t.Error("infinite output accepted")
+	} else if e, ok := err.(*neural.NonFiniteError); !ok || e.Row != 5 || e.Col != 2 {
+		t.Errorf("error %v, expected NonFiniteError at [5][2]", err)
+	}
+}
+
+func TestArgumentError(t *testing.T) {
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xy := regressionSet(20)
+	if _, err := neural.MlpTrainLbfgs(mlp, &xy, len(xy), 0.001, 0, 0.01, 0); err == nil {
+		t.Error("zero restarts accepted")
+	} else if _, ok := err.(*neural.ArgumentError); !ok {
+		t.Errorf("error %v, expected ArgumentError", err)
+	}
+	if _, _, err := neural.MlpKfoldCvLm(mlp, &xy, len(xy), 0.001, 1, len(xy)+1); err == nil {
+		t.Error("folds count larger than points count accepted")
+	} else if _, ok := err.(*neural.ArgumentError); !ok {
+		t.Errorf("error %v, expected ArgumentError", err)
+	}
+}
+
+func TestTerminationReason(t *testing.T) {
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xy := regressionSet(20)
+	rep, err := neural.MlpTrainLm(mlp, &xy, len(xy), 0.001, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.GetTerminationReason() != neural.TerminationConverged {
+		t.Errorf("termination reason %v, expected %v", rep.GetTerminationReason(), neural.TerminationConverged)
+	}
+	rep, _, err = neural.MlpKfoldCvLbfgs(mlp, &xy, len(xy), 0.001, 1, 0.01, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.GetTerminationReason() != neural.TerminationCompleted {
+		t.Errorf("termination reason %v, expected %v", rep.GetTerminationReason(), neural.TerminationCompleted)
+	}
+}
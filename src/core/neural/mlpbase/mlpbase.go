@@ -33,7 +33,6 @@ type Multilayerperceptron struct {
 	DError        []float64
 	X             []float64
 	Y             []float64
-	Chunks        [][]float64
 	NwBuf         []float64
 	IntegerBuf    []int
 }
@@ -52,7 +51,6 @@ func NewMlp() *Multilayerperceptron {
 		DError: []float64{},
 		X: []float64{},
 		Y: []float64{},
-		Chunks: [][]float64{},
 		NwBuf: []float64{},
 		IntegerBuf: []int{}}
 }
@@ -667,7 +665,6 @@ func MlpCopy(network1 *Multilayerperceptron, network2 *Multilayerperceptron) {
 	network2.DError = utils.CloneArrayFloat64(network1.DError)
 	network2.X = utils.CloneArrayFloat64(network1.X)
 	network2.Y = utils.CloneArrayFloat64(network1.Y)
-	network2.Chunks = utils.CloneMatrixFloat64(network1.Chunks)
 	network2.NwBuf = utils.CloneArrayFloat64(network1.NwBuf)
 	network2.IntegerBuf = utils.CloneArrayInt(network1.IntegerBuf)
 }
@@ -796,7 +793,6 @@ func MlpUnserializeOld(ra []float64, network *Multilayerperceptron) error {
 	network.ColumnMeans = make([]float64, sigmalen - 1 + 1)
 	network.ColumnSigmas = make([]float64, sigmalen - 1 + 1)
 	network.Neurons = make([]float64, ntotal - 1 + 1)
-	network.Chunks = utils.MakeMatrixFloat64(3 * ntotal + 1, chunksize - 1 + 1)
 	network.NwBuf = make([]float64, utils.MaxInt(wcount, 2 * nout) - 1 + 1)
 	network.DfdNet = make([]float64, ntotal - 1 + 1)
 	network.X = make([]float64, nin - 1 + 1)
@@ -1528,6 +1524,28 @@ func MlpProcess(network *Multilayerperceptron, x, y *[]float64) {
 	MlpInternalProcessVector(&network.StructInfo, &network.Weights, &network.ColumnMeans, &network.ColumnSigmas, &network.Neurons, &network.DfdNet, x, y)
 }
 
+/*************************************************************************
+Procesing, safe for concurrent use
+
+Same as MLPProcess, but internal buffers of the network are not used, all
+the intermediate values are placed to the workspace taken for the call.
+So network can be processed by the several goroutines at once, as long as
+nobody modifies it (training, randomization, setting of the weights).
+*************************************************************************/
+func MlpProcessConcurrent(network *Multilayerperceptron, x, y *[]float64) error {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	if len(*x) < nin {
+		return fmt.Errorf("MLPProcessConcurrent: length of X is less than NIn!")
+	}
+	if len(*y) < nout {
+		*y = make([]float64, nout)
+	}
+	buf := getmlpbuffer(network)
+	defer putmlpbuffer(buf)
+	return MlpInternalProcessVector(&network.StructInfo, &network.Weights, &network.ColumnMeans, &network.ColumnSigmas, &buf.neurons, &buf.dfdnet, x, y)
+}
+
 /*************************************************************************
 'interactive'  variant  of  MLPProcess  for  languages  like  Python which
 support constructs like "Y = MLPProcess(NN,X)" and interactive mode of the
@@ -1551,42 +1569,35 @@ Error function for neural network, internal subroutine.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpError(network *Multilayerperceptron, xy *[][]float64, ssize int) float64 {
-	nin := 0
-	nout := 0
-	wcount := 0
-	var e float64 = 0
+func MlpError(network *Multilayerperceptron, xy *[][]float64, ssize int) (float64, error) {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	softmax := MlpIsSoftMax(network)
 
-	MlpProperties(network, &nin, &nout, &wcount)
-	var result float64 = 0.0
-	for i := 0; i <= ssize - 1; i++ {
-		for j := 0; j <= nin - 1; j++ {
-			network.X[j] = (*xy)[i][j]
-		}
-		MlpProcess(network, &network.X, &network.Y);
-		if MlpIsSoftMax(network) {
+	result, err := mlperrorbatchinternal(network, *xy, ssize, func(buf *mlpbuffer, row []float64) float64 {
+		if softmax {
 			//
 			// class labels outputs
 			//
-			k := utils.RoundInt((*xy)[i][nin])
+			k := utils.RoundInt(row[nin])
 			if k >= 0 && k < nout {
-				network.Y[k] = network.Y[k] - 1
+				buf.y[k] = buf.y[k] - 1
 			}
 		}else {
 			//
 			// real outputs
 			//
 			for j := 0; j <= nout - 1; j++ {
-				network.Y[j] = network.Y[j] - (*xy)[i][ j + nin]
+				buf.y[j] = buf.y[j] - row[j + nin]
 			}
 		}
-		e = 0.0
+		e := 0.0
 		for j := 0; j <= nout - 1; j++ {
-			e += network.Y[j] * network.Y[j]
+			e += buf.y[j] * buf.y[j]
 		}
-		result = result + e / 2
-	}
-	return result
+		return e / 2
+	})
+	return result, err
 }
 
 /*************************************************************************
@@ -1595,49 +1606,33 @@ Natural error function for neural network, internal subroutine.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpErrorN(network *Multilayerperceptron, xy *[][]float64, ssize int) float64 {
-	k := 0
-	nin := 0
-	nout := 0
-	wcount := 0
-
-	MlpProperties(network, &nin, &nout, &wcount)
-	var result float64 = 0.0
-	for i := 0; i <= ssize - 1; i++ {
-		//
-		// Process vector
-		//
-		for j := 0; j <= nin - 1; j++ {
-			network.X[j] = (*xy)[i][j]
-		}
-		MlpProcess(network, &network.X, &network.Y)
+func MlpErrorN(network *Multilayerperceptron, xy *[][]float64, ssize int) (float64, error) {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	leastsquares := network.StructInfo[6] == 0
 
-		//
-		// Update error function
-		//
-		if network.StructInfo[6] == 0 {
+	result, err := mlperrorbatchinternal(network, *xy, ssize, func(buf *mlpbuffer, row []float64) float64 {
+		if leastsquares {
 			//
 			// Least squares error function
 			//
-			for j := 0; j <= nout - 1; j++ {
-				network.Y[j] = network.Y[j] - (*xy)[i][ j + nin]
-			}
 			e := 0.0
 			for j := 0; j <= nout - 1; j++ {
-				e += network.Y[j] * network.Y[j]
-			}
-			result = result + e / 2
-		}else {
-			//
-			// Cross-entropy error function
-			//
-			k = utils.RoundInt((*xy)[i][nin])
-			if k >= 0 && k < nout {
-				result = result + safecrossentropy(1, network.Y[k])
+				e += utils.SqrFloat64(buf.y[j] - row[j + nin])
 			}
+			return e / 2
 		}
-	}
-	return result
+
+		//
+		// Cross-entropy error function
+		//
+		k := utils.RoundInt(row[nin])
+		if k >= 0 && k < nout {
+			return safecrossentropy(1, buf.y[k])
+		}
+		return 0
+	})
+	return result, err
 }
 
 /*************************************************************************
@@ -1646,61 +1641,45 @@ Classification error
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpClsError(network *Multilayerperceptron, xy *[][]float64, ssize int) int {
-	nin := 0
-	nout := 0
-	wcount := 0
-	nn := 0
-	ns := 0
-	nmax := 0
-
-	MlpProperties(network, &nin, &nout, &wcount)
-	workx := make([]float64, nin - 1 + 1)
-	worky := make([]float64, nout - 1 + 1)
-	result := 0
-	for i := 0; i <= ssize - 1; i++ {
-		//
-		// Process
-		//
-		for j := 0; j <= nin - 1; j++ {
-			workx[j] = (*xy)[i][j]
-		}
-		MlpProcess(network, &workx, &worky)
+func MlpClsError(network *Multilayerperceptron, xy *[][]float64, ssize int) (int, error) {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	softmax := MlpIsSoftMax(network)
 
+	result, err := mlperrorbatchinternal(network, *xy, ssize, func(buf *mlpbuffer, row []float64) float64 {
 		//
 		// Network version of the answer
 		//
-		nmax = 0
+		nn := 0
 		for j := 0; j <= nout - 1; j++ {
-			if worky[j] > worky[nmax] {
-				nmax = j
+			if buf.y[j] > buf.y[nn] {
+				nn = j
 			}
 		}
-		nn = nmax
 
 		//
 		// Right answer
 		//
-		if MlpIsSoftMax(network) {
-			ns = utils.RoundInt((*xy)[i][nin])
+		ns := 0
+		if softmax {
+			ns = utils.RoundInt(row[nin])
 		}else {
-			nmax = 0
 			for j := 0; j <= nout - 1; j++ {
-				if (*xy)[i][nin + j] > (*xy)[i][nin + nmax] {
-					nmax = j
+				if row[nin + j] > row[nin + ns] {
+					ns = j
 				}
 			}
-			ns = nmax
 		}
 
 		//
 		// compare
 		//
 		if nn != ns {
-			result = result + 1
+			return 1
 		}
-	}
-	return result
+		return 0
+	})
+	return int(result), err
 }
 
 /*************************************************************************
@@ -1719,8 +1698,9 @@ RESULT:
   -- ALGLIB --
 	 Copyright 25.12.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpRelClsError(network *Multilayerperceptron, xy *[][]float64, npoints int) float64 {
-	return float64(MlpClsError(network, xy, npoints)) / float64(npoints)
+func MlpRelClsError(network *Multilayerperceptron, xy *[][]float64, npoints int) (float64, error) {
+	result, err := MlpClsError(network, xy, npoints)
+	return float64(result) / float64(npoints), err
 }
 
 /*************************************************************************
@@ -1738,7 +1718,7 @@ RESULT:
   -- ALGLIB --
 	 Copyright 08.01.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgce(network *Multilayerperceptron, xy *[][]float64, npoints int) float64 {
+func MlpAvgce(network *Multilayerperceptron, xy *[][]float64, npoints int) (float64, error) {
 	result := 0.0
 	nin := 0
 	nout := 0
@@ -1746,11 +1726,15 @@ func MlpAvgce(network *Multilayerperceptron, xy *[][]float64, npoints int) float
 
 	if MlpIsSoftMax(network) {
 		MlpProperties(network, &nin, &nout, &wcount)
-		result = MlpErrorN(network, xy, npoints) / (float64(npoints) * math.Log(2))
+		e, err := MlpErrorN(network, xy, npoints)
+		if err != nil {
+			return 0, err
+		}
+		result = e / (float64(npoints) * math.Log(2))
 	}else {
 		result = 0
 	}
-	return result;
+	return result, nil
 }
 
 /*************************************************************************
@@ -1770,13 +1754,17 @@ RESULT:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpRmsError(network *Multilayerperceptron, xy *[][]float64, npoints int) float64 {
+func MlpRmsError(network *Multilayerperceptron, xy *[][]float64, npoints int) (float64, error) {
 	nin := 0
 	nout := 0
 	wcount := 0
 
 	MlpProperties(network, &nin, &nout, &wcount)
-	return math.Sqrt(2 * MlpError(network, xy, npoints) / float64(npoints * nout))
+	e, err := MlpError(network, xy, npoints)
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(2 * e / float64(npoints * nout)), nil
 }
 
 /*************************************************************************
@@ -1795,28 +1783,23 @@ RESULT:
   -- ALGLIB --
 	 Copyright 11.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgError(network *Multilayerperceptron, xy *[][]float64, npoints int) float64 {
-	nin := 0
-	nout := 0
-	wcount := 0
+func MlpAvgError(network *Multilayerperceptron, xy *[][]float64, npoints int) (float64, error) {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	softmax := MlpIsSoftMax(network)
 
-	MlpProperties(network, &nin, &nout, &wcount)
-	result := 0.0
-	for i := 0; i <= npoints - 1; i++ {
-		for j := 0; j <= nin - 1; j++ {
-			network.X[j] = (*xy)[i][j]
-		}
-		MlpProcess(network, &network.X, &network.Y)
-		if MlpIsSoftMax(network) {
+	result, err := mlperrorbatchinternal(network, *xy, npoints, func(buf *mlpbuffer, row []float64) float64 {
+		e := 0.0
+		if softmax {
 			//
 			// class labels
 			//
-			k := utils.RoundInt((*xy)[i][nin])
+			k := utils.RoundInt(row[nin])
 			for j := 0; j <= nout - 1; j++ {
 				if j == k {
-					result = result + math.Abs(1 - network.Y[j])
+					e += math.Abs(1 - buf.y[j])
 				}else {
-					result = result + math.Abs(network.Y[j])
+					e += math.Abs(buf.y[j])
 				}
 			}
 		}else {
@@ -1824,12 +1807,12 @@ func MlpAvgError(network *Multilayerperceptron, xy *[][]float64, npoints int) fl
 			// real outputs
 			//
 			for j := 0; j <= nout - 1; j++ {
-				result = result + math.Abs((*xy)[i][nin + j] - network.Y[j])
+				e += math.Abs(row[nin + j] - buf.y[j])
 			}
 		}
-	}
-	result = result / float64(npoints * nout)
-	return result
+		return e
+	})
+	return result / float64(npoints * nout), err
 }
 
 /*************************************************************************
@@ -1848,46 +1831,56 @@ RESULT:
   -- ALGLIB --
 	 Copyright 11.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgRelError(network *Multilayerperceptron, xy *[][]float64, npoints int) float64 {
-	nin := 0
-	nout := 0
-	wcount := 0
+func MlpAvgRelError(network *Multilayerperceptron, xy *[][]float64, npoints int) (float64, error) {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	softmax := MlpIsSoftMax(network)
 
-	MlpProperties(network, &nin, &nout, &wcount)
-	result := 0.0
+	//
+	// count of the relative errors depends on the dataset only
+	//
 	k := 0
 	for i := 0; i <= npoints - 1; i++ {
-		for j := 0; j <= nin - 1; j++ {
-			network.X[j] = (*xy)[i][j]
-		}
-		MlpProcess(network, &network.X, &network.Y)
-		if MlpIsSoftMax(network) {
-			//
-			// class labels
-			//
+		if softmax {
 			lk := utils.RoundInt((*xy)[i][nin])
+			if lk >= 0 && lk < nout {
+				k = k + 1
+			}
+		}else {
 			for j := 0; j <= nout - 1; j++ {
-				if j == lk {
-					result = result + math.Abs(1 - network.Y[j])
+				if (*xy)[i][nin + j] != 0 {
 					k = k + 1
 				}
 			}
+		}
+	}
+
+	result, err := mlperrorbatchinternal(network, *xy, npoints, func(buf *mlpbuffer, row []float64) float64 {
+		e := 0.0
+		if softmax {
+			//
+			// class labels
+			//
+			lk := utils.RoundInt(row[nin])
+			if lk >= 0 && lk < nout {
+				e += math.Abs(1 - buf.y[lk])
+			}
 		}else {
 			//
 			// real outputs
 			//
 			for j := 0; j <= nout - 1; j++ {
-				if (*xy)[i][nin + j] != 0 {
-					result = result + math.Abs((*xy)[i][nin + j] - network.Y[j]) / math.Abs((*xy)[i][nin + j])
-					k += 1
+				if row[nin + j] != 0 {
+					e += math.Abs(row[nin + j] - buf.y[j]) / math.Abs(row[nin + j])
 				}
 			}
 		}
-	}
+		return e
+	})
 	if k != 0 {
 		result /= float64(k)
 	}
-	return result
+	return result, err
 }
 
 /*************************************************************************
@@ -2032,22 +2025,7 @@ OUTPUT PARAMETERS:
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
 func MlpGradBatch(network *Multilayerperceptron, xy [][]float64, ssize int, e *float64, grad *[]float64) error {
-	nin := 0
-	nout := 0
-	wcount := 0
-
-	MlpProperties(network, &nin, &nout, &wcount)
-	for i := 0; i <= wcount - 1; i++ {
-		(*grad)[i] = 0
-	}
-	*e = 0.0
-
-	for i := 0; i <= ssize - 1; i += chunksize {
-		if err := mlpchunkedgradient(network, xy, i, utils.MinInt(ssize, i + chunksize) - i, e, grad, false); err != nil {
-			return err
-		}
-	}
-	return nil
+	return mlpgradbatchinternal(network, xy, ssize, false, e, grad)
 }
 
 /*************************************************************************
@@ -2074,23 +2052,7 @@ OUTPUT PARAMETERS:
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
 func MlpGradNBatch(network *Multilayerperceptron, xy [][]float64, ssize int, e *float64, grad *[]float64) error {
-	nin := 0
-	nout := 0
-	wcount := 0
-
-	MlpProperties(network, &nin, &nout, &wcount)
-	for i := 0; i <= wcount - 1; i++ {
-		(*grad)[i] = 0
-	}
-	*e = 0
-	i := 0
-	for i <= ssize - 1 {
-		if err := mlpchunkedgradient(network, xy, i, utils.MinInt(ssize, i + chunksize) - i, e, grad, true); err != nil {
-			return err
-		}
-		i += chunksize
-	}
-	return nil
+	return mlpgradbatchinternal(network, xy, ssize, true, e, grad)
 }
 
 /*************************************************************************
@@ -2106,8 +2068,7 @@ Internal subroutine.
 	 Neural Computation, 1994.
 *************************************************************************/
 func MlpHessianNBatch(network *Multilayerperceptron, xy *[][]float64, ssize int, e *float64, grad *[]float64, h *[][]float64) error {
-	*e = 0
-	return mlphessianbatchinternal(network, xy, ssize, true, e, grad, h)
+	return mlphessianbatchparallel(network, xy, ssize, true, e, grad, h)
 }
 
 /*************************************************************************
@@ -2123,8 +2084,7 @@ Internal subroutine.
 	 Neural Computation, 1994.
 *************************************************************************/
 func MlpHessianBatch(network *Multilayerperceptron, xy *[][]float64, ssize int, e *float64, grad *[]float64, h *[][]float64) error {
-	*e = 0
-	return mlphessianbatchinternal(network, xy, ssize, false, e, grad, h)
+	return mlphessianbatchparallel(network, xy, ssize, false, e, grad, h)
 }
 
 /*************************************************************************
@@ -2392,7 +2352,6 @@ func mlpcreate(nin, nout int, lsizes, ltypes, lconnfirst, lconnlast *[]int, laye
 		network.ColumnSigmas = make([]float64, nin + nout - 1 + 1)
 	}
 	network.Neurons = make([]float64, ntotal - 1 + 1)
-	network.Chunks = utils.MakeMatrixFloat64(3 * ntotal + 1, chunksize - 1 + 1)
 	network.NwBuf = make([]float64, utils.MaxInt(wcount, 2 * nout) - 1 + 1)
 	network.IntegerBuf = make([]int, 3 + 1)
 	network.DfdNet = make([]float64, ntotal - 1 + 1)
@@ -2973,9 +2932,12 @@ func mlpinternalcalculategradient(network *Multilayerperceptron, neurons, weight
 
 /*************************************************************************
 Internal subroutine, chunked gradient
+
+Intermediate values are placed to Buf, network is only read, so chunks
+can be processed concurrently with distinct buffers.
 *************************************************************************/
 
-func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, csize int, e *float64, grad *[]float64, naturalerrorfunc bool) error {
+func mlpchunkedgradient(network *Multilayerperceptron, buf *mlpbuffer, xy [][]float64, cstart, csize int, e *float64, grad *[]float64, naturalerrorfunc bool) error {
 	i := 0
 	j := 0
 	k := 0
@@ -3023,7 +2985,7 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 	iderror = 2 * ntotal
 	izeros = 3 * ntotal
 	for j = 0; j <= csize - 1; j++ {
-		network.Chunks[izeros][j] = 0
+		buf.chunks[izeros][j] = 0
 	}
 
 	//
@@ -3034,9 +2996,9 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 	for i = 0; i <= nin - 1; i++ {
 		for j = 0; j <= csize - 1; j++ {
 			if network.ColumnSigmas[i] != 0.0 {
-				network.Chunks[i][j] = (xy[c1 + j][i] - network.ColumnMeans[i]) / network.ColumnSigmas[i]
+				buf.chunks[i][j] = (xy[c1 + j][i] - network.ColumnMeans[i]) / network.ColumnSigmas[i]
 			}else {
-				network.Chunks[i][j] = xy[c1 + j][i] - network.ColumnMeans[i]
+				buf.chunks[i][j] = xy[c1 + j][i] - network.ColumnMeans[i]
 			}
 		}
 	}
@@ -3049,12 +3011,12 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 			//
 			n1 = network.StructInfo[offs + 2]
 			for i_ = 0; i_ <= csize - 1; i_++ {
-				network.Chunks[i][i_] = network.Chunks[n1][i_]
+				buf.chunks[i][i_] = buf.chunks[n1][i_]
 			}
 			for j = 0; j <= csize - 1; j++ {
-				MlpActivationFunction(network.Chunks[i][j], network.StructInfo[offs + 0], &f, &df, &d2f)
-				network.Chunks[i][ j] = f
-				network.Chunks[idfdnet + i][ j] = df
+				MlpActivationFunction(buf.chunks[i][j], network.StructInfo[offs + 0], &f, &df, &d2f)
+				buf.chunks[i][ j] = f
+				buf.chunks[idfdnet + i][ j] = df
 			}
 			continue
 		}
@@ -3068,12 +3030,12 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 			w1 = network.StructInfo[offs + 3]
 			w2 = w1 + network.StructInfo[offs + 1] - 1
 			for i_ = 0; i_ <= csize - 1; i_++ {
-				network.Chunks[i][ i_] = network.Chunks[izeros][ i_]
+				buf.chunks[i][ i_] = buf.chunks[izeros][ i_]
 			}
 			for j = n1; j <= n2; j++ {
 				v = network.Weights[w1 + j - n1]
 				for i_ = 0; i_ <= csize - 1; i_++ {
-					network.Chunks[i][ i_] = network.Chunks[i][ i_] + v * network.Chunks[j][ i_]
+					buf.chunks[i][ i_] = buf.chunks[i][ i_] + v * buf.chunks[j][ i_]
 				}
 			}
 			continue;
@@ -3091,7 +3053,7 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 				// "-1" neuron
 				//
 				for k = 0; k <= csize - 1; k++ {
-					network.Chunks[i][ k] = -1
+					buf.chunks[i][ k] = -1
 				}
 				bflag = true
 			}
@@ -3100,7 +3062,7 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 				// "0" neuron
 				//
 				for k = 0; k <= csize - 1; k++ {
-					network.Chunks[i][ k] = 0
+					buf.chunks[i][ k] = 0
 				}
 				bflag = true
 			}
@@ -3116,7 +3078,7 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 	//
 	for i = 0; i <= ntotal - 1; i++ {
 		for i_ = 0; i_ <= csize - 1; i_++ {
-			network.Chunks[iderror + i][ i_] = network.Chunks[izeros][ i_]
+			buf.chunks[iderror + i][ i_] = buf.chunks[izeros][ i_]
 		}
 	}
 	if !((network.StructInfo[6] == 0) || (network.StructInfo[6] == 1)) {
@@ -3135,14 +3097,14 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 			//
 			// Normalize
 			//
-			mx = network.Chunks[ntotal - nout][ k]
+			mx = buf.chunks[ntotal - nout][ k]
 			for i = 1; i <= nout - 1; i++ {
-				mx = math.Max(mx, network.Chunks[ntotal - nout + i][ k])
+				mx = math.Max(mx, buf.chunks[ntotal - nout + i][ k])
 			}
 			net = 0
 			for i = 0; i <= nout - 1; i++ {
-				network.NwBuf[i] = math.Exp(network.Chunks[ntotal - nout + i][ k] - mx)
-				net = net + network.NwBuf[i]
+				buf.nwbuf[i] = math.Exp(buf.chunks[ntotal - nout + i][ k] - mx)
+				net = net + buf.nwbuf[i]
 			}
 
 			//
@@ -3162,8 +3124,8 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 					}else {
 						v = 0
 					}
-					network.Chunks[iderror + ntotal - nout + i][ k] = s * network.NwBuf[i] / net - v
-					*e = *e + safecrossentropy(v, network.NwBuf[i] / net)
+					buf.chunks[iderror + ntotal - nout + i][ k] = s * buf.nwbuf[i] / net - v
+					*e = *e + safecrossentropy(v, buf.nwbuf[i] / net)
 				}
 			}else {
 				//
@@ -3173,11 +3135,11 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 				kl = utils.RoundInt(xy[cstart + k][ nin])
 				for i = 0; i <= nout - 1; i++ {
 					if i == kl {
-						v = network.NwBuf[i] / net - 1
+						v = buf.nwbuf[i] / net - 1
 					}else {
-						v = network.NwBuf[i] / net
+						v = buf.nwbuf[i] / net
 					}
-					network.NwBuf[nout + i] = v
+					buf.nwbuf[nout + i] = v
 					*e += utils.SqrFloat64(v) / 2
 				}
 
@@ -3187,12 +3149,12 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 				i1_ = (0) - (nout)
 				v = 0.0
 				for i_ = nout; i_ <= 2 * nout - 1; i_++ {
-					v += network.NwBuf[i_] * network.NwBuf[i_ + i1_]
+					v += buf.nwbuf[i_] * buf.nwbuf[i_ + i1_]
 				}
 				for i = 0; i <= nout - 1; i++ {
-					fown = network.NwBuf[i]
-					deown = network.NwBuf[nout + i]
-					network.Chunks[iderror + ntotal - nout + i][ k] = (-v + deown * fown + deown * (net - fown)) * fown / utils.SqrFloat64(net)
+					fown = buf.nwbuf[i]
+					deown = buf.nwbuf[nout + i]
+					buf.chunks[iderror + ntotal - nout + i][ k] = (-v + deown * fown + deown * (net - fown)) * fown / utils.SqrFloat64(net)
 				}
 			}
 		}
@@ -3205,8 +3167,8 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 		//
 		for i = 0; i <= nout - 1; i++ {
 			for j = 0; j <= csize - 1; j++ {
-				v = network.Chunks[ntotal - nout + i][ j] * network.ColumnSigmas[nin + i] + network.ColumnMeans[nin + i] - xy[cstart + j][ nin + i]
-				network.Chunks[iderror + ntotal - nout + i][ j] = v * network.ColumnSigmas[nin + i]
+				v = buf.chunks[ntotal - nout + i][ j] * network.ColumnSigmas[nin + i] + network.ColumnMeans[nin + i] - xy[cstart + j][ nin + i]
+				buf.chunks[iderror + ntotal - nout + i][ j] = v * network.ColumnSigmas[nin + i]
 				*e += utils.SqrFloat64(v) / 2
 			}
 		}
@@ -3227,10 +3189,10 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 			//
 			n1 = network.StructInfo[offs + 2]
 			for k = 0; k <= csize - 1; k++ {
-				network.Chunks[iderror + i][ k] = network.Chunks[iderror + i][ k] * network.Chunks[idfdnet + i][k]
+				buf.chunks[iderror + i][ k] = buf.chunks[iderror + i][ k] * buf.chunks[idfdnet + i][k]
 			}
 			for i_ = 0; i_ <= csize - 1; i_++ {
-				network.Chunks[iderror + n1][ i_] = network.Chunks[iderror + n1][ i_] + network.Chunks[iderror + i][ i_]
+				buf.chunks[iderror + n1][ i_] = buf.chunks[iderror + n1][ i_] + buf.chunks[iderror + i][ i_]
 			}
 			continue
 		}
@@ -3245,14 +3207,14 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 			for j = w1; j <= w2; j++ {
 				v = 0.0
 				for i_ = 0; i_ <= csize - 1; i_++ {
-					v += network.Chunks[n1 + j - w1][ i_] * network.Chunks[iderror + i][ i_]
+					v += buf.chunks[n1 + j - w1][ i_] * buf.chunks[iderror + i][ i_]
 				}
 				(*grad)[j] = (*grad)[j] + v
 			}
 			for j = n1; j <= n2; j++ {
 				v = network.Weights[w1 + j - n1]
 				for i_ = 0; i_ <= csize - 1; i_++ {
-					network.Chunks[iderror + j][ i_] = network.Chunks[iderror + j][ i_] + v * network.Chunks[iderror + i][ i_]
+					buf.chunks[iderror + j][ i_] = buf.chunks[iderror + j][ i_] + v * buf.chunks[iderror + i][ i_]
 				}
 			}
 			continue
@@ -3275,11 +3237,12 @@ func mlpchunkedgradient(network *Multilayerperceptron, xy [][]float64, cstart, c
 }
 
 /*************************************************************************
-Internal subroutine for Hessian calculation.
+Internal subroutine for Hessian calculation. Rows [From, To) of XY are
+processed, error, gradient and Hessian are added to E, Grad and H.
 
 WARNING!!! Unspeakable math far beyong human capabilities :)
 *************************************************************************/
-func mlphessianbatchinternal(network *Multilayerperceptron, xy *[][]float64, ssize int, naturalerr bool, e *float64, grad *[]float64, h *[][]float64) error {
+func mlphessianbatchinternal(network *Multilayerperceptron, xy *[][]float64, from, to int, naturalerr bool, e *float64, grad *[]float64, h *[][]float64) error {
 	nin := 0;
 	nout := 0
 	wcount := 0
@@ -3326,24 +3289,15 @@ func mlphessianbatchinternal(network *Multilayerperceptron, xy *[][]float64, ssi
 	ry := utils.MakeMatrixFloat64(ntotal + nout - 1 + 1, wcount - 1 + 1)
 	rdx := utils.MakeMatrixFloat64(ntotal + nout - 1 + 1, wcount - 1 + 1)
 	rdy := utils.MakeMatrixFloat64(ntotal + nout - 1 + 1, wcount - 1 + 1)
-	*e = 0.0
 
 	for i := 0; i <= wcount - 1; i++ {
 		zeros[i] = 0
 	}
-	for i_ := 0; i_ <= wcount - 1; i_++ {
-		(*grad)[i_] = zeros[i_]
-	}
-	for i := 0; i <= wcount - 1; i++ {
-		for i_ := 0; i_ <= wcount - 1; i_++ {
-			(*h)[i][ i_] = zeros[i_]
-		}
-	}
 
 	//
 	// Process
 	//
-	for k = 0; k <= ssize - 1; k++ {
+	for k = from; k <= to - 1; k++ {
 		//
 		// Process vector with MLPGradN.
 		// Now Neurons, DFDNET and DError contains results of the last run.
@@ -0,0 +1,268 @@
+package mlpbase
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	// minimal count of rows per block of the gradient and error evaluation,
+	// smaller datasets are processed by the calling goroutine
+	parallelgradrows = 4 * chunksize
+	// minimal count of rows per block of the Hessian evaluation
+	parallelhessianrows = 8
+	// maximal count of the blocks, partial results of each block are kept until the reduction
+	parallelgradblocks    = 64
+	parallelhessianblocks = 8
+)
+
+// MaxWorkers limits count of goroutines used by the batch gradient, error and Hessian
+// evaluation, count of CPU is used when not positive, 1 disables parallel evaluation.
+var MaxWorkers = 0
+
+// mlpbuffer - per-worker buffers of the batch evaluation. Network itself is only read by
+// the workers, all the intermediate values are placed here.
+type mlpbuffer struct {
+	chunks  [][]float64
+	nwbuf   []float64
+	x       []float64
+	y       []float64
+	neurons []float64
+	dfdnet  []float64
+}
+
+var mlpbuffers = sync.Pool{New: func() interface{} { return new(mlpbuffer) }}
+
+// getmlpbuffer takes buffer fitting the network from the pool
+func getmlpbuffer(network *Multilayerperceptron) *mlpbuffer {
+	nin := network.StructInfo[1]
+	nout := network.StructInfo[2]
+	ntotal := network.StructInfo[3]
+	wcount := network.StructInfo[4]
+
+	buf := mlpbuffers.Get().(*mlpbuffer)
+	if len(buf.chunks) < 3*ntotal+1 {
+		buf.chunks = utils.MakeMatrixFloat64(3*ntotal+1, chunksize)
+	}
+	buf.nwbuf = resizebuffer(buf.nwbuf, utils.MaxInt(wcount, 2*nout))
+	buf.x = resizebuffer(buf.x, nin)
+	buf.y = resizebuffer(buf.y, nout)
+	buf.neurons = resizebuffer(buf.neurons, ntotal)
+	buf.dfdnet = resizebuffer(buf.dfdnet, ntotal)
+	return buf
+}
+
+func putmlpbuffer(buf *mlpbuffer) {
+	mlpbuffers.Put(buf)
+}
+
+func resizebuffer(a []float64, n int) []float64 {
+	if cap(a) < n {
+		return make([]float64, n)
+	}
+	return a[:n]
+}
+
+// process network on buf.x, result is placed to buf.y
+func (f *mlpbuffer) process(network *Multilayerperceptron) error {
+	return MlpInternalProcessVector(&network.StructInfo, &network.Weights, &network.ColumnMeans, &network.ColumnSigmas, &f.neurons, &f.dfdnet, &f.x, &f.y)
+}
+
+// mlpsplit splits ssize rows into contiguous blocks [from, to) of at least grain rows
+// and at most maxblocks blocks, bounds of the blocks are multiples of align. Split depends
+// on ssize only, so reduction of the partial results in the order of the blocks gives
+// the same result for any count of workers.
+func mlpsplit(ssize, grain, align, maxblocks int) [][2]int {
+	if ssize <= 0 {
+		return nil
+	}
+	size := utils.MaxInt(grain, (ssize+maxblocks-1)/maxblocks)
+	size = (size + align - 1) / align * align
+	result := make([][2]int, 0, (ssize+size-1)/size)
+	for from := 0; from < ssize; from += size {
+		result = append(result, [2]int{from, utils.MinInt(from+size, ssize)})
+	}
+	return result
+}
+
+// mlpworkers return count of goroutines processing nblocks blocks
+func mlpworkers(nblocks int) int {
+	workers := MaxWorkers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	return utils.MaxInt(utils.MinInt(workers, nblocks), 1)
+}
+
+// mlprun runs fn for every block by mlpworkers goroutines, w is index of the worker
+// and b is index of the block. Single worker processes the blocks by the calling
+// goroutine. Returns error of the first failed block in the order of the blocks.
+func mlprun(blocks [][2]int, fn func(w, b, from, to int) error) error {
+	errs := make([]error, len(blocks))
+	workers := mlpworkers(len(blocks))
+	if workers == 1 {
+		for b, r := range blocks {
+			errs[b] = fn(0, b, r[0], r[1])
+		}
+	} else {
+		next := int32(-1)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for b := int(atomic.AddInt32(&next, 1)); b < len(blocks); b = int(atomic.AddInt32(&next, 1)) {
+					errs[b] = fn(w, b, blocks[b][0], blocks[b][1])
+				}
+			}(w)
+		}
+		wg.Wait()
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mlpgradbatchinternal - batch gradient. Rows are split into the blocks, the first block
+// accumulates error and gradient in e and grad, the others in their own partials, which
+// are added up in the order of the blocks.
+func mlpgradbatchinternal(network *Multilayerperceptron, xy [][]float64, ssize int, naturalerrorfunc bool, e *float64, grad *[]float64) error {
+	wcount := network.StructInfo[4]
+	if len(*grad) < wcount {
+		*grad = make([]float64, wcount)
+	}
+	for i := 0; i <= wcount-1; i++ {
+		(*grad)[i] = 0
+	}
+	*e = 0
+
+	blocks := mlpsplit(ssize, parallelgradrows, chunksize, parallelgradblocks)
+	es := make([]float64, len(blocks))
+	grads := make([][]float64, len(blocks))
+	for b := 1; b < len(blocks); b++ {
+		grads[b] = make([]float64, wcount)
+	}
+	bufs := make([]*mlpbuffer, mlpworkers(len(blocks)))
+	for w := range bufs {
+		bufs[w] = getmlpbuffer(network)
+	}
+	err := mlprun(blocks, func(w, b, from, to int) error {
+		be, bgrad := &es[b], &grads[b]
+		if b == 0 {
+			be, bgrad = e, grad
+		}
+		for i := from; i < to; i += chunksize {
+			if err := mlpchunkedgradient(network, bufs[w], xy, i, utils.MinInt(to, i+chunksize)-i, be, bgrad, naturalerrorfunc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for _, buf := range bufs {
+		putmlpbuffer(buf)
+	}
+	if err != nil {
+		return err
+	}
+	for b := 1; b < len(blocks); b++ {
+		*e += es[b]
+		for i := 0; i <= wcount-1; i++ {
+			(*grad)[i] += grads[b][i]
+		}
+	}
+	return nil
+}
+
+// mlperrorbatchinternal - batch error. Rowerror is called for every row after
+// the network processing with the inputs in buf.x and outputs in buf.y,
+// partial sums of the blocks are added up in the order of the blocks.
+func mlperrorbatchinternal(network *Multilayerperceptron, xy [][]float64, ssize int, rowerror func(buf *mlpbuffer, row []float64) float64) (float64, error) {
+	nin := network.StructInfo[1]
+	blocks := mlpsplit(ssize, parallelgradrows, 1, parallelgradblocks)
+	es := make([]float64, len(blocks))
+	bufs := make([]*mlpbuffer, mlpworkers(len(blocks)))
+	for w := range bufs {
+		bufs[w] = getmlpbuffer(network)
+	}
+	err := mlprun(blocks, func(w, b, from, to int) error {
+		buf := bufs[w]
+		for i := from; i < to; i++ {
+			copy(buf.x, xy[i][:nin])
+			if err := buf.process(network); err != nil {
+				return err
+			}
+			es[b] += rowerror(buf, xy[i])
+		}
+		return nil
+	})
+	for _, buf := range bufs {
+		putmlpbuffer(buf)
+	}
+	if err != nil {
+		return 0, err
+	}
+	result := 0.0
+	for _, item := range es {
+		result += item
+	}
+	return result, nil
+}
+
+// mlphessianbatchparallel - batch Hessian. Hessian calculation uses all the
+// internal buffers of the network, so every worker except the first one
+// processes its blocks by the copy of the network. The first block accumulates
+// in e, grad and h, partials of the others are added up in the order of the blocks.
+func mlphessianbatchparallel(network *Multilayerperceptron, xy *[][]float64, ssize int, naturalerr bool, e *float64, grad *[]float64, h *[][]float64) error {
+	wcount := network.StructInfo[4]
+	*e = 0
+	for i := 0; i <= wcount-1; i++ {
+		(*grad)[i] = 0
+		for j := 0; j <= wcount-1; j++ {
+			(*h)[i][j] = 0
+		}
+	}
+
+	blocks := mlpsplit(ssize, parallelhessianrows, 1, parallelhessianblocks)
+	if len(blocks) <= 1 {
+		return mlphessianbatchinternal(network, xy, 0, ssize, naturalerr, e, grad, h)
+	}
+	es := make([]float64, len(blocks))
+	grads := make([][]float64, len(blocks))
+	hs := make([][][]float64, len(blocks))
+	for b := 1; b < len(blocks); b++ {
+		grads[b] = make([]float64, wcount)
+		hs[b] = utils.MakeMatrixFloat64(wcount, wcount)
+	}
+	// copies are made before the start of the workers, the first worker modifies the network
+	networks := make([]*Multilayerperceptron, mlpworkers(len(blocks)))
+	networks[0] = network
+	for w := 1; w < len(networks); w++ {
+		networks[w] = NewMlp()
+		MlpCopy(network, networks[w])
+	}
+	err := mlprun(blocks, func(w, b, from, to int) error {
+		if b == 0 {
+			return mlphessianbatchinternal(networks[w], xy, from, to, naturalerr, e, grad, h)
+		}
+		return mlphessianbatchinternal(networks[w], xy, from, to, naturalerr, &es[b], &grads[b], &hs[b])
+	})
+	if err != nil {
+		return err
+	}
+	for b := 1; b < len(blocks); b++ {
+		*e += es[b]
+		for i := 0; i <= wcount-1; i++ {
+			(*grad)[i] += grads[b][i]
+			for j := 0; j <= wcount-1; j++ {
+				(*h)[i][j] += hs[b][i][j]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package mlpbase
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func testSet(npoints, nin, nout int, classes bool, seed int64) [][]float64 {
+	rnd := rand.New(rand.NewSource(seed))
+	ncols := nin + nout
+	if classes {
+		ncols = nin + 1
+	}
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		xy[i] = make([]float64, ncols)
+		for j := 0; j < nin; j++ {
+			xy[i][j] = rnd.Float64()*2 - 1
+		}
+		if classes {
+			xy[i][nin] = float64(rnd.Intn(nout))
+		} else {
+			for j := 0; j < nout; j++ {
+				xy[i][nin+j] = rnd.Float64()
+			}
+		}
+	}
+	return xy
+}
+
+func testNetworks(t testing.TB) map[string]*Multilayerperceptron {
+	regression := NewMlp()
+	if err := MlpCreate1(6, 12, 2, regression); err != nil {
+		t.Fatal(err)
+	}
+	classifier := NewMlp()
+	if err := MlpCreatec1(6, 12, 3, classifier); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]*Multilayerperceptron{"regression": regression, "classifier": classifier}
+}
+
+func withWorkers(workers int, fn func()) {
+	saved := MaxWorkers
+	MaxWorkers = workers
+	defer func() { MaxWorkers = saved }()
+	fn()
+}
+
+func assertEqual(t *testing.T, name string, serial, parallel float64) {
+	if serial != parallel {
+		t.Errorf("%s: serial %v, parallel %v", name, serial, parallel)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, ssize := range []int{0, 1, 31, 128, 500, 1000, 10001, 100000} {
+		var blocks [][2]int
+		withWorkers(1, func() { blocks = mlpsplit(ssize, parallelgradrows, chunksize, parallelgradblocks) })
+		withWorkers(4, func() {
+			if fmt.Sprint(blocks) != fmt.Sprint(mlpsplit(ssize, parallelgradrows, chunksize, parallelgradblocks)) {
+				t.Errorf("ssize %d: blocks depend on count of workers", ssize)
+			}
+		})
+		next := 0
+		for _, r := range blocks {
+			if r[0] != next || r[1] <= r[0] || r[0]%chunksize != 0 {
+				t.Errorf("ssize %d: invalid block %v of %v", ssize, r, blocks)
+			}
+			next = r[1]
+		}
+		if next != ssize {
+			t.Errorf("ssize %d: blocks %v do not cover all the rows", ssize, blocks)
+		}
+		if len(blocks) > parallelgradblocks || (ssize > 0 && ssize <= parallelgradrows && len(blocks) != 1) {
+			t.Errorf("ssize %d: unexpected count of blocks %d", ssize, len(blocks))
+		}
+	}
+}
+
+func TestGradBatchParallel(t *testing.T) {
+	for name, network := range testNetworks(t) {
+		xy := testSet(2000, 6, network.StructInfo[2], MlpIsSoftMax(network), 1)
+		wcount := network.StructInfo[4]
+		for _, natural := range []bool{false, true} {
+			var es, ep float64
+			gs := make([]float64, wcount)
+			gp := make([]float64, wcount)
+			withWorkers(1, func() {
+				if err := mlpgradbatchinternal(network, xy, len(xy), natural, &es, &gs); err != nil {
+					t.Fatal(err)
+				}
+			})
+			withWorkers(4, func() {
+				if err := mlpgradbatchinternal(network, xy, len(xy), natural, &ep, &gp); err != nil {
+					t.Fatal(err)
+				}
+			})
+			// partial sums are added up in the order of the blocks for any count of workers
+			assertEqual(t, name+" error", es, ep)
+			for i := range gs {
+				assertEqual(t, name+" gradient", gs[i], gp[i])
+			}
+		}
+	}
+}
+
+func TestErrorBatchParallel(t *testing.T) {
+	for name, network := range testNetworks(t) {
+		xy := testSet(2000, 6, network.StructInfo[2], MlpIsSoftMax(network), 2)
+		var serial, parallel [4]float64
+		eval := func(result *[4]float64) {
+			var err error
+			var cls int
+			if result[0], err = MlpError(network, &xy, len(xy)); err != nil {
+				t.Fatal(err)
+			}
+			if result[1], err = MlpErrorN(network, &xy, len(xy)); err != nil {
+				t.Fatal(err)
+			}
+			if cls, err = MlpClsError(network, &xy, len(xy)); err != nil {
+				t.Fatal(err)
+			}
+			result[2] = float64(cls)
+			if result[3], err = MlpAvgError(network, &xy, len(xy)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		withWorkers(1, func() { eval(&serial) })
+		withWorkers(4, func() { eval(&parallel) })
+		for i := range serial {
+			assertEqual(t, name+" error", serial[i], parallel[i])
+		}
+	}
+}
+
+func TestErrorBatchFailure(t *testing.T) {
+	network := NewMlp()
+	MlpCopy(testNetworks(t)["classifier"], network)
+	xy := testSet(2000, 6, 3, true, 2)
+	// unknown normalization type fails every block
+	network.StructInfo[6] = 2
+	withWorkers(4, func() {
+		if _, err := MlpError(network, &xy, len(xy)); err == nil {
+			t.Error("error of the block is lost")
+		}
+		if _, err := MlpClsError(network, &xy, len(xy)); err == nil {
+			t.Error("error of the block is lost")
+		}
+	})
+}
+
+func TestHessianBatchParallel(t *testing.T) {
+	for name, network := range testNetworks(t) {
+		xy := testSet(200, 6, network.StructInfo[2], MlpIsSoftMax(network), 3)
+		wcount := network.StructInfo[4]
+		for _, natural := range []bool{false, true} {
+			var es, ep float64
+			gs := make([]float64, wcount)
+			gp := make([]float64, wcount)
+			hs := make([][]float64, wcount)
+			hp := make([][]float64, wcount)
+			for i := range hs {
+				hs[i] = make([]float64, wcount)
+				hp[i] = make([]float64, wcount)
+			}
+			withWorkers(1, func() {
+				if err := mlphessianbatchparallel(network, &xy, len(xy), natural, &es, &gs, &hs); err != nil {
+					t.Fatal(err)
+				}
+			})
+			withWorkers(4, func() {
+				if err := mlphessianbatchparallel(network, &xy, len(xy), natural, &ep, &gp, &hp); err != nil {
+					t.Fatal(err)
+				}
+			})
+			assertEqual(t, name+" error", es, ep)
+			for i := range gs {
+				assertEqual(t, name+" gradient", gs[i], gp[i])
+				for j := range hs[i] {
+					assertEqual(t, name+" hessian", hs[i][j], hp[i][j])
+				}
+			}
+		}
+	}
+}
+
+func benchmarkGradBatch(b *testing.B, workers, npoints int) {
+	network := testNetworks(b)["classifier"]
+	xy := testSet(npoints, 6, 3, true, 4)
+	grad := make([]float64, network.StructInfo[4])
+	e := 0.0
+	withWorkers(workers, func() {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			MlpGradNBatch(network, xy, npoints, &e, &grad)
+		}
+	})
+}
+
+func BenchmarkGradBatchSerial1000(b *testing.B)     { benchmarkGradBatch(b, 1, 1000) }
+func BenchmarkGradBatchParallel1000(b *testing.B)   { benchmarkGradBatch(b, 0, 1000) }
+func BenchmarkGradBatchSerial100000(b *testing.B)   { benchmarkGradBatch(b, 1, 100000) }
+func BenchmarkGradBatchParallel100000(b *testing.B) { benchmarkGradBatch(b, 0, 100000) }
+
+func benchmarkHessianBatch(b *testing.B, workers, npoints int) {
+	network := testNetworks(b)["regression"]
+	xy := testSet(npoints, 6, 2, false, 5)
+	wcount := network.StructInfo[4]
+	grad := make([]float64, wcount)
+	h := make([][]float64, wcount)
+	for i := range h {
+		h[i] = make([]float64, wcount)
+	}
+	e := 0.0
+	withWorkers(workers, func() {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			MlpHessianBatch(network, &xy, npoints, &e, &grad, &h)
+		}
+	})
+}
+
+func BenchmarkHessianBatchSerial(b *testing.B)   { benchmarkHessianBatch(b, 1, 2000) }
+func BenchmarkHessianBatchParallel(b *testing.B) { benchmarkHessianBatch(b, 0, 2000) }
@@ -13,12 +13,11 @@ const (
 
 	maxrealnumber = 1E300
 	minrealnumber = 1E-300
-	machineepsilon = 5E-16
 
 	stpmin = 1.0E-50
 	defstpmax = 1.0E+50
 	ftol = 0.001
-	xtol = 100 * machineepsilon
+	xtol = 100 * utils.MachineEpsilon
 	maxfev = 20
 	gtol = 0.4
 )
@@ -289,25 +288,25 @@ automatic stopping criterion selection (small EpsX).
 *************************************************************************/
 func minlbfgssetcond(state *minlbfgsstate, epsg, epsf, epsx float64, maxits int) error {
 	if !(utils.IsFinite(epsg)) {
-		fmt.Errorf("MinLBFGSSetCond: EpsG is not finite number!")
+		return fmt.Errorf("MinLBFGSSetCond: EpsG is not finite number!")
 	}
 	if !(epsg >= 0) {
-		fmt.Errorf("MinLBFGSSetCond: negative EpsG!")
+		return fmt.Errorf("MinLBFGSSetCond: negative EpsG!")
 	}
 	if !(utils.IsFinite(epsf)) {
-		fmt.Errorf("MinLBFGSSetCond: EpsF is not finite number!")
+		return fmt.Errorf("MinLBFGSSetCond: EpsF is not finite number!")
 	}
 	if !(epsf >= 0) {
-		fmt.Errorf("MinLBFGSSetCond: negative EpsF!")
+		return fmt.Errorf("MinLBFGSSetCond: negative EpsF!")
 	}
 	if !(utils.IsFinite(epsx)) {
-		fmt.Errorf("MinLBFGSSetCond: EpsX is not finite number!")
+		return fmt.Errorf("MinLBFGSSetCond: EpsX is not finite number!")
 	}
 	if !(epsx >= 0) {
-		fmt.Errorf("MinLBFGSSetCond: negative EpsX!")
+		return fmt.Errorf("MinLBFGSSetCond: negative EpsX!")
 	}
 	if !(maxits >= 0) {
-		fmt.Errorf("MinLBFGSSetCond: negative MaxIts!")
+		return fmt.Errorf("MinLBFGSSetCond: negative MaxIts!")
 	}
 
 	if ((epsg == 0 && epsf == 0) && epsx == 0) && maxits == 0 {
@@ -4169,6 +4168,24 @@ func spdmatrixcholeskysolve(cha *[][]float64, n int, isupper bool, b *[]float64,
 	}
 }
 
+/*************************************************************************
+Exported Cholesky decomposition of the symmetric positive definite matrix
+for the other ALGLIB units, see SPDMatrixCholesky.
+*************************************************************************/
+func SpdMatrixCholesky(a *[][]float64, n int, isupper bool) bool {
+	return spdmatrixcholesky(a, n, isupper)
+}
+
+/*************************************************************************
+Exported solver of the system with the Cholesky decomposition for the other
+ALGLIB units, see SPDMatrixCholeskySolve. Info is the same, the report is
+not returned.
+*************************************************************************/
+func SpdMatrixCholeskySolve(cha *[][]float64, n int, isupper bool, b *[]float64, info *int, x *[]float64) {
+	rep := new(densesolverreport)
+	spdmatrixcholeskysolve(cha, n, isupper, b, info, rep, x)
+}
+
 /*************************************************************************
 This function checks that all values from upper/lower triangle of
 X[0..N-1,0..N-1] are finite
@@ -4728,7 +4745,17 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 10.03.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, info *int, rep *MlpReport) {
+func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, info *int, rep *MlpReport) error {
+	return MlpTrainLmMonitored(network, xy, npoints, decay, restarts, info, rep, nil)
+}
+
+/*************************************************************************
+MlpTrainLm observed by the monitor. Monitor receives progress after every
+iteration of the both stages of the hybrid algorithm and may stop the
+training, then Info is 8 and the network contains the best weights found
+so far.
+*************************************************************************/
+func MlpTrainLmMonitored(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, info *int, rep *MlpReport, mon *Monitor) error {
 	nin := 0
 	nout := 0
 	wcount := 0
@@ -4737,6 +4764,7 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	v := 0.0
 	e := 0.0
 	enew := 0.0
+	var err error
 	xnorm2 := 0.0
 	stepnorm := 0.0
 	g := make([]float64, 0)
@@ -4776,13 +4804,13 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	//
 	if npoints <= 0 || restarts < 1 {
 		*info = -1
-		return
+		return nil
 	}
 	if mlpbase.MlpIsSoftMax(network) {
 		for i = 0; i <= npoints - 1; i++ {
 			if utils.RoundInt((*xy)[i][ nin]) < 0 || utils.RoundInt((*xy)[i][nin]) >= nout {
 				*info = -2
-				return
+				return nil
 			}
 		}
 	}
@@ -4826,16 +4854,40 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			wbase[i_] = network.Weights[i_]
 		}
-		minlbfgscreate(wcount, utils.MinInt(wcount, 5), &wbase, state)
-		minlbfgssetcond(state, 0, 0, 0, utils.MaxInt(25, wcount))
+		if err := minlbfgscreate(wcount, utils.MinInt(wcount, 5), &wbase, state); err != nil {
+			return err
+		}
+		if err := minlbfgssetcond(state, 0, 0, 0, utils.MaxInt(25, wcount)); err != nil {
+			return err
+		}
+		minlbfgssetxrep(state, mon != nil)
+		iteration := 0
 		for minlbfgsiteration(state) {
+			//
+			// progress
+			//
+			if state.xupdated {
+				if mon.next(Progress{Pass: pass, Iteration: iteration, Error: state.f, GradNorm: norm2(state.g, wcount)}) {
+					for i_ = 0; i_ <= wcount - 1; i_++ {
+						network.Weights[i_] = state.x[i_]
+					}
+					keepbest(network, wcount, state.f, wbest, ebest)
+					*info = stoppedinfo
+					return nil
+				}
+				iteration++
+				continue
+			}
+
 			//
 			// gradient
 			//
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				network.Weights[i_] = state.x[i_]
 			}
-			mlpbase.MlpGradBatch(network, *xy, npoints, &state.f, &state.g)
+			if err := mlpbase.MlpGradBatch(network, *xy, npoints, &state.f, &state.g); err != nil {
+				return err
+			}
 
 			//
 			// weight decay
@@ -4866,7 +4918,9 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 		// G with gradient,
 		// E with regularized error.
 		//
-		mlpbase.MlpHessianBatch(network, xy, npoints, &e, &g, &h)
+		if err := mlpbase.MlpHessianBatch(network, xy, npoints, &e, &g, &h); err != nil {
+			return err
+		}
 		v = 0.0
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			v += network.Weights[i_] * network.Weights[i_]
@@ -4928,7 +4982,10 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 				stepnorm += wdir[i_] * wdir[i_]
 			}
 			stepnorm = math.Sqrt(stepnorm);
-			enew = mlpbase.MlpError(network, xy, npoints) + 0.5 * decay * xnorm2
+			if enew, err = mlpbase.MlpError(network, xy, npoints); err != nil {
+				return err
+			}
+			enew = enew + 0.5 * decay * xnorm2
 			if stepnorm < lmsteptol * (1 + math.Sqrt(xnorm2)) {
 				break
 			}
@@ -4948,7 +5005,7 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 				// TODO: make WCount steps in direction suggested by HMod
 				//
 				*info = -9
-				return
+				return nil
 			}
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				wbase[i_] = network.Weights[i_]
@@ -4957,9 +5014,11 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 				wt[i] = 0
 			}
 			if err := minlbfgscreatex(wcount, wcount, &wt, 1, 0.0, state); err != nil {
-				fmt.Printf("Err 01: %v", err)
+				return err
+			}
+			if err := minlbfgssetcond(state, 0, 0, 0, 5); err != nil {
+				return err
 			}
-			minlbfgssetcond(state, 0, 0, 0, 5)
 			for minlbfgsiteration(state) {
 				//
 				// gradient
@@ -4971,7 +5030,9 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 					}
 					network.Weights[i] = wbase[i] + v
 				}
-				mlpbase.MlpGradBatch(network, *xy, npoints, &state.f, &g)
+				if err := mlpbase.MlpGradBatch(network, *xy, npoints, &state.f, &g); err != nil {
+					return err
+				}
 				for i = 0; i <= wcount - 1; i++ {
 					state.g[i] = 0
 				}
@@ -5016,7 +5077,9 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 				}
 				network.Weights[i] = wbase[i] + v
 			}
-			mlpbase.MlpHessianBatch(network, xy, npoints, &e, &g, &h)
+			if err := mlpbase.MlpHessianBatch(network, xy, npoints, &e, &g, &h); err != nil {
+				return err
+			}
 			v = 0.0
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				v += network.Weights[i_] * network.Weights[i_]
@@ -5030,6 +5093,16 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 			}
 			rep.NHess += 1
 
+			//
+			// progress
+			//
+			iteration++
+			if mon.next(Progress{Pass: pass, Iteration: iteration, Error: e, GradNorm: norm2(g, wcount)}) {
+				keepbest(network, wcount, e, wbest, ebest)
+				*info = stoppedinfo
+				return nil
+			}
+
 			//
 			// Update lambda
 			//
@@ -5044,7 +5117,10 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			v += network.Weights[i_] * network.Weights[i_]
 		}
-		e = 0.5 * decay * v + mlpbase.MlpError(network, xy, npoints)
+		if e, err = mlpbase.MlpError(network, xy, npoints); err != nil {
+			return err
+		}
+		e = 0.5 * decay * v + e
 		if e < ebest {
 			ebest = e
 			for i_ = 0; i_ <= wcount - 1; i_++ {
@@ -5059,6 +5135,7 @@ func MlpTrainLm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	for i_ = 0; i_ <= wcount - 1; i_++ {
 		network.Weights[i_] = wbest[i_]
 	}
+	return nil
 }
 
 /*************************************************************************
@@ -5099,6 +5176,15 @@ OUTPUT PARAMETERS:
 	 Copyright 09.12.2007 by Bochkanov Sergey
 *************************************************************************/
 func MlpTrainLbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int, info *int, rep *MlpReport) error {
+	return MlpTrainLbfgsMonitored(network, xy, npoints, decay, restarts, wstep, maxits, info, rep, nil)
+}
+
+/*************************************************************************
+MlpTrainLbfgs observed by the monitor. Monitor receives progress after
+every iteration and may stop the training, then Info is 8 and the network
+contains the best weights found so far.
+*************************************************************************/
+func MlpTrainLbfgsMonitored(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int, info *int, rep *MlpReport, mon *Monitor) error {
 	i := 0
 	pass := 0
 	nin := 0
@@ -5107,6 +5193,7 @@ func MlpTrainLbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoin
 	w := make([]float64, 0)
 	wbest := make([]float64, 0)
 	e := 0.0
+	var err error
 	v := 0.0
 	ebest := 0.0
 	internalrep := &minlbfgsreport{}
@@ -5162,7 +5249,21 @@ func MlpTrainLbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoin
 		if err := minlbfgssetcond(state, 0.0, 0.0, wstep, maxits); err != nil {
 			return err
 		}
+		minlbfgssetxrep(state, mon != nil)
+		iteration := 0
 		for minlbfgsiteration(state) {
+			if state.xupdated {
+				if mon.next(Progress{Pass: pass, Iteration: iteration, Error: state.f, GradNorm: norm2(state.g, wcount)}) {
+					for i_ = 0; i_ <= wcount - 1; i_++ {
+						network.Weights[i_] = state.x[i_]
+					}
+					keepbest(network, wcount, state.f, wbest, ebest)
+					*info = stoppedinfo
+					return nil
+				}
+				iteration++
+				continue
+			}
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				network.Weights[i_] = state.x[i_]
 			}
@@ -5190,7 +5291,10 @@ func MlpTrainLbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoin
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			v += network.Weights[i_] * network.Weights[i_]
 		}
-		e = mlpbase.MlpErrorN(network, xy, npoints) + 0.5 * decay * v
+		if e, err = mlpbase.MlpErrorN(network, xy, npoints); err != nil {
+			return err
+		}
+		e = e + 0.5 * decay * v
 		if e < ebest {
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				wbest[i_] = network.Weights[i_]
@@ -5249,7 +5353,7 @@ minimum of validation set error.
   -- ALGLIB --
 	 Copyright 10.03.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsize int, valxy *[][]float64, valsize int, decay float64, restarts int, info *int, rep *MlpReport) {
+func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsize int, valxy *[][]float64, valsize int, decay float64, restarts int, info *int, rep *MlpReport) error {
 	i := 0
 	pass := 0
 	nin := 0
@@ -5258,6 +5362,7 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 	w := make([]float64, 0)
 	wbest := make([]float64, 0)
 	e := 0.0
+	var err error
 	v := 0.0
 	ebest := 0.0
 	wfinal := make([]float64, 0)
@@ -5276,20 +5381,20 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 	//
 	if ((trnsize <= 0 || valsize <= 0) || restarts < 1) || decay < 0 {
 		*info = -1
-		return
+		return nil
 	}
 	mlpbase.MlpProperties(network, &nin, &nout, &wcount)
 	if mlpbase.MlpIsSoftMax(network) {
 		for i = 0; i <= trnsize - 1; i++ {
 			if utils.RoundInt(trnxy[i][ nin]) < 0 || utils.RoundInt(trnxy[i][nin]) >= nout {
 				*info = -2
-				return
+				return nil
 			}
 		}
 		for i = 0; i <= valsize - 1; i++ {
 			if utils.RoundInt((*valxy)[i][ nin]) < 0 || utils.RoundInt((*valxy)[i][ nin]) >= nout {
 				*info = -2
-				return
+				return nil
 			}
 		}
 	}
@@ -5318,7 +5423,9 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 		// Process
 		//
 		mlpbase.MlpRandomize(network)
-		ebest = mlpbase.MlpError(network, valxy, valsize)
+		if ebest, err = mlpbase.MlpError(network, valxy, valsize); err != nil {
+			return err
+		}
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			wbest[i_] = network.Weights[i_]
 		}
@@ -5326,8 +5433,12 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 		for i_ = 0; i_ <= wcount - 1; i_++ {
 			w[i_] = network.Weights[i_]
 		}
-		minlbfgscreate(wcount, utils.MinInt(wcount, 10), &w, state)
-		minlbfgssetcond(state, 0.0, 0.0, wstep, 0)
+		if err := minlbfgscreate(wcount, utils.MinInt(wcount, 10), &w, state); err != nil {
+			return err
+		}
+		if err := minlbfgssetcond(state, 0.0, 0.0, wstep, 0); err != nil {
+			return err
+		}
 		minlbfgssetxrep(state, true)
 		for minlbfgsiteration(state) {
 			//
@@ -5336,7 +5447,9 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				network.Weights[i_] = state.x[i_]
 			}
-			mlpbase.MlpGradNBatch(network, trnxy, trnsize, &state.f, &state.g)
+			if err := mlpbase.MlpGradNBatch(network, trnxy, trnsize, &state.f, &state.g); err != nil {
+				return err
+			}
 			v = 0.0
 			for i_ = 0; i_ <= wcount - 1; i_++ {
 				v += network.Weights[i_] * network.Weights[i_]
@@ -5354,7 +5467,9 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 				for i_ = 0; i_ <= wcount - 1; i_++ {
 					network.Weights[i_] = w[i_]
 				}
-				e = mlpbase.MlpError(network, valxy, valsize)
+				if e, err = mlpbase.MlpError(network, valxy, valsize); err != nil {
+					return err
+				}
 				if e < ebest {
 					ebest = e
 					for i_ = 0; i_ <= wcount - 1; i_++ {
@@ -5387,6 +5502,7 @@ func MlpTraines(network *mlpbase.Multilayerperceptron, trnxy [][]float64, trnsiz
 	for i_ = 0; i_ <= wcount - 1; i_++ {
 		network.Weights[i_] = wfinal[i_]
 	}
+	return nil
 }
 
 /*************************************************************************
@@ -5438,7 +5554,7 @@ func mlpkfoldsplit(xy *[][]float64, npoints, nclasses, foldscount int, stratifie
 /*************************************************************************
 Internal cross-validation subroutine
 *************************************************************************/
-func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int, lmalgorithm bool, wstep float64, maxits int, info *int, rep *MlpReport, cvrep *MlpCvReport) {
+func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int, lmalgorithm bool, wstep float64, maxits int, info *int, rep *MlpReport, cvrep *MlpCvReport) error {
 	i := 0
 	fold := 0
 	j := 0
@@ -5476,7 +5592,7 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	}
 	if (npoints <= 0 || foldscount < 2) || foldscount > npoints {
 		*info = -1
-		return
+		return nil
 	}
 	mlpbase.MlpCopy(n, network)
 
@@ -5488,7 +5604,9 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	cvset = utils.MakeMatrixFloat64(npoints - 1 + 1, rowlen - 1 + 1)
 	x = make([]float64, nin - 1 + 1)
 	y = make([]float64, nout - 1 + 1)
-	mlpkfoldsplit(xy, npoints, nclasses, foldscount, false, &folds)
+	if err := mlpkfoldsplit(xy, npoints, nclasses, foldscount, false, &folds); err != nil {
+		return err
+	}
 	cvrep.RelclsError = 0
 	cvrep.Avgce = 0
 	cvrep.RmsError = 0
@@ -5522,10 +5640,14 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 		//
 		// Train on CV training set
 		//
+		var err error
 		if lmalgorithm {
-			MlpTrainLm(network, &cvset, cvssize, decay, restarts, info, &internalrep)
+			err = MlpTrainLm(network, &cvset, cvssize, decay, restarts, info, &internalrep)
 		}else {
-			MlpTrainLbfgs(network, &cvset, cvssize, decay, restarts, wstep, maxits, info, &internalrep)
+			err = MlpTrainLbfgs(network, &cvset, cvssize, decay, restarts, wstep, maxits, info, &internalrep)
+		}
+		if err != nil {
+			return err
 		}
 		if *info < 0 {
 			cvrep.RelclsError = 0
@@ -5533,7 +5655,7 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 			cvrep.RmsError = 0
 			cvrep.AvgError = 0
 			cvrep.AvgrelError = 0
-			return
+			return nil
 		}
 		rep.NGrad = rep.NGrad + internalrep.NGrad
 		rep.NHess = rep.NHess + internalrep.NHess
@@ -5546,8 +5668,16 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 			//
 			// classification-only code
 			//
-			cvrep.RelclsError += float64(mlpbase.MlpClsError(network, &testset, tssize))
-			cvrep.Avgce += mlpbase.MlpErrorN(network, &testset, tssize)
+			relcls, err := mlpbase.MlpClsError(network, &testset, tssize)
+			if err != nil {
+				return err
+			}
+			avgce, err := mlpbase.MlpErrorN(network, &testset, tssize)
+			if err != nil {
+				return err
+			}
+			cvrep.RelclsError += float64(relcls)
+			cvrep.Avgce += avgce
 		}
 		for i = 0; i <= tssize - 1; i++ {
 			for i_ = 0; i_ <= nin - 1; i_++ {
@@ -5593,6 +5723,7 @@ func mlpkfoldcvgeneral(n *mlpbase.Multilayerperceptron, xy *[][]float64, npoints
 	cvrep.AvgError = cvrep.AvgError / float64(npoints * nout)
 	cvrep.AvgrelError = cvrep.AvgrelError / float64(relcnt)
 	*info = 1
+	return nil
 }
 
 /*************************************************************************
@@ -5624,9 +5755,9 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 09.12.2007 by Bochkanov Sergey
 *************************************************************************/
-func Mlpkfoldcvlbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits, foldscount int, info *int, rep *MlpReport, cvrep *MlpCvReport) {
+func Mlpkfoldcvlbfgs(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits, foldscount int, info *int, rep *MlpReport, cvrep *MlpCvReport) error {
 	*info = 0
-	mlpkfoldcvgeneral(network, xy, npoints, decay, restarts, foldscount, false, wstep, maxits, info, rep, cvrep)
+	return mlpkfoldcvgeneral(network, xy, npoints, decay, restarts, foldscount, false, wstep, maxits, info, rep, cvrep)
 }
 
 /*************************************************************************
@@ -5656,9 +5787,9 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 09.12.2007 by Bochkanov Sergey
 *************************************************************************/
-func Mlpkfoldcvlm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int, info *int, rep *MlpReport, cvrep *MlpCvReport) {
-	*info = 0;
-	mlpkfoldcvgeneral(network, xy, npoints, decay, restarts, foldscount, true, 0.0, 0, info, rep, cvrep)
+func Mlpkfoldcvlm(network *mlpbase.Multilayerperceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int, info *int, rep *MlpReport, cvrep *MlpCvReport) error {
+	*info = 0
+	return mlpkfoldcvgeneral(network, xy, npoints, decay, restarts, foldscount, true, 0.0, 0, info, rep, cvrep)
 }
 
 
@@ -0,0 +1,58 @@
+package mlptrain
+
+import (
+	"math"
+
+	"pr.optima/src/core/neural/mlpbase"
+)
+
+// stoppedinfo - return code of the training stopped by the monitor, the same
+// code is used by ALGLIB for the termination requested by the user
+const stoppedinfo = 8
+
+// Progress - state of the training after the iteration of the optimizer
+type Progress struct {
+	Pass      int     // restart from the random position, starting from 1
+	Iteration int     // iteration within the pass, 0 for the starting point
+	Error     float64 // error function with the weight decay term
+	GradNorm  float64 // norm of the gradient of the error function
+}
+
+// Monitor - observes the training and stops it before the completion.
+// Stopped training leaves in the network the best weights found so far.
+type Monitor struct {
+	Done     <-chan struct{}       // training is stopped when the channel is closed
+	Progress func(p Progress) bool // called after every iteration, training is stopped when it returns false
+}
+
+// next report progress to the monitor, returns true if the training must be stopped
+func (m *Monitor) next(p Progress) bool {
+	if m == nil {
+		return false
+	}
+	if m.Done != nil {
+		select {
+		case <-m.Done:
+			return true
+		default:
+		}
+	}
+	return m.Progress != nil && !m.Progress(p)
+}
+
+// norm2 - euclidean norm of the first n elements
+func norm2(x []float64, n int) float64 {
+	v := 0.0
+	for i := 0; i < n; i++ {
+		v += x[i] * x[i]
+	}
+	return math.Sqrt(v)
+}
+
+// keepbest leaves in the network its current weights with error e or the best
+// weights of the previous passes, whichever is smaller
+func keepbest(network *mlpbase.Multilayerperceptron, wcount int, e float64, wbest []float64, ebest float64) {
+	if ebest < e {
+		copy(network.Weights[:wcount], wbest[:wcount])
+	}
+}
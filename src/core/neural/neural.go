@@ -1,8 +1,11 @@
 package neural
 
 import (
+	"fmt"
+
 	"pr.optima/src/core/neural/mlpbase"
 	"pr.optima/src/core/neural/mlptrain"
+	"pr.optima/src/core/neural/utils"
 )
 
 type MultiLayerPerceptron struct {
@@ -22,9 +25,11 @@ Training report:
 	* NGrad     - number of gradient calculations
 	* NHess     - number of Hessian calculations
 	* NCholesky - number of Cholesky decompositions
+	* TerminationReason - why the training has been finished
 *************************************************************************/
 type MlpReport struct {
-	innerObj *mlptrain.MlpReport
+	innerObj    *mlptrain.MlpReport
+	termination TerminationReason
 }
 func NewMlpReport() *MlpReport {
 	return &MlpReport{
@@ -40,6 +45,18 @@ func (r *MlpReport) GetNHess() int { return r.innerObj.NHess }
 func (r *MlpReport) SetNHess(value int) { r.innerObj.NHess = value }
 func (r *MlpReport) GetNCholesky() int { return r.innerObj.NCholesky }
 func (r *MlpReport) SetNCholesky(value int) { r.innerObj.NCholesky = value }
+func (r *MlpReport) GetTerminationReason() TerminationReason { return r.termination }
+func (r *MlpReport) SetTerminationReason(value TerminationReason) { r.termination = value }
+
+// terminated store the termination reason of the training, ALGLIB error codes are converted to errors
+func (r *MlpReport) terminated(op string, info int) (*MlpReport, error) {
+	reason, err := infoResult(op, info)
+	if err != nil {
+		return nil, err
+	}
+	r.termination = reason
+	return r, nil
+}
 
 /*************************************************************************
 Cross-validation estimates of generalization error
@@ -74,10 +91,15 @@ random values.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreate0(nin, nout int) *MultiLayerPerceptron {
+func MlpCreate0(nin, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreate0", nin, nout, false); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreate0(nin, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreate0(nin, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreate0", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -87,10 +109,15 @@ non-linear activation function. Output layer is linear.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreate1(nin, nhid, nout int) *MultiLayerPerceptron {
+func MlpCreate1(nin, nhid, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreate1", nin, nout, false, nhid); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreate1(nin, nhid, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreate1(nin, nhid, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreate1", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -101,10 +128,15 @@ with non-linear activation function. Output layer is linear.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreate2(nin, nhid1, nhid2, nout int) *MultiLayerPerceptron {
+func MlpCreate2(nin, nhid1, nhid2, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreate2", nin, nout, false, nhid1, nhid2); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreate2(nin, nhid1, nhid2, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreate2(nin, nhid1, nhid2, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreate2", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -121,10 +153,15 @@ or
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateB0(nin, nout int, b, d float64) *MultiLayerPerceptron {
+func MlpCreateB0(nin, nout int, b, d float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateB0", nin, nout, false); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreateb0(nin, nout, b, d, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreateb0(nin, nout, b, d, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateB0", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -133,10 +170,15 @@ Same as MLPCreateB0 but with non-linear hidden layer.
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateB1(nin, nhid, nout int, b, d float64) *MultiLayerPerceptron {
+func MlpCreateB1(nin, nhid, nout int, b, d float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateB1", nin, nout, false, nhid); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreateb1(nin, nhid, nout, b, d, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreateb1(nin, nhid, nout, b, d, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateB1", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -145,10 +187,15 @@ Same as MLPCreateB0 but with two non-linear hidden layers.
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateB2(nin, nhid1, nhid2, nout int, b, d float64) *MultiLayerPerceptron {
+func MlpCreateB2(nin, nhid1, nhid2, nout int, b, d float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateB2", nin, nout, false, nhid1, nhid2); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreateb2(nin, nhid1, nhid2, nout, b, d, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreateb2(nin, nhid1, nhid2, nout, b, d, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateB2", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -159,10 +206,15 @@ random values. Activation function of the output layer takes values [A,B].
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateR0(nin, nout int, a, b float64) *MultiLayerPerceptron {
+func MlpCreateR0(nin, nout int, a, b float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateR0", nin, nout, false); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreater0(nin, nout, a, b, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreater0(nin, nout, a, b, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateR0", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -171,10 +223,15 @@ Same as MLPCreateR0, but with non-linear hidden layer.
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateR1(nin, nhid, nout int, a, b float64) *MultiLayerPerceptron {
+func MlpCreateR1(nin, nhid, nout int, a, b float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateR1", nin, nout, false, nhid); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreater1(nin, nhid, nout, a, b, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreater1(nin, nhid, nout, a, b, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateR1", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -183,10 +240,15 @@ Same as MLPCreateR0, but with two non-linear hidden layers.
   -- ALGLIB --
 	 Copyright 30.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateR2(nin, nhid1, nhid2, nout int, a, b float64) *MultiLayerPerceptron {
+func MlpCreateR2(nin, nhid1, nhid2, nout int, a, b float64) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateR2", nin, nout, false, nhid1, nhid2); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreater2(nin, nhid1, nhid2, nout, a, b, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreater2(nin, nhid1, nhid2, nout, a, b, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateR2", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -198,10 +260,15 @@ probabilities).
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateC0(nin, nout int) *MultiLayerPerceptron {
+func MlpCreateC0(nin, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateC0", nin, nout, true); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreatec0(nin, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreatec0(nin, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateC0", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -210,10 +277,15 @@ Same as MLPCreateC0, but with one non-linear hidden layer.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateC1(nin, nhid, nout int) *MultiLayerPerceptron {
+func MlpCreateC1(nin, nhid, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateC1", nin, nout, true, nhid); err != nil {
+		return nil, err
+	}
 	network := NewMlp()
-	mlpbase.MlpCreatec1(nin, nhid, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpCreatec1(nin, nhid, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateC1", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -222,10 +294,48 @@ Same as MLPCreateC0, but with two non-linear hidden layers.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpCreateC2(nin, nhid1, nhid2, nout int) *MultiLayerPerceptron {
+func MlpCreateC2(nin, nhid1, nhid2, nout int) (*MultiLayerPerceptron, error) {
+	if err := checkGeometry("MlpCreateC2", nin, nout, true, nhid1, nhid2); err != nil {
+		return nil, err
+	}
+	network := NewMlp()
+	if err := mlpbase.MlpCreatec2(nin, nhid1, nhid2, nout, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpCreateC2", Msg: err.Error()}
+	}
+	return network, nil
+}
+
+/*************************************************************************
+Serialization of MultiLayerPerceptron strucure to the array of real numbers:
+structure, weights and standartisator of the network.
+
+  -- ALGLIB --
+	 Copyright 29.03.2008 by Bochkanov Sergey
+*************************************************************************/
+func MlpSerialize(network *MultiLayerPerceptron) []float64 {
+	ra := make([]float64, 0)
+	rlen := 0
+	mlpbase.MlpSerializeOld(network.innerobj, &ra, &rlen)
+	return ra
+}
+
+/*************************************************************************
+Unserialization of MultiLayerPerceptron strucure. Sizes of the layers are
+not stored, so the restored network processes data, but doesn't provide
+the layer and neuron info (MlpGetLayerSize, MlpGetNeuronInfo, ...).
+
+  -- ALGLIB --
+	 Copyright 29.03.2008 by Bochkanov Sergey
+*************************************************************************/
+func MlpUnserialize(ra []float64) (*MultiLayerPerceptron, error) {
+	if len(ra) < 3 || int(ra[0]) != len(ra) {
+		return nil, &ShapeError{Op: "MlpUnserialize", Msg: fmt.Sprintf("array length %d doesn't match the stored one", len(ra))}
+	}
 	network := NewMlp()
-	mlpbase.MlpCreatec2(nin, nhid1, nhid2, nout, network.innerobj)
-	return network
+	if err := mlpbase.MlpUnserializeOld(ra, network.innerobj); err != nil {
+		return nil, &ArgumentError{Op: "MlpUnserialize", Msg: err.Error()}
+	}
+	return network, nil
 }
 
 /*************************************************************************
@@ -297,7 +407,14 @@ connections - it is just zero.
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
 func MlpGetLayerSize(network *MultiLayerPerceptron, k int) (int, error) {
-	return mlpbase.MlpGetLayerSize(network.innerobj, k)
+	if err := checkLayer("MlpGetLayerSize", network, k); err != nil {
+		return -1, err
+	}
+	size, err := mlpbase.MlpGetLayerSize(network.innerobj, k)
+	if err != nil {
+		return -1, &ArgumentError{Op: "MlpGetLayerSize", Msg: err.Error()}
+	}
+	return size, nil
 }
 
 /*************************************************************************
@@ -319,10 +436,14 @@ before feeding to the network
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpGetInputScaling(network *MultiLayerPerceptron, i int) (mean, sigma float64) {
-	mean = 0.0
-	sigma = 0.0
-	mlpbase.MlpGetInputScaling(network.innerobj, i, &mean, &sigma)
+func MlpGetInputScaling(network *MultiLayerPerceptron, i int) (mean, sigma float64, err error) {
+	nin, _, _ := MlpProperties(network)
+	if err = checkIndex("MlpGetInputScaling", "input", i, nin); err != nil {
+		return
+	}
+	if e := mlpbase.MlpGetInputScaling(network.innerobj, i, &mean, &sigma); e != nil {
+		err = &ArgumentError{Op: "MlpGetInputScaling", Msg: e.Error()}
+	}
 	return
 }
 
@@ -346,10 +467,14 @@ we return (Mean,Sigma)=(0.0,1.0).
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpGetOutputScaling(network *MultiLayerPerceptron, i int) (mean, sigma float64) {
-	mean = 0.0
-	sigma = 0.0
-	mlpbase.MlpGetOutputScaling(network.innerobj, i, &mean, &sigma)
+func MlpGetOutputScaling(network *MultiLayerPerceptron, i int) (mean, sigma float64, err error) {
+	_, nout, _ := MlpProperties(network)
+	if err = checkIndex("MlpGetOutputScaling", "output", i, nout); err != nil {
+		return
+	}
+	if e := mlpbase.MlpGetOutputScaling(network.innerobj, i, &mean, &sigma); e != nil {
+		err = &ArgumentError{Op: "MlpGetOutputScaling", Msg: e.Error()}
+	}
 	return
 }
 
@@ -373,10 +498,13 @@ do not exists.
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpGetNeuronInfo(network *MultiLayerPerceptron, k, i int) (fkind int, threshold float64) {
-	fkind = 0
-	threshold = 0.0
-	mlpbase.MlpGetNeuronInfo(network.innerobj, k, i, &fkind, &threshold)
+func MlpGetNeuronInfo(network *MultiLayerPerceptron, k, i int) (fkind int, threshold float64, err error) {
+	if err = checkNeuron("MlpGetNeuronInfo", network, k, i); err != nil {
+		return
+	}
+	if e := mlpbase.MlpGetNeuronInfo(network.innerobj, k, i, &fkind, &threshold); e != nil {
+		err = &ArgumentError{Op: "MlpGetNeuronInfo", Msg: e.Error()}
+	}
 	return
 }
 
@@ -402,7 +530,17 @@ This function:
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
 func MlpGetWeight(network *MultiLayerPerceptron, k0, i0, k1, i1 int) (float64, error) {
-	return mlpbase.MlpGetWeight(network.innerobj, k0, i0, k1, i1)
+	if err := checkNeuron("MlpGetWeight", network, k0, i0); err != nil {
+		return 0, err
+	}
+	if err := checkNeuron("MlpGetWeight", network, k1, i1); err != nil {
+		return 0, err
+	}
+	w, err := mlpbase.MlpGetWeight(network.innerobj, k0, i0, k1, i1)
+	if err != nil {
+		return 0, &ArgumentError{Op: "MlpGetWeight", Msg: err.Error()}
+	}
+	return w, nil
 }
 
 /*************************************************************************
@@ -422,8 +560,15 @@ before feeding to the network. This function sets Mean and Sigma.
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpSetInputScaling(network *MultiLayerPerceptron, i int, mean, sigma float64) {
-	mlpbase.MlpSetInputScaling(network.innerobj, i, mean, sigma)
+func MlpSetInputScaling(network *MultiLayerPerceptron, i int, mean, sigma float64) error {
+	nin, _, _ := MlpProperties(network)
+	if err := checkIndex("MlpSetInputScaling", "input", i, nin); err != nil {
+		return err
+	}
+	if err := mlpbase.MlpSetInputScaling(network.innerobj, i, mean, sigma); err != nil {
+		return &ArgumentError{Op: "MlpSetInputScaling", Msg: err.Error()}
+	}
+	return nil
 }
 
 /*************************************************************************
@@ -447,8 +592,15 @@ other than(0.0,1.0) - this function will throw exception.
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpSetOutputScaling(network *MultiLayerPerceptron, i int, mean, sigma float64) {
-	mlpbase.MlpSetOutputScaling(network.innerobj, i, mean, sigma)
+func MlpSetOutputScaling(network *MultiLayerPerceptron, i int, mean, sigma float64) error {
+	_, nout, _ := MlpProperties(network)
+	if err := checkIndex("MlpSetOutputScaling", "output", i, nout); err != nil {
+		return err
+	}
+	if err := mlpbase.MlpSetOutputScaling(network.innerobj, i, mean, sigma); err != nil {
+		return &ArgumentError{Op: "MlpSetOutputScaling", Msg: err.Error()}
+	}
+	return nil
 }
 
 /*************************************************************************
@@ -477,8 +629,14 @@ NOTES:
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpSetNeuronInfo(network *MultiLayerPerceptron, k, i, fkind int, threshold float64) {
-	mlpbase.MlpSetNeuronInfo(network.innerobj, k, i, fkind, threshold)
+func MlpSetNeuronInfo(network *MultiLayerPerceptron, k, i, fkind int, threshold float64) error {
+	if err := checkNeuron("MlpSetNeuronInfo", network, k, i); err != nil {
+		return err
+	}
+	if err := mlpbase.MlpSetNeuronInfo(network.innerobj, k, i, fkind, threshold); err != nil {
+		return &ArgumentError{Op: "MlpSetNeuronInfo", Msg: err.Error()}
+	}
+	return nil
 }
 
 /*************************************************************************
@@ -502,8 +660,17 @@ This function:
   -- ALGLIB --
 	 Copyright 25.03.2011 by Bochkanov Sergey
 *************************************************************************/
-func MlpSetWeight(network *MultiLayerPerceptron, k0, i0, k1, i1 int, w float64) {
-	mlpbase.MlpSetWeight(network.innerobj, k0, i0, k1, i1, w)
+func MlpSetWeight(network *MultiLayerPerceptron, k0, i0, k1, i1 int, w float64) error {
+	if err := checkNeuron("MlpSetWeight", network, k0, i0); err != nil {
+		return err
+	}
+	if err := checkNeuron("MlpSetWeight", network, k1, i1); err != nil {
+		return err
+	}
+	if err := mlpbase.MlpSetWeight(network.innerobj, k0, i0, k1, i1, w); err != nil {
+		return &ArgumentError{Op: "MlpSetWeight", Msg: err.Error()}
+	}
+	return nil
 }
 
 /*************************************************************************
@@ -540,15 +707,25 @@ OUTPUT PARAMETERS:
 	Y       -   result. Regression estimate when solving regression  task,
 				vector of posterior probabilities for classification task.
 
+Network is only read, so it can be processed by the several goroutines at
+once, e.g. by the concurrent requests, as long as nobody trains or modifies
+it.
+
 See also MLPProcessI
 
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpProcess(network *MultiLayerPerceptron, x *[]float64) *[]float64 {
+func MlpProcess(network *MultiLayerPerceptron, x *[]float64) (*[]float64, error) {
+	nin, _, _ := MlpProperties(network)
+	if err := checkVector("MlpProcess", x, nin); err != nil {
+		return nil, err
+	}
 	y := make([]float64, 0)
-	mlpbase.MlpProcess(network.innerobj, x, &y)
-	return &y
+	if err := mlpbase.MlpProcessConcurrent(network.innerobj, x, &y); err != nil {
+		return nil, err
+	}
+	return &y, nil
 }
 
 /*************************************************************************
@@ -563,10 +740,8 @@ when you call it from command line.
   -- ALGLIB --
 	 Copyright 21.09.2010 by Bochkanov Sergey
 *************************************************************************/
-func MlpProcessI(network *MultiLayerPerceptron, x *[]float64) *[]float64 {
-	y := make([]float64, 0)
-	mlpbase.MlpProcessi(network.innerobj, x, &y)
-	return &y
+func MlpProcessI(network *MultiLayerPerceptron, x *[]float64) (*[]float64, error) {
+	return MlpProcess(network, x)
 }
 
 /*************************************************************************
@@ -575,7 +750,10 @@ Error function for neural network, internal subroutine.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpError(network *MultiLayerPerceptron, xy *[][]float64, ssize  int) float64 {
+func MlpError(network *MultiLayerPerceptron, xy *[][]float64, ssize int) (float64, error) {
+	if err := checkNetworkDataset("MlpError", network, xy, ssize, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpErrorN(network.innerobj, xy, ssize)
 }
 
@@ -585,7 +763,10 @@ Natural error function for neural network, internal subroutine.
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpErrorN(network *MultiLayerPerceptron, xy *[][]float64, ssize int) float64 {
+func MlpErrorN(network *MultiLayerPerceptron, xy *[][]float64, ssize int) (float64, error) {
+	if err := checkNetworkDataset("MlpErrorN", network, xy, ssize, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpErrorN(network.innerobj, xy, ssize)
 }
 
@@ -595,7 +776,10 @@ Classification error
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpClsError(network *MultiLayerPerceptron, xy *[][]float64, ssize int) int {
+func MlpClsError(network *MultiLayerPerceptron, xy *[][]float64, ssize int) (int, error) {
+	if err := checkNetworkDataset("MlpClsError", network, xy, ssize, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpClsError(network.innerobj, xy, ssize)
 }
 
@@ -615,7 +799,10 @@ RESULT:
   -- ALGLIB --
 	 Copyright 25.12.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpRelClsError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) float64 {
+func MlpRelClsError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkNetworkDataset("MlpRelClsError", network, xy, npoints, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpRelClsError(network.innerobj, xy, npoints)
 }
 
@@ -634,7 +821,10 @@ RESULT:
   -- ALGLIB --
 	 Copyright 08.01.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgce(network *MultiLayerPerceptron, xy *[][]float64, npoints  int) float64 {
+func MlpAvgce(network *MultiLayerPerceptron, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkNetworkDataset("MlpAvgce", network, xy, npoints, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpAvgce(network.innerobj, xy, npoints)
 }
 
@@ -655,7 +845,10 @@ RESULT:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpRmsError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) float64 {
+func MlpRmsError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkNetworkDataset("MlpRmsError", network, xy, npoints, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpRmsError(network.innerobj, xy, npoints)
 }
 
@@ -675,7 +868,10 @@ RESULT:
   -- ALGLIB --
 	 Copyright 11.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) float64 {
+func MlpAvgError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkNetworkDataset("MlpAvgError", network, xy, npoints, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpAvgError(network.innerobj, xy, npoints)
 }
 
@@ -695,7 +891,10 @@ RESULT:
   -- ALGLIB --
 	 Copyright 11.03.2008 by Bochkanov Sergey
 *************************************************************************/
-func MlpAvgRelError(network *MultiLayerPerceptron, xy *[][]float64, npoints  int) float64 {
+func MlpAvgRelError(network *MultiLayerPerceptron, xy *[][]float64, npoints int) (float64, error) {
+	if err := checkNetworkDataset("MlpAvgRelError", network, xy, npoints, 1); err != nil {
+		return 0, err
+	}
 	return mlpbase.MlpAvgRelError(network.innerobj, xy, npoints)
 }
 
@@ -718,9 +917,15 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpGrad(network *MultiLayerPerceptron, x, desiredy, grad *[]float64) (e float64) {
-	e = 0
-	mlpbase.MlpGrad(network.innerobj, x, desiredy, &e, grad)
+func MlpGrad(network *MultiLayerPerceptron, x, desiredy, grad *[]float64) (e float64, err error) {
+	nin, nout, _ := MlpProperties(network)
+	if err = checkVector("MlpGrad", x, nin); err != nil {
+		return
+	}
+	if err = checkVector("MlpGrad", desiredy, nout); err != nil {
+		return
+	}
+	err = mlpbase.MlpGrad(network.innerobj, x, desiredy, &e, grad)
 	return
 }
 
@@ -744,9 +949,15 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpGradN(network *MultiLayerPerceptron, x, desiredy, grad *[]float64) (e float64) {
-	e = 0
-	mlpbase.MlpGradn(network.innerobj, x, desiredy, &e, grad)
+func MlpGradN(network *MultiLayerPerceptron, x, desiredy, grad *[]float64) (e float64, err error) {
+	nin, nout, _ := MlpProperties(network)
+	if err = checkVector("MlpGradN", x, nin); err != nil {
+		return
+	}
+	if err = checkVector("MlpGradN", desiredy, nout); err != nil {
+		return
+	}
+	err = mlpbase.MlpGradn(network.innerobj, x, desiredy, &e, grad)
 	return
 }
 
@@ -771,9 +982,15 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpGradBatch(network *MultiLayerPerceptron, xy [][]float64, ssize int, grad *[]float64) (e float64) {
-	e = 0
-	mlpbase.MlpGradBatch(network.innerobj, xy, ssize, &e, grad)
+func MlpGradBatch(network *MultiLayerPerceptron, xy [][]float64, ssize int, grad *[]float64) (e float64, err error) {
+	if err = checkNetworkDataset("MlpGradBatch", network, &xy, ssize, 0); err != nil {
+		return
+	}
+	_, _, wcount := MlpProperties(network)
+	if len(*grad) < wcount {
+		*grad = make([]float64, wcount)
+	}
+	err = mlpbase.MlpGradBatch(network.innerobj, xy, ssize, &e, grad)
 	return
 }
 
@@ -800,9 +1017,15 @@ OUTPUT PARAMETERS:
   -- ALGLIB --
 	 Copyright 04.11.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpGradNBatch(network *MultiLayerPerceptron, xy [][]float64, ssize int, grad *[]float64) (e float64) {
-	e = 0
-	mlpbase.MlpGradNBatch(network.innerobj, xy, ssize, &e, grad)
+func MlpGradNBatch(network *MultiLayerPerceptron, xy [][]float64, ssize int, grad *[]float64) (e float64, err error) {
+	if err = checkNetworkDataset("MlpGradNBatch", network, &xy, ssize, 0); err != nil {
+		return
+	}
+	_, _, wcount := MlpProperties(network)
+	if len(*grad) < wcount {
+		*grad = make([]float64, wcount)
+	}
+	err = mlpbase.MlpGradNBatch(network.innerobj, xy, ssize, &e, grad)
 	return
 }
 
@@ -818,8 +1041,19 @@ Internal subroutine.
 	 B. A. Pearlmutter,
 	 Neural Computation, 1994.
 *************************************************************************/
-func MlpHessianNBatch(network *MultiLayerPerceptron, xy *[][]float64, ssize int, e *float64, grad *[]float64, h *[][]float64) {
-	mlpbase.MlpHessianNBatch(network.innerobj, xy, ssize, e, grad, h)
+func MlpHessianNBatch(network *MultiLayerPerceptron, xy *[][]float64, ssize int, grad *[]float64, h *[][]float64) (e float64, err error) {
+	if err = checkNetworkDataset("MlpHessianNBatch", network, xy, ssize, 0); err != nil {
+		return
+	}
+	_, _, wcount := MlpProperties(network)
+	if len(*grad) < wcount {
+		*grad = make([]float64, wcount)
+	}
+	if len(*h) < wcount || len((*h)[0]) < wcount {
+		*h = utils.MakeMatrixFloat64(wcount, wcount)
+	}
+	err = mlpbase.MlpHessianNBatch(network.innerobj, xy, ssize, &e, grad, h)
+	return
 }
 
 /*************************************************************************
@@ -834,8 +1068,19 @@ Internal subroutine.
 	 B. A. Pearlmutter,
 	 Neural Computation, 1994.
 *************************************************************************/
-func MlpHessianBatch(network *MultiLayerPerceptron, xy *[][]float64, ssize int, e *float64, grad *[]float64, h *[][]float64) {
-	mlpbase.MlpHessianBatch(network.innerobj, xy, ssize, e, grad, h)
+func MlpHessianBatch(network *MultiLayerPerceptron, xy *[][]float64, ssize int, grad *[]float64, h *[][]float64) (e float64, err error) {
+	if err = checkNetworkDataset("MlpHessianBatch", network, xy, ssize, 0); err != nil {
+		return
+	}
+	_, _, wcount := MlpProperties(network)
+	if len(*grad) < wcount {
+		*grad = make([]float64, wcount)
+	}
+	if len(*h) < wcount || len((*h)[0]) < wcount {
+		*h = utils.MakeMatrixFloat64(wcount, wcount)
+	}
+	err = mlpbase.MlpHessianBatch(network.innerobj, xy, ssize, &e, grad, h)
+	return
 }
 
 /*************************************************************************
@@ -857,23 +1102,32 @@ INPUT PARAMETERS:
 
 OUTPUT PARAMETERS:
 	Network     -   trained neural network.
-	Info        -   return code:
-					* -9, if internal matrix inverse subroutine failed
-					* -2, if there is a point with class number
-						  outside of [0..NOut-1].
-					* -1, if wrong parameters specified
-						  (NPoints<0, Restarts<1).
-					*  2, if task has been solved.
-	Rep         -   training report
+	Rep         -   training report, termination reason is
+					TerminationConverged if task has been solved.
+	Err         -   *ShapeError if there is a point with class number
+					outside of [0..NOut-1] or XY is too short,
+					*NonFiniteError if XY contains NaN or Inf,
+					*ArgumentError if wrong parameters specified
+					(NPoints<1, Restarts<1, Decay<0),
+					*ConvergenceError if internal matrix inverse
+					subroutine failed.
 
   -- ALGLIB --
 	 Copyright 10.03.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpTrainLm(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int) (int, *MlpReport) {
+func MlpTrainLm(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int) (*MlpReport, error) {
+	if err := checkNetworkDataset("MlpTrainLm", network, xy, npoints, 1); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpTrainLm", decay, restarts); err != nil {
+		return nil, err
+	}
 	info := 0
-	rep := MlpReport{}
-	mlptrain.MlpTrainLm(network.innerobj, xy, npoints, decay, restarts, &info, rep.innerObj)
-	return info, &rep
+	rep := NewMlpReport()
+	if err := mlptrain.MlpTrainLm(network.innerobj, xy, npoints, decay, restarts, &info, rep.innerObj); err != nil {
+		return nil, err
+	}
+	return rep.terminated("MlpTrainLm", info)
 }
 
 /*************************************************************************
@@ -901,25 +1155,34 @@ INPUT PARAMETERS:
 
 OUTPUT PARAMETERS:
 	Network     -   trained neural network.
-	Info        -   return code:
-					* -8, if both WStep=0 and MaxIts=0
-					* -2, if there is a point with class number
-						  outside of [0..NOut-1].
-					* -1, if wrong parameters specified
-						  (NPoints<0, Restarts<1).
-					*  2, if task has been solved.
-	Rep         -   training report
+	Rep         -   training report, termination reason is
+					TerminationConverged if task has been solved.
+	Err         -   *ShapeError if there is a point with class number
+					outside of [0..NOut-1] or XY is too short,
+					*NonFiniteError if XY contains NaN or Inf,
+					*ArgumentError if wrong parameters specified
+					(NPoints<1, Restarts<1, Decay<0) or if both
+					WStep=0 and MaxIts=0.
 
   -- ALGLIB --
 	 Copyright 09.12.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpTrainLbfgs(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int) (int, *MlpReport, error) {
+func MlpTrainLbfgs(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int) (*MlpReport, error) {
+	if err := checkNetworkDataset("MlpTrainLbfgs", network, xy, npoints, 1); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpTrainLbfgs", decay, restarts); err != nil {
+		return nil, err
+	}
+	if err := checkLbfgsParams("MlpTrainLbfgs", wstep, maxits); err != nil {
+		return nil, err
+	}
 	info := 0
 	rep := NewMlpReport()
 	if err := mlptrain.MlpTrainLbfgs(network.innerobj, xy, npoints, decay, restarts, wstep, maxits, &info, rep.innerObj); err != nil {
-		return 0, nil, err
+		return nil, err
 	}
-	return info, rep, nil
+	return rep.terminated("MlpTrainLbfgs", info)
 }
 
 /*************************************************************************
@@ -941,18 +1204,18 @@ INPUT PARAMETERS:
 
 OUTPUT PARAMETERS:
 	Network     -   trained neural network.
-	Info        -   return code:
-					* -2, if there is a point with class number
-						  outside of [0..NOut-1].
-					* -1, if wrong parameters specified
-						  (NPoints<0, Restarts<1, ...).
-					*  2, task has been solved, stopping  criterion  met -
-						  sufficiently small step size.  Not expected  (we
-						  use  EARLY  stopping)  but  possible  and not an
-						  error.
-					*  6, task has been solved, stopping  criterion  met -
-						  increasing of validation set error.
-	Rep         -   training report
+	Rep         -   training report, termination reason is:
+					* TerminationConverged, stopping criterion met -
+					  sufficiently small step size.  Not expected  (we
+					  use  EARLY  stopping)  but  possible  and not an
+					  error.
+					* TerminationEarlyStopping, stopping criterion met -
+					  increasing of validation set error.
+	Err         -   *ShapeError if there is a point with class number
+					outside of [0..NOut-1] or a set is too short,
+					*NonFiniteError if a set contains NaN or Inf,
+					*ArgumentError if wrong parameters specified
+					(NPoints<1, Restarts<1, Decay<0).
 
 NOTE:
 
@@ -964,11 +1227,22 @@ minimum of validation set error.
   -- ALGLIB --
 	 Copyright 10.03.2009 by Bochkanov Sergey
 *************************************************************************/
-func MlpTrainEs(network *MultiLayerPerceptron, trnxy [][]float64, trnsize int, valxy *[][]float64, valsize int, decay float64, restarts  int) (int, *MlpReport) {
+func MlpTrainEs(network *MultiLayerPerceptron, trnxy [][]float64, trnsize int, valxy *[][]float64, valsize int, decay float64, restarts int) (*MlpReport, error) {
+	if err := checkNetworkDataset("MlpTrainEs", network, &trnxy, trnsize, 1); err != nil {
+		return nil, err
+	}
+	if err := checkNetworkDataset("MlpTrainEs", network, valxy, valsize, 1); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpTrainEs", decay, restarts); err != nil {
+		return nil, err
+	}
 	info := 0
-	rep := MlpReport{}
-	mlptrain.MlpTraines(network.innerobj, trnxy, trnsize, valxy, valsize, decay, restarts, &info, rep.innerObj)
-	return info, &rep
+	rep := NewMlpReport()
+	if err := mlptrain.MlpTraines(network.innerobj, trnxy, trnsize, valxy, valsize, decay, restarts, &info, rep.innerObj); err != nil {
+		return nil, err
+	}
+	return rep.terminated("MlpTrainEs", info)
 }
 
 /*************************************************************************
@@ -993,19 +1267,32 @@ func MlpTrainEs(network *MultiLayerPerceptron, trnxy [][]float64, trnsize int, v
                         recommended value: 10.
 
     OUTPUT PARAMETERS:
-        Info        -   return code, same as in MLPTrainLBFGS
-        Rep         -   report, same as in MLPTrainLM/MLPTrainLBFGS
+        Rep         -   report, same as in MLPTrainLM/MLPTrainLBFGS,
+                        termination reason is TerminationCompleted
         CVRep       -   generalization error estimates
+        Err         -   same as in MLPTrainLBFGS, *ArgumentError
+                        if FoldsCount is out of range
 
       -- ALGLIB --
          Copyright 09.12.2007 by Bochkanov Sergey
     *************************************************************************/
-func MlpKfoldCvLbfgs(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits, foldscount int) (int, *MlpReport, *MlpCvReport) {
+func MlpKfoldCvLbfgs(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits, foldscount int) (*MlpReport, *MlpCvReport, error) {
+	if err := checkKfold("MlpKfoldCvLbfgs", network, xy, npoints, decay, restarts, foldscount); err != nil {
+		return nil, nil, err
+	}
+	if err := checkLbfgsParams("MlpKfoldCvLbfgs", wstep, maxits); err != nil {
+		return nil, nil, err
+	}
 	info := 0
-	rep := MlpReport{}
-	cvrep := MlpCvReport{}
-	mlptrain.Mlpkfoldcvlbfgs(network.innerobj, xy, npoints, decay, restarts, wstep, maxits, foldscount, &info, rep.innerObj, cvrep.innerObj);
-	return info, &rep, &cvrep
+	rep := NewMlpReport()
+	cvrep := NewMlpCvReport()
+	if err := mlptrain.Mlpkfoldcvlbfgs(network.innerobj, xy, npoints, decay, restarts, wstep, maxits, foldscount, &info, rep.innerObj, cvrep.innerObj); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rep.terminated("MlpKfoldCvLbfgs", info); err != nil {
+		return nil, nil, err
+	}
+	return rep, cvrep, nil
 }
 
 /*************************************************************************
@@ -1028,17 +1315,27 @@ INPUT PARAMETERS:
 					recommended value: 10.
 
 OUTPUT PARAMETERS:
-	Info        -   return code, same as in MLPTrainLBFGS
-	Rep         -   report, same as in MLPTrainLM/MLPTrainLBFGS
+	Rep         -   report, same as in MLPTrainLM/MLPTrainLBFGS,
+					termination reason is TerminationCompleted
 	CVRep       -   generalization error estimates
+	Err         -   same as in MLPTrainLM, *ArgumentError
+					if FoldsCount is out of range
 
   -- ALGLIB --
 	 Copyright 09.12.2007 by Bochkanov Sergey
 *************************************************************************/
-func MlpKfoldCvLm(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int) (int, *MlpReport, *MlpCvReport) {
+func MlpKfoldCvLm(network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts, foldscount int) (*MlpReport, *MlpCvReport, error) {
+	if err := checkKfold("MlpKfoldCvLm", network, xy, npoints, decay, restarts, foldscount); err != nil {
+		return nil, nil, err
+	}
 	info := 0
-	rep := MlpReport{}
-	cvrep := MlpCvReport{}
-	mlptrain.Mlpkfoldcvlm(network.innerobj, xy, npoints, decay, restarts, foldscount, &info, rep.innerObj, cvrep.innerObj)
-	return info, &rep, &cvrep
-}
\ No newline at end of file
+	rep := NewMlpReport()
+	cvrep := NewMlpCvReport()
+	if err := mlptrain.Mlpkfoldcvlm(network.innerobj, xy, npoints, decay, restarts, foldscount, &info, rep.innerObj, cvrep.innerObj); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rep.terminated("MlpKfoldCvLm", info); err != nil {
+		return nil, nil, err
+	}
+	return rep, cvrep, nil
+}
@@ -2,7 +2,7 @@ package neural_test
 import (
 	"testing"
 	"fmt"
-	"../neural"
+	"pr.optima/src/core/neural"
 )
 
 
@@ -29,21 +29,25 @@ func TestNeuron(t *testing.T) {
 	process3 := dataF[len(dataF) - step:]
 
 	// create
-	mlp := neural.MlpCreate1(step, step, 1)
+	mlp, err := neural.MlpCreate1(step, step, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// train
-	info, _, err := neural.MlpTrainLbfgs(mlp, &train, limit, 0.001, 2, 0.01, 0)
+	rep, err := neural.MlpTrainLbfgs(mlp, &train, limit, 0.001, 2, 0.01, 0)
 	if err != nil {
 		t.Errorf("test error %v\n", err)
-	}else if info != 2 {
-		t.Errorf("info param %d", info)
+	}else if rep.GetTerminationReason() != neural.TerminationConverged {
+		t.Errorf("termination reason %v", rep.GetTerminationReason())
 	}else {
 		// get result
-		result := neural.MlpProcess(mlp, &process)
-		fmt.Printf("nueral result: %v\n", *result)
-		result = neural.MlpProcess(mlp, &process2)
-		fmt.Printf("nueral result: %v\n", *result)
-		result = neural.MlpProcess(mlp, &process3)
-		fmt.Printf("nueral result: %v\n", *result)
+		for _, x := range []*[]float64{&process, &process2, &process3} {
+			result, err := neural.MlpProcess(mlp, x)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Printf("nueral result: %v\n", *result)
+		}
 	}
 }
\ No newline at end of file
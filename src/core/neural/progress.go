@@ -0,0 +1,91 @@
+package neural
+
+import (
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/neural/mlptrain"
+)
+
+// TrainProgress - state of the training after the iteration of the optimizer
+type TrainProgress struct {
+	Pass      int     // restart from the random position, starting from 1
+	Iteration int     // iteration within the pass, 0 for the starting point
+	Error     float64 // error function with the weight decay term
+	GradNorm  float64 // norm of the gradient of the error function
+}
+
+// ProgressFunc - progress callback of the training, training is stopped when it returns false
+type ProgressFunc func(p TrainProgress) bool
+
+// IterationLimit return progress callback stopping the training after n iterations of all the passes
+func IterationLimit(n int) ProgressFunc {
+	count := 0
+	return func(p TrainProgress) bool {
+		if p.Iteration == 0 {
+			return true
+		}
+		count++
+		return count < n
+	}
+}
+
+func newMonitor(ctx context.Context, progress ProgressFunc) *mlptrain.Monitor {
+	monitor := &mlptrain.Monitor{Done: ctx.Done()}
+	if progress != nil {
+		monitor.Progress = func(p mlptrain.Progress) bool {
+			return progress(TrainProgress{Pass: p.Pass, Iteration: p.Iteration, Error: p.Error, GradNorm: p.GradNorm})
+		}
+	}
+	return monitor
+}
+
+// stopped convert return code of the monitored training, cancelled training
+// returns the report together with the error of the context
+func (r *MlpReport) stopped(ctx context.Context, op string, info int) (*MlpReport, error) {
+	rep, err := r.terminated(op, info)
+	if err != nil {
+		return nil, err
+	}
+	if rep.termination == TerminationStopped && ctx.Err() != nil {
+		return rep, ctx.Err()
+	}
+	return rep, nil
+}
+
+// MlpTrainLmContext - MlpTrainLm observed by the progress callback (may be nil) and cancelled by
+// the context. Cancelled or stopped training leaves in the network the best weights found so far
+// and sets TerminationStopped reason, cancellation also returns the error of the context.
+func MlpTrainLmContext(ctx context.Context, network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, progress ProgressFunc) (*MlpReport, error) {
+	if err := checkNetworkDataset("MlpTrainLmContext", network, xy, npoints, 1); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpTrainLmContext", decay, restarts); err != nil {
+		return nil, err
+	}
+	info := 0
+	rep := NewMlpReport()
+	if err := mlptrain.MlpTrainLmMonitored(network.innerobj, xy, npoints, decay, restarts, &info, rep.innerObj, newMonitor(ctx, progress)); err != nil {
+		return nil, err
+	}
+	return rep.stopped(ctx, "MlpTrainLmContext", info)
+}
+
+// MlpTrainLbfgsContext - MlpTrainLbfgs observed by the progress callback (may be nil) and cancelled
+// by the context, result of the cancelled or stopped training is the same as of MlpTrainLmContext.
+func MlpTrainLbfgsContext(ctx context.Context, network *MultiLayerPerceptron, xy *[][]float64, npoints int, decay float64, restarts int, wstep float64, maxits int, progress ProgressFunc) (*MlpReport, error) {
+	if err := checkNetworkDataset("MlpTrainLbfgsContext", network, xy, npoints, 1); err != nil {
+		return nil, err
+	}
+	if err := checkTrainParams("MlpTrainLbfgsContext", decay, restarts); err != nil {
+		return nil, err
+	}
+	if err := checkLbfgsParams("MlpTrainLbfgsContext", wstep, maxits); err != nil {
+		return nil, err
+	}
+	info := 0
+	rep := NewMlpReport()
+	if err := mlptrain.MlpTrainLbfgsMonitored(network.innerobj, xy, npoints, decay, restarts, wstep, maxits, &info, rep.innerObj, newMonitor(ctx, progress)); err != nil {
+		return nil, err
+	}
+	return rep.stopped(ctx, "MlpTrainLbfgsContext", info)
+}
@@ -0,0 +1,95 @@
+package neural_test
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/neural"
+)
+
+func TestTrainProgress(t *testing.T) {
+	xy := regressionSet(40)
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []neural.TrainProgress
+	rep, err := neural.MlpTrainLbfgsContext(context.Background(), mlp, &xy, len(xy), 0.001, 2, 0.01, 0, func(p neural.TrainProgress) bool {
+		reported = append(reported, p)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.GetTerminationReason() != neural.TerminationConverged {
+		t.Errorf("termination reason %v", rep.GetTerminationReason())
+	}
+	if len(reported) == 0 || reported[len(reported)-1].Pass != 2 {
+		t.Fatalf("progress of the both passes is not reported: %d reports", len(reported))
+	}
+	for i, p := range reported {
+		if math.IsNaN(p.Error) || math.IsNaN(p.GradNorm) || p.GradNorm < 0 {
+			t.Fatalf("report %d: %+v", i, p)
+		}
+		if i > 0 && p.Pass == reported[i-1].Pass && (p.Iteration != reported[i-1].Iteration+1 || p.Error > reported[i-1].Error) {
+			t.Fatalf("report %d: %+v after %+v", i, p, reported[i-1])
+		}
+	}
+}
+
+func TestTrainCancel(t *testing.T) {
+	xy := regressionSet(40)
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	last := math.MaxFloat64
+	rep, err := neural.MlpTrainLmContext(ctx, mlp, &xy, len(xy), 0.001, 2, func(p neural.TrainProgress) bool {
+		last = p.Error
+		if p.Iteration == 3 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("error %v, expected %v", err, context.Canceled)
+	}
+	if rep == nil || rep.GetTerminationReason() != neural.TerminationStopped {
+		t.Fatalf("report %+v", rep)
+	}
+	// weights of the last iteration are kept, error without the decay term is smaller
+	if e, err := neural.MlpError(mlp, &xy, len(xy)); err != nil || e > last {
+		t.Errorf("error %v of the network, reported %v (%v)", e, last, err)
+	}
+
+	// cancelled before the start
+	if _, err := neural.MlpTrainLbfgsContext(ctx, mlp, &xy, len(xy), 0.001, 2, 0.01, 0, nil); err != context.Canceled {
+		t.Errorf("error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestIterationLimit(t *testing.T) {
+	xy := regressionSet(40)
+	mlp, err := neural.MlpCreate1(2, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := neural.IterationLimit(5)
+	iterations := 0
+	rep, err := neural.MlpTrainLbfgsContext(context.Background(), mlp, &xy, len(xy), 0.001, 2, 0, 100, func(p neural.TrainProgress) bool {
+		if p.Iteration > 0 {
+			iterations++
+		}
+		return limit(p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.GetTerminationReason() != neural.TerminationStopped || iterations != 5 {
+		t.Errorf("termination reason %v after %d iterations", rep.GetTerminationReason(), iterations)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"math"
 )
 
+// MachineEpsilon - relative precision of float64 used by the ALGLIB ports in the tolerances
+const MachineEpsilon = 5E-16
+
 func MakeMatrixFloat64(n, m int) [][]float64 {
 	result := make([][]float64, n)
 	for i := range result {
@@ -0,0 +1,115 @@
+package pca
+
+import (
+	"fmt"
+
+	"pr.optima/src/core/neural/utils"
+	"pr.optima/src/core/svd"
+)
+
+/*************************************************************************
+Principal components analysis
+
+Subroutine  builds  orthogonal  basis  where  first  axis  corresponds  to
+direction with maximum variance, second axis maximizes variance in subspace
+orthogonal to first axis and so on.
+
+It should be noted that, unlike LDA, PCA does not use class labels.
+
+INPUT PARAMETERS:
+	X           -   dataset, array[0..NPoints-1,0..NVars-1].
+					matrix contains ONLY INDEPENDENT VARIABLES.
+	NPoints     -   dataset size, NPoints>=0
+	NVars       -   number of independent variables, NVars>=1
+
+OUTPUT PARAMETERS:
+	Info        -   return code:
+					* -4, if SVD subroutine haven't converged
+					* -1, if wrong parameters has been passed (NPoints<0,
+						  NVars<1)
+					*  1, if task is solved
+	S2          -   array[0..NVars-1]. variance values corresponding
+					to basis vectors.
+	V           -   array[0..NVars-1,0..NVars-1]
+					matrix, whose columns store basis vectors.
+
+  -- ALGLIB --
+	 Copyright 25.08.2008 by Bochkanov Sergey
+*************************************************************************/
+func PcaBuildBasis(x *[][]float64, npoints, nvars int, info *int, s2 *[]float64, v *[][]float64) error {
+	var u, vt [][]float64
+
+	*info = 0
+	*s2 = []float64{}
+	*v = [][]float64{}
+
+	//
+	// Check input data
+	//
+	if npoints < 0 || nvars < 1 {
+		*info = -1
+		return nil
+	}
+	if len(*x) < npoints {
+		return fmt.Errorf("PCABuildBasis: rows count %d less than NPoints %d", len(*x), npoints)
+	}
+	for i := 0; i <= npoints-1; i++ {
+		if len((*x)[i]) < nvars {
+			return fmt.Errorf("PCABuildBasis: row %d length %d less than NVars", i, len((*x)[i]))
+		}
+	}
+	*info = 1
+
+	//
+	// Special case: NPoints=0
+	//
+	if npoints == 0 {
+		*s2 = make([]float64, nvars)
+		*v = utils.MakeMatrixFloat64(nvars, nvars)
+		for i := 0; i <= nvars-1; i++ {
+			(*v)[i][i] = 1
+		}
+		return nil
+	}
+
+	//
+	// Calculate means
+	//
+	m := make([]float64, nvars)
+	for j := 0; j <= nvars-1; j++ {
+		for i := 0; i <= npoints-1; i++ {
+			m[j] = m[j] + (*x)[i][j]
+		}
+		m[j] = m[j] / float64(npoints)
+	}
+
+	//
+	// Center, apply SVD, prepare output
+	//
+	rows := npoints
+	if nvars > rows {
+		rows = nvars
+	}
+	a := utils.MakeMatrixFloat64(rows, nvars)
+	for i := 0; i <= npoints-1; i++ {
+		for i_ := 0; i_ <= nvars-1; i_++ {
+			a[i][i_] = (*x)[i][i_] - m[i_]
+		}
+	}
+	if !svd.RMatrixSvd(&a, rows, nvars, s2, &u, &vt) {
+		*info = -4
+		return nil
+	}
+	if npoints != 1 {
+		for i := 0; i <= nvars-1; i++ {
+			(*s2)[i] = utils.SqrFloat64((*s2)[i]) / float64(npoints-1)
+		}
+	}
+	*v = utils.MakeMatrixFloat64(nvars, nvars)
+	for i := 0; i <= nvars-1; i++ {
+		for j := 0; j <= nvars-1; j++ {
+			(*v)[i][j] = vt[j][i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pca_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/pca"
+)
+
+func TestBuildBasis(t *testing.T) {
+	// common factor along (1, 1, 1) with std 2, independent noise with std 0.1
+	x := make([][]float64, 500)
+	for i := range x {
+		factor := rand.NormFloat64() * 2
+		x[i] = []float64{5 + factor + rand.NormFloat64()*0.1, factor + rand.NormFloat64()*0.1, -3 + factor + rand.NormFloat64()*0.1}
+	}
+	var s2 []float64
+	var v [][]float64
+	info := 0
+	if err := pca.PcaBuildBasis(&x, len(x), 3, &info, &s2, &v); err != nil || info != 1 {
+		t.Fatalf("info: %d, error: %v", info, err)
+	}
+	// the variance of the factor direction is 3 * 4, other directions have the noise only
+	if math.Abs(s2[0]-12) > 3 || s2[1] > 0.02 || s2[2] > s2[1] {
+		t.Errorf("variances: %v", s2)
+	}
+	for i := 0; i < 3; i++ {
+		if math.Abs(math.Abs(v[i][0])-1/math.Sqrt(3)) > 0.01 {
+			t.Errorf("first basis vector: %v, %v, %v", v[0][0], v[1][0], v[2][0])
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			var r float64
+			for k := 0; k < 3; k++ {
+				r += v[k][i] * v[k][j]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(r-expected) > 1e-12 {
+				t.Fatalf("basis is not orthonormal: %v", v)
+			}
+		}
+	}
+}
+
+func TestSpecialCases(t *testing.T) {
+	var s2 []float64
+	var v [][]float64
+	info := 0
+	x := [][]float64{}
+	if err := pca.PcaBuildBasis(&x, 0, 2, &info, &s2, &v); err != nil || info != 1 || v[0][0] != 1 || v[1][1] != 1 || s2[0] != 0 {
+		t.Errorf("empty set, info: %d, variances: %v, basis: %v, error: %v", info, s2, v, err)
+	}
+	// fewer points than variables
+	x = [][]float64{{1, 2, 3}, {3, 2, 1}}
+	if err := pca.PcaBuildBasis(&x, len(x), 3, &info, &s2, &v); err != nil || info != 1 || math.Abs(s2[0]-4) > 1e-12 || s2[1] > 1e-12 {
+		t.Errorf("two points, info: %d, variances: %v, error: %v", info, s2, err)
+	}
+	if err := pca.PcaBuildBasis(&x, len(x), 0, &info, &s2, &v); err != nil || info != -1 {
+		t.Errorf("no variables, info: %d, error: %v", info, err)
+	}
+	if err := pca.PcaBuildBasis(&x, len(x), 4, &info, &s2, &v); err == nil {
+		t.Error("short rows accepted")
+	}
+}
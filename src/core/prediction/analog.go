@@ -0,0 +1,213 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/kdtree"
+)
+
+// TTAnalog - nearest train windows (analogs) of the current window, explainable forecast
+// by the classes which followed the similar past hours
+const TTAnalog = "ANALOG"
+
+// AnalogParams - hyperparameters of the analog forecaster
+type AnalogParams struct {
+	Neighbours int // count of the nearest train windows
+}
+
+func init() {
+	RegisterPredictor(TTAnalog, newAnalogPredictor, TrainParams{Analog: AnalogParams{Neighbours: 10}})
+}
+
+// Analog - train window similar to the predicted one
+type Analog struct {
+	Row      int     // index of the window in the train set, the windows are in the order of time
+	Distance float64 // euclidean distance to the predicted window
+	Class    int     // class which followed the window
+}
+
+// AnalogFinder - predictor explaining the forecast by the similar train windows
+type AnalogFinder interface {
+	Analogs(x []float64) ([]Analog, error)
+}
+
+// analogPredictor - KD-tree (core/kdtree) of the train windows, the output is the distribution
+// of the classes which followed the Neighbours nearest windows, the query state is kept
+// in the tree, so the predictor isn't safe for concurrent use
+type analogPredictor struct {
+	spec PredictorSpec
+	kdt  *kdtree.KdTree
+	xy   [][]float64 // train windows followed by the class, kept for the serialization
+}
+
+func newAnalogPredictor(spec PredictorSpec) (Predictor, error) {
+	if spec.Horizon != 1 {
+		return nil, errors.New("analog forecaster predicts single step only")
+	}
+	if spec.Inputs < 1 {
+		return nil, fmt.Errorf("inputs count: %d must be positive", spec.Inputs)
+	}
+	if spec.RangeCount < 2 {
+		return nil, errors.New("classes count must be more than 1")
+	}
+	return &analogPredictor{spec: spec}, nil
+}
+
+// Fit build the tree of the train windows
+func (f *analogPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	if params.Analog.Neighbours < 1 {
+		return fmt.Errorf("neighbours count: %d must be positive value", params.Analog.Neighbours)
+	}
+	xy := make([][]float64, len(dataset.Train))
+	for i, row := range dataset.Train {
+		if len(row) != f.spec.Inputs+1 {
+			return fmt.Errorf("row %d length: %d, expected inputs: %d and the output", i, len(row), f.spec.Inputs)
+		}
+		class := row[f.spec.Inputs]
+		if class != math.Floor(class) || class < 0 || int(class) >= f.spec.RangeCount {
+			return fmt.Errorf("row %d class: %v out of range [0, %d)", i, class, f.spec.RangeCount)
+		}
+		xy[i] = append([]float64{}, row...)
+	}
+	kdt, err := f.build(xy)
+	if err != nil {
+		return err
+	}
+	f.kdt = kdt
+	f.xy = xy
+	f.spec.Params = params
+	return nil
+}
+
+// Predict return part of the analogs followed by each class
+func (f *analogPredictor) Predict(x []float64) ([]float64, error) {
+	analogs, err := f.Analogs(x)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, f.spec.RangeCount)
+	for _, analog := range analogs {
+		result[analog.Class]++
+	}
+	for i := range result {
+		result[i] /= float64(len(analogs))
+	}
+	return result, nil
+}
+
+// Analogs return the nearest train windows ordered by the distance
+func (f *analogPredictor) Analogs(x []float64) ([]Analog, error) {
+	if f.kdt == nil {
+		return nil, errors.New("analog forecaster is not trained")
+	}
+	if len(x) != f.spec.Inputs {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), f.spec.Inputs)
+	}
+	k, err := kdtree.KdTreeQueryKnn(f.kdt, x, f.spec.Params.Analog.Neighbours, true)
+	if err != nil {
+		return nil, err
+	}
+	if k == 0 {
+		return nil, errors.New("empty train set")
+	}
+	var rows []int
+	var distances []float64
+	kdtree.KdTreeQueryResultsTags(f.kdt, &rows)
+	kdtree.KdTreeQueryResultsDistances(f.kdt, &distances)
+	result := make([]Analog, k)
+	for i := range result {
+		result[i] = Analog{Row: rows[i], Distance: distances[i], Class: int(f.xy[rows[i]][f.spec.Inputs])}
+	}
+	return result, nil
+}
+
+// NetType return NTClassifier, the output is the distribution of the classes
+func (f *analogPredictor) NetType() string {
+	return NTClassifier
+}
+
+// Serialize return sizes followed by the train windows and their classes
+func (f *analogPredictor) Serialize() ([]float64, error) {
+	if f.kdt == nil {
+		return nil, errors.New("analog forecaster is not trained")
+	}
+	result := []float64{float64(f.spec.Inputs), float64(f.spec.RangeCount), float64(f.spec.Params.Analog.Neighbours), float64(len(f.xy))}
+	for _, row := range f.xy {
+		result = append(result, row...)
+	}
+	return result, nil
+}
+
+// Load rebuild the tree of the serialized windows of the same inputs and classes
+func (f *analogPredictor) Load(ra []float64) error {
+	if len(ra) < 4 {
+		return errors.New("incorrect array!")
+	}
+	nvars, nclasses, neighbours, npoints := int(ra[0]), int(ra[1]), int(ra[2]), int(ra[3])
+	if nvars != f.spec.Inputs || nclasses != f.spec.RangeCount {
+		return fmt.Errorf("analogs %d-%d don't match the predictor %d-%d", nvars, nclasses, f.spec.Inputs, f.spec.RangeCount)
+	}
+	if neighbours < 1 || npoints < 0 || len(ra) != 4+npoints*(nvars+1) {
+		return errors.New("incorrect array!")
+	}
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		xy[i] = append([]float64{}, ra[4+i*(nvars+1):4+(i+1)*(nvars+1)]...)
+		if class := xy[i][nvars]; class != math.Floor(class) || class < 0 || int(class) >= nclasses {
+			return errors.New("incorrect array!")
+		}
+	}
+	kdt, err := f.build(xy)
+	if err != nil {
+		return err
+	}
+	f.kdt = kdt
+	f.xy = xy
+	f.spec.Params.Analog.Neighbours = neighbours
+	return nil
+}
+
+// Describe return size of the model and count of the analogs
+func (f *analogPredictor) Describe() string {
+	return fmt.Sprintf("Analogs %d-%d, %d nearest of %d windows", f.spec.Inputs, f.spec.RangeCount, f.spec.Params.Analog.Neighbours, len(f.xy))
+}
+
+// RmsError return rms error of the probabilities of the classes, the same as of the classifier network
+func (f *analogPredictor) RmsError(xy [][]float64) (float64, error) {
+	if len(xy) == 0 {
+		return 0, errors.New("empty set")
+	}
+	var sum float64
+	for i, row := range xy {
+		if len(row) != f.spec.Inputs+1 {
+			return 0, fmt.Errorf("row %d length: %d, expected inputs: %d and the class", i, len(row), f.spec.Inputs)
+		}
+		p, err := f.Predict(row[:f.spec.Inputs])
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %v", i, err)
+		}
+		class := int(row[f.spec.Inputs])
+		for j, item := range p {
+			if j == class {
+				item--
+			}
+			sum += item * item
+		}
+	}
+	return math.Sqrt(sum / float64(len(xy)*f.spec.RangeCount)), nil
+}
+
+// build return euclidean tree of the windows tagged by the row index
+func (f *analogPredictor) build(xy [][]float64) (*kdtree.KdTree, error) {
+	tags := make([]int, len(xy))
+	for i := range tags {
+		tags[i] = i
+	}
+	kdt := kdtree.NewKdTree()
+	if err := kdtree.KdTreeBuildTagged(&xy, tags, len(xy), f.spec.Inputs, 0, 2, kdt); err != nil {
+		return nil, err
+	}
+	return kdt, nil
+}
@@ -0,0 +1,110 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pr.optima/src/core/entities"
+)
+
+// BacktestReport - summary of the rate history replayed through the engine
+type BacktestReport struct {
+	Model       string
+	Symbol      string
+	From        int64 // timestamp of the first processed rate
+	To          int64 // timestamp of the last processed rate
+	Steps       int   // count of the processed rates
+	Predictions int   // count of the stored predictions
+	Retrains    int   // count of the network trainings
+	Failures    int   // count of the steps finished with error
+	Efficiency  entities.Efficiency
+}
+
+// ToString method
+func (f *BacktestReport) ToString() string {
+	lines := []string{fmt.Sprintf("Backtest {: Model: %s, Symbol: %s, From: %v, To: %v, Steps: %d, Predictions: %d, Retrains: %d, Failures: %d }",
+		f.Model,
+		f.Symbol,
+		time.Unix(f.From, 0).UTC(),
+		time.Unix(f.To, 0).UTC(),
+		f.Steps,
+		f.Predictions,
+		f.Retrains,
+		f.Failures),
+		f.Efficiency.ToString()}
+	for _, horizon := range f.Efficiency.Horizons {
+		lines = append(lines, fmt.Sprintf("\tHorizon: %d, HitRate: %v, DirectionRate: %v",
+			horizon,
+			f.Efficiency.GetHorizonHitRate(horizon),
+			f.Efficiency.GetHorizonDirectionRate(horizon)))
+	}
+	for regime := range f.Efficiency.RegimeCount {
+		lines = append(lines, fmt.Sprintf("\tRegime: %d, Count: %d, HitRate: %v, DirectionRate: %v",
+			regime,
+			f.Efficiency.RegimeCount[regime],
+			f.Efficiency.GetRegimeHitRate(int32(regime)),
+			f.Efficiency.GetRegimeDirectionRate(int32(regime))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Backtest replay rates hour by hour through the engine as the live job does:
+// each step gets the newest window rates (size of the rates repo) and
+// the step is processed when the engine limit is less than the count of the rates.
+// Results and efficiency are written to the stores, errors of the steps are logged and counted.
+func Backtest(engine *Engine, rates []entities.Rate, window int, results ResultStore, efficiency EfficiencyStore, logf Logger) (*BacktestReport, error) {
+	if window < engine.Limit+1 {
+		return nil, fmt.Errorf("window: %d must be more than engine limit: %d", window, engine.Limit)
+	}
+	if len(rates) <= engine.Limit {
+		return nil, errors.New("rates count must be more than engine limit")
+	}
+
+	history := make([]entities.Rate, len(rates))
+	copy(history, rates)
+	SortRates(history)
+
+	report := &BacktestReport{Model: engine.Model(), Symbol: engine.Symbol()}
+	retrains := engine.Retrains()
+	for i := range history {
+		start := i + 1 - window
+		if start < 0 {
+			start = 0
+		}
+		current := history[start : i+1]
+		if engine.Limit >= len(current) {
+			continue
+		}
+		if report.Steps == 0 {
+			report.From = history[i].ID
+		}
+		report.To = history[i].ID
+		report.Steps++
+
+		if _, err := engine.Process(current, results, efficiency, logf); err != nil {
+			report.Failures++
+			if logf != nil {
+				logf("%s %s backtest step %v error: %v", engine.Symbol(), engine.Model(), history[i].Timestamp(), err)
+			}
+			continue
+		}
+		report.Predictions++
+	}
+	report.Retrains = engine.Retrains() - retrains
+	report.Efficiency, _ = efficiency.GetLast()
+	return report, nil
+}
+
+// SortRates sort rates by timestamp
+func SortRates(rates []entities.Rate) {
+	sort.Sort(ratesByID(rates))
+}
+
+type ratesByID []entities.Rate
+
+func (a ratesByID) Len() int           { return len(a) }
+func (a ratesByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a ratesByID) Less(i, j int) bool { return a[i].ID < a[j].ID }
@@ -0,0 +1,171 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"pr.optima/src/core/linreg"
+	"pr.optima/src/core/statistic"
+	"pr.optima/src/core/statistic/smoothing"
+)
+
+const (
+	// TTPersistence - baseline predicting the last class
+	TTPersistence = "BASE-PERSISTENCE"
+	// TTMajority - baseline predicting the most frequent class of the window
+	TTMajority = "BASE-MAJORITY"
+	// TTFrequency - baseline drawing the class from the frequency distribution of the window classes
+	TTFrequency = "BASE-FREQUENCY"
+	// TTSmaCross - baseline predicting the direction of the fast SMA of the rates relative to the slow one
+	TTSmaCross = "BASE-SMA-CROSS"
+	// TTLinear - baseline predicting the class of the next rate delta by the linear autoregression of the deltas
+	TTLinear = "BASE-LINEAR"
+)
+
+// BaselineInput - data of the window available to the baseline
+type BaselineInput struct {
+	Rates      []float32 // rates of the symbol, the last one is the newest
+	Classes    []int     // classes of the rate deltas
+	Ranges     []float64 // ranges of the classes
+	RangeCount int
+	Frame      int
+}
+
+// Baseline - predictor of the next class without network
+type Baseline func(input BaselineInput, rnd *rand.Rand) (int, error)
+
+var _baselines = make(map[string]Baseline)
+
+func init() {
+	RegisterBaseline(TTPersistence, persistence)
+	RegisterBaseline(TTMajority, majority)
+	RegisterBaseline(TTFrequency, frequency)
+	RegisterBaseline(TTSmaCross, smaCross)
+	RegisterBaseline(TTLinear, linear)
+}
+
+// RegisterBaseline add baseline predictor, it is used as train type of the engine
+func RegisterBaseline(name string, baseline Baseline) {
+	_baselines[name] = baseline
+}
+
+// Baselines return sorted names of the registered baselines
+func Baselines() []string {
+	result := make([]string, 0, len(_baselines))
+	for name := range _baselines {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// IsBaseline check that train type is the baseline predictor
+func IsBaseline(trainType string) bool {
+	_, found := _baselines[trainType]
+	return found
+}
+
+// PredictBaseline predict the next class by the registered baseline
+func PredictBaseline(name string, input BaselineInput, rnd *rand.Rand) (int, error) {
+	baseline, found := _baselines[name]
+	if !found {
+		return -1, fmt.Errorf("unknown baseline: '%s'", name)
+	}
+	if len(input.Classes) == 0 {
+		return -1, errors.New("classes required")
+	}
+	return baseline(input, rnd)
+}
+
+func persistence(input BaselineInput, rnd *rand.Rand) (int, error) {
+	return input.Classes[len(input.Classes)-1], nil
+}
+
+func majority(input BaselineInput, rnd *rand.Rand) (int, error) {
+	counts := make(map[int]int)
+	result, max := -1, 0
+	// the newest class wins the tie
+	for i := len(input.Classes) - 1; i > -1; i-- {
+		class := input.Classes[i]
+		counts[class]++
+		if counts[class] > max {
+			result, max = class, counts[class]
+		}
+	}
+	return result, nil
+}
+
+func frequency(input BaselineInput, rnd *rand.Rand) (int, error) {
+	return input.Classes[rnd.Intn(len(input.Classes))], nil
+}
+
+func smaCross(input BaselineInput, rnd *rand.Rand) (int, error) {
+	slow := input.Frame
+	fast := slow / 2
+	if fast < 2 {
+		fast = 2
+	}
+	if slow <= fast || len(input.Rates) < slow {
+		return -1, fmt.Errorf("rates count: %d less than slow SMA frame: %d", len(input.Rates), slow)
+	}
+	series := make([]float64, slow)
+	for i := range series {
+		series[i] = float64(input.Rates[len(input.Rates)-slow+i])
+	}
+	slowSma, err := smoothing.SMA(series, slow)
+	if err != nil {
+		return -1, err
+	}
+	fastSma, err := smoothing.SMA(series[slow-fast:], fast)
+	if err != nil {
+		return -1, err
+	}
+
+	// the smallest move in the direction of the crossover, the middle class of the odd count is the flat one
+	switch {
+	case fastSma[0] > slowSma[0]:
+		return (input.RangeCount + 1) / 2, nil
+	case fastSma[0] < slowSma[0]:
+		return input.RangeCount/2 - 1, nil
+	}
+	return majority(input, rnd)
+}
+
+func linear(input BaselineInput, rnd *rand.Rand) (int, error) {
+	if len(input.Ranges) == 0 {
+		return -1, errors.New("ranges required")
+	}
+	// the order is the fast SMA frame of the crossover
+	order := input.Frame / 2
+	if order < 1 {
+		order = 1
+	}
+	deltas := make([]float64, len(input.Rates)-1)
+	for i := range deltas {
+		deltas[i] = float64(input.Rates[i+1] / input.Rates[i])
+	}
+	npoints := len(deltas) - order
+	if npoints <= order+1 {
+		return -1, fmt.Errorf("rates count: %d too small for the autoregression of order: %d", len(input.Rates), order)
+	}
+	xy := make([][]float64, npoints)
+	for i := range xy {
+		xy[i] = deltas[i : i+order+1]
+	}
+	lm := linreg.NewLinearModel()
+	info := 0
+	if err := linreg.LrBuild(&xy, npoints, order, &info, lm, linreg.NewLrReport()); err != nil {
+		return -1, err
+	}
+	if info != 1 {
+		return majority(input, rnd)
+	}
+	x := deltas[len(deltas)-order:]
+	delta := linreg.LrProcess(lm, &x)
+	if delta <= 0 {
+		return majority(input, rnd)
+	}
+	return statistic.DetectClass(input.Ranges, float32(delta))
+}
@@ -0,0 +1,102 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Dataset - set of samples built from the series, each row holds frame inputs followed by horizon outputs
+type Dataset struct {
+	Frame      int
+	Horizon    int
+	Train      [][]float64
+	Validation [][]float64
+}
+
+// TrainSize return count of the train samples
+func (f *Dataset) TrainSize() int {
+	return len(f.Train)
+}
+
+// ValidationSize return count of the validation samples
+func (f *Dataset) ValidationSize() int {
+	return len(f.Validation)
+}
+
+// BuildDataset convert series to overlapping samples: series[s:s+frame] as inputs and
+// series[s+frame:s+frame+horizon] as outputs, where s is shifted by stride.
+// The last validationPart of the samples (newest ones) is held out as validation set.
+func BuildDataset(series []float64, frame, horizon, stride int, validationPart float64) (*Dataset, error) {
+	if frame < 1 || horizon < 1 || stride < 1 {
+		return nil, errors.New("frame, horizon and stride must be positive values")
+	}
+	if validationPart < 0 || validationPart >= 1 {
+		return nil, fmt.Errorf("validation part: %v must be in range [0, 1)", validationPart)
+	}
+	length := len(series)
+	if length < frame+horizon {
+		return nil, fmt.Errorf("series length: %d less than frame + horizon: %d", length, frame+horizon)
+	}
+
+	// align the last sample to the end of the series, so the newest data is always used
+	cnt := (length-frame-horizon)/stride + 1
+	offset := length - frame - horizon - (cnt-1)*stride
+	samples := make([][]float64, cnt)
+	for i := range samples {
+		start := offset + i*stride
+		samples[i] = make([]float64, frame+horizon)
+		copy(samples[i], series[start:start+frame+horizon])
+	}
+
+	return newDataset(frame, horizon, samples, validationPart), nil
+}
+
+// newDataset split samples, the last validationPart of them is held out as validation set
+func newDataset(frame, horizon int, samples [][]float64, validationPart float64) *Dataset {
+	cnt := len(samples)
+	valSize := validationSize(cnt, validationPart)
+
+	result := new(Dataset)
+	result.Frame = frame
+	result.Horizon = horizon
+	result.Train = samples[:cnt-valSize]
+	result.Validation = samples[cnt-valSize:]
+	return result
+}
+
+// validationSize return count of the validation samples, at least one sample is left for training
+func validationSize(cnt int, validationPart float64) int {
+	result := int(float64(cnt) * validationPart)
+	if result >= cnt {
+		result = cnt - 1
+	}
+	return result
+}
+
+// transformDataset return dataset of the transformed inputs followed by the same outputs,
+// frame is the count of the transformed inputs
+func transformDataset(dataset *Dataset, frame int, transform func([]float64) ([]float64, error)) (*Dataset, error) {
+	apply := func(rows [][]float64) ([][]float64, error) {
+		result := make([][]float64, len(rows))
+		for i, row := range rows {
+			if len(row) != dataset.Frame+dataset.Horizon {
+				return nil, fmt.Errorf("row %d length: %d, expected: %d", i, len(row), dataset.Frame+dataset.Horizon)
+			}
+			x, err := transform(row[:dataset.Frame])
+			if err != nil {
+				return nil, err
+			}
+			result[i] = append(x, row[dataset.Frame:]...)
+		}
+		return result, nil
+	}
+	result := &Dataset{Frame: frame, Horizon: dataset.Horizon}
+	var err error
+	if result.Train, err = apply(dataset.Train); err != nil {
+		return nil, err
+	}
+	if result.Validation, err = apply(dataset.Validation); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,609 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/statistic"
+)
+
+// ResultStore - storage of the predictions, implemented by repository.ResultDataRepo
+type ResultStore interface {
+	Push(entities.ResultData) error
+	Sync(entities.ResultData) error
+	Get(int64) (entities.ResultData, bool)
+}
+
+// EfficiencyStore - storage of the efficiency, implemented by repository.EfficiencyRepo
+type EfficiencyStore interface {
+	Sync(entities.Efficiency) error
+	GetLast() (entities.Efficiency, bool)
+}
+
+// Logger - printf-like log function
+type Logger func(format string, args ...interface{})
+
+// Engine - prediction of the one symbol: assess previous predictions, refit the predictor
+// every frame steps and predict the class of the next rate delta
+type Engine struct {
+	Limit       int
+	predictor   Predictor // nil for baseline
+	frame       int
+	rangeCount  int
+	symbol      string
+	trainType   string
+	trainParams TrainParams
+	netType     string
+	model       string
+	// sliding window settings of the train set
+	stride         int
+	validationPart float64
+	// multi-step forecast settings
+	horizons    []int
+	horizonMode string
+	// sizes of the hidden layers, nil means one layer with size of the inputs
+	hidden []int
+	// optional inputs besides the class history, nil means the last frame classes only
+	features *FeatureSet
+	// standardization of the features and ranges of the classes of the other symbols, fitted by the train set
+	scaler       *Scaler
+	symbolRanges map[string][]float64
+	// optional Fisher LDA projection of the features, nil means the features are the inputs
+	projection *Projection
+	// optional detector of the market regime of the predictions, nil means no regimes
+	regimes   *RegimeDetector
+	loopCount int
+	retrains  int
+	ranges    []float64
+	// random source of the baseline, nil for predictor
+	rnd *rand.Rand
+	// non-zero while Process runs, a timed out processing may still be running in the next cycle
+	busy int32
+}
+
+// DefaultHorizons - steps ahead forecasted by default
+var DefaultHorizons = []int{1, 4, 12, 24}
+
+// NewEngine create engine with default train params and horizons, train type is
+// the name of the network train type, ensemble train type, registered predictor or baseline,
+// baseline train type creates engine without predictor
+func NewEngine(rCount, frame, limit int, trainType, netType, symbol string) (*Engine, error) {
+	var trainParams TrainParams
+	var err error
+	if !IsBaseline(trainType) {
+		if trainParams, err = DefaultTrainParams(trainType); err != nil {
+			return nil, err
+		}
+	}
+	result := new(Engine)
+	result.symbol = symbol
+	result.Limit = limit
+	result.frame = frame
+	result.rangeCount = rCount
+	result.trainType = trainType
+	result.trainParams = trainParams
+	result.netType = netType
+	result.model = ModelName(trainType, netType)
+	result.stride = 1
+	result.validationPart = 0.2
+	result.horizons = DefaultHorizons
+	result.horizonMode = HMRecursive
+	if IsBaseline(trainType) {
+		result.model = trainType
+		result.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	} else if err = result.newModel(frame, TrainHorizon(result.horizonMode, result.horizons), []int{frame}); err != nil {
+		return nil, err
+	}
+	result.loopCount = 0
+	result.ranges = nil
+
+	return result, nil
+}
+
+// WithHorizons replace forecasted horizons, empty horizons turn off the multi-step forecast
+func (f *Engine) WithHorizons(mode string, horizons ...int) (*Engine, error) {
+	if f.features != nil && len(horizons) > 0 {
+		return nil, errors.New("features can't be fed back, multi-step forecast is not supported")
+	}
+	if err := f.newModel(f.inputs(), TrainHorizon(mode, horizons), f.hiddenLayers(f.inputs())); err != nil {
+		return nil, err
+	}
+	f.horizons = horizons
+	f.horizonMode = mode
+	return f, nil
+}
+
+// WithHidden replace sizes of the hidden layers (up to 2), no sizes means network without hidden layers
+func (f *Engine) WithHidden(hidden ...int) (*Engine, error) {
+	for _, size := range hidden {
+		if size < 1 {
+			return nil, fmt.Errorf("hidden layer size: %d must be positive value", size)
+		}
+	}
+	if err := f.newModel(f.inputs(), TrainHorizon(f.horizonMode, f.horizons), hidden); err != nil {
+		return nil, err
+	}
+	f.hidden = make([]int, len(hidden))
+	copy(f.hidden, hidden)
+	return f, nil
+}
+
+// WithTrainParams replace default hyperparameters of the train type, they are used by the next fit
+func (f *Engine) WithTrainParams(params TrainParams) (*Engine, error) {
+	f.trainParams = params
+	return f, nil
+}
+
+// WithPredictor replace predictor of the engine, it must accept the window of the engine inputs
+// and predict the engine horizons at once
+func (f *Engine) WithPredictor(predictor Predictor) (*Engine, error) {
+	if f.isBaseline() {
+		return nil, errors.New("baseline doesn't use predictor")
+	}
+	if predictor == nil {
+		return nil, errors.New("predictor required")
+	}
+	f.predictor = predictor
+	return f, nil
+}
+
+// WithFeatures replace the class history input by the feature pipeline,
+// features can't be fed back, so only the next step is predicted
+func (f *Engine) WithFeatures(name string, specs ...FeatureSpec) (*Engine, error) {
+	if f.isBaseline() {
+		return nil, errors.New("baseline doesn't use features")
+	}
+	features, err := NewFeatureSet(name, specs...)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.newModel(features.Width(), 1, f.hiddenLayers(features.Width())); err != nil {
+		return nil, err
+	}
+	f.features = features
+	f.scaler = new(Scaler)
+	f.symbolRanges = nil
+	f.projection = nil
+	f.horizons = nil
+	f.model = fmt.Sprintf("%s-%s", ModelName(f.trainType, f.netType), name)
+	return f, nil
+}
+
+// WithProjection replace the features by their projection onto dims directions which best
+// separate the next classes, the directions are found by Fisher LDA on each train set
+func (f *Engine) WithProjection(dims int) (*Engine, error) {
+	if f.features == nil {
+		return nil, errors.New("projection requires features")
+	}
+	if dims > f.features.Width() {
+		return nil, fmt.Errorf("projection dimensions: %d more than features width: %d", dims, f.features.Width())
+	}
+	projection, err := NewProjection(dims)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.newModel(dims, 1, f.hiddenLayers(dims)); err != nil {
+		return nil, err
+	}
+	f.projection = projection
+	f.model = fmt.Sprintf("%s-LDA%d", f.model, dims)
+	return f, nil
+}
+
+// WithRegimes detect the market regime of each prediction by k-means clusters of the rate windows,
+// the clusters are fitted with the ranges on all passed rates, efficiency is broken down per regime
+func (f *Engine) WithRegimes(kind string, k, window int) (*Engine, error) {
+	regimes, err := NewRegimeDetector(kind, k, window)
+	if err != nil {
+		return nil, err
+	}
+	f.regimes = regimes
+	return f, nil
+}
+
+// Model return name of the model used as TrainType of the stored records
+func (f *Engine) Model() string {
+	return f.model
+}
+
+// Symbol return predicted symbol
+func (f *Engine) Symbol() string {
+	return f.symbol
+}
+
+// RangeCount return count of the range classes
+func (f *Engine) RangeCount() int {
+	return f.rangeCount
+}
+
+// Frame return count of the class history inputs
+func (f *Engine) Frame() int {
+	return f.frame
+}
+
+// TrainParams return hyperparameters of the training
+func (f *Engine) TrainParams() TrainParams {
+	return f.trainParams
+}
+
+// Predictor return predictor of the engine, nil for baseline
+func (f *Engine) Predictor() Predictor {
+	return f.predictor
+}
+
+// Retrains return count of the predictor fits
+func (f *Engine) Retrains() int {
+	return f.retrains
+}
+
+// ResultsLimit - stored predictions must cover the max horizon to be assessed
+func (f *Engine) ResultsLimit() int {
+	result := f.Limit
+	for _, horizon := range f.horizons {
+		if horizon+1 > result {
+			result = horizon + 1
+		}
+	}
+	return result
+}
+
+// Process assess previous predictions by the latest rate, refit predictor if required
+// and store prediction of the next class, rates are sorted by timestamp
+func (f *Engine) Process(rates []entities.Rate, results ResultStore, efficiency EfficiencyStore, logf Logger) (int, error) {
+	return f.ProcessContext(context.Background(), rates, results, efficiency, logf)
+}
+
+// ProcessContext - Process stopped by the cancelled context, the cancelled refit is repeated by the next processing
+func (f *Engine) ProcessContext(ctx context.Context, rates []entities.Rate, results ResultStore, efficiency EfficiencyStore, logf Logger) (int, error) {
+	if !atomic.CompareAndSwapInt32(&f.busy, 0, 1) {
+		return -1, errors.New("previous processing is not finished")
+	}
+	defer atomic.StoreInt32(&f.busy, 0)
+
+	if len(rates) < 2 {
+		return -1, errors.New("at least two rates required")
+	}
+	// prepare income data
+	var rawSource = rates
+	if len(rates) > f.Limit+1 {
+		rawSource = rates[len(rates)-f.Limit-1:]
+	}
+
+	_time := rawSource[len(rawSource)-1].ID
+	source, isValid := extractFloatSet(rawSource, f.symbol)
+	sourceLength := len(source)
+
+	// assess previous predictions
+	if f.ranges != nil && len(f.ranges) > 0 && sourceLength > 1 {
+		class, err := statistic.DetectClass(f.ranges, source[sourceLength-1]/source[sourceLength-2])
+		if err != nil {
+			return -1, err
+		}
+		eff, _ := efficiency.GetLast()
+		effUpdated := false
+		if last, found := results.Get(rawSource[sourceLength-2].ID); found {
+			last.Result = int32(class)
+			eff.AddResult(last.Prediction, last.Result, last.Probabilities)
+			if last.RegimesCount > 0 {
+				eff.AddRegimeResult(last.Regime, last.Prediction, last.Result)
+			}
+			eff.Timestamp = last.Timestamp
+
+			if err := results.Sync(last); err != nil {
+				return -1, err
+			}
+			effUpdated = true
+		}
+		// the latest class is the actual class of the predictions made horizon steps ago
+		for _, horizon := range f.horizons {
+			idx := len(rates) - 1 - horizon
+			if idx < 0 {
+				continue
+			}
+			item, found := results.Get(rates[idx].ID)
+			if !found {
+				continue
+			}
+			hIdx := item.HorizonIndex(int32(horizon))
+			if hIdx < 0 || item.HorizonResults[hIdx] != -1 {
+				continue
+			}
+			item.HorizonResults[hIdx] = int32(class)
+			eff.AddHorizonResult(int32(horizon), item.HorizonPredictions[hIdx], int32(class))
+			if err := results.Sync(item); err != nil {
+				return -1, err
+			}
+			effUpdated = true
+		}
+		if effUpdated {
+			if err := efficiency.Sync(eff); err != nil {
+				return -1, err
+			}
+		}
+	}
+
+	if !isValid {
+		f.ranges = nil
+		return -1, errors.New("no activity detected")
+	}
+
+	// refit predictor
+	if f.loopCount > f.frame || f.ranges == nil {
+		var err error
+		if f.ranges, err = statistic.CalculateEvenRanges2(source, f.rangeCount); err != nil {
+			f.ranges = nil
+			return -1, err
+		}
+
+		// the clusters of the previous fit are kept on error, the regime isn't the reason to skip the prediction
+		if f.regimes != nil {
+			all, _ := extractFloatSet(rates, f.symbol)
+			if err := f.regimes.Fit(all); err != nil && logf != nil {
+				logf("%s %s regimes fit error: %v", f.symbol, f.model, err)
+			}
+		}
+
+		// refit predictor, baselines use the new ranges only
+		if !f.isBaseline() {
+			if err := f.train(ctx, source, rawSource, logf); err != nil {
+				// the predictor isn't fitted by the new ranges, the next processing refits it
+				f.loopCount = f.frame + 1
+				return -1, err
+			}
+		}
+
+		f.loopCount = 0
+		f.retrains++
+	}
+
+	if f.ranges != nil {
+		// baselines skip the training, so the window of the prediction is checked here
+		if len(source) < f.frame+1 {
+			return -1, fmt.Errorf("rates count: %d less than frame + 1: %d", len(source), f.frame+1)
+		}
+		f.loopCount++
+		classes, err := statistic.CalculateClasses(source[len(source)-f.frame-1:], f.ranges)
+		if err != nil {
+			return -1, err
+		}
+		// process
+		forecast, forecasts, err := f.predict(source, classes, rawSource)
+		if err != nil {
+			return -1, err
+		}
+		result := entities.ResultData{
+			RangesCount:   int32(f.rangeCount),
+			TrainType:     f.model,
+			Limit:         int32(f.Limit),
+			Step:          int32(f.frame),
+			Symbol:        f.symbol,
+			Timestamp:     _time,
+			Source:        convertArrayToInt32(classes),
+			Prediction:    int32(forecast.Class),
+			Probabilities: forecast.Probabilities,
+			Confidence:    forecast.Confidence,
+			Result:        -1,
+			Regime:        -1}
+		if f.regimes != nil {
+			if regime, err := f.regimes.Detect(source); err == nil {
+				result.Regime = int32(regime)
+				result.RegimesCount = int32(f.regimes.K)
+			} else if logf != nil {
+				logf("%s %s regime detection error: %v", f.symbol, f.model, err)
+			}
+		}
+		if len(forecasts) > 0 {
+			result.Horizons = make([]int32, len(f.horizons))
+			result.HorizonPredictions = make([]int32, len(f.horizons))
+			result.HorizonResults = make([]int32, len(f.horizons))
+			for i, item := range forecasts {
+				result.Horizons[i] = int32(f.horizons[i])
+				result.HorizonPredictions[i] = int32(item.Class)
+				result.HorizonResults[i] = -1
+			}
+		}
+		if err := results.Push(result); err != nil {
+			return -1, err
+		}
+		return int(result.Prediction), nil
+	}
+	return -1, nil
+}
+
+// train build dataset by the current ranges and fit predictor
+func (f *Engine) train(ctx context.Context, source []float32, rawSource []entities.Rate, logf Logger) error {
+	var dataset *Dataset
+	var err error
+	if f.features != nil {
+		// ranges of the other symbols are refitted with the ranges of the symbol
+		src := f.featureSource(rawSource)
+		if src.SymbolRanges, err = f.features.FitRanges(src, f.stride, f.validationPart); err == nil {
+			f.symbolRanges = src.SymbolRanges
+			dataset, _, err = f.features.Build(src, f.stride, f.validationPart)
+		}
+		if err == nil {
+			if err = f.scaler.Fit(dataset.Train, f.features.Width()); err == nil {
+				dataset, err = f.scaler.TransformDataset(dataset)
+			}
+		}
+		if err == nil && f.projection != nil {
+			if err = f.projection.Fit(dataset.Train, f.features.Width(), f.rangeCount); err == nil {
+				dataset, err = f.projection.TransformDataset(dataset)
+			}
+		}
+	} else {
+		var classes []int
+		if classes, err = statistic.CalculateClasses(source, f.ranges); err != nil {
+			return err
+		}
+		dataset, err = BuildDataset(convertArrayToFloat64(classes), f.frame, TrainHorizon(f.horizonMode, f.horizons), f.stride, f.validationPart)
+	}
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if fitter, ok := f.predictor.(ContextFitter); ok {
+		err = fitter.FitContext(ctx, dataset, f.trainParams)
+	} else {
+		err = f.predictor.Fit(dataset, f.trainParams)
+	}
+	if err != nil {
+		return err
+	}
+	if validator, ok := f.predictor.(Validator); ok && dataset.ValidationSize() > 0 && logf != nil {
+		rms, err := validator.RmsError(dataset.Validation)
+		if err != nil {
+			return err
+		}
+		logf("%s %s validation rms error: %v", f.symbol, f.model, rms)
+	}
+	return nil
+}
+
+// predict return forecast of the next class and forecasts of the horizons
+func (f *Engine) predict(rates []float32, classes []int, rawSource []entities.Rate) (Forecast, []Forecast, error) {
+	if f.isBaseline() {
+		all, err := statistic.CalculateClasses(rates, f.ranges)
+		if err != nil {
+			return Forecast{}, nil, err
+		}
+		input := BaselineInput{Rates: rates, Classes: all, Ranges: f.ranges, RangeCount: f.rangeCount, Frame: f.frame}
+		class, err := PredictBaseline(f.trainType, input, f.rnd)
+		if err != nil {
+			return Forecast{}, nil, err
+		}
+		// deterministic baselines repeat the class for each horizon, frequency baseline draws the new one
+		// for each horizon except the next step, which is the prediction itself
+		forecasts := make([]Forecast, len(f.horizons))
+		for i := range forecasts {
+			if f.horizons[i] == 1 {
+				forecasts[i].Class = class
+			} else if forecasts[i].Class, err = PredictBaseline(f.trainType, input, f.rnd); err != nil {
+				return Forecast{}, nil, err
+			}
+		}
+		return Forecast{Class: class}, forecasts, nil
+	}
+
+	process := convertArrayToFloat64(classes)
+	if f.features != nil {
+		var err error
+		if _, process, err = f.features.Build(f.featureSource(rawSource), f.stride, f.validationPart); err != nil {
+			return Forecast{}, nil, err
+		}
+		if process, err = f.scaler.Transform(process); err != nil {
+			return Forecast{}, nil, err
+		}
+		if f.projection != nil {
+			if process, err = f.projection.Transform(process); err != nil {
+				return Forecast{}, nil, err
+			}
+		}
+	}
+	output, err := f.predictor.Predict(process)
+	if err != nil {
+		return Forecast{}, nil, err
+	}
+	forecast, err := DecodeOutput(output, f.predictor.NetType())
+	if err != nil || len(f.horizons) == 0 {
+		return forecast, nil, err
+	}
+	forecasts, err := forecastHorizons(predictorProcessor(f.predictor), f.predictor.NetType(), f.horizonMode, process, f.horizons)
+	return forecast, forecasts, err
+}
+
+// newModel create predictor of the engine type, baseline has no predictor
+func (f *Engine) newModel(inputs, horizon int, hidden []int) error {
+	if f.isBaseline() {
+		return nil
+	}
+	predictor, err := NewPredictor(PredictorSpec{
+		TrainType:  f.trainType,
+		NetType:    f.netType,
+		Inputs:     inputs,
+		RangeCount: f.rangeCount,
+		Horizon:    horizon,
+		Hidden:     hidden,
+		Params:     f.trainParams})
+	if err != nil {
+		return err
+	}
+	f.predictor = predictor
+	return nil
+}
+
+func (f *Engine) isBaseline() bool {
+	return f.rnd != nil
+}
+
+func (f *Engine) inputs() int {
+	if f.projection != nil {
+		return f.projection.Dims
+	}
+	if f.features != nil {
+		return f.features.Width()
+	}
+	return f.frame
+}
+
+func (f *Engine) hiddenLayers(inputs int) []int {
+	if f.hidden == nil {
+		return []int{inputs}
+	}
+	return f.hidden
+}
+
+func (f *Engine) featureSource(rates []entities.Rate) FeatureSource {
+	return FeatureSource{Rates: rates, Symbol: f.symbol, Ranges: f.ranges, RangeCount: f.rangeCount, SymbolRanges: f.symbolRanges}
+}
+
+// extractFloatSet return rates of the symbol and flag of the activity during the last steps
+func extractFloatSet(rates []entities.Rate, symbol string) ([]float32, bool) {
+	l := len(rates)
+	result := make([]float32, l)
+	for i, element := range rates {
+		result[i], _ = element.GetForSymbol(symbol)
+	}
+
+	if l < 2 {
+		return result, true
+	}
+
+	cnt := 3
+	if l < cnt {
+		cnt = l
+	}
+
+	isValid := false
+	for i := 1; i < cnt; i++ {
+		if result[l-i] != result[l-i-1] {
+			isValid = true
+			break
+		}
+	}
+	return result, isValid
+}
+
+func convertArrayToFloat64(a []int) []float64 {
+	result := make([]float64, len(a))
+	for i, item := range a {
+		result[i] = float64(item)
+	}
+	return result
+}
+
+func convertArrayToInt32(a []int) []int32 {
+	result := make([]int32, len(a))
+	for i, item := range a {
+		result[i] = int32(item)
+	}
+	return result
+}
@@ -0,0 +1,206 @@
+package prediction
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/neural"
+)
+
+const (
+	// TTEnsembleLbfgs - bagging ensemble of the networks trained with L-BFGS
+	TTEnsembleLbfgs = "ENS-L-BFGS"
+	// TTEnsembleLm - bagging ensemble of the networks trained with Levenberg-Marquardt
+	TTEnsembleLm = "ENS-LM"
+	// TTEnsembleEs - ensemble of the networks trained with early stopping on random splits
+	TTEnsembleEs = "ENS-ES"
+)
+
+// EnsembleParams - hyperparameters of the ensemble train types, the networks are trained with NetworkParams
+type EnsembleParams struct {
+	Size int // count of the networks of the ensemble
+}
+
+// EnsembleTrainer - trains ensemble on the first npoints rows of xy, the cancelled training isn't started
+type EnsembleTrainer func(ctx context.Context, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error)
+
+type ensembleType struct {
+	trainer  EnsembleTrainer
+	defaults TrainParams
+}
+
+var _ensembleTypes = make(map[string]ensembleType)
+
+func init() {
+	RegisterEnsembleType(TTEnsembleLbfgs, trainEnsembleLbfgs, NetworkParams{Decay: 0.001, Restarts: 2, WStep: 0.01}, EnsembleParams{Size: 5})
+	RegisterEnsembleType(TTEnsembleLm, trainEnsembleLm, NetworkParams{Decay: 0.001, Restarts: 2}, EnsembleParams{Size: 5})
+	RegisterEnsembleType(TTEnsembleEs, trainEnsembleEs, NetworkParams{Decay: 0.001, Restarts: 2}, EnsembleParams{Size: 5})
+}
+
+// RegisterEnsembleType add ensemble train type to the registry, existing type with the same name is replaced
+func RegisterEnsembleType(name string, trainer EnsembleTrainer, network NetworkParams, ensemble EnsembleParams) {
+	_ensembleTypes[name] = ensembleType{trainer: trainer, defaults: TrainParams{Network: network, Ensemble: ensemble}}
+}
+
+// EnsembleTypes return sorted names of the registered ensemble train types
+func EnsembleTypes() []string {
+	result := make([]string, 0, len(_ensembleTypes))
+	for name := range _ensembleTypes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// IsEnsemble check that train type trains ensemble of the networks
+func IsEnsemble(trainType string) bool {
+	_, found := _ensembleTypes[trainType]
+	return found
+}
+
+// TrainEnsemble train ensemble with the registered ensemble train type
+func TrainEnsemble(name string, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	return TrainEnsembleContext(context.Background(), name, ensemble, xy, npoints, params)
+}
+
+// TrainEnsembleContext - TrainEnsemble cancelled by the context
+func TrainEnsembleContext(ctx context.Context, name string, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	et, found := _ensembleTypes[name]
+	if !found {
+		return nil, fmt.Errorf("unknown ensemble train type: '%s'", name)
+	}
+	// the ensemble trainers have no cancellable variants
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return et.trainer(ctx, ensemble, xy, npoints, params)
+}
+
+// NewEnsemble create ensemble of size networks with the geometry of NewNetwork
+func NewEnsemble(netType string, nin, classes, horizon, size int, hidden ...int) (*neural.MlpEnsemble, error) {
+	network, err := NewNetwork(netType, nin, classes, horizon, hidden...)
+	if err != nil {
+		return nil, err
+	}
+	return neural.MlpeCreateFromNetwork(network, size)
+}
+
+func trainEnsembleLbfgs(ctx context.Context, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	rep, oob, err := neural.MlpeBaggingLbfgs(ensemble, xy, npoints, params.Decay, params.Restarts, params.WStep, params.MaxIts)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep, CV: oob}, nil
+}
+
+func trainEnsembleLm(ctx context.Context, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	rep, oob, err := neural.MlpeBaggingLm(ensemble, xy, npoints, params.Decay, params.Restarts)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep, CV: oob}, nil
+}
+
+func trainEnsembleEs(ctx context.Context, ensemble *neural.MlpEnsemble, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	rep, err := neural.MlpeTrainEs(ensemble, xy, npoints, params.Decay, params.Restarts)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep}, nil
+}
+
+// ensemblePredictor - ensemble trained by the registered ensemble train type
+type ensemblePredictor struct {
+	ensemble *neural.MlpEnsemble
+	spec     PredictorSpec
+	report   *TrainReport // report of the last training
+}
+
+func newEnsemblePredictor(spec PredictorSpec) (Predictor, error) {
+	ensemble, err := NewEnsemble(spec.NetType, spec.Inputs, spec.RangeCount, spec.Horizon, spec.Params.Ensemble.Size, spec.Hidden...)
+	if err != nil {
+		return nil, err
+	}
+	return &ensemblePredictor{ensemble: ensemble, spec: spec}, nil
+}
+
+// Fit train ensemble on the train set of the dataset, the ensemble is recreated when its size is changed
+func (f *ensemblePredictor) Fit(dataset *Dataset, params TrainParams) error {
+	return f.FitContext(context.Background(), dataset, params)
+}
+
+// FitContext - Fit cancelled by the context
+func (f *ensemblePredictor) FitContext(ctx context.Context, dataset *Dataset, params TrainParams) error {
+	if params.Ensemble.Size != f.spec.Params.Ensemble.Size {
+		ensemble, err := NewEnsemble(f.spec.NetType, f.spec.Inputs, f.spec.RangeCount, f.spec.Horizon, params.Ensemble.Size, f.spec.Hidden...)
+		if err != nil {
+			return err
+		}
+		f.ensemble = ensemble
+		f.spec.Params = params
+	}
+	report, err := TrainEnsembleContext(ctx, f.spec.TrainType, f.ensemble, &dataset.Train, dataset.TrainSize(), params.Network)
+	if err != nil {
+		return err
+	}
+	f.report = report
+	return nil
+}
+
+// Predict return ensemble output
+func (f *ensemblePredictor) Predict(x []float64) ([]float64, error) {
+	y, err := neural.MlpeProcess(f.ensemble, &x)
+	if err != nil {
+		return nil, err
+	}
+	return *y, nil
+}
+
+// NetType return type of the networks of the ensemble
+func (f *ensemblePredictor) NetType() string {
+	return f.spec.NetType
+}
+
+// Serialize return networks of the ensemble
+func (f *ensemblePredictor) Serialize() ([]float64, error) {
+	return neural.MlpeSerialize(f.ensemble), nil
+}
+
+// Load replace ensemble by the serialized one of the same inputs and outputs
+func (f *ensemblePredictor) Load(ra []float64) error {
+	ensemble, err := neural.MlpeUnserialize(ra)
+	if err != nil {
+		return err
+	}
+	nin, nout := neural.MlpeProperties(ensemble)
+	expectedNin, expectedNout := neural.MlpeProperties(f.ensemble)
+	if nin != expectedNin || nout != expectedNout || neural.MlpeIsSoftMax(ensemble) != neural.MlpeIsSoftMax(f.ensemble) {
+		return fmt.Errorf("ensemble %d-%d doesn't match the predictor %d-%d", nin, nout, expectedNin, expectedNout)
+	}
+	f.ensemble = ensemble
+	f.spec.Params.Ensemble.Size = neural.MlpeSize(ensemble)
+	f.report = nil
+	return nil
+}
+
+// Report return report of the last training
+func (f *ensemblePredictor) Report() *TrainReport {
+	return f.report
+}
+
+// Describe return type and size of the ensemble, termination reason and out-of-bag error of the training
+func (f *ensemblePredictor) Describe() string {
+	nin, nout := neural.MlpeProperties(f.ensemble)
+	result := fmt.Sprintf("MLP ensemble %s %d-%d, %d networks, %s", f.spec.NetType, nin, nout, neural.MlpeSize(f.ensemble), f.spec.TrainType)
+	if f.report != nil {
+		result += f.report.describe("out-of-bag")
+	}
+	return result
+}
+
+// RmsError return rms error of the ensemble on the set
+func (f *ensemblePredictor) RmsError(xy [][]float64) (float64, error) {
+	return neural.MlpeRmsError(f.ensemble, &xy, len(xy))
+}
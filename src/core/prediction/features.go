@@ -0,0 +1,474 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/linreg"
+	"pr.optima/src/core/statistic"
+	"pr.optima/src/core/statistic/correlation"
+	"pr.optima/src/core/statistic/smoothing"
+)
+
+const (
+	// FKClasses - last Window range classes of the symbol
+	FKClasses = "classes"
+	// FKSma - simple moving average of the last Window rate deltas
+	FKSma = "sma"
+	// FKMedian - trailing moving median (statistic/smoothing.TMM) of the last Window rate deltas
+	FKMedian = "mm"
+	// FKVolatility - standard deviation of the last Window rate deltas
+	FKVolatility = "volatility"
+	// FKTrend - slope of the linear regression (core/linreg) of the last Window+1 rates
+	// relative to the newest one, i.e. the relative change of the rate per step
+	FKTrend = "trend"
+	// FKPca - score of the Component of the principal components of the PanelSymbols rate changes
+	// over the last Window steps, the Symbol is not used
+	FKPca = "pca"
+	// FKCorrelation - pearson correlation (statistic/correlation) of the rate changes of the symbol
+	// of the work item with the changes of the Symbol over the last Window steps
+	FKCorrelation = "correlation"
+	// FKHour - hour of the day, encoded as sin/cos pair
+	FKHour = "hour"
+	// FKWeekday - day of the week, encoded as sin/cos pair
+	FKWeekday = "weekday"
+)
+
+// FeatureSpec - declarative description of the network input feature.
+// Feature values are built unscaled, the engine standardizes them by the train set (Scaler),
+// so the forest, MNL and analog predictors get the scaled inputs as the networks do.
+type FeatureSpec struct {
+	Kind   string // one of FK* constants
+	Symbol string // source symbol, empty value means the symbol of the work item
+	Window int    // frame of the classes, sma, mm, volatility, trend, pca and correlation features
+	// principal component of the pca feature, 0 is the component with the max variance
+	Component int
+}
+
+// FeatureSource - rates and ranges of the work item used to build the features
+type FeatureSource struct {
+	Rates      []entities.Rate
+	Symbol     string
+	Ranges     []float64 // ranges of the symbol, classes of other symbols use their own ranges
+	RangeCount int
+	// ranges of the classes of the other symbols, nil means they are fitted by Build (see FitRanges)
+	SymbolRanges map[string][]float64
+}
+
+// FeatureSet - pipeline of the features, the inputs of the network are concatenated features
+type FeatureSet struct {
+	Name  string // used as suffix of the model name
+	Specs []FeatureSpec
+}
+
+// NewFeatureSet validate specs and create pipeline
+func NewFeatureSet(name string, specs ...FeatureSpec) (*FeatureSet, error) {
+	if name == "" {
+		return nil, errors.New("feature set name required")
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("at least one feature required")
+	}
+	for _, spec := range specs {
+		switch spec.Kind {
+		case FKClasses, FKMedian:
+			if spec.Window < 1 {
+				return nil, fmt.Errorf("feature '%s' window must be positive value", spec.Kind)
+			}
+		case FKSma, FKVolatility, FKTrend:
+			if spec.Window < 2 {
+				return nil, fmt.Errorf("feature '%s' window must be more than 1", spec.Kind)
+			}
+		case FKPca:
+			if spec.Window < 2 {
+				return nil, fmt.Errorf("feature '%s' window must be more than 1", spec.Kind)
+			}
+			if spec.Component < 0 || spec.Component >= len(PanelSymbols) {
+				return nil, fmt.Errorf("feature '%s' component: %d out of range [0, %d)", spec.Kind, spec.Component, len(PanelSymbols))
+			}
+		case FKCorrelation:
+			if spec.Window < 3 {
+				return nil, fmt.Errorf("feature '%s' window must be more than 2", spec.Kind)
+			}
+			if spec.Symbol == "" {
+				return nil, fmt.Errorf("feature '%s' symbol required", spec.Kind)
+			}
+		case FKHour, FKWeekday:
+		default:
+			return nil, fmt.Errorf("unknown feature: '%s'", spec.Kind)
+		}
+	}
+
+	result := new(FeatureSet)
+	result.Name = name
+	result.Specs = specs
+	return result, nil
+}
+
+// Width return count of the network inputs
+func (f *FeatureSet) Width() int {
+	result := 0
+	for _, spec := range f.Specs {
+		result += specWidth(spec)
+	}
+	return result
+}
+
+// Lookback return count of the rate deltas required to build the first sample
+func (f *FeatureSet) Lookback() int {
+	result := 1
+	for _, spec := range f.Specs {
+		if l := specLookback(spec); l > result {
+			result = l
+		}
+	}
+	return result
+}
+
+// Build convert rates to the samples: the features of the delta t as inputs and
+// the class of the delta t+1 as output. The last validationPart of the samples
+// is held out as validation set. The features of the latest delta are returned
+// as inputs of the next prediction.
+func (f *FeatureSet) Build(src FeatureSource, stride int, validationPart float64) (*Dataset, []float64, error) {
+	if stride < 1 {
+		return nil, nil, errors.New("stride must be positive value")
+	}
+	if validationPart < 0 || validationPart >= 1 {
+		return nil, nil, fmt.Errorf("validation part: %v must be in range [0, 1)", validationPart)
+	}
+	series, err := symbolSeries(src.Rates, src.Symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	classes, err := statistic.CalculateClasses(series, src.Ranges)
+	if err != nil {
+		return nil, nil, err
+	}
+	length := len(classes)
+	lookback := f.Lookback()
+	if length < lookback+1 {
+		return nil, nil, fmt.Errorf("deltas count: %d less than lookback + 1: %d", length, lookback+1)
+	}
+
+	if src.SymbolRanges == nil {
+		if src.SymbolRanges, err = f.FitRanges(src, stride, validationPart); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	columns := make([][][]float64, len(f.Specs))
+	for i, spec := range f.Specs {
+		if columns[i], err = specColumn(spec, src, classes); err != nil {
+			return nil, nil, err
+		}
+	}
+	row := func(t int) []float64 {
+		result := make([]float64, 0, f.Width()+1)
+		for _, column := range columns {
+			result = append(result, column[t]...)
+		}
+		return result
+	}
+
+	// align the last sample to the end of the series, so the newest data is always used
+	cnt := (length-1-lookback)/stride + 1
+	samples := make([][]float64, cnt)
+	for i := range samples {
+		t := length - 2 - (cnt-1-i)*stride
+		samples[i] = append(row(t), float64(classes[t+1]))
+	}
+	return newDataset(f.Width(), 1, samples, validationPart), row(length - 1), nil
+}
+
+// FitRanges calculate ranges of the classes of the other symbols by the rates of the train samples only,
+// so the validation samples and the rates after them don't leak into the classes of the earlier samples
+func (f *FeatureSet) FitRanges(src FeatureSource, stride int, validationPart float64) (map[string][]float64, error) {
+	if stride < 1 {
+		return nil, errors.New("stride must be positive value")
+	}
+	if validationPart < 0 || validationPart >= 1 {
+		return nil, fmt.Errorf("validation part: %v must be in range [0, 1)", validationPart)
+	}
+	length := len(src.Rates) - 1
+	lookback := f.Lookback()
+	if length < lookback+1 {
+		return nil, fmt.Errorf("deltas count: %d less than lookback + 1: %d", length, lookback+1)
+	}
+	// the last train sample predicts the delta length-1-valSize*stride, which ends at the next rate
+	cnt := (length-1-lookback)/stride + 1
+	end := length + 1 - validationSize(cnt, validationPart)*stride
+
+	result := make(map[string][]float64)
+	for _, spec := range f.Specs {
+		if spec.Kind != FKClasses || spec.Symbol == "" || spec.Symbol == src.Symbol {
+			continue
+		}
+		if _, found := result[spec.Symbol]; found {
+			continue
+		}
+		series, err := symbolSeries(src.Rates[:end], spec.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if result[spec.Symbol], err = statistic.CalculateEvenRanges2(series, src.RangeCount); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func specWidth(spec FeatureSpec) int {
+	switch spec.Kind {
+	case FKClasses:
+		return spec.Window
+	case FKHour, FKWeekday:
+		return 2
+	}
+	return 1
+}
+
+func specLookback(spec FeatureSpec) int {
+	switch spec.Kind {
+	case FKHour, FKWeekday:
+		return 1
+	}
+	return spec.Window
+}
+
+// specColumn return feature values for each delta, values of the deltas before lookback are nil
+func specColumn(spec FeatureSpec, src FeatureSource, classes []int) ([][]float64, error) {
+	length := len(src.Rates) - 1
+	result := make([][]float64, length)
+	start := specLookback(spec) - 1
+
+	switch spec.Kind {
+	case FKHour, FKWeekday:
+		for t := range result {
+			ts := src.Rates[t+1].Timestamp()
+			if spec.Kind == FKHour {
+				result[t] = cyclic(float64(ts.Hour()), 24)
+			} else {
+				result[t] = cyclic(float64(ts.Weekday()), 7)
+			}
+		}
+		return result, nil
+	case FKPca:
+		changes, err := panelChanges(src.Rates, PanelSymbols)
+		if err != nil {
+			return nil, err
+		}
+		// the components of the changes known at t
+		for t := start; t < length; t++ {
+			components, err := panelComponents(changes[t-spec.Window+1:t+1], PanelSymbols)
+			if err != nil {
+				return nil, err
+			}
+			score, err := components.Score(changes[t], spec.Component)
+			if err != nil {
+				return nil, err
+			}
+			result[t] = []float64{score}
+		}
+		return result, nil
+	case FKCorrelation:
+		changes, err := panelChanges(src.Rates, []string{src.Symbol, spec.Symbol})
+		if err != nil {
+			return nil, err
+		}
+		for t := start; t < length; t++ {
+			window := changes[t-spec.Window+1 : t+1]
+			var c [][]float64
+			if err := correlation.PearsonCorrM(&window, spec.Window, 2, &c); err != nil {
+				return nil, err
+			}
+			result[t] = []float64{c[0][1]}
+		}
+		return result, nil
+	}
+
+	symbol := spec.Symbol
+	if symbol == "" {
+		symbol = src.Symbol
+	}
+	series, err := symbolSeries(src.Rates, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Kind == FKClasses {
+		if symbol != src.Symbol {
+			ranges, found := src.SymbolRanges[symbol]
+			if !found {
+				return nil, fmt.Errorf("ranges of '%s' are not fitted", symbol)
+			}
+			if classes, err = statistic.CalculateClasses(series, ranges); err != nil {
+				return nil, err
+			}
+		}
+		for t := start; t < length; t++ {
+			result[t] = make([]float64, spec.Window)
+			for i := range result[t] {
+				result[t][i] = float64(classes[t-spec.Window+1+i])
+			}
+		}
+		return result, nil
+	}
+
+	deltas := make([]float64, length)
+	for i := range deltas {
+		deltas[i] = float64(series[i+1] / series[i])
+	}
+	switch spec.Kind {
+	case FKSma:
+		sma, err := smoothing.SMA(deltas, spec.Window)
+		if err != nil {
+			return nil, err
+		}
+		for t := start; t < length; t++ {
+			result[t] = []float64{sma[t-spec.Window+1]}
+		}
+	case FKMedian:
+		// the trailing window uses the deltas known at t only
+		mm, err := smoothing.TMM(deltas, spec.Window)
+		if err != nil {
+			return nil, err
+		}
+		for t := start; t < length; t++ {
+			result[t] = []float64{mm[t-spec.Window+1]}
+		}
+	case FKVolatility:
+		for t := start; t < length; t++ {
+			result[t] = []float64{deviation(deltas[t-spec.Window+1 : t+1])}
+		}
+	case FKTrend:
+		// rates t-Window+1..t+1 are known at the delta t
+		values := make([]float64, spec.Window+1)
+		for t := start; t < length; t++ {
+			for i := range values {
+				values[i] = float64(series[t-spec.Window+1+i] / series[t+1])
+			}
+			slope, err := trend(values)
+			if err != nil {
+				return nil, err
+			}
+			result[t] = []float64{slope}
+		}
+	}
+	return result, nil
+}
+
+// Scaler - standardization of the inputs by the means and standard deviations of the train set,
+// the constant inputs are centered only
+type Scaler struct {
+	Means  []float64
+	Sigmas []float64
+}
+
+// Fit calculate means and standard deviations of the first nvars columns of the rows
+func (f *Scaler) Fit(xy [][]float64, nvars int) error {
+	if len(xy) == 0 {
+		return errors.New("at least one row required")
+	}
+	means := make([]float64, nvars)
+	sigmas := make([]float64, nvars)
+	for i, row := range xy {
+		if len(row) < nvars {
+			return fmt.Errorf("row %d length: %d less than inputs count: %d", i, len(row), nvars)
+		}
+		for j := range means {
+			means[j] += row[j]
+		}
+	}
+	for j := range means {
+		means[j] /= float64(len(xy))
+	}
+	for _, row := range xy {
+		for j := range sigmas {
+			sigmas[j] += (row[j] - means[j]) * (row[j] - means[j])
+		}
+	}
+	for j := range sigmas {
+		if sigmas[j] = math.Sqrt(sigmas[j] / float64(len(xy))); sigmas[j] == 0 {
+			sigmas[j] = 1
+		}
+	}
+	f.Means = means
+	f.Sigmas = sigmas
+	return nil
+}
+
+// Transform return standardized inputs
+func (f *Scaler) Transform(x []float64) ([]float64, error) {
+	if len(f.Means) == 0 {
+		return nil, errors.New("scaler is not fitted")
+	}
+	if len(x) != len(f.Means) {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), len(f.Means))
+	}
+	result := make([]float64, len(x))
+	for j, item := range x {
+		result[j] = (item - f.Means[j]) / f.Sigmas[j]
+	}
+	return result, nil
+}
+
+// TransformDataset return dataset of the standardized inputs followed by the same outputs
+func (f *Scaler) TransformDataset(dataset *Dataset) (*Dataset, error) {
+	return transformDataset(dataset, len(f.Means), f.Transform)
+}
+
+func symbolSeries(rates []entities.Rate, symbol string) ([]float32, error) {
+	result := make([]float32, len(rates))
+	for i, rate := range rates {
+		value, err := rate.GetForSymbol(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if value <= 0 {
+			return nil, fmt.Errorf("rate of '%s' must be positive value", symbol)
+		}
+		result[i] = value
+	}
+	return result, nil
+}
+
+func cyclic(value, period float64) []float64 {
+	angle := 2 * math.Pi * value / period
+	return []float64{math.Sin(angle), math.Cos(angle)}
+}
+
+// trend return slope of the linear regression of the values on the step index
+func trend(values []float64) (float64, error) {
+	xy := make([][]float64, len(values))
+	for i, item := range values {
+		xy[i] = []float64{float64(i), item}
+	}
+	lm := linreg.NewLinearModel()
+	info := 0
+	if err := linreg.LrBuild(&xy, len(xy), 1, &info, lm, linreg.NewLrReport()); err != nil {
+		return 0, err
+	}
+	if info != 1 {
+		return 0, fmt.Errorf("linear regression of %d values failed, info: %d", len(values), info)
+	}
+	var v []float64
+	nvars := 0
+	linreg.LrUnpack(lm, &v, &nvars)
+	return v[0], nil
+}
+
+func deviation(values []float64) float64 {
+	var mean float64
+	for _, item := range values {
+		mean += item
+	}
+	mean /= float64(len(values))
+
+	var result float64
+	for _, item := range values {
+		result += (item - mean) * (item - mean)
+	}
+	return math.Sqrt(result / float64(len(values)-1))
+}
@@ -0,0 +1,163 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+
+	"pr.optima/src/core/forest"
+)
+
+// TTForest - random decision forest over the class windows or the features
+const TTForest = "RDF"
+
+// ForestParams - hyperparameters of the decision forest
+type ForestParams struct {
+	Trees       int     // count of the trees
+	SampleRatio float64 // part of the train set used to build each tree, (0, 1]
+	RndVars     int     // count of the variables compared on each split of the tree, half of the inputs if 0
+}
+
+func init() {
+	RegisterPredictor(TTForest, newForestPredictor, TrainParams{Forest: ForestParams{Trees: 50, SampleRatio: 0.66}})
+}
+
+// forestPredictor - random decision forest, classifier forest outputs the share of the trees voted for
+// each class, regression forest outputs the mean of the trees
+type forestPredictor struct {
+	df     *forest.DecisionForest
+	spec   PredictorSpec
+	report *forest.DfReport
+}
+
+func newForestPredictor(spec PredictorSpec) (Predictor, error) {
+	if spec.Horizon != 1 {
+		return nil, errors.New("decision forest predicts single step only")
+	}
+	if spec.Inputs < 1 {
+		return nil, fmt.Errorf("inputs count: %d must be positive", spec.Inputs)
+	}
+	if spec.NetType != NTRegression && spec.NetType != NTClassifier {
+		return nil, fmt.Errorf("unknown network type: '%s'", spec.NetType)
+	}
+	if spec.NetType == NTClassifier && spec.RangeCount < 2 {
+		return nil, errors.New("classes count must be more than 1")
+	}
+	return &forestPredictor{df: forest.NewDecisionForest(), spec: spec}, nil
+}
+
+// classes return NClasses of the forest: 1 for regression
+func (f *forestPredictor) classes() int {
+	if f.spec.NetType == NTClassifier {
+		return f.spec.RangeCount
+	}
+	return 1
+}
+
+// Fit build the forest on the train set of the dataset
+func (f *forestPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	npoints := dataset.TrainSize()
+	if npoints < 1 {
+		return errors.New("train set is empty")
+	}
+	for i, row := range dataset.Train {
+		if len(row) != f.spec.Inputs+1 {
+			return fmt.Errorf("row %d length: %d, expected inputs: %d and the output", i, len(row), f.spec.Inputs)
+		}
+	}
+	rndVars := params.Forest.RndVars
+	if rndVars == 0 {
+		rndVars = f.spec.Inputs / 2
+		if rndVars < 1 {
+			rndVars = 1
+		}
+	}
+	df := forest.NewDecisionForest()
+	rep := forest.NewDfReport()
+	info := 0
+	if err := forest.DfBuildRandomDecisionForestX1(&dataset.Train, npoints, f.spec.Inputs, f.classes(), params.Forest.Trees, rndVars, params.Forest.SampleRatio, &info, df, rep); err != nil {
+		return err
+	}
+	switch info {
+	case 1:
+	case -2:
+		return fmt.Errorf("class out of range [0, %d) in the train set", f.spec.RangeCount)
+	default:
+		return fmt.Errorf("invalid forest parameters: %d trees, sample ratio %v, %d variables of %d per split",
+			params.Forest.Trees, params.Forest.SampleRatio, rndVars, f.spec.Inputs)
+	}
+	f.df = df
+	f.report = rep
+	f.spec.Params = params
+	return nil
+}
+
+// Predict return votes of the classes or the regression estimate
+func (f *forestPredictor) Predict(x []float64) ([]float64, error) {
+	if f.df.NTrees == 0 {
+		return nil, errors.New("decision forest is not built")
+	}
+	if len(x) != f.df.NVars {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), f.df.NVars)
+	}
+	var y []float64
+	forest.DfProcessi(f.df, &x, &y)
+	return y, nil
+}
+
+// NetType return type of the forest
+func (f *forestPredictor) NetType() string {
+	return f.spec.NetType
+}
+
+// Serialize return trees of the forest
+func (f *forestPredictor) Serialize() ([]float64, error) {
+	if f.df.NTrees == 0 {
+		return nil, errors.New("decision forest is not built")
+	}
+	var ra []float64
+	rlen := 0
+	forest.DfSerialize(f.df, &ra, &rlen)
+	return ra, nil
+}
+
+// Load replace forest by the serialized one of the same inputs and outputs
+func (f *forestPredictor) Load(ra []float64) error {
+	df := forest.NewDecisionForest()
+	if err := forest.DfUnserialize(ra, df); err != nil {
+		return err
+	}
+	if df.NVars != f.spec.Inputs || df.NClasses != f.classes() {
+		return fmt.Errorf("forest %d-%d doesn't match the predictor %d-%d", df.NVars, df.NClasses, f.spec.Inputs, f.classes())
+	}
+	f.df = df
+	f.report = nil
+	return nil
+}
+
+// Describe return size of the forest and its out-of-bag error
+func (f *forestPredictor) Describe() string {
+	result := fmt.Sprintf("RDF %s %d-%d, %d trees", f.spec.NetType, f.spec.Inputs, f.classes(), f.df.NTrees)
+	if f.report != nil {
+		result += fmt.Sprintf(", out-of-bag rms error %.4f", f.report.OobRmsError)
+	}
+	return result
+}
+
+// RmsError return rms error of the forest on the set
+func (f *forestPredictor) RmsError(xy [][]float64) (float64, error) {
+	if f.df.NTrees == 0 {
+		return 0, errors.New("decision forest is not built")
+	}
+	if len(xy) == 0 {
+		return 0, errors.New("empty set")
+	}
+	for i, row := range xy {
+		if len(row) != f.df.NVars+1 {
+			return 0, fmt.Errorf("row %d length: %d, expected inputs: %d and the output", i, len(row), f.df.NVars)
+		}
+		if class := int(row[f.df.NVars]); f.df.NClasses > 1 && (class < 0 || class >= f.df.NClasses) {
+			return 0, fmt.Errorf("row %d: class %d out of range [0, %d)", i, class, f.df.NClasses)
+		}
+	}
+	return forest.DfRmsError(f.df, &xy, len(xy)), nil
+}
@@ -0,0 +1,104 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+
+	"pr.optima/src/core/neural"
+)
+
+const (
+	// HMRecursive - one step network, each prediction is fed back as input of the next step
+	HMRecursive = "recursive"
+	// HMDirect - regression network with one output per step up to the max horizon
+	HMDirect = "direct"
+)
+
+// TrainHorizon return count of the steps predicted by the network at once
+func TrainHorizon(mode string, horizons []int) int {
+	if mode != HMDirect {
+		return 1
+	}
+	return maxHorizon(horizons)
+}
+
+// processor - output of the network or the predictor for the input
+type processor func(x *[]float64) (*[]float64, error)
+
+// ForecastHorizons predict classes for each of the horizons (steps ahead),
+// window holds the last inputs of the network
+func ForecastHorizons(mlp *neural.MultiLayerPerceptron, netType, mode string, window []float64, horizons []int) ([]Forecast, error) {
+	return forecastHorizons(networkProcessor(mlp), netType, mode, window, horizons)
+}
+
+func forecastHorizons(process processor, netType, mode string, window []float64, horizons []int) ([]Forecast, error) {
+	if len(horizons) == 0 {
+		return nil, errors.New("horizons required")
+	}
+	for _, h := range horizons {
+		if h < 1 {
+			return nil, fmt.Errorf("horizon: %d must be positive value", h)
+		}
+	}
+
+	steps := maxHorizon(horizons)
+	forecasts := make([]Forecast, steps)
+	switch mode {
+	case HMDirect:
+		if netType == NTClassifier {
+			return nil, errors.New("direct horizon mode requires regression network")
+		}
+		input := make([]float64, len(window))
+		copy(input, window)
+		y, err := process(&input)
+		if err != nil {
+			return nil, err
+		}
+		output := *y
+		if len(output) < steps {
+			return nil, fmt.Errorf("network outputs: %d less than max horizon: %d", len(output), steps)
+		}
+		for i := range forecasts {
+			forecasts[i], _ = DecodeOutput(output[i:i+1], netType)
+		}
+	case HMRecursive:
+		input := make([]float64, len(window))
+		copy(input, window)
+		for i := range forecasts {
+			y, err := process(&input)
+			if err != nil {
+				return nil, err
+			}
+			forecast, err := DecodeOutput(*y, netType)
+			if err != nil {
+				return nil, err
+			}
+			forecasts[i] = forecast
+			input = append(input[1:], float64(forecast.Class))
+		}
+	default:
+		return nil, fmt.Errorf("unknown horizon mode: '%s'", mode)
+	}
+
+	result := make([]Forecast, len(horizons))
+	for i, h := range horizons {
+		result[i] = forecasts[h-1]
+	}
+	return result, nil
+}
+
+func networkProcessor(mlp *neural.MultiLayerPerceptron) processor {
+	return func(x *[]float64) (*[]float64, error) {
+		return neural.MlpProcess(mlp, x)
+	}
+}
+
+func maxHorizon(horizons []int) int {
+	result := 1
+	for _, h := range horizons {
+		if h > result {
+			result = h
+		}
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/lda"
+)
+
+// TTLda - Fisher LDA of the range class, quick linear classifier to compare with the networks
+const TTLda = "LDA"
+
+// LdaParams - hyperparameters of the LDA classifier
+type LdaParams struct {
+	// floor of the within-class variance, the perfectly separated train set has zero variance
+	MinVariance float64
+}
+
+func init() {
+	RegisterPredictor(TTLda, newLdaPredictor, TrainParams{Lda: LdaParams{MinVariance: 1e-12}})
+}
+
+// Projection - Fisher LDA basis (core/lda) of the input rows, the inputs are replaced by the
+// first Dims directions which best separate the next classes of the train set
+type Projection struct {
+	Dims  int
+	basis [][]float64 // rows are inputs, columns are directions
+}
+
+// NewProjection create not fitted projection onto dims directions
+func NewProjection(dims int) (*Projection, error) {
+	if dims < 1 {
+		return nil, fmt.Errorf("projection dimensions: %d must be positive value", dims)
+	}
+	return &Projection{Dims: dims}, nil
+}
+
+// Fit find the basis by the rows of nvars inputs followed by the class
+func (f *Projection) Fit(xy [][]float64, nvars, nclasses int) error {
+	if f.Dims > nvars {
+		return fmt.Errorf("projection dimensions: %d more than inputs count: %d", f.Dims, nvars)
+	}
+	basis, err := fisherBasis(xy, nvars, nclasses)
+	if err != nil {
+		return err
+	}
+	f.basis = basis
+	return nil
+}
+
+// Transform return coordinates of the inputs in the first Dims directions
+func (f *Projection) Transform(x []float64) ([]float64, error) {
+	if len(f.basis) == 0 {
+		return nil, errors.New("projection is not fitted")
+	}
+	if len(x) != len(f.basis) {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), len(f.basis))
+	}
+	return project(f.basis, x, f.Dims), nil
+}
+
+// TransformDataset return dataset of the projected inputs followed by the same outputs
+func (f *Projection) TransformDataset(dataset *Dataset) (*Dataset, error) {
+	return transformDataset(dataset, f.Dims, f.Transform)
+}
+
+// ldaPredictor - the inputs projected onto the Fisher directions are classified by the
+// distance to the class means scaled by the within-class variance of each direction
+type ldaPredictor struct {
+	spec      PredictorSpec
+	dims      int
+	basis     [][]float64
+	centroids [][]float64 // mean projection of each class
+	variances []float64   // pooled within-class variance of each direction
+	counts    []float64   // train samples of each class, the prior of the class
+}
+
+func newLdaPredictor(spec PredictorSpec) (Predictor, error) {
+	if spec.Horizon != 1 {
+		return nil, errors.New("LDA predicts single step only")
+	}
+	if spec.Inputs < 1 {
+		return nil, fmt.Errorf("inputs count: %d must be positive", spec.Inputs)
+	}
+	if spec.RangeCount < 2 {
+		return nil, errors.New("classes count must be more than 1")
+	}
+	// the class means lie in the subspace of RangeCount-1 directions
+	dims := spec.RangeCount - 1
+	if dims > spec.Inputs {
+		dims = spec.Inputs
+	}
+	return &ldaPredictor{spec: spec, dims: dims}, nil
+}
+
+// Fit find the Fisher directions of the train set, the class means and the variances in them
+func (f *ldaPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	if params.Lda.MinVariance <= 0 {
+		return fmt.Errorf("minimal variance: %v must be positive value", params.Lda.MinVariance)
+	}
+	for i, row := range dataset.Train {
+		if len(row) != f.spec.Inputs+1 {
+			return fmt.Errorf("row %d length: %d, expected inputs: %d and the output", i, len(row), f.spec.Inputs)
+		}
+	}
+	basis, err := fisherBasis(dataset.Train, f.spec.Inputs, f.spec.RangeCount)
+	if err != nil {
+		return err
+	}
+
+	centroids := make([][]float64, f.spec.RangeCount)
+	for i := range centroids {
+		centroids[i] = make([]float64, f.dims)
+	}
+	counts := make([]float64, f.spec.RangeCount)
+	projections := make([][]float64, len(dataset.Train))
+	for i, row := range dataset.Train {
+		class := int(math.Floor(row[f.spec.Inputs] + 0.5))
+		projections[i] = project(basis, row[:f.spec.Inputs], f.dims)
+		for j, item := range projections[i] {
+			centroids[class][j] += item
+		}
+		counts[class]++
+	}
+	classes := 0
+	for i, cnt := range counts {
+		if cnt == 0 {
+			continue
+		}
+		classes++
+		for j := range centroids[i] {
+			centroids[i][j] /= cnt
+		}
+	}
+	if len(dataset.Train) <= classes {
+		return fmt.Errorf("train set size: %d must be more than count of the classes: %d", len(dataset.Train), classes)
+	}
+	variances := make([]float64, f.dims)
+	for i, row := range dataset.Train {
+		class := int(math.Floor(row[f.spec.Inputs] + 0.5))
+		for j, item := range projections[i] {
+			variances[j] += (item - centroids[class][j]) * (item - centroids[class][j])
+		}
+	}
+	for j := range variances {
+		variances[j] = math.Max(variances[j]/float64(len(dataset.Train)-classes), params.Lda.MinVariance)
+	}
+
+	f.basis = basis
+	f.centroids = centroids
+	f.variances = variances
+	f.counts = counts
+	f.spec.Params = params
+	return nil
+}
+
+// Predict return posterior probabilities of the classes
+func (f *ldaPredictor) Predict(x []float64) ([]float64, error) {
+	if len(f.basis) == 0 {
+		return nil, errors.New("LDA is not trained")
+	}
+	if len(x) != f.spec.Inputs {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), f.spec.Inputs)
+	}
+	p := project(f.basis, x, f.dims)
+	result := make([]float64, f.spec.RangeCount)
+	scores := make([]float64, f.spec.RangeCount)
+	max := math.Inf(-1)
+	for i, cnt := range f.counts {
+		if cnt == 0 {
+			continue
+		}
+		scores[i] = math.Log(cnt)
+		for j, item := range p {
+			scores[i] -= (item - f.centroids[i][j]) * (item - f.centroids[i][j]) / (2 * f.variances[j])
+		}
+		max = math.Max(max, scores[i])
+	}
+	var sum float64
+	for i, cnt := range f.counts {
+		if cnt == 0 {
+			continue
+		}
+		result[i] = math.Exp(scores[i] - max)
+		sum += result[i]
+	}
+	for i := range result {
+		result[i] /= sum
+	}
+	return result, nil
+}
+
+// NetType return NTClassifier, the output is the distribution of the classes
+func (f *ldaPredictor) NetType() string {
+	return NTClassifier
+}
+
+// Serialize return sizes followed by the basis, class means, variances and class counts
+func (f *ldaPredictor) Serialize() ([]float64, error) {
+	if len(f.basis) == 0 {
+		return nil, errors.New("LDA is not trained")
+	}
+	result := []float64{float64(f.spec.Inputs), float64(f.spec.RangeCount), float64(f.dims)}
+	for _, row := range f.basis {
+		result = append(result, row[:f.dims]...)
+	}
+	for _, row := range f.centroids {
+		result = append(result, row...)
+	}
+	result = append(result, f.variances...)
+	return append(result, f.counts...), nil
+}
+
+// Load replace model by the serialized one of the same inputs and classes
+func (f *ldaPredictor) Load(ra []float64) error {
+	if len(ra) < 3 {
+		return errors.New("incorrect array!")
+	}
+	nvars, nclasses, dims := int(ra[0]), int(ra[1]), int(ra[2])
+	if nvars != f.spec.Inputs || nclasses != f.spec.RangeCount || dims != f.dims {
+		return fmt.Errorf("LDA %d-%d of %d directions doesn't match the predictor %d-%d", nvars, nclasses, dims, f.spec.Inputs, f.spec.RangeCount)
+	}
+	if len(ra) != 3+nvars*dims+nclasses*dims+dims+nclasses {
+		return errors.New("incorrect array!")
+	}
+	for _, item := range ra {
+		if math.IsNaN(item) || math.IsInf(item, 0) {
+			return errors.New("incorrect array!")
+		}
+	}
+	offs := 3
+	next := func(cnt int) []float64 {
+		result := append([]float64{}, ra[offs:offs+cnt]...)
+		offs += cnt
+		return result
+	}
+	basis := make([][]float64, nvars)
+	for i := range basis {
+		basis[i] = next(dims)
+	}
+	centroids := make([][]float64, nclasses)
+	for i := range centroids {
+		centroids[i] = next(dims)
+	}
+	f.basis = basis
+	f.centroids = centroids
+	f.variances = next(dims)
+	f.counts = next(nclasses)
+	return nil
+}
+
+// Describe return size of the model and count of the directions
+func (f *ldaPredictor) Describe() string {
+	return fmt.Sprintf("LDA %d-%d, %d directions", f.spec.Inputs, f.spec.RangeCount, f.dims)
+}
+
+// RmsError return rms error of the posterior probabilities on the set, the same as of the classifier network
+func (f *ldaPredictor) RmsError(xy [][]float64) (float64, error) {
+	if len(xy) == 0 {
+		return 0, errors.New("empty set")
+	}
+	var sum float64
+	for i, row := range xy {
+		if len(row) != f.spec.Inputs+1 {
+			return 0, fmt.Errorf("row %d length: %d, expected inputs: %d and the class", i, len(row), f.spec.Inputs)
+		}
+		p, err := f.Predict(row[:f.spec.Inputs])
+		if err != nil {
+			return 0, err
+		}
+		class := int(row[f.spec.Inputs])
+		for j, item := range p {
+			if j == class {
+				item--
+			}
+			sum += item * item
+		}
+	}
+	return math.Sqrt(sum / float64(len(xy)*f.spec.RangeCount)), nil
+}
+
+// fisherBasis return Fisher LDA basis of the rows of nvars inputs followed by the class
+func fisherBasis(xy [][]float64, nvars, nclasses int) ([][]float64, error) {
+	var basis [][]float64
+	info := 0
+	if err := lda.FisherLdaN(&xy, len(xy), nvars, nclasses, &info, &basis); err != nil {
+		return nil, err
+	}
+	switch info {
+	case 1, 2:
+	case -2:
+		return nil, fmt.Errorf("class out of range [0, %d) in the train set", nclasses)
+	default:
+		return nil, fmt.Errorf("Fisher LDA of %d rows failed, info: %d", len(xy), info)
+	}
+	return basis, nil
+}
+
+// project return coordinates of the inputs in the first dims columns of the basis
+func project(basis [][]float64, x []float64, dims int) []float64 {
+	result := make([]float64, dims)
+	for i, item := range x {
+		for j := range result {
+			result[j] += item * basis[i][j]
+		}
+	}
+	return result
+}
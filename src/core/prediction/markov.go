@@ -0,0 +1,150 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/statistic/markov"
+)
+
+// TTMarkov - n-th order Markov chain of the range classes, interpretable baseline of the networks
+const TTMarkov = "MARKOV"
+
+// MarkovParams - hyperparameters of the Markov chain
+type MarkovParams struct {
+	Order     int     // length of the history of the chain
+	Smoothing float64 // weight of the prior distribution of the transitions
+}
+
+func init() {
+	RegisterPredictor(TTMarkov, newMarkovPredictor, TrainParams{Markov: MarkovParams{Order: 1, Smoothing: 1}})
+}
+
+// markovPredictor - transition matrix of the classes estimated on the train set,
+// the output is the distribution of the next class after the last Order classes of the window
+type markovPredictor struct {
+	chain *markov.Chain
+	spec  PredictorSpec
+}
+
+func newMarkovPredictor(spec PredictorSpec) (Predictor, error) {
+	if spec.Horizon != 1 {
+		return nil, errors.New("Markov chain predicts single step only")
+	}
+	chain, err := newChain(spec, spec.Params.Markov)
+	if err != nil {
+		return nil, err
+	}
+	return &markovPredictor{chain: chain, spec: spec}, nil
+}
+
+func newChain(spec PredictorSpec, params MarkovParams) (*markov.Chain, error) {
+	if params.Order > spec.Inputs {
+		return nil, fmt.Errorf("Markov chain order: %d more than the window: %d", params.Order, spec.Inputs)
+	}
+	return markov.NewChain(spec.RangeCount, params.Order)
+}
+
+// Fit count transitions of the train set and estimate the transition matrix,
+// the chain is recreated on each fit so the order may be changed by the params
+func (f *markovPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	chain, err := newChain(f.spec, params.Markov)
+	if err != nil {
+		return err
+	}
+	for i, row := range dataset.Train {
+		if len(row) != f.spec.Inputs+1 {
+			return fmt.Errorf("row %d length: %d, expected window: %d and the next class", i, len(row), f.spec.Inputs)
+		}
+		classes, err := toClasses(row)
+		if err != nil {
+			return fmt.Errorf("row %d: %v", i, err)
+		}
+		if err := chain.AddTransition(classes[:f.spec.Inputs], classes[f.spec.Inputs]); err != nil {
+			return fmt.Errorf("row %d: %v", i, err)
+		}
+	}
+	if err := chain.Solve(params.Markov.Smoothing); err != nil {
+		return err
+	}
+	f.chain = chain
+	f.spec.Params = params
+	return nil
+}
+
+// Predict return probabilities of the next class
+func (f *markovPredictor) Predict(x []float64) ([]float64, error) {
+	classes, err := toClasses(x)
+	if err != nil {
+		return nil, err
+	}
+	return f.chain.Probabilities(classes)
+}
+
+// NetType return NTClassifier, the output is the distribution of the classes
+func (f *markovPredictor) NetType() string {
+	return NTClassifier
+}
+
+// Serialize return transition counts of the chain
+func (f *markovPredictor) Serialize() ([]float64, error) {
+	return f.chain.Serialize(), nil
+}
+
+// Load replace chain by the serialized one over the same classes
+func (f *markovPredictor) Load(ra []float64) error {
+	chain, err := markov.Unserialize(ra)
+	if err != nil {
+		return err
+	}
+	if chain.States() != f.spec.RangeCount || chain.Order() > f.spec.Inputs {
+		return fmt.Errorf("Markov chain of the order %d over %d classes doesn't match the predictor window %d over %d classes",
+			chain.Order(), chain.States(), f.spec.Inputs, f.spec.RangeCount)
+	}
+	f.chain = chain
+	return nil
+}
+
+// Describe return order and smoothing of the chain
+func (f *markovPredictor) Describe() string {
+	return fmt.Sprintf("Markov chain order %d over %d classes, smoothing %v, %d transitions",
+		f.chain.Order(), f.chain.States(), f.chain.Smoothing(), f.chain.Transitions())
+}
+
+// RmsError return rms error of the probabilities of the classes, the same as of the classifier network
+func (f *markovPredictor) RmsError(xy [][]float64) (float64, error) {
+	if len(xy) == 0 {
+		return 0, errors.New("empty set")
+	}
+	var sum float64
+	for i, row := range xy {
+		if len(row) != f.spec.Inputs+1 {
+			return 0, fmt.Errorf("row %d length: %d, expected window: %d and the next class", i, len(row), f.spec.Inputs)
+		}
+		p, err := f.Predict(row[:f.spec.Inputs])
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %v", i, err)
+		}
+		class := int(row[f.spec.Inputs])
+		for j, item := range p {
+			if j == class {
+				item--
+			}
+			sum += item * item
+		}
+	}
+	return math.Sqrt(sum / float64(len(xy)*f.spec.RangeCount)), nil
+}
+
+// toClasses convert window of the classes, the features are not supported
+func toClasses(x []float64) ([]int, error) {
+	result := make([]int, len(x))
+	for i, item := range x {
+		if item != math.Floor(item) {
+			return nil, fmt.Errorf("input %d: %v is not a class", i, item)
+		}
+		result[i] = int(item)
+	}
+	return result, nil
+}
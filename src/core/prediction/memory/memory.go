@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"pr.optima/src/core/entities"
+)
+
+// in-memory implementations of the repos, used by offline runs (backtest, search) and tests without datastore,
+// the package belongs to the core, so the prediction doesn't depend on the storage layer, repository wraps it
+
+// RateRepo - in-memory repo of the rates
+type RateRepo struct {
+	mutex      sync.Mutex
+	limit      int
+	autoResize bool
+	data       []entities.Rate
+}
+
+// NewRateRepo - return new instance of the RateRepo filled by rates, sorted by timestamp
+func NewRateRepo(limit int, autoResize bool, rates []entities.Rate) *RateRepo {
+	rr := new(RateRepo)
+	rr.limit = limit
+	rr.autoResize = autoResize == true
+	rr.data = make([]entities.Rate, 0, len(rates))
+	for _, rate := range rates {
+		rr.Push(rate)
+	}
+	return rr
+}
+
+// Push - add rate to repo
+func (rr *RateRepo) Push(rate entities.Rate) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	if l := len(rr.data); l > 0 && rate.ID <= rr.data[l-1].ID {
+		return fmt.Errorf("shift required (last: %d, new: %d)", rr.data[l-1].ID, rate.ID)
+	}
+	rr.data = append(rr.data, rate)
+	if l := len(rr.data); l > rr.limit && rr.autoResize {
+		rr.data = rr.data[l-rr.limit:]
+	}
+	return nil
+}
+
+// Len return length of stored data
+func (rr *RateRepo) Len() int {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return len(rr.data)
+}
+
+// GetAll return array of rates
+func (rr *RateRepo) GetAll() []entities.Rate {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return rr.data
+}
+
+// GetLast - return the last Rate from repo
+func (rr *RateRepo) GetLast() (entities.Rate, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	if l := len(rr.data); l > 0 {
+		return rr.data[l-1], true
+	}
+	return entities.Rate{}, false
+}
+
+// Close - close repo method
+func (rr *RateRepo) Close() []entities.Rate {
+	return rr.GetAll()
+}
+
+// Resize - resize repo length
+func (rr *RateRepo) Resize(size int) (int, error) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	l := len(rr.data)
+	if size < 0 || l < size {
+		return -1, fmt.Errorf("repo size: %d less than new size: %d", l, size)
+	}
+	rr.data = rr.data[l-size:]
+	return size, nil
+}
+
+// Reload - nothing to reload, return length of stored data
+func (rr *RateRepo) Reload() (int, error) {
+	return rr.Len(), nil
+}
+
+// Clear remove rates older than date
+func (rr *RateRepo) Clear(date int64) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	var data []entities.Rate
+	for _, item := range rr.data {
+		if item.ID >= date {
+			data = append(data, item)
+		}
+	}
+	rr.data = data
+	return nil
+}
+
+// ResultDataRepo - in-memory repo of the results, keeps all pushed records besides the limited window
+type ResultDataRepo struct {
+	mutex      sync.Mutex
+	symbol     string
+	trainType  string
+	limit      int
+	autoResize bool
+	lastID     int64
+	data       []entities.ResultData
+	history    []entities.ResultData
+}
+
+// NewResultDataRepo - return new instance of the ResultDataRepo, limit is ignored without autoResize
+func NewResultDataRepo(limit int, autoResize bool, trainType, symbol string) *ResultDataRepo {
+	rr := new(ResultDataRepo)
+	rr.symbol = symbol
+	rr.trainType = trainType
+	rr.limit = limit
+	rr.autoResize = autoResize == true
+	return rr
+}
+
+// Push add new ResultData to repo
+func (rr *ResultDataRepo) Push(value entities.ResultData) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	// the same shift rule as the datastore repo
+	if value.Timestamp < rr.lastID+2500 {
+		return fmt.Errorf("shift required (last: %d, new: %d)", rr.lastID, value.Timestamp)
+	}
+	rr.lastID = value.Timestamp
+	rr.data = append(rr.data, value)
+	rr.history = append(rr.history, value)
+	if l := len(rr.data); l > rr.limit && rr.autoResize {
+		rr.data = rr.data[l-rr.limit:]
+	}
+	return nil
+}
+
+// Sync repo
+func (rr *ResultDataRepo) Sync(value entities.ResultData) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	key := value.GetCompositeKey()
+	found := false
+	for i := len(rr.data) - 1; i > -1; i-- {
+		if rr.data[i].GetCompositeKey() == key {
+			rr.data[i] = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("ResultDataRepo Sync error: local data with key '%s' not found", key)
+	}
+	for i := len(rr.history) - 1; i > -1; i-- {
+		if rr.history[i].GetCompositeKey() == key {
+			rr.history[i] = value
+			break
+		}
+	}
+	return nil
+}
+
+// Len length of the repo
+func (rr *ResultDataRepo) Len() int {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return len(rr.data)
+}
+
+// GetAll - return all stored data
+func (rr *ResultDataRepo) GetAll() []entities.ResultData {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return rr.data
+}
+
+// History - return all pushed records, including the ones dropped by resize
+func (rr *ResultDataRepo) History() []entities.ResultData {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return rr.history
+}
+
+// Close repo
+func (rr *ResultDataRepo) Close() []entities.ResultData {
+	return rr.GetAll()
+}
+
+// GetLast retrun the last item from repo
+func (rr *ResultDataRepo) GetLast() (entities.ResultData, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	if l := len(rr.data); l > 0 {
+		return rr.data[l-1], true
+	}
+	return entities.ResultData{}, false
+}
+
+// Get return item by timestamp
+func (rr *ResultDataRepo) Get(timestamp int64) (entities.ResultData, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	// the engine looks up the latest predictions
+	for i := len(rr.data) - 1; i > -1; i-- {
+		if rr.data[i].Timestamp == timestamp {
+			return rr.data[i], true
+		}
+	}
+	return entities.ResultData{}, false
+}
+
+// Resize chanhe size of the repo
+func (rr *ResultDataRepo) Resize(size int) (int, error) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	l := len(rr.data)
+	if size < 0 || l < size {
+		return -1, fmt.Errorf("repo size: %d less than new size: %d", l, size)
+	}
+	rr.data = rr.data[l-size:]
+	return size, nil
+}
+
+// Reload - nothing to reload, return length of stored data
+func (rr *ResultDataRepo) Reload() (int, error) {
+	return rr.Len(), nil
+}
+
+// Clear remove data older than date
+func (rr *ResultDataRepo) Clear(date int64) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	var data []entities.ResultData
+	for _, item := range rr.data {
+		if item.Timestamp >= date {
+			data = append(data, item)
+		}
+	}
+	rr.data = data
+	return nil
+}
+
+// EfficiencyRepo - in-memory repo of the efficiency records
+type EfficiencyRepo struct {
+	mutex       sync.Mutex
+	symbol      string
+	limit       int32
+	frame       int32
+	rangesCount int32
+	trainType   string
+	data        []entities.Efficiency
+}
+
+// NewEfficiencyRepo return instance of the EfficiencyRepo, GetLast of the empty repo return new record of the model
+func NewEfficiencyRepo(trainType, symbol string, rangesCount, limit, frame int32) *EfficiencyRepo {
+	rr := new(EfficiencyRepo)
+	rr.symbol = symbol
+	rr.trainType = trainType
+	rr.limit = limit
+	rr.frame = frame
+	rr.rangesCount = rangesCount
+	return rr
+}
+
+// Sync replace record with the same key or add the new one
+func (rr *EfficiencyRepo) Sync(value entities.Efficiency) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	key := value.GetCompositeKey()
+	for i, item := range rr.data {
+		if item.GetCompositeKey() == key {
+			rr.data[i] = value
+			return nil
+		}
+	}
+	rr.data = append(rr.data, value)
+	return nil
+}
+
+// Len length of the repo
+func (rr *EfficiencyRepo) Len() int {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return len(rr.data)
+}
+
+// GetAll - return all stored data
+func (rr *EfficiencyRepo) GetAll() []entities.Efficiency {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	return rr.data
+}
+
+// Close repo
+func (rr *EfficiencyRepo) Close() []entities.Efficiency {
+	return rr.GetAll()
+}
+
+// GetLast return the last record
+func (rr *EfficiencyRepo) GetLast() (entities.Efficiency, bool) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	if l := len(rr.data); l > 0 {
+		return rr.data[l-1], true
+	}
+	return entities.Efficiency{TrainType: rr.trainType, Symbol: rr.symbol, RangesCount: rr.rangesCount, Limit: rr.limit, Frame: rr.frame}, false
+}
+
+// Reload - nothing to reload, return length of stored data
+func (rr *EfficiencyRepo) Reload() (int, error) {
+	return rr.Len(), nil
+}
+
+// Clear remove data older than date
+func (rr *EfficiencyRepo) Clear(date int64) error {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	var data []entities.Efficiency
+	for _, item := range rr.data {
+		if item.Timestamp >= date {
+			data = append(data, item)
+		}
+	}
+	rr.data = data
+	return nil
+}
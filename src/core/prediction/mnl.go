@@ -0,0 +1,136 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+
+	"pr.optima/src/core/logit"
+)
+
+// TTMnl - multinomial logit regression of the range class, cheap calibrated classifier to compare with the networks
+const TTMnl = "MNL"
+
+func init() {
+	RegisterPredictor(TTMnl, newMnlPredictor, TrainParams{})
+}
+
+// mnlPredictor - multinomial logit model, the output is the posterior probabilities of the classes
+type mnlPredictor struct {
+	lm     *logit.LogitModel
+	spec   PredictorSpec
+	report *logit.MnlReport
+}
+
+func newMnlPredictor(spec PredictorSpec) (Predictor, error) {
+	if spec.Horizon != 1 {
+		return nil, errors.New("logit model predicts single step only")
+	}
+	if spec.Inputs < 1 {
+		return nil, fmt.Errorf("inputs count: %d must be positive", spec.Inputs)
+	}
+	if spec.RangeCount < 2 {
+		return nil, errors.New("classes count must be more than 1")
+	}
+	return &mnlPredictor{lm: logit.NewLogitModel(), spec: spec}, nil
+}
+
+// Fit train the model on the train set of the dataset
+func (f *mnlPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	npoints := dataset.TrainSize()
+	for i, row := range dataset.Train {
+		if len(row) != f.spec.Inputs+1 {
+			return fmt.Errorf("row %d length: %d, expected inputs: %d and the output", i, len(row), f.spec.Inputs)
+		}
+	}
+	lm := logit.NewLogitModel()
+	rep := logit.NewMnlReport()
+	info := 0
+	if err := logit.MnlTrainH(&dataset.Train, npoints, f.spec.Inputs, f.spec.RangeCount, &info, lm, rep); err != nil {
+		return err
+	}
+	switch info {
+	case 1:
+	case -2:
+		return fmt.Errorf("class out of range [0, %d) in the train set", f.spec.RangeCount)
+	default:
+		return fmt.Errorf("train set size: %d less than inputs count + 2: %d", npoints, f.spec.Inputs+2)
+	}
+	f.lm = lm
+	f.report = rep
+	f.spec.Params = params
+	return nil
+}
+
+// Predict return posterior probabilities of the classes
+func (f *mnlPredictor) Predict(x []float64) ([]float64, error) {
+	if len(f.lm.W) == 0 {
+		return nil, errors.New("logit model is not trained")
+	}
+	if len(x) != f.spec.Inputs {
+		return nil, fmt.Errorf("input length: %d, expected: %d", len(x), f.spec.Inputs)
+	}
+	var y []float64
+	logit.MnlProcessi(f.lm, &x, &y)
+	return y, nil
+}
+
+// NetType return NTClassifier, the output is the distribution of the classes
+func (f *mnlPredictor) NetType() string {
+	return NTClassifier
+}
+
+// Serialize return coefficients of the model
+func (f *mnlPredictor) Serialize() ([]float64, error) {
+	if len(f.lm.W) == 0 {
+		return nil, errors.New("logit model is not trained")
+	}
+	var ra []float64
+	rlen := 0
+	logit.MnlSerialize(f.lm, &ra, &rlen)
+	return ra, nil
+}
+
+// Load replace model by the serialized one of the same inputs and classes
+func (f *mnlPredictor) Load(ra []float64) error {
+	lm := logit.NewLogitModel()
+	if err := logit.MnlUnserialize(ra, lm); err != nil {
+		return err
+	}
+	var a [][]float64
+	nvars, nclasses := 0, 0
+	logit.MnlUnpack(lm, &a, &nvars, &nclasses)
+	if nvars != f.spec.Inputs || nclasses != f.spec.RangeCount {
+		return fmt.Errorf("logit model %d-%d doesn't match the predictor %d-%d", nvars, nclasses, f.spec.Inputs, f.spec.RangeCount)
+	}
+	f.lm = lm
+	f.report = nil
+	return nil
+}
+
+// Describe return size of the model and the train effort
+func (f *mnlPredictor) Describe() string {
+	result := fmt.Sprintf("MNL %d-%d", f.spec.Inputs, f.spec.RangeCount)
+	if f.report != nil {
+		result += fmt.Sprintf(", %d gradients, %d hessians", f.report.NGrad, f.report.NHess)
+	}
+	return result
+}
+
+// RmsError return rms error of the posterior probabilities on the set
+func (f *mnlPredictor) RmsError(xy [][]float64) (float64, error) {
+	if len(f.lm.W) == 0 {
+		return 0, errors.New("logit model is not trained")
+	}
+	if len(xy) == 0 {
+		return 0, errors.New("empty set")
+	}
+	for i, row := range xy {
+		if len(row) != f.spec.Inputs+1 {
+			return 0, fmt.Errorf("row %d length: %d, expected inputs: %d and the class", i, len(row), f.spec.Inputs)
+		}
+		if class := int(row[f.spec.Inputs]); class < 0 || class >= f.spec.RangeCount {
+			return 0, fmt.Errorf("row %d: class %d out of range [0, %d)", i, class, f.spec.RangeCount)
+		}
+	}
+	return logit.MnlRmsError(f.lm, &xy, len(xy)), nil
+}
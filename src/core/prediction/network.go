@@ -0,0 +1,177 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/neural"
+)
+
+const (
+	// NTRegression - network with linear output, predicted class is the rounded output
+	NTRegression = "regression"
+	// NTClassifier - network with SOFTMAX-normalized outputs, one output per range class
+	NTClassifier = "classifier"
+
+	classifierSuffix = "SOFTMAX"
+)
+
+// Forecast - decoded network output
+type Forecast struct {
+	Class         int
+	Probabilities []float64 // posterior probabilities of the classes, nil for regression network
+	Confidence    float64   // probability of the predicted class, zero for regression network
+}
+
+// NewNetwork create network of the required type with 0, 1 or 2 hidden layers.
+// Regression network has one output per predicted step (horizon),
+// classifier network predicts one step and has one output per class.
+func NewNetwork(netType string, nin, classes, horizon int, hidden ...int) (*neural.MultiLayerPerceptron, error) {
+	if nin < 1 || classes < 2 || horizon < 1 {
+		return nil, errors.New("inputs count and horizon must be positive, classes count must be more than 1")
+	}
+	switch netType {
+	case NTRegression:
+		switch len(hidden) {
+		case 0:
+			return neural.MlpCreate0(nin, horizon)
+		case 1:
+			return neural.MlpCreate1(nin, hidden[0], horizon)
+		case 2:
+			return neural.MlpCreate2(nin, hidden[0], hidden[1], horizon)
+		}
+	case NTClassifier:
+		if horizon != 1 {
+			return nil, errors.New("classifier network predicts single step only")
+		}
+		switch len(hidden) {
+		case 0:
+			return neural.MlpCreateC0(nin, classes)
+		case 1:
+			return neural.MlpCreateC1(nin, hidden[0], classes)
+		case 2:
+			return neural.MlpCreateC2(nin, hidden[0], hidden[1], classes)
+		}
+	default:
+		return nil, fmt.Errorf("unknown network type: '%s'", netType)
+	}
+	return nil, fmt.Errorf("unsupported hidden layers count: %d", len(hidden))
+}
+
+// ModelName return name used as TrainType of the stored results,
+// regression networks keep plain train type name
+func ModelName(trainType, netType string) string {
+	if netType == NTClassifier {
+		return fmt.Sprintf("%s-%s", trainType, classifierSuffix)
+	}
+	return trainType
+}
+
+// DecodeOutput convert network output to the predicted class
+func DecodeOutput(output []float64, netType string) (Forecast, error) {
+	if len(output) == 0 {
+		return Forecast{Class: -1}, errors.New("network output is empty")
+	}
+	if netType != NTClassifier {
+		return Forecast{Class: int(math.Floor(output[0] + .5))}, nil
+	}
+
+	result := Forecast{Probabilities: make([]float64, len(output))}
+	copy(result.Probabilities, output)
+	for i, item := range output {
+		if i == 0 || item > result.Confidence {
+			result.Class = i
+			result.Confidence = item
+		}
+	}
+	return result, nil
+}
+
+// networkPredictor - network trained by the registered train type
+type networkPredictor struct {
+	mlp       *neural.MultiLayerPerceptron
+	trainType string
+	netType   string
+	report    *TrainReport // report of the last training
+}
+
+func newNetworkPredictor(spec PredictorSpec) (Predictor, error) {
+	mlp, err := NewNetwork(spec.NetType, spec.Inputs, spec.RangeCount, spec.Horizon, spec.Hidden...)
+	if err != nil {
+		return nil, err
+	}
+	return &networkPredictor{mlp: mlp, trainType: spec.TrainType, netType: spec.NetType}, nil
+}
+
+// Fit train network on the train set of the dataset
+func (f *networkPredictor) Fit(dataset *Dataset, params TrainParams) error {
+	return f.FitContext(context.Background(), dataset, params)
+}
+
+// FitContext - Fit cancelled by the context
+func (f *networkPredictor) FitContext(ctx context.Context, dataset *Dataset, params TrainParams) error {
+	report, err := TrainContext(ctx, f.trainType, f.mlp, &dataset.Train, dataset.TrainSize(), params.Network)
+	if err != nil {
+		return err
+	}
+	f.report = report
+	return nil
+}
+
+// Predict return network output
+func (f *networkPredictor) Predict(x []float64) ([]float64, error) {
+	y, err := neural.MlpProcess(f.mlp, &x)
+	if err != nil {
+		return nil, err
+	}
+	return *y, nil
+}
+
+// NetType return type of the network
+func (f *networkPredictor) NetType() string {
+	return f.netType
+}
+
+// Serialize return structure, weights and standartisator of the network
+func (f *networkPredictor) Serialize() ([]float64, error) {
+	return neural.MlpSerialize(f.mlp), nil
+}
+
+// Load replace network by the serialized one of the same inputs and outputs
+func (f *networkPredictor) Load(ra []float64) error {
+	mlp, err := neural.MlpUnserialize(ra)
+	if err != nil {
+		return err
+	}
+	nin, nout, _ := neural.MlpProperties(mlp)
+	expectedNin, expectedNout, _ := neural.MlpProperties(f.mlp)
+	if nin != expectedNin || nout != expectedNout || neural.MlpIsSoftMax(mlp) != neural.MlpIsSoftMax(f.mlp) {
+		return fmt.Errorf("network %d-%d doesn't match the predictor %d-%d", nin, nout, expectedNin, expectedNout)
+	}
+	f.mlp = mlp
+	f.report = nil
+	return nil
+}
+
+// Report return report of the last training
+func (f *networkPredictor) Report() *TrainReport {
+	return f.report
+}
+
+// Describe return type and geometry of the network, termination reason and cross-validation error of the training
+func (f *networkPredictor) Describe() string {
+	nin, nout, wcount := neural.MlpProperties(f.mlp)
+	result := fmt.Sprintf("MLP %s %d-%d, %d weights, %s", f.netType, nin, nout, wcount, f.trainType)
+	if f.report != nil {
+		result += f.report.describe("cross-validation")
+	}
+	return result
+}
+
+// RmsError return rms error of the network on the set
+func (f *networkPredictor) RmsError(xy [][]float64) (float64, error) {
+	return neural.MlpRmsError(f.mlp, &xy, len(xy))
+}
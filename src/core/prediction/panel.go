@@ -0,0 +1,141 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/pca"
+	"pr.optima/src/core/statistic/correlation"
+)
+
+// PanelSymbols - USD based quotes of the rate snapshot, the panel of the principal components
+var PanelSymbols = []string{"RUB", "JPY", "GBP", "EUR", "CNY", "CHF"}
+
+// PanelComponents - principal components (core/pca) of the relative rate changes of the symbols:
+// the first component is usually the common dollar factor, the rest are the idiosyncratic moves
+type PanelComponents struct {
+	Symbols   []string
+	Means     []float64   // mean change of each symbol
+	Variances []float64   // variance of each component in descending order
+	Loadings  [][]float64 // Loadings[c][s] - weight of the symbol s in the component c, the sum of the weights is not negative
+}
+
+// PanelPca return principal components of the last window rate changes of the symbols
+func PanelPca(rates []entities.Rate, symbols []string, window int) (*PanelComponents, error) {
+	if len(symbols) == 0 {
+		return nil, errors.New("at least one symbol required")
+	}
+	if window < 2 {
+		return nil, errors.New("window must be more than 1")
+	}
+	if len(rates) < window+1 {
+		return nil, fmt.Errorf("rates count: %d less than window: %d and the previous rate", len(rates), window)
+	}
+	changes, err := panelChanges(rates[len(rates)-window-1:], symbols)
+	if err != nil {
+		return nil, err
+	}
+	return panelComponents(changes, symbols)
+}
+
+// PanelCorrelation return correlation matrix (statistic/correlation) of the last window rate changes
+// of the symbols by the method, the p-values test the hypothesis of the zero correlation
+func PanelCorrelation(rates []entities.Rate, symbols []string, window int, method string) (*correlation.Matrix, error) {
+	if len(symbols) < 2 {
+		return nil, errors.New("at least two symbols required")
+	}
+	if window < 2 {
+		return nil, errors.New("window must be more than 1")
+	}
+	if len(rates) < window+1 {
+		return nil, fmt.Errorf("rates count: %d less than window: %d and the previous rate", len(rates), window)
+	}
+	changes, err := panelChanges(rates[len(rates)-window-1:], symbols)
+	if err != nil {
+		return nil, err
+	}
+	return correlation.NewMatrix(changes, method)
+}
+
+// Score return the score of the changes of the symbols by the component
+func (f *PanelComponents) Score(changes []float64, component int) (float64, error) {
+	if component < 0 || component >= len(f.Loadings) {
+		return 0, fmt.Errorf("component: %d out of range [0, %d)", component, len(f.Loadings))
+	}
+	if len(changes) != len(f.Symbols) {
+		return 0, fmt.Errorf("changes count: %d, expected: %d", len(changes), len(f.Symbols))
+	}
+	var result float64
+	for i, item := range changes {
+		result += (item - f.Means[i]) * f.Loadings[component][i]
+	}
+	return result, nil
+}
+
+// ExplainedRatio return part of the total variance explained by the component
+func (f *PanelComponents) ExplainedRatio(component int) float64 {
+	var total float64
+	for _, item := range f.Variances {
+		total += item
+	}
+	if component < 0 || component >= len(f.Variances) || total == 0 {
+		return 0
+	}
+	return f.Variances[component] / total
+}
+
+func panelComponents(changes [][]float64, symbols []string) (*PanelComponents, error) {
+	nvars := len(symbols)
+	var s2 []float64
+	var v [][]float64
+	info := 0
+	if err := pca.PcaBuildBasis(&changes, len(changes), nvars, &info, &s2, &v); err != nil {
+		return nil, err
+	}
+	if info != 1 {
+		return nil, fmt.Errorf("principal components of %d changes failed, info: %d", len(changes), info)
+	}
+
+	result := &PanelComponents{Symbols: symbols, Means: make([]float64, nvars), Variances: s2, Loadings: make([][]float64, nvars)}
+	for _, row := range changes {
+		for j := range result.Means {
+			result.Means[j] += row[j] / float64(len(changes))
+		}
+	}
+	for c := range result.Loadings {
+		loadings := make([]float64, nvars)
+		var sum float64
+		for j := range loadings {
+			loadings[j] = v[j][c]
+			sum += loadings[j]
+		}
+		// the sign of the basis vector is arbitrary, the common move of the symbols is positive
+		if sum < 0 {
+			for j := range loadings {
+				loadings[j] = -loadings[j]
+			}
+		}
+		result.Loadings[c] = loadings
+	}
+	return result, nil
+}
+
+// panelChanges return relative changes of the symbols between the rates, a row per change
+func panelChanges(rates []entities.Rate, symbols []string) ([][]float64, error) {
+	series := make([][]float32, len(symbols))
+	for j, symbol := range symbols {
+		var err error
+		if series[j], err = symbolSeries(rates, symbol); err != nil {
+			return nil, err
+		}
+	}
+	result := make([][]float64, len(rates)-1)
+	for i := range result {
+		result[i] = make([]float64, len(symbols))
+		for j := range symbols {
+			result[i][j] = float64(series[j][i+1]/series[j][i]) - 1
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,1317 @@
+package prediction_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/neural"
+	"pr.optima/src/core/prediction"
+	"pr.optima/src/core/prediction/memory"
+	"pr.optima/src/core/statistic"
+	"pr.optima/src/core/statistic/correlation"
+)
+
+func TestTrainTypes(t *testing.T) {
+	npoints := 40
+	train := make([][]float64, npoints)
+	for i := range train {
+		a, b := rand.Float64(), rand.Float64()
+		train[i] = []float64{a, b, a + 2*b}
+	}
+
+	for _, trainType := range prediction.TrainTypes() {
+		params, err := prediction.DefaultTrainParams(trainType)
+		if err != nil {
+			t.Fatalf("%s default params error: %v", trainType, err)
+		}
+		mlp, _ := neural.MlpCreate1(2, 3, 1)
+		if _, err := prediction.Train(trainType, mlp, &train, npoints, params.Network); err != nil {
+			t.Errorf("%s train error: %v", trainType, err)
+		}
+	}
+
+	mlp, _ := neural.MlpCreate1(2, 3, 1)
+	if _, err := prediction.Train("unknown", mlp, &train, npoints, prediction.NetworkParams{}); err == nil {
+		t.Error("unknown train type must return error")
+	}
+}
+
+func TestBuildDataset(t *testing.T) {
+	series := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	dataset, err := prediction.BuildDataset(series, 3, 1, 1, 0.2)
+	if err != nil {
+		t.Fatalf("build dataset error: %v", err)
+	}
+	if dataset.TrainSize() != 6 || dataset.ValidationSize() != 1 {
+		t.Fatalf("wrong dataset size, train: %d, validation: %d", dataset.TrainSize(), dataset.ValidationSize())
+	}
+	for i, row := range append(dataset.Train, dataset.Validation...) {
+		for j, item := range row {
+			if item != float64(i+j) {
+				t.Fatalf("wrong sample %d: %v", i, row)
+			}
+		}
+	}
+
+	// the newest sample must end with the last element of the series
+	dataset, err = prediction.BuildDataset(series, 3, 2, 3, 0)
+	if err != nil {
+		t.Fatalf("build dataset error: %v", err)
+	}
+	last := dataset.Train[dataset.TrainSize()-1]
+	if dataset.TrainSize() != 2 || last[0] != 5 || last[4] != 9 {
+		t.Errorf("wrong strided dataset: %v", dataset.Train)
+	}
+
+	if _, err := prediction.BuildDataset(series, 8, 3, 1, 0); err == nil {
+		t.Error("series shorter than frame + horizon must return error")
+	}
+}
+
+func TestClassifierNetwork(t *testing.T) {
+	npoints := 60
+	train := make([][]float64, npoints)
+	for i := range train {
+		a, b := rand.Float64(), rand.Float64()
+		class := 0.0
+		if a > b {
+			class = 1
+		}
+		train[i] = []float64{a, b, class}
+	}
+
+	mlp, err := prediction.NewNetwork(prediction.NTClassifier, 2, 2, 1, 4)
+	if err != nil {
+		t.Fatalf("create network error: %v", err)
+	}
+	if _, err := prediction.Train(prediction.TTLbfgs, mlp, &train, npoints, prediction.NetworkParams{Decay: 0.001, Restarts: 2, WStep: 0.01}); err != nil {
+		t.Fatalf("train error: %v", err)
+	}
+
+	x := []float64{0.9, 0.1}
+	y, err := neural.MlpProcess(mlp, &x)
+	if err != nil {
+		t.Fatalf("process error: %v", err)
+	}
+	forecast, err := prediction.DecodeOutput(*y, prediction.NTClassifier)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if forecast.Class != 1 || len(forecast.Probabilities) != 2 || forecast.Confidence < 0.5 {
+		t.Errorf("wrong forecast: %+v", forecast)
+	}
+
+	forecast, _ = prediction.DecodeOutput([]float64{2.4}, prediction.NTRegression)
+	if forecast.Class != 2 || forecast.Probabilities != nil {
+		t.Errorf("wrong regression forecast: %+v", forecast)
+	}
+}
+
+func TestForecastHorizons(t *testing.T) {
+	// alternating series: the next class is always the opposite of the last one
+	series := make([]float64, 60)
+	for i := range series {
+		series[i] = float64(i % 2)
+	}
+	dataset, err := prediction.BuildDataset(series, 2, 1, 1, 0)
+	if err != nil {
+		t.Fatalf("build dataset error: %v", err)
+	}
+	mlp, _ := prediction.NewNetwork(prediction.NTClassifier, 2, 2, 1, 3)
+	if _, err := prediction.Train(prediction.TTLbfgs, mlp, &dataset.Train, dataset.TrainSize(), prediction.NetworkParams{Decay: 0.001, Restarts: 2, WStep: 0.01}); err != nil {
+		t.Fatalf("train error: %v", err)
+	}
+
+	forecasts, err := prediction.ForecastHorizons(mlp, prediction.NTClassifier, prediction.HMRecursive, []float64{0, 1}, []int{1, 2, 5})
+	if err != nil {
+		t.Fatalf("forecast error: %v", err)
+	}
+	if forecasts[0].Class != 0 || forecasts[1].Class != 1 || forecasts[2].Class != 0 {
+		t.Errorf("wrong recursive forecasts: %+v", forecasts)
+	}
+
+	if _, err := prediction.ForecastHorizons(mlp, prediction.NTClassifier, prediction.HMDirect, []float64{0, 1}, []int{1, 2}); err == nil {
+		t.Error("direct mode of the classifier must return error")
+	}
+}
+
+func TestFeatureSet(t *testing.T) {
+	features, err := prediction.NewFeatureSet("TEST",
+		prediction.FeatureSpec{Kind: prediction.FKClasses, Window: 3},
+		prediction.FeatureSpec{Kind: prediction.FKSma, Window: 4},
+		prediction.FeatureSpec{Kind: prediction.FKMedian, Window: 2},
+		prediction.FeatureSpec{Kind: prediction.FKVolatility, Window: 5},
+		prediction.FeatureSpec{Kind: prediction.FKHour},
+		prediction.FeatureSpec{Kind: prediction.FKWeekday},
+		prediction.FeatureSpec{Kind: prediction.FKClasses, Symbol: "EUR", Window: 2})
+	if err != nil {
+		t.Fatalf("create feature set error: %v", err)
+	}
+	if features.Width() != 12 || features.Lookback() != 5 {
+		t.Fatalf("wrong width: %d or lookback: %d", features.Width(), features.Lookback())
+	}
+
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 31)
+	for i := range rates {
+		rates[i] = entities.Rate{USD: 1 + rand.Float32()/10, EUR: 1 + rand.Float32()/10}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	ranges := []float64{0.95, 1, 1.05}
+	src := prediction.FeatureSource{Rates: rates, Symbol: "USD", Ranges: ranges, RangeCount: 4}
+	dataset, latest, err := features.Build(src, 1, 0.2)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	// 30 deltas, the first sample uses deltas 0..4, the last one predicts delta 29
+	if dataset.TrainSize()+dataset.ValidationSize() != 25 || dataset.ValidationSize() != 5 {
+		t.Fatalf("wrong dataset size, train: %d, validation: %d", dataset.TrainSize(), dataset.ValidationSize())
+	}
+	if len(latest) != features.Width() || len(dataset.Train[0]) != features.Width()+1 {
+		t.Fatalf("wrong row length: %d", len(latest))
+	}
+	// the latest delta ends at 06:00, sin/cos of the hour follow sma, mm and volatility
+	if math.Abs(latest[6]-1) > 1e-9 || math.Abs(latest[7]) > 1e-9 {
+		t.Errorf("wrong hour feature: %v", latest[6:8])
+	}
+
+	// ranges of the correlated symbol are fitted by the rates of the 20 train samples only, the last one predicts delta 24
+	series := make([]float32, 26)
+	for i := range series {
+		series[i] = rates[i].EUR
+	}
+	expected, err := statistic.CalculateEvenRanges2(series, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fitted, err := features.FitRanges(src, 1, 0.2)
+	if err != nil || len(fitted) != 1 || len(fitted["EUR"]) != len(expected) {
+		t.Fatalf("fitted ranges: %v, error: %v", fitted, err)
+	}
+	for i := range expected {
+		if fitted["EUR"][i] != expected[i] {
+			t.Fatalf("fitted ranges: %v, expected: %v", fitted["EUR"], expected)
+		}
+	}
+	// the spike after the train samples doesn't change them
+	src.Rates = append([]entities.Rate{}, rates...)
+	src.Rates[30].EUR *= 2
+	spiked, _, err := features.Build(src, 1, 0.2)
+	if err != nil {
+		t.Fatalf("build error: %v", err)
+	}
+	for i, row := range dataset.Train {
+		for j := range row {
+			if row[j] != spiked.Train[i][j] {
+				t.Fatalf("train sample %d changed by the future rate: %v, %v", i, row, spiked.Train[i])
+			}
+		}
+	}
+	src.SymbolRanges = map[string][]float64{}
+	if _, _, err := features.Build(src, 1, 0.2); err == nil {
+		t.Error("classes of the symbol without ranges accepted")
+	}
+
+	if _, err := prediction.NewFeatureSet("TEST", prediction.FeatureSpec{Kind: prediction.FKSma, Window: 1}); err == nil {
+		t.Error("sma window less than 2 must return error")
+	}
+	if _, err := prediction.NewFeatureSet("TEST", prediction.FeatureSpec{Kind: prediction.FKTrend, Window: 1}); err == nil {
+		t.Error("trend window less than 2 must return error")
+	}
+}
+
+func TestScaler(t *testing.T) {
+	xy := [][]float64{{1, 10, 5, 0}, {2, 20, 5, 1}, {3, 60, 5, 0}}
+	scaler := new(prediction.Scaler)
+	if _, err := scaler.Transform([]float64{1, 2, 3}); err == nil {
+		t.Error("not fitted scaler accepted")
+	}
+	if err := scaler.Fit(xy, 3); err != nil {
+		t.Fatal(err)
+	}
+	dataset, err := scaler.TransformDataset(&prediction.Dataset{Frame: 3, Horizon: 1, Train: xy[:2], Validation: xy[2:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := append(dataset.Train, dataset.Validation...)
+	for j := 0; j < 3; j++ {
+		var mean, variance float64
+		for _, row := range rows {
+			mean += row[j] / 3
+		}
+		for _, row := range rows {
+			variance += (row[j] - mean) * (row[j] - mean) / 3
+		}
+		// the constant input is centered only
+		if expected := []float64{1, 1, 0}[j]; math.Abs(mean) > 1e-12 || math.Abs(variance-expected) > 1e-12 {
+			t.Errorf("input %d mean: %v, variance: %v", j, mean, variance)
+		}
+	}
+	if rows[1][3] != 1 || dataset.Frame != 3 {
+		t.Errorf("outputs changed: %v", rows)
+	}
+	if _, err := scaler.Transform([]float64{1, 2}); err == nil {
+		t.Error("short input accepted")
+	}
+	if err := scaler.Fit(nil, 3); err == nil {
+		t.Error("empty set accepted")
+	}
+}
+
+func TestMedianFeature(t *testing.T) {
+	// deltas: 1, 4, 3, 0.5, 2, 1
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	values := []float32{1, 1, 4, 12, 6, 12, 12}
+	rates := make([]entities.Rate, len(values))
+	for i := range rates {
+		rates[i] = entities.Rate{USD: values[i]}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	for window, expected := range map[int]float64{2: 1.5, 3: 1, 5: 2} {
+		features, err := prediction.NewFeatureSet("MM", prediction.FeatureSpec{Kind: prediction.FKMedian, Window: window})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if features.Lookback() != window {
+			t.Errorf("window %d lookback: %d", window, features.Lookback())
+		}
+		_, latest, err := features.Build(prediction.FeatureSource{Rates: rates, Symbol: "USD", Ranges: []float64{1}, RangeCount: 2}, 1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(latest[0]-expected) > 1e-6 {
+			t.Errorf("window %d median: %v, expected: %v", window, latest[0], expected)
+		}
+	}
+}
+
+func TestTrendFeature(t *testing.T) {
+	features, err := prediction.NewFeatureSet("TREND", prediction.FeatureSpec{Kind: prediction.FKTrend, Window: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if features.Width() != 1 || features.Lookback() != 4 {
+		t.Fatalf("wrong width: %d or lookback: %d", features.Width(), features.Lookback())
+	}
+	// the rate grows by 0.5 per step, the newest one is 10
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 11)
+	for i := range rates {
+		rates[i] = entities.Rate{USD: 5 + float32(i)/2}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	dataset, latest, err := features.Build(prediction.FeatureSource{Rates: rates, Symbol: "USD", Ranges: []float64{1}, RangeCount: 2}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataset.TrainSize() != 6 || len(latest) != 1 || math.Abs(latest[0]-0.05) > 1e-6 {
+		t.Errorf("train size: %d, latest trend: %v", dataset.TrainSize(), latest)
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 80)
+	for i := range rates {
+		// newest rates first, backtest must sort them
+		rates[len(rates)-1-i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[len(rates)-1-i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+
+	engine, err := prediction.NewEngine(4, 3, 20, prediction.TTLbfgs, prediction.NTRegression, "RUB")
+	if err != nil {
+		t.Fatalf("create engine error: %v", err)
+	}
+	if engine, err = engine.WithHorizons(prediction.HMRecursive, 1, 2); err != nil {
+		t.Fatalf("horizons error: %v", err)
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+	report, err := prediction.Backtest(engine, rates, 50, results, efficiency, nil)
+	if err != nil {
+		t.Fatalf("backtest error: %v", err)
+	}
+
+	// 20 rates are required before the first step, network is retrained each frame + 1 steps
+	if report.Steps != 60 || report.Predictions != 60 || report.Failures != 0 || report.Retrains != 15 {
+		t.Fatalf("wrong report: %s", report.ToString())
+	}
+	data := results.GetAll()
+	if len(data) != 60 || data[0].Result != data[1].Source[2] || data[59].Result != -1 {
+		t.Errorf("wrong results: %d", len(data))
+	}
+	if len(report.Efficiency.LastSD) != 59 || report.Efficiency.HorizonCount[1] != 58 {
+		t.Errorf("wrong efficiency: %+v", report.Efficiency)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 60)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32(), EUR: 1}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+
+	space := prediction.SearchSpace{
+		RangeCounts: []int{4},
+		Frames:      []int{2, 3},
+		Limits:      []int{15},
+		Hidden:      []int{0, 3},
+		Decays:      []float64{0.001},
+		Restarts:    []int{1}}
+	candidates, err := space.Grid()
+	if err != nil || len(candidates) != 4 {
+		t.Fatalf("wrong grid: %v, error: %v", candidates, err)
+	}
+	if sample, _ := space.Random(3, rand.New(rand.NewSource(1))); len(sample) != 3 || sample[0] == sample[1] || sample[1] == sample[2] {
+		t.Errorf("wrong random sample: %v", sample)
+	}
+	if err := space.Validate(prediction.TTLbfgs); err != nil {
+		t.Errorf("network space error: %v", err)
+	}
+	// decay and restarts aren't hyperparameters of the forest, they must not be silently ignored
+	if err := space.Validate(prediction.TTForest); err == nil {
+		t.Error("network hyperparameters accepted by forest")
+	}
+	forest := prediction.SearchSpace{RangeCounts: []int{4}, Frames: []int{3}, Limits: []int{15}, Hidden: []int{-1}, Decays: []float64{0}, Restarts: []int{0}}
+	if err := forest.Validate(prediction.TTForest); err != nil {
+		t.Errorf("forest space error: %v", err)
+	}
+	if err := forest.Validate(prediction.TTLbfgs); err == nil {
+		t.Error("zero restarts accepted by network")
+	}
+
+	results := prediction.Search(rates, []string{"RUB", "EUR"}, candidates, prediction.SearchConfig{TrainType: prediction.TTLbfgs, NetType: prediction.NTRegression, Horizons: []int{1, 2}, Workers: 2})
+	if len(results) != 8 {
+		t.Fatalf("wrong results count: %d", len(results))
+	}
+	// constant EUR rates can't be evaluated and must be ranked the last
+	for i, item := range results {
+		if (i < 4) != (item.Error == nil) || (i < 4 && item.Symbol != "RUB") {
+			t.Fatalf("wrong rank %d: %s", i, item.ToString())
+		}
+		if i > 0 && i < 4 && item.DirectionRate > results[i-1].DirectionRate {
+			t.Errorf("results are not sorted by direction rate: %s", item.ToString())
+		}
+	}
+}
+
+func TestBaselines(t *testing.T) {
+	input := prediction.BaselineInput{
+		Rates:      []float32{1, 1, 1, 1, 1.1, 1.2},
+		Classes:    []int{2, 3, 3, 1, 5},
+		RangeCount: 6,
+		Frame:      4}
+	rnd := rand.New(rand.NewSource(1))
+	expected := map[string]int{
+		prediction.TTPersistence: 5,
+		prediction.TTMajority:    3,
+		prediction.TTSmaCross:    3, // fast SMA is above the slow one, the smallest up move
+	}
+	for name, class := range expected {
+		if result, err := prediction.PredictBaseline(name, input, rnd); err != nil || result != class {
+			t.Errorf("%s wrong prediction: %d, error: %v", name, result, err)
+		}
+	}
+	if result, _ := prediction.PredictBaseline(prediction.TTFrequency, input, rnd); result < 1 || result > 5 {
+		t.Errorf("frequency class: %d is not in the window", result)
+	}
+	// the middle class of the odd count is the flat move, the smallest moves are around it
+	cross := []struct {
+		rates      []float32
+		rangeCount int
+		expected   int
+	}{
+		{[]float32{1, 1, 1.1, 1.2}, 5, 3},
+		{[]float32{1.2, 1.2, 1.1, 1}, 5, 1},
+		{[]float32{1.2, 1.2, 1.1, 1}, 6, 2},
+	}
+	for _, test := range cross {
+		crossInput := prediction.BaselineInput{Rates: test.rates, Classes: []int{0}, RangeCount: test.rangeCount, Frame: 4}
+		if result, err := prediction.PredictBaseline(prediction.TTSmaCross, crossInput, rnd); err != nil || result != test.expected {
+			t.Errorf("%s rates: %v, ranges: %d, prediction: %d, expected: %d, error: %v", prediction.TTSmaCross, test.rates, test.rangeCount, result, test.expected, err)
+		}
+	}
+	if _, err := prediction.PredictBaseline(prediction.TTLinear, input, rnd); err == nil {
+		t.Error("linear baseline without ranges must return error")
+	}
+	input.Ranges = []float64{0.95, 1, 1.05}
+	if _, err := prediction.PredictBaseline(prediction.TTLinear, input, rnd); err == nil {
+		t.Error("linear baseline of the short window must return error")
+	}
+	// the rate swings between 1 and 1.1, the last move is up, so the next one is down
+	input.Rates = make([]float32, 12)
+	for i := range input.Rates {
+		input.Rates[i] = 1 + float32(i%2)/10
+	}
+	if result, err := prediction.PredictBaseline(prediction.TTLinear, input, rnd); err != nil || result != 0 {
+		t.Errorf("%s wrong prediction: %d, error: %v", prediction.TTLinear, result, err)
+	}
+
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 40)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	for _, name := range prediction.Baselines() {
+		engine, err := prediction.NewEngine(6, 5, 20, name, prediction.NTRegression, "RUB")
+		if err != nil {
+			t.Fatalf("%s create engine error: %v", name, err)
+		}
+		efficiency := memory.NewEfficiencyRepo("", "RUB", 6, 0, 0)
+		report, err := prediction.Backtest(engine, rates, 50, memory.NewResultDataRepo(0, false, "", "RUB"), efficiency, nil)
+		if err != nil || report.Predictions != 20 || report.Model != name || report.Efficiency.HorizonCount[0] != 19 {
+			t.Errorf("%s wrong backtest, error: %v", name, err)
+		}
+	}
+
+	// the next step forecast of the frequency baseline is the prediction itself
+	engine, err := prediction.NewEngine(6, 5, 20, prediction.TTFrequency, prediction.NTRegression, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	if _, err := prediction.Backtest(engine, rates, 50, results, memory.NewEfficiencyRepo("", "RUB", 6, 0, 0), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results.GetAll() {
+		if result.Horizons[0] != 1 || result.HorizonPredictions[0] != result.Prediction {
+			t.Fatalf("%s next step forecast: %d, prediction: %d", prediction.TTFrequency, result.HorizonPredictions[0], result.Prediction)
+		}
+	}
+
+	// the ranges need rangeCount rates only, the window of the frame is longer
+	engine, err = prediction.NewEngine(4, 10, 20, prediction.TTPersistence, prediction.NTRegression, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Process(rates[:8], memory.NewResultDataRepo(0, false, "", "RUB"), memory.NewEfficiencyRepo("", "RUB", 4, 0, 0), nil); err == nil {
+		t.Error("rates shorter than the frame accepted")
+	}
+}
+
+func TestEnsembleEngine(t *testing.T) {
+	if !prediction.IsEnsemble(prediction.TTEnsembleLbfgs) || prediction.IsEnsemble(prediction.TTLbfgs) {
+		t.Fatal("wrong ensemble train types")
+	}
+	params, err := prediction.DefaultTrainParams(prediction.TTEnsembleEs)
+	if err != nil || params.Ensemble.Size < 1 {
+		t.Fatalf("ensemble default params: %+v, error: %v", params, err)
+	}
+
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 40)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	for _, trainType := range prediction.EnsembleTypes() {
+		engine, err := prediction.NewEngine(4, 3, 20, trainType, prediction.NTRegression, "RUB")
+		if err == nil {
+			engine, err = engine.WithHorizons(prediction.HMRecursive, 1, 3)
+		}
+		if err == nil {
+			params := engine.TrainParams()
+			params.Ensemble.Size = 3
+			engine, err = engine.WithTrainParams(params)
+		}
+		if err != nil {
+			t.Fatalf("%s create engine error: %v", trainType, err)
+		}
+		results := memory.NewResultDataRepo(0, false, "", "RUB")
+		efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+		report, err := prediction.Backtest(engine, rates, 30, results, efficiency, nil)
+		if err != nil {
+			t.Fatalf("%s backtest error: %v", trainType, err)
+		}
+		if report.Model != trainType || report.Predictions != 20 || report.Failures != 0 {
+			t.Errorf("%s wrong report: %s", trainType, report.ToString())
+		}
+		if first := results.GetAll()[0]; len(first.HorizonPredictions) != 2 {
+			t.Errorf("%s horizons not forecasted: %+v", trainType, first)
+		}
+	}
+
+	if _, err := prediction.NewEnsemble(prediction.NTClassifier, 3, 4, 1, 0); err == nil {
+		t.Error("empty ensemble created")
+	}
+}
+
+func TestRunTasks(t *testing.T) {
+	var running, maxRunning int32
+	var tasks []prediction.Task
+	for i := 0; i < 8; i++ {
+		value := i
+		tasks = append(tasks, prediction.Task{Name: "ok", Run: func(ctx context.Context) (int, error) {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return value, nil
+		}})
+	}
+	tasks = append(tasks,
+		prediction.Task{Name: "error", Run: func(ctx context.Context) (int, error) { return -1, errors.New("failed") }},
+		prediction.Task{Name: "panic", Run: func(ctx context.Context) (int, error) { panic("broken symbol") }},
+		prediction.Task{Name: "slow", Run: func(ctx context.Context) (int, error) {
+			select {
+			case <-time.After(10 * time.Second):
+				return 1, nil
+			case <-ctx.Done():
+				return -1, ctx.Err()
+			}
+		}},
+		prediction.Task{Name: "hung", Run: func(ctx context.Context) (int, error) {
+			// ignores the context, as the fit without cancellable variant
+			time.Sleep(10 * time.Second)
+			return 1, nil
+		}})
+
+	start := time.Now()
+	report := prediction.RunTasks(tasks, 3, 200*time.Millisecond)
+	// the slow task is cancelled by the timeout, the hung one is abandoned, RunTasks doesn't wait for their natural end
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("timed out task isn't cancelled: %v", time.Since(start))
+	}
+	if len(report.Results) != len(tasks) {
+		t.Fatalf("results: %d", len(report.Results))
+	}
+	if maxRunning > 3 {
+		t.Errorf("running tasks: %d more than workers", maxRunning)
+	}
+	for i := 0; i < 8; i++ {
+		if report.Results[i].Prediction != i || report.Results[i].Error != nil {
+			t.Errorf("wrong result %d: %+v", i, report.Results[i])
+		}
+	}
+	failed := report.Failed()
+	if len(failed) != 4 || failed[0].Name != "error" || !failed[1].Panicked || !failed[2].TimedOut || !failed[3].TimedOut {
+		t.Errorf("wrong failed tasks: %+v", failed)
+	}
+	if err := report.Error(); err == nil {
+		t.Error("aggregated error expected")
+	}
+	if err := prediction.RunTasks(tasks[:2], 0, 0).Error(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngineBusy(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 30)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	engine, err := prediction.NewEngine(4, 3, 20, prediction.TTLbfgs, prediction.NTRegression, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same engine processed concurrently, only one processing is allowed at a time
+	var tasks []prediction.Task
+	for i := 0; i < 4; i++ {
+		results := memory.NewResultDataRepo(0, false, "", "RUB")
+		efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+		tasks = append(tasks, prediction.Task{Name: "RUB", Run: func(ctx context.Context) (int, error) {
+			return engine.ProcessContext(ctx, rates, results, efficiency, nil)
+		}})
+	}
+	report := prediction.RunTasks(tasks, 4, 0)
+	succeeded := len(report.Results) - len(report.Failed())
+	if succeeded < 1 {
+		t.Errorf("no processing succeeded: %v", report.Error())
+	}
+}
+
+func TestEngineCancel(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 30)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	for _, trainType := range []string{prediction.TTLbfgs, prediction.TTEnsembleLm} {
+		results := memory.NewResultDataRepo(0, false, "", "RUB")
+		efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+		engine, err := prediction.NewEngine(4, 3, 20, trainType, prediction.NTRegression, "RUB")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := engine.ProcessContext(ctx, rates[:25], results, efficiency, nil); err != context.Canceled || engine.Retrains() != 0 {
+			t.Errorf("%s cancelled processing error: %v, retrains: %d", trainType, err, engine.Retrains())
+		}
+		// the cancelled fit is repeated by the next processing
+		if _, err := engine.Process(rates, results, efficiency, nil); err != nil || engine.Retrains() != 1 {
+			t.Errorf("%s processing error: %v, retrains: %d", trainType, err, engine.Retrains())
+		}
+	}
+}
+
+// lastClassPredictor - classifier predicting the last class of the window with certainty
+type lastClassPredictor struct {
+	rangeCount int
+	fits       int
+}
+
+func (f *lastClassPredictor) Fit(dataset *prediction.Dataset, params prediction.TrainParams) error {
+	f.fits++
+	return nil
+}
+
+func (f *lastClassPredictor) Predict(x []float64) ([]float64, error) {
+	result := make([]float64, f.rangeCount)
+	result[int(x[len(x)-1])] = 1
+	return result, nil
+}
+
+func (f *lastClassPredictor) NetType() string               { return prediction.NTClassifier }
+func (f *lastClassPredictor) Serialize() ([]float64, error) { return []float64{float64(f.fits)}, nil }
+func (f *lastClassPredictor) Load(ra []float64) error       { f.fits = int(ra[0]); return nil }
+func (f *lastClassPredictor) Describe() string              { return "last class" }
+
+func TestPredictor(t *testing.T) {
+	prediction.RegisterPredictor("TEST-LAST", func(spec prediction.PredictorSpec) (prediction.Predictor, error) {
+		return &lastClassPredictor{rangeCount: spec.RangeCount}, nil
+	}, prediction.TrainParams{})
+
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 40)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	engine, err := prediction.NewEngine(4, 3, 20, "TEST-LAST", prediction.NTClassifier, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+	report, err := prediction.Backtest(engine, rates, 30, results, efficiency, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	predictor := engine.Predictor().(*lastClassPredictor)
+	if report.Predictions != 20 || report.Retrains != predictor.fits || len(report.Efficiency.LastSD) != 19 {
+		t.Fatalf("wrong report: %s", report.ToString())
+	}
+	for _, item := range results.GetAll() {
+		if item.Prediction != item.Source[len(item.Source)-1] || item.Confidence != 1 || len(item.HorizonPredictions) != 4 {
+			t.Fatalf("wrong result: %+v", item)
+		}
+	}
+
+	if _, err := prediction.NewPredictor(prediction.PredictorSpec{TrainType: "unknown"}); err == nil {
+		t.Error("unknown predictor created")
+	}
+}
+
+func TestPredictorSerialization(t *testing.T) {
+	series := make([]float64, 40)
+	for i := range series {
+		series[i] = float64(rand.Intn(4))
+	}
+	dataset, err := prediction.BuildDataset(series, 3, 1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trainType := range []string{prediction.TTLbfgs, prediction.TTEnsembleLbfgs, prediction.TTMarkov, prediction.TTForest, prediction.TTMnl, prediction.TTLda, prediction.TTAnalog} {
+		params, _ := prediction.DefaultTrainParams(trainType)
+		params.Ensemble.Size = 2
+		spec := prediction.PredictorSpec{TrainType: trainType, NetType: prediction.NTClassifier, Inputs: 3, RangeCount: 4, Horizon: 1, Hidden: []int{3}, Params: params}
+		predictor, err := prediction.NewPredictor(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := predictor.Fit(dataset, params); err != nil {
+			t.Fatalf("%s fit error: %v", trainType, err)
+		}
+		ra, err := predictor.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, _ := prediction.NewPredictor(spec)
+		if err := restored.Load(ra); err != nil {
+			t.Fatalf("%s load error: %v", trainType, err)
+		}
+		for _, row := range dataset.Train {
+			expected, _ := predictor.Predict(row[:3])
+			actual, err := restored.Predict(row[:3])
+			if err != nil || len(actual) != 4 || actual[0] != expected[0] || actual[3] != expected[3] {
+				t.Fatalf("%s outputs differ: %v, restored %v, error: %v", trainType, expected, actual, err)
+			}
+		}
+
+		spec.RangeCount = 5
+		other, _ := prediction.NewPredictor(spec)
+		if err := other.Load(ra); err == nil {
+			t.Errorf("%s %s loaded into different classes", trainType, predictor.Describe())
+		}
+	}
+}
+
+func TestPredictorReport(t *testing.T) {
+	series := make([]float64, 40)
+	for i := range series {
+		series[i] = float64(rand.Intn(4))
+	}
+	dataset, err := prediction.BuildDataset(series, 3, 1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		trainType string
+		estimate  string
+	}{
+		{prediction.TTKfoldLbfgs, "cross-validation"},
+		{prediction.TTEnsembleLbfgs, "out-of-bag"},
+		{prediction.TTEnsembleEs, ""},
+	}
+	for _, test := range tests {
+		params, _ := prediction.DefaultTrainParams(test.trainType)
+		params.Ensemble.Size = 2
+		spec := prediction.PredictorSpec{TrainType: test.trainType, NetType: prediction.NTClassifier, Inputs: 3, RangeCount: 4, Horizon: 1, Hidden: []int{3}, Params: params}
+		predictor, err := prediction.NewPredictor(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reporter, ok := predictor.(prediction.Reporter)
+		if !ok {
+			t.Fatalf("%s predictor has no report", test.trainType)
+		}
+		if reporter.Report() != nil {
+			t.Errorf("%s report of the not fitted predictor", test.trainType)
+		}
+		if err := predictor.Fit(dataset, params); err != nil {
+			t.Fatalf("%s fit error: %v", test.trainType, err)
+		}
+		report := reporter.Report()
+		if report == nil || report.Reason == neural.TerminationNone || (report.CV != nil) != (test.estimate != "") {
+			t.Fatalf("%s report: %+v", test.trainType, report)
+		}
+		desc := predictor.Describe()
+		if !strings.Contains(desc, ", "+report.Reason.String()) {
+			t.Errorf("%s description without the termination reason: %s", test.trainType, desc)
+		}
+		if test.estimate != "" && !strings.Contains(desc, ", "+test.estimate+" rms error ") {
+			t.Errorf("%s description without the error estimate: %s", test.trainType, desc)
+		}
+
+		ra, _ := predictor.Serialize()
+		if err := predictor.Load(ra); err != nil || reporter.Report() != nil {
+			t.Errorf("%s report of the loaded predictor: %+v, error: %v", test.trainType, reporter.Report(), err)
+		}
+	}
+}
+
+func TestMarkovPredictor(t *testing.T) {
+	// the period is 3, the second order chain predicts it exactly
+	series := make([]float64, 60)
+	for i := range series {
+		series[i] = float64([]int{0, 2, 2}[i%3])
+	}
+	dataset, err := prediction.BuildDataset(series, 4, 1, 1, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := prediction.DefaultTrainParams(prediction.TTMarkov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.Markov.Order = 2
+	spec := prediction.PredictorSpec{TrainType: prediction.TTMarkov, Inputs: 4, RangeCount: 3, Horizon: 1, Params: params}
+	predictor, err := prediction.NewPredictor(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := predictor.Fit(dataset, params); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range dataset.Validation {
+		output, err := predictor.Predict(row[:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		forecast, err := prediction.DecodeOutput(output, predictor.NetType())
+		if err != nil || forecast.Class != int(row[4]) || forecast.Confidence < 0.9 {
+			t.Fatalf("forecast %+v of %v, error: %v", forecast, row, err)
+		}
+	}
+	if rms, err := predictor.(prediction.Validator).RmsError(dataset.Validation); err != nil || rms > 0.1 {
+		t.Errorf("validation rms error: %v, error: %v", rms, err)
+	}
+
+	params.Markov.Order = 5
+	if err := predictor.Fit(dataset, params); err == nil {
+		t.Error("order longer than the window accepted")
+	}
+	if _, err := predictor.Predict([]float64{0, 1.5, 2, 2}); err == nil {
+		t.Error("feature input accepted")
+	}
+	spec.Horizon = 2
+	if _, err := prediction.NewPredictor(spec); err == nil {
+		t.Error("multi-step Markov chain created")
+	}
+}
+
+func TestForestFeatures(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 60)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32(), EUR: 1 + rand.Float32()/10}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	engine, err := prediction.NewEngine(4, 3, 30, prediction.TTForest, prediction.NTClassifier, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine, err = engine.WithFeatures("TEST",
+		prediction.FeatureSpec{Kind: prediction.FKClasses, Window: 3},
+		prediction.FeatureSpec{Kind: prediction.FKVolatility, Window: 4},
+		prediction.FeatureSpec{Kind: prediction.FKHour}); err != nil {
+		t.Fatal(err)
+	}
+	params := engine.TrainParams()
+	params.Forest.Trees = 10
+	if _, err := engine.WithTrainParams(params); err != nil {
+		t.Fatal(err)
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+	report, err := prediction.Backtest(engine, rates, 40, results, efficiency, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Predictions != 30 || len(results.GetAll()) != 30 || report.Failures != 0 {
+		t.Fatalf("wrong report: %s", report.ToString())
+	}
+	for _, item := range results.GetAll() {
+		if item.Prediction < 0 || item.Prediction > 3 || item.Confidence <= 0 || item.Confidence > 1 {
+			t.Fatalf("wrong result: %+v", item)
+		}
+	}
+	if desc := engine.Predictor().Describe(); desc != "RDF classifier 6-4, 10 trees, out-of-bag rms error "+desc[len(desc)-6:] {
+		t.Errorf("description: %s", desc)
+	}
+
+	params.Forest.SampleRatio = 0
+	if _, err := engine.WithTrainParams(params); err != nil {
+		t.Fatal(err)
+	}
+	if report, err = prediction.Backtest(engine, rates, 40, results, efficiency, nil); err != nil || report.Failures == 0 {
+		t.Errorf("zero sample ratio accepted: %s, error: %v", report.ToString(), err)
+	}
+}
+
+func TestMnlPredictor(t *testing.T) {
+	series := make([]float64, 60)
+	for i := range series {
+		series[i] = float64([]int{0, 2, 1}[i%3])
+	}
+	dataset, err := prediction.BuildDataset(series, 4, 1, 1, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := prediction.PredictorSpec{TrainType: prediction.TTMnl, Inputs: 4, RangeCount: 3, Horizon: 1}
+	predictor, err := prediction.NewPredictor(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := predictor.Predict([]float64{0, 2, 1, 0}); err == nil {
+		t.Error("prediction of the not trained model")
+	}
+	if err := predictor.Fit(dataset, spec.Params); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range dataset.Validation {
+		output, err := predictor.Predict(row[:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		forecast, err := prediction.DecodeOutput(output, predictor.NetType())
+		if err != nil || forecast.Class != int(row[4]) {
+			t.Fatalf("forecast %+v of %v, error: %v", forecast, row, err)
+		}
+	}
+	if rms, err := predictor.(prediction.Validator).RmsError(dataset.Validation); err != nil || rms > 0.1 {
+		t.Errorf("validation rms error: %v, error: %v", rms, err)
+	}
+	if desc := predictor.Describe(); !strings.HasPrefix(desc, "MNL 4-3, ") {
+		t.Errorf("description: %s", desc)
+	}
+
+	short := &prediction.Dataset{Train: dataset.Train[:5]}
+	if err := predictor.Fit(short, spec.Params); err == nil {
+		t.Error("train set shorter than inputs + 2 accepted")
+	}
+	spec.Horizon = 2
+	if _, err := prediction.NewPredictor(spec); err == nil {
+		t.Error("multi-step logit model created")
+	}
+}
+
+func TestRegimeDetector(t *testing.T) {
+	// calm and volatile periods alternate every 10 steps
+	rates := make([]float32, 81)
+	rates[0] = 1
+	for i := 1; i < len(rates); i++ {
+		amplitude := float32(0.001)
+		if (i/10)%2 == 1 {
+			amplitude = 0.05
+		}
+		if i%2 == 0 {
+			amplitude = -amplitude
+		}
+		rates[i] = rates[i-1] * (1 + amplitude)
+	}
+	detector, err := prediction.NewRegimeDetector(prediction.RKVolatility, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := detector.Detect(rates); err == nil {
+		t.Error("detection of the not fitted detector")
+	}
+	if err := detector.Fit(rates); err != nil {
+		t.Fatal(err)
+	}
+	// the window of the steps 76-79 is volatile, the window of the steps 1-4 is calm
+	if regime, err := detector.Detect(rates[:80]); err != nil || regime != 1 {
+		t.Errorf("latest regime: %d, error: %v", regime, err)
+	}
+	if regime, err := detector.Detect(rates[:5]); err != nil || regime != 0 {
+		t.Errorf("first regime: %d, error: %v", regime, err)
+	}
+
+	if _, err := prediction.NewRegimeDetector(prediction.RKVolatility, 2, 1); err == nil {
+		t.Error("volatility window less than 2 accepted")
+	}
+	if _, err := prediction.NewRegimeDetector(prediction.RKDeltas, 1, 3); err == nil {
+		t.Error("single regime accepted")
+	}
+	if _, err := prediction.NewRegimeDetector("trend", 2, 3); err == nil {
+		t.Error("unknown regime accepted")
+	}
+	deltas, _ := prediction.NewRegimeDetector(prediction.RKDeltas, 3, 2)
+	if err := deltas.Fit([]float32{1, 1, 1, 1, 1}); err == nil {
+		t.Error("flat rates clustered into 3 regimes")
+	}
+}
+
+func TestEngineRegimes(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 60)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32()}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	engine, err := prediction.NewEngine(4, 3, 20, prediction.TTPersistence, prediction.NTRegression, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engine, err = engine.WithRegimes(prediction.RKVolatility, 3, 4); err != nil {
+		t.Fatal(err)
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+	report, err := prediction.Backtest(engine, rates, 40, results, efficiency, nil)
+	if err != nil || report.Failures != 0 {
+		t.Fatalf("backtest error: %v", err)
+	}
+	var count int32
+	for _, item := range results.GetAll() {
+		if item.RegimesCount != 3 || item.Regime < 0 || item.Regime > 2 {
+			t.Fatalf("wrong regime: %+v", item)
+		}
+	}
+	for _, cnt := range report.Efficiency.RegimeCount {
+		count += cnt
+	}
+	if count != report.Efficiency.Count || !strings.Contains(report.ToString(), "Regime: ") {
+		t.Errorf("regimes count: %d, results: %d, report: %s", count, report.Efficiency.Count, report.ToString())
+	}
+}
+
+func TestPanelPca(t *testing.T) {
+	// the dollar moves all quotes together, the ruble has its own noise
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 41)
+	dollar := float32(1)
+	for i := range rates {
+		dollar *= 1 + float32(rand.NormFloat64())*0.01
+		rates[i] = entities.Rate{
+			RUB: 60 * dollar * (1 + float32(rand.NormFloat64())*0.001),
+			JPY: 110 * dollar,
+			GBP: 0.8 * dollar,
+			EUR: 0.9 * dollar,
+			CNY: 6.5 * dollar,
+			CHF: dollar}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	components, err := prediction.PanelPca(rates, prediction.PanelSymbols, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components.Variances) != 6 || components.ExplainedRatio(0) < 0.99 {
+		t.Fatalf("variances: %v", components.Variances)
+	}
+	for i, item := range components.Loadings[0] {
+		if math.Abs(item-1/math.Sqrt(6)) > 0.03 {
+			t.Errorf("%s loading of the common factor: %v", components.Symbols[i], item)
+		}
+	}
+	if score, err := components.Score(components.Means, 0); err != nil || math.Abs(score) > 1e-12 {
+		t.Errorf("score of the mean changes: %v, error: %v", score, err)
+	}
+	if _, err := components.Score(components.Means, 6); err == nil {
+		t.Error("component out of range accepted")
+	}
+	if _, err := prediction.PanelPca(rates, prediction.PanelSymbols, 41); err == nil {
+		t.Error("window longer than the rates accepted")
+	}
+
+	features, err := prediction.NewFeatureSet("PCA",
+		prediction.FeatureSpec{Kind: prediction.FKPca, Window: 10},
+		prediction.FeatureSpec{Kind: prediction.FKPca, Window: 10, Component: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataset, latest, err := features.Build(prediction.FeatureSource{Rates: rates, Symbol: "RUB", Ranges: []float64{1}, RangeCount: 2}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if features.Width() != 2 || dataset.TrainSize() != 30 || len(latest) != 2 {
+		t.Errorf("width: %d, train size: %d, latest: %v", features.Width(), dataset.TrainSize(), latest)
+	}
+	if _, err := prediction.NewFeatureSet("PCA", prediction.FeatureSpec{Kind: prediction.FKPca, Window: 10, Component: 6}); err == nil {
+		t.Error("component out of range accepted")
+	}
+}
+
+func TestPanelCorrelation(t *testing.T) {
+	// the ruble follows the dollar, the yen moves against it, the pound has its own moves
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 41)
+	dollar := float32(1)
+	for i := range rates {
+		dollar *= 1 + float32(rand.NormFloat64())*0.01
+		rates[i] = entities.Rate{
+			RUB: 60 * dollar * (1 + float32(rand.NormFloat64())*0.001),
+			JPY: 110 / dollar,
+			GBP: 0.8 * (1 + float32(rand.NormFloat64())*0.01)}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	symbols := []string{"RUB", "JPY", "GBP"}
+	for _, method := range []string{correlation.MPearson, correlation.MSpearman} {
+		matrix, err := prediction.PanelCorrelation(rates, symbols, 40, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matrix.Observations != 40 || len(matrix.Coefficients) != 3 {
+			t.Fatalf("%s matrix: %+v", method, matrix)
+		}
+		if matrix.Coefficients[0][1] > -0.9 || matrix.PValues[0][1] > 1e-6 {
+			t.Errorf("%s ruble and yen: %v, p-value: %v", method, matrix.Coefficients[0][1], matrix.PValues[0][1])
+		}
+	}
+	if _, err := prediction.PanelCorrelation(rates, symbols, 41, correlation.MPearson); err == nil {
+		t.Error("window longer than the rates accepted")
+	}
+	if _, err := prediction.PanelCorrelation(rates, symbols[:1], 40, correlation.MPearson); err == nil {
+		t.Error("single symbol accepted")
+	}
+
+	features, err := prediction.NewFeatureSet("CORR", prediction.FeatureSpec{Kind: prediction.FKCorrelation, Symbol: "JPY", Window: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataset, latest, err := features.Build(prediction.FeatureSource{Rates: rates, Symbol: "RUB", Ranges: []float64{1}, RangeCount: 2}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if features.Width() != 1 || dataset.TrainSize() != 30 || len(latest) != 1 || latest[0] > -0.9 {
+		t.Errorf("width: %d, train size: %d, latest: %v", features.Width(), dataset.TrainSize(), latest)
+	}
+	if _, err := prediction.NewFeatureSet("CORR", prediction.FeatureSpec{Kind: prediction.FKCorrelation, Window: 10}); err == nil {
+		t.Error("correlation without symbol accepted")
+	}
+}
+
+func TestLdaPredictor(t *testing.T) {
+	series := make([]float64, 60)
+	for i := range series {
+		series[i] = float64([]int{0, 2, 1}[i%3])
+	}
+	dataset, err := prediction.BuildDataset(series, 4, 1, 1, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// noise keeps the within-class variance positive
+	for _, row := range dataset.Train {
+		for j := range row[:4] {
+			row[j] += rand.NormFloat64() * 0.1
+		}
+	}
+	params, err := prediction.DefaultTrainParams(prediction.TTLda)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := prediction.PredictorSpec{TrainType: prediction.TTLda, Inputs: 4, RangeCount: 3, Horizon: 1, Params: params}
+	predictor, err := prediction.NewPredictor(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := predictor.Predict([]float64{0, 2, 1, 0}); err == nil {
+		t.Error("prediction of the not trained model")
+	}
+	if err := predictor.Fit(dataset, spec.Params); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range dataset.Validation {
+		output, err := predictor.Predict(row[:4])
+		if err != nil {
+			t.Fatal(err)
+		}
+		forecast, err := prediction.DecodeOutput(output, predictor.NetType())
+		if err != nil || forecast.Class != int(row[4]) {
+			t.Fatalf("forecast %+v of %v, error: %v", forecast, row, err)
+		}
+	}
+	if rms, err := predictor.(prediction.Validator).RmsError(dataset.Validation); err != nil || rms > 0.1 {
+		t.Errorf("validation rms error: %v, error: %v", rms, err)
+	}
+	if desc := predictor.Describe(); desc != "LDA 4-3, 2 directions" {
+		t.Errorf("description: %s", desc)
+	}
+
+	short := &prediction.Dataset{Train: dataset.Train[:3]}
+	if err := predictor.Fit(short, spec.Params); err == nil {
+		t.Error("train set without within-class samples accepted")
+	}
+	params.Lda.MinVariance = 0
+	if err := predictor.Fit(dataset, params); err == nil {
+		t.Error("zero minimal variance accepted")
+	}
+	spec.Horizon = 2
+	if _, err := prediction.NewPredictor(spec); err == nil {
+		t.Error("multi-step LDA created")
+	}
+}
+
+func TestEngineProjection(t *testing.T) {
+	start := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	rates := make([]entities.Rate, 60)
+	for i := range rates {
+		rates[i] = entities.Rate{RUB: 60 + rand.Float32(), EUR: 1 + rand.Float32()/10}
+		rates[i].SetTimestamp(start.Add(time.Duration(i) * time.Hour))
+	}
+	engine, err := prediction.NewEngine(4, 3, 30, prediction.TTLbfgs, prediction.NTClassifier, "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.WithProjection(2); err == nil {
+		t.Error("projection without features accepted")
+	}
+	if engine, err = engine.WithFeatures("TEST",
+		prediction.FeatureSpec{Kind: prediction.FKClasses, Window: 3},
+		prediction.FeatureSpec{Kind: prediction.FKVolatility, Window: 4},
+		prediction.FeatureSpec{Kind: prediction.FKClasses, Symbol: "EUR", Window: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.WithProjection(7); err == nil {
+		t.Error("projection wider than features accepted")
+	}
+	if engine, err = engine.WithProjection(2); err != nil {
+		t.Fatal(err)
+	}
+	if engine.Model() != "L-BFGS-SOFTMAX-TEST-LDA2" {
+		t.Errorf("model: %s", engine.Model())
+	}
+	results := memory.NewResultDataRepo(0, false, "", "RUB")
+	efficiency := memory.NewEfficiencyRepo("", "RUB", 4, 0, 0)
+	report, err := prediction.Backtest(engine, rates, 40, results, efficiency, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Predictions != 30 || len(results.GetAll()) != 30 || report.Failures != 0 {
+		t.Fatalf("wrong report: %s", report.ToString())
+	}
+	if desc := engine.Predictor().Describe(); !strings.Contains(desc, " 2-4, ") {
+		t.Errorf("description: %s", desc)
+	}
+}
+
+func TestAnalogPredictor(t *testing.T) {
+	series := make([]float64, 60)
+	for i := range series {
+		series[i] = float64([]int{0, 2, 1, 1}[i%4])
+	}
+	dataset, err := prediction.BuildDataset(series, 3, 1, 1, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, _ := prediction.DefaultTrainParams(prediction.TTAnalog)
+	params.Analog.Neighbours = 5
+	spec := prediction.PredictorSpec{TrainType: prediction.TTAnalog, Inputs: 3, RangeCount: 3, Horizon: 1, Params: params}
+	predictor, err := prediction.NewPredictor(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := predictor.Predict([]float64{0, 2, 1}); err == nil {
+		t.Error("prediction of the not trained model")
+	}
+	if err := predictor.Fit(dataset, params); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range dataset.Validation {
+		output, err := predictor.Predict(row[:3])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output[int(row[3])] != 1 {
+			t.Fatalf("output %v of %v", output, row)
+		}
+	}
+	if rms, err := predictor.(prediction.Validator).RmsError(dataset.Validation); err != nil || rms != 0 {
+		t.Errorf("validation rms error: %v, error: %v", rms, err)
+	}
+	analogs, err := predictor.(prediction.AnalogFinder).Analogs([]float64{2, 1, 1.2})
+	if err != nil || len(analogs) != 5 {
+		t.Fatalf("analogs: %+v, error: %v", analogs, err)
+	}
+	for i, analog := range analogs {
+		row := dataset.Train[analog.Row]
+		if analog.Class != 0 || row[0] != 2 || row[1] != 1 || row[2] != 1 || math.Abs(analog.Distance-0.2) > 1e-9 {
+			t.Fatalf("analog %d: %+v of the row %v", i, analog, row)
+		}
+	}
+	if desc := predictor.Describe(); desc != "Analogs 3-3, 5 nearest of "+strconv.Itoa(dataset.TrainSize())+" windows" {
+		t.Errorf("description: %s", desc)
+	}
+
+	params.Analog.Neighbours = 0
+	if err := predictor.Fit(dataset, params); err == nil {
+		t.Error("zero neighbours accepted")
+	}
+	spec.Horizon = 2
+	if _, err := prediction.NewPredictor(spec); err == nil {
+		t.Error("multi-step analog forecaster created")
+	}
+}
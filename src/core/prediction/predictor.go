@@ -0,0 +1,102 @@
+package prediction
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+)
+
+// Predictor - model of the engine: fitted on the dataset of the class or feature windows,
+// predicts outputs of the next step by the latest window
+type Predictor interface {
+	// Fit train the model, rows of the dataset hold the window followed by the outputs
+	Fit(dataset *Dataset, params TrainParams) error
+	// Predict return outputs for the window, they are decoded by DecodeOutput with NetType
+	Predict(x []float64) ([]float64, error)
+	// NetType return type of the outputs: NTRegression or NTClassifier
+	NetType() string
+	// Serialize return state of the fitted model
+	Serialize() ([]float64, error)
+	// Load restore state of the model returned by Serialize
+	Load(ra []float64) error
+	// Describe return short human readable description of the model
+	Describe() string
+}
+
+// Validator - predictor estimating its error on the validation set
+type Validator interface {
+	RmsError(xy [][]float64) (float64, error)
+}
+
+// Reporter - predictor keeping the report of its last training
+type Reporter interface {
+	// Report return report of the last Fit, nil if the predictor isn't fitted or is loaded
+	Report() *TrainReport
+}
+
+// ContextFitter - predictor which training is stopped by the cancelled context
+type ContextFitter interface {
+	FitContext(ctx context.Context, dataset *Dataset, params TrainParams) error
+}
+
+// PredictorSpec - geometry of the predictor required by the engine
+type PredictorSpec struct {
+	TrainType  string
+	NetType    string
+	Inputs     int   // width of the window
+	RangeCount int   // count of the range classes
+	Horizon    int   // count of the steps predicted at once
+	Hidden     []int // sizes of the hidden layers of the network
+	Params     TrainParams
+}
+
+// PredictorFactory - creates not fitted predictor by the spec
+type PredictorFactory func(spec PredictorSpec) (Predictor, error)
+
+type predictorType struct {
+	factory  PredictorFactory
+	defaults TrainParams
+}
+
+var _predictorTypes = make(map[string]predictorType)
+
+// RegisterPredictor add predictor to the registry, its name is used as train type of the engine
+func RegisterPredictor(name string, factory PredictorFactory, defaults TrainParams) {
+	_predictorTypes[name] = predictorType{factory: factory, defaults: defaults}
+}
+
+// Predictors return sorted names of the registered predictors
+func Predictors() []string {
+	result := make([]string, 0, len(_predictorTypes))
+	for name := range _predictorTypes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// NewPredictor create predictor of the train type: registered predictor, ensemble or network
+func NewPredictor(spec PredictorSpec) (Predictor, error) {
+	if pt, found := _predictorTypes[spec.TrainType]; found {
+		return pt.factory(spec)
+	}
+	if IsEnsemble(spec.TrainType) {
+		return newEnsemblePredictor(spec)
+	}
+	if _, found := _trainTypes[spec.TrainType]; found {
+		return newNetworkPredictor(spec)
+	}
+	return nil, fmt.Errorf("unknown train type: '%s'", spec.TrainType)
+}
+
+// predictorProcessor - processor of the horizon forecast by the predictor
+func predictorProcessor(predictor Predictor) processor {
+	return func(x *[]float64) (*[]float64, error) {
+		y, err := predictor.Predict(*x)
+		if err != nil {
+			return nil, err
+		}
+		return &y, nil
+	}
+}
@@ -0,0 +1,148 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"pr.optima/src/core/kmeans"
+)
+
+const (
+	// RKDeltas - regime of the window of the relative rate deltas, regimes are ordered from the falling to the rising market
+	RKDeltas = "deltas"
+	// RKVolatility - regime of the standard deviation of the window rate deltas, regimes are ordered from the calm to the volatile market
+	RKVolatility = "volatility"
+
+	regimeRestarts = 5
+)
+
+// RegimeDetector - k-means clusters (core/kmeans) of the sliding windows of the rates,
+// the cluster of the latest window is the market regime
+type RegimeDetector struct {
+	Kind   string // one of RK* constants
+	K      int    // count of the regimes
+	Window int    // count of the rate deltas of the window
+	// rows are centers of the clusters ordered by the regime
+	centers [][]float64
+}
+
+// NewRegimeDetector validate settings and create not fitted detector
+func NewRegimeDetector(kind string, k, window int) (*RegimeDetector, error) {
+	switch kind {
+	case RKDeltas:
+		if window < 1 {
+			return nil, fmt.Errorf("regime '%s' window must be positive value", kind)
+		}
+	case RKVolatility:
+		if window < 2 {
+			return nil, fmt.Errorf("regime '%s' window must be more than 1", kind)
+		}
+	default:
+		return nil, fmt.Errorf("unknown regime: '%s'", kind)
+	}
+	if k < 2 {
+		return nil, errors.New("regimes count must be more than 1")
+	}
+	return &RegimeDetector{Kind: kind, K: k, Window: window}, nil
+}
+
+// Fit cluster all windows of the rates
+func (f *RegimeDetector) Fit(rates []float32) error {
+	xy, err := f.samples(rates)
+	if err != nil {
+		return err
+	}
+	if len(xy) < f.K {
+		return fmt.Errorf("windows count: %d less than regimes count: %d", len(xy), f.K)
+	}
+	nvars := len(xy[0])
+	var c [][]float64
+	var xyc []int
+	info := 0
+	if err := kmeans.KMeansGenerate(&xy, len(xy), nvars, f.K, regimeRestarts, &info, &c, &xyc); err != nil {
+		return err
+	}
+	switch info {
+	case 1:
+	case -3:
+		return fmt.Errorf("distinct windows count less than regimes count: %d", f.K)
+	default:
+		return fmt.Errorf("clustering of %d windows failed, info: %d", len(xy), info)
+	}
+
+	centers := make([][]float64, f.K)
+	for i := range centers {
+		centers[i] = make([]float64, nvars)
+		for j := range centers[i] {
+			centers[i][j] = c[j][i]
+		}
+	}
+	// the order by the mean of the center keeps the meaning of the regime between the fits
+	sort.Stable(centersByMean(centers))
+	f.centers = centers
+	return nil
+}
+
+// Detect return regime of the latest window of the rates, the regime of the nearest center
+func (f *RegimeDetector) Detect(rates []float32) (int, error) {
+	if len(f.centers) == 0 {
+		return -1, errors.New("regime detector is not fitted")
+	}
+	if len(rates) < f.Window+1 {
+		return -1, fmt.Errorf("rates count: %d less than window: %d and the previous rate", len(rates), f.Window)
+	}
+	xy, err := f.samples(rates[len(rates)-f.Window-1:])
+	if err != nil {
+		return -1, err
+	}
+	result, min := -1, 0.0
+	for i, center := range f.centers {
+		var d float64
+		for j, item := range xy[0] {
+			d += (item - center[j]) * (item - center[j])
+		}
+		if result < 0 || d < min {
+			result, min = i, d
+		}
+	}
+	return result, nil
+}
+
+// samples return the sample of each window of the rates
+func (f *RegimeDetector) samples(rates []float32) ([][]float64, error) {
+	deltas := make([]float64, len(rates)-1)
+	for i := range deltas {
+		if rates[i] <= 0 {
+			return nil, errors.New("rates must be positive values")
+		}
+		deltas[i] = float64(rates[i+1]/rates[i]) - 1
+	}
+	if len(deltas) < f.Window {
+		return nil, fmt.Errorf("deltas count: %d less than window: %d", len(deltas), f.Window)
+	}
+	result := make([][]float64, len(deltas)-f.Window+1)
+	for i := range result {
+		window := deltas[i : i+f.Window]
+		if f.Kind == RKVolatility {
+			result[i] = []float64{deviation(window)}
+		} else {
+			result[i] = window
+		}
+	}
+	return result, nil
+}
+
+func average(values []float64) float64 {
+	var result float64
+	for _, item := range values {
+		result += item
+	}
+	return result / float64(len(values))
+}
+
+type centersByMean [][]float64
+
+func (a centersByMean) Len() int           { return len(a) }
+func (a centersByMean) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a centersByMean) Less(i, j int) bool { return average(a[i]) < average(a[j]) }
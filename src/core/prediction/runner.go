@@ -0,0 +1,147 @@
+package prediction
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Task - independent unit of the prediction cycle, e.g. processing of the symbol by the one model,
+// the task must stop when the context is cancelled
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) (int, error)
+}
+
+// TaskResult - outcome of the task
+type TaskResult struct {
+	Name       string
+	Prediction int
+	Error      error
+	Panicked   bool
+	TimedOut   bool
+	Duration   time.Duration
+}
+
+// RunReport - outcomes of all the tasks in the order of the tasks
+type RunReport struct {
+	Results  []TaskResult
+	Duration time.Duration
+}
+
+// Failed return results of the failed, panicked and timed out tasks
+func (f *RunReport) Failed() []TaskResult {
+	var result []TaskResult
+	for _, item := range f.Results {
+		if item.Error != nil {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Error return aggregated error of the failed tasks, nil if all the tasks succeeded
+func (f *RunReport) Error() error {
+	failed := f.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d of %d tasks failed:", len(failed), len(f.Results))
+	for _, item := range failed {
+		fmt.Fprintf(&buf, "\n\t%s: %v", item.Name, item.Error)
+	}
+	return fmt.Errorf("%s", buf.String())
+}
+
+// ToString method
+func (f *RunReport) ToString() string {
+	return fmt.Sprintf("RunReport { Tasks: %d, Failed: %d, Duration: %v }",
+		len(f.Results),
+		len(f.Failed()),
+		f.Duration)
+}
+
+// RunTasks run tasks concurrently by at most workers goroutines (count of CPU if not positive).
+// Panic of the task is recovered and reported as its error. The task running longer than the positive
+// timeout is reported as timed out and its worker takes the next task at once: the context of the task
+// is cancelled, but the task which doesn't check it is abandoned and finishes in the background,
+// its result is discarded.
+func RunTasks(tasks []Task, workers int, timeout time.Duration) *RunReport {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	start := time.Now()
+	report := &RunReport{Results: make([]TaskResult, len(tasks))}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range queue {
+				report.Results[idx] = runTask(tasks[idx], timeout)
+			}
+		}()
+	}
+	for i := range tasks {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+func runTask(task Task, timeout time.Duration) TaskResult {
+	start := time.Now()
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
+	// buffered, so the abandoned task doesn't block on the send
+	done := make(chan TaskResult, 1)
+	go func() {
+		result := TaskResult{Name: task.Name, Prediction: -1}
+		defer func() {
+			if p := recover(); p != nil {
+				result.Prediction = -1
+				result.Panicked = true
+				result.Error = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+			}
+			done <- result
+		}()
+		result.Prediction, result.Error = task.Run(ctx)
+	}()
+
+	var result TaskResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = TaskResult{Name: task.Name, Prediction: -1}
+	}
+	// the result of the task finished after the deadline is discarded
+	if ctx.Err() == context.DeadlineExceeded {
+		result.Prediction = -1
+		result.TimedOut = true
+		if result.Error != nil {
+			result.Error = fmt.Errorf("timeout %v exceeded: %v", timeout, result.Error)
+		} else {
+			result.Error = fmt.Errorf("timeout %v exceeded", timeout)
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
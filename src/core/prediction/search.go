@@ -0,0 +1,305 @@
+package prediction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction/memory"
+)
+
+// defaultSearchWindow - size of the rates repo of the live job
+const defaultSearchWindow = 200
+
+// SearchSpace - values of the hyperparameters, candidates are combinations of them
+type SearchSpace struct {
+	RangeCounts []int
+	Frames      []int
+	Limits      []int
+	Hidden      []int // size of the hidden layer, zero means no hidden layers, negative means size of the inputs
+	Decays      []float64
+	Restarts    []int
+}
+
+// Candidate - single combination of the hyperparameters
+type Candidate struct {
+	RangeCount int
+	Frame      int
+	Limit      int
+	Hidden     int
+	Decay      float64
+	Restarts   int
+}
+
+// ToString method
+func (f *Candidate) ToString() string {
+	return fmt.Sprintf("Candidate {: Ranges: %d, Frame: %d, Limit: %d, Hidden: %d, Decay: %v, Restarts: %d }",
+		f.RangeCount,
+		f.Frame,
+		f.Limit,
+		f.Hidden,
+		f.Decay,
+		f.Restarts)
+}
+
+// Apply set hidden layer and train params of the candidate to the engine
+func (f *Candidate) Apply(engine *Engine) (*Engine, error) {
+	if err := f.validate(engine.trainType); err != nil {
+		return nil, err
+	}
+	if !isNetwork(engine.trainType) {
+		return engine, nil
+	}
+	var err error
+	if f.Hidden > 0 {
+		engine, err = engine.WithHidden(f.Hidden)
+	} else if f.Hidden == 0 {
+		engine, err = engine.WithHidden()
+	}
+	if err != nil {
+		return nil, err
+	}
+	params := engine.TrainParams()
+	params.Network.Decay = f.Decay
+	params.Network.Restarts = f.Restarts
+	return engine.WithTrainParams(params)
+}
+
+// validate check the network hyperparameters are set for the networks only
+func (f *Candidate) validate(trainType string) error {
+	if isNetwork(trainType) {
+		if f.Decay < 0 || f.Restarts < 1 {
+			return fmt.Errorf("decay: %v and restarts: %d of the '%s' must be non-negative and positive", f.Decay, f.Restarts, trainType)
+		}
+		return nil
+	}
+	if f.Hidden >= 0 || f.Decay != 0 || f.Restarts != 0 {
+		return fmt.Errorf("'%s' has no hidden layer, decay and restarts", trainType)
+	}
+	return nil
+}
+
+// Validate check each candidate of the space is applicable to the train type
+func (f *SearchSpace) Validate(trainType string) error {
+	candidates, err := f.Grid()
+	if err != nil {
+		return err
+	}
+	for _, candidate := range candidates {
+		if err := candidate.validate(trainType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Grid return all combinations of the values
+func (f *SearchSpace) Grid() ([]Candidate, error) {
+	if len(f.RangeCounts) == 0 || len(f.Frames) == 0 || len(f.Limits) == 0 || len(f.Hidden) == 0 || len(f.Decays) == 0 || len(f.Restarts) == 0 {
+		return nil, errors.New("each hyperparameter requires at least one value")
+	}
+	var result []Candidate
+	for _, rangeCount := range f.RangeCounts {
+		for _, frame := range f.Frames {
+			for _, limit := range f.Limits {
+				for _, hidden := range f.Hidden {
+					for _, decay := range f.Decays {
+						for _, restarts := range f.Restarts {
+							result = append(result, Candidate{rangeCount, frame, limit, hidden, decay, restarts})
+						}
+					}
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// Random return count combinations chosen randomly from the grid without repetition
+func (f *SearchSpace) Random(count int, rnd *rand.Rand) ([]Candidate, error) {
+	grid, err := f.Grid()
+	if err != nil {
+		return nil, err
+	}
+	if count >= len(grid) {
+		return grid, nil
+	}
+	result := make([]Candidate, count)
+	for i, idx := range rnd.Perm(len(grid))[:count] {
+		result[i] = grid[idx]
+	}
+	return result, nil
+}
+
+// SearchConfig - settings shared by all evaluated candidates
+type SearchConfig struct {
+	TrainType string
+	NetType   string
+	Horizons  []int // recursive forecast horizons, empty for one step only
+	Window    int   // count of the newest rates passed on each step, see Backtest
+	Workers   int   // count of the parallel evaluations, count of CPU if not positive
+}
+
+// SearchResult - walk-forward evaluation of the candidate on the symbol
+type SearchResult struct {
+	Candidate
+	Symbol               string
+	Assessed             int     // count of the predictions with known result
+	HitRate              float64 // part of the predictions with exact class match
+	DirectionRate        float64 // part of the predictions with direction match
+	HorizonDirectionRate float64 // mean direction rate of the horizons
+	MAE                  float64 // mean absolute error in classes
+	// direction rate of the naive baseline predicting the last class, the candidate must beat it
+	PersistenceDirectionRate float64
+	Report                   *BacktestReport
+	Error                    error
+}
+
+// ToString method
+func (f *SearchResult) ToString() string {
+	if f.Error != nil {
+		return fmt.Sprintf("%s %s error: %v", f.Symbol, f.Candidate.ToString(), f.Error)
+	}
+	return fmt.Sprintf("%s %s DirectionRate: %.4f, HitRate: %.4f, HorizonDirectionRate: %.4f, MAE: %.4f, PersistenceDirectionRate: %.4f, Assessed: %d",
+		f.Symbol,
+		f.Candidate.ToString(),
+		f.DirectionRate,
+		f.HitRate,
+		f.HorizonDirectionRate,
+		f.MAE,
+		f.PersistenceDirectionRate,
+		f.Assessed)
+}
+
+// Search evaluate each candidate on each symbol by backtest in parallel and return ranked results.
+// The evaluation is walk-forward: each step uses the past rates only and the network is retrained
+// with the same cadence as the live job.
+func Search(rates []entities.Rate, symbols []string, candidates []Candidate, config SearchConfig) []SearchResult {
+	workers := config.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]SearchResult, 0, len(symbols)*len(candidates))
+	for _, symbol := range symbols {
+		for _, candidate := range candidates {
+			results = append(results, SearchResult{Candidate: candidate, Symbol: symbol})
+		}
+	}
+
+	queue := make(chan *SearchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				evaluate(item, rates, config)
+			}
+		}()
+	}
+	for i := range results {
+		queue <- &results[i]
+	}
+	close(queue)
+	wg.Wait()
+
+	RankResults(results)
+	return results
+}
+
+// RankResults sort results by direction rate, exact hit rate and horizons direction rate, failed results are the last
+func RankResults(results []SearchResult) {
+	sort.Stable(byRank(results))
+}
+
+func evaluate(item *SearchResult, rates []entities.Rate, config SearchConfig) {
+	c := item.Candidate
+	engine, err := NewEngine(c.RangeCount, c.Frame, c.Limit, config.TrainType, config.NetType, item.Symbol)
+	if err == nil {
+		engine, err = engine.WithHorizons(HMRecursive, config.Horizons...)
+	}
+	if err == nil {
+		engine, err = c.Apply(engine)
+	}
+	if err != nil {
+		item.Error = err
+		return
+	}
+
+	window := config.Window
+	if window < 1 {
+		window = defaultSearchWindow
+	}
+	if window < c.Limit+1 {
+		window = c.Limit + 1
+	}
+	results := memory.NewResultDataRepo(0, false, engine.Model(), item.Symbol)
+	efficiency := memory.NewEfficiencyRepo(engine.Model(), item.Symbol, int32(c.RangeCount), int32(c.Limit), int32(c.Frame))
+	if item.Report, item.Error = Backtest(engine, rates, window, results, efficiency, nil); item.Error != nil {
+		return
+	}
+
+	eff := item.Report.Efficiency
+	if eff.Count == 0 {
+		item.Error = errors.New("no assessed predictions")
+		return
+	}
+	item.Assessed = int(eff.Count)
+	item.HitRate = eff.GetHitRate()
+	item.DirectionRate = eff.GetDirectionRate()
+	item.MAE = eff.GetMAE()
+	item.PersistenceDirectionRate = eff.GetBaselineDirectionRate(entities.BLPersistence)
+
+	item.HorizonDirectionRate = math.NaN()
+	var sum float64
+	var cnt int
+	for _, horizon := range eff.Horizons {
+		if rate := eff.GetHorizonDirectionRate(horizon); !math.IsNaN(rate) {
+			sum += rate
+			cnt++
+		}
+	}
+	if cnt > 0 {
+		item.HorizonDirectionRate = sum / float64(cnt)
+	}
+}
+
+type byRank []SearchResult
+
+func (a byRank) Len() int      { return len(a) }
+func (a byRank) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byRank) Less(i, j int) bool {
+	if (a[i].Error == nil) != (a[j].Error == nil) {
+		return a[i].Error == nil
+	}
+	if a[i].DirectionRate != a[j].DirectionRate {
+		return a[i].DirectionRate > a[j].DirectionRate
+	}
+	if a[i].HitRate != a[j].HitRate {
+		return a[i].HitRate > a[j].HitRate
+	}
+	return greater(a[i].HorizonDirectionRate, a[j].HorizonDirectionRate)
+}
+
+// isNetwork check the train type trains network or ensemble of networks
+func isNetwork(trainType string) bool {
+	_, found := _trainTypes[trainType]
+	if !found {
+		_, found = _ensembleTypes[trainType]
+	}
+	return found
+}
+
+// greater compare values, NaN is less than any number
+func greater(a, b float64) bool {
+	if math.IsNaN(a) {
+		return false
+	}
+	return math.IsNaN(b) || a > b
+}
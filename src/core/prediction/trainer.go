@@ -0,0 +1,192 @@
+package prediction
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"pr.optima/src/core/neural"
+)
+
+const (
+	// TTLbfgs - L-BFGS training with regularization
+	TTLbfgs = "L-BFGS"
+	// TTLm - Levenberg-Marquardt training with exact Hessian
+	TTLm = "LM"
+	// TTEs - early stopping training, validation set is the tail of the train set
+	TTEs = "ES"
+	// TTKfoldLbfgs - k-fold cross-validation estimate, final network trained with L-BFGS
+	TTKfoldLbfgs = "CV-L-BFGS"
+	// TTKfoldLm - k-fold cross-validation estimate, final network trained with Levenberg-Marquardt
+	TTKfoldLm = "CV-LM"
+)
+
+// TrainParams - hyperparameters of the predictor, each model family uses its own params only,
+// MNL and the baselines have no hyperparameters
+type TrainParams struct {
+	Network  NetworkParams  // network and ensemble train types
+	Ensemble EnsembleParams // ensemble train types
+	Markov   MarkovParams
+	Forest   ForestParams
+	Lda      LdaParams
+	Analog   AnalogParams
+}
+
+// NetworkParams - hyperparameters of the network training algorithm, each train type uses its own subset
+type NetworkParams struct {
+	Decay          float64 // weight decay constant, used by all types
+	Restarts       int     // number of restarts from random position, used by all types
+	WStep          float64 // L-BFGS stopping criterion by step size
+	MaxIts         int     // L-BFGS stopping criterion by iterations count
+	Folds          int     // number of folds for k-fold cross-validation
+	ValidationPart float64 // part of the train set held out as validation set for early stopping
+}
+
+// TrainReport - result of the training
+type TrainReport struct {
+	Reason neural.TerminationReason // why the training has been finished
+	Report *neural.MlpReport
+	CV     *neural.MlpCvReport // cross-validation or out-of-bag estimate, nil if not calculated
+}
+
+// describe return termination reason and the error estimate of the report,
+// estimate names the CV report: cross-validation or out-of-bag
+func (f *TrainReport) describe(estimate string) string {
+	result := fmt.Sprintf(", %s", f.Reason)
+	if f.CV != nil {
+		result += fmt.Sprintf(", %s rms error %.4f", estimate, f.CV.GetRmsError())
+	}
+	return result
+}
+
+// Trainer - trains network on the first npoints rows of xy, the training is stopped by the cancelled context
+type Trainer func(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error)
+
+type trainType struct {
+	trainer  Trainer
+	defaults NetworkParams
+}
+
+var _trainTypes = make(map[string]trainType)
+
+func init() {
+	RegisterTrainType(TTLbfgs, trainLbfgs, NetworkParams{Decay: 0.001, Restarts: 2, WStep: 0.01})
+	RegisterTrainType(TTLm, trainLm, NetworkParams{Decay: 0.001, Restarts: 2})
+	RegisterTrainType(TTEs, trainEs, NetworkParams{Decay: 0.001, Restarts: 2, ValidationPart: 0.25})
+	RegisterTrainType(TTKfoldLbfgs, trainKfoldLbfgs, NetworkParams{Decay: 0.001, Restarts: 2, WStep: 0.01, Folds: 5})
+	RegisterTrainType(TTKfoldLm, trainKfoldLm, NetworkParams{Decay: 0.001, Restarts: 2, Folds: 5})
+}
+
+// RegisterTrainType add train type to the registry, existing type with the same name is replaced
+func RegisterTrainType(name string, trainer Trainer, defaults NetworkParams) {
+	_trainTypes[name] = trainType{trainer: trainer, defaults: defaults}
+}
+
+// TrainTypes return sorted names of the registered train types
+func TrainTypes() []string {
+	result := make([]string, 0, len(_trainTypes))
+	for name := range _trainTypes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DefaultTrainParams return default hyperparameters of the train type, the ensemble train type or the predictor
+func DefaultTrainParams(name string) (TrainParams, error) {
+	if pt, found := _predictorTypes[name]; found {
+		return pt.defaults, nil
+	}
+	if et, found := _ensembleTypes[name]; found {
+		return et.defaults, nil
+	}
+	tt, found := _trainTypes[name]
+	if !found {
+		return TrainParams{}, fmt.Errorf("unknown train type: '%s'", name)
+	}
+	return TrainParams{Network: tt.defaults}, nil
+}
+
+// Train network with the registered train type
+func Train(name string, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	return TrainContext(context.Background(), name, mlp, xy, npoints, params)
+}
+
+// TrainContext - Train cancelled by the context
+func TrainContext(ctx context.Context, name string, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	tt, found := _trainTypes[name]
+	if !found {
+		return nil, fmt.Errorf("unknown train type: '%s'", name)
+	}
+	return tt.trainer(ctx, mlp, xy, npoints, params)
+}
+
+func trainLbfgs(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	rep, err := neural.MlpTrainLbfgsContext(ctx, mlp, xy, npoints, params.Decay, params.Restarts, params.WStep, params.MaxIts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep}, nil
+}
+
+func trainLm(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	rep, err := neural.MlpTrainLmContext(ctx, mlp, xy, npoints, params.Decay, params.Restarts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep}, nil
+}
+
+func trainEs(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	valSize := int(float64(npoints) * params.ValidationPart)
+	if valSize < 1 {
+		valSize = 1
+	}
+	trnSize := npoints - valSize
+	if trnSize < 1 {
+		return nil, fmt.Errorf("MlpTrainEs error: not enough points (%d) for validation part %v", npoints, params.ValidationPart)
+	}
+	// early stopping has no cancellable variant, the cancelled training isn't started
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	valXY := (*xy)[trnSize:npoints]
+	rep, err := neural.MlpTrainEs(mlp, *xy, trnSize, &valXY, valSize, params.Decay, params.Restarts)
+	if err != nil {
+		return nil, err
+	}
+	return &TrainReport{Reason: rep.GetTerminationReason(), Report: rep}, nil
+}
+
+func trainKfoldLbfgs(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	_, cvRep, err := neural.MlpKfoldCvLbfgs(mlp, xy, npoints, params.Decay, params.Restarts, params.WStep, params.MaxIts, params.Folds)
+	if err != nil {
+		return nil, err
+	}
+	result, err := trainLbfgs(ctx, mlp, xy, npoints, params)
+	if err != nil {
+		return nil, err
+	}
+	result.CV = cvRep
+	return result, nil
+}
+
+func trainKfoldLm(ctx context.Context, mlp *neural.MultiLayerPerceptron, xy *[][]float64, npoints int, params NetworkParams) (*TrainReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	_, cvRep, err := neural.MlpKfoldCvLm(mlp, xy, npoints, params.Decay, params.Restarts, params.Folds)
+	if err != nil {
+		return nil, err
+	}
+	result, err := trainLm(ctx, mlp, xy, npoints, params)
+	if err != nil {
+		return nil, err
+	}
+	result.CV = cvRep
+	return result, nil
+}
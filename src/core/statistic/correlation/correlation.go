@@ -0,0 +1,513 @@
+package correlation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+// MPearson, MSpearman - methods of the correlation matrix
+const (
+	MPearson  = "pearson"
+	MSpearman = "spearman"
+)
+
+// Matrix - correlation of the variables with the significance of each coefficient
+type Matrix struct {
+	Method       string
+	Observations int
+	Coefficients [][]float64
+	PValues      [][]float64 // two-tailed p-values of the zero correlation hypothesis
+}
+
+// NewMatrix calculate correlation matrix of the columns of x by the method,
+// the rows are observations of the same length
+func NewMatrix(x [][]float64, method string) (*Matrix, error) {
+	if len(x) == 0 {
+		return nil, fmt.Errorf("empty sample")
+	}
+	m := len(x[0])
+	for i, row := range x {
+		if len(row) != m {
+			return nil, fmt.Errorf("row %d length: %d, expected: %d", i, len(row), m)
+		}
+	}
+	var c [][]float64
+	var significance func(r float64, n int, bothTails, leftTail, rightTail *float64)
+	switch method {
+	case MPearson:
+		if err := PearsonCorrM(&x, len(x), m, &c); err != nil {
+			return nil, err
+		}
+		significance = PearsonCorrelationSignificance
+	case MSpearman:
+		if err := SpearmanCorrM(&x, len(x), m, &c); err != nil {
+			return nil, err
+		}
+		significance = SpearmanRankCorrelationSignificance
+	default:
+		return nil, fmt.Errorf("unknown correlation method: %s", method)
+	}
+	p := utils.MakeMatrixFloat64(m, m)
+	for i := range p {
+		for j := range p[i] {
+			var leftTail, rightTail float64
+			significance(c[i][j], len(x), &p[i][j], &leftTail, &rightTail)
+		}
+	}
+	return &Matrix{Method: method, Observations: len(x), Coefficients: c, PValues: p}, nil
+}
+
+/*************************************************************************
+Covariance matrix
+
+INPUT PARAMETERS:
+	X   -   array[N,M], sample matrix:
+			* J-th column corresponds to J-th variable
+			* I-th row corresponds to I-th observation
+	N   -   N>=0, number of observations, only leading N rows of X are used
+	M   -   M>0, number of variables, only leading M columns of X are used
+
+OUTPUT PARAMETERS:
+	C   -   array[M,M], covariance matrix (zero if N=0 or N=1)
+
+  -- ALGLIB --
+	 Copyright 28.10.2010 by Bochkanov Sergey
+*************************************************************************/
+func CovM(x *[][]float64, n, m int, c *[][]float64) error {
+	if err := checkSample("CovM", x, n, m); err != nil {
+		return err
+	}
+	xc := utils.MakeMatrixFloat64(n, m)
+	for i := 0; i <= n-1; i++ {
+		copy(xc[i], (*x)[i][:m])
+	}
+	*c = covariance(xc, n, m)
+	return nil
+}
+
+/*************************************************************************
+Pearson product-moment correlation matrix
+
+INPUT PARAMETERS:
+	X   -   array[N,M], sample matrix:
+			* J-th column corresponds to J-th variable
+			* I-th row corresponds to I-th observation
+	N   -   N>=0, number of observations, only leading N rows of X are used
+	M   -   M>0, number of variables, only leading M columns of X are used
+
+OUTPUT PARAMETERS:
+	C   -   array[M,M], correlation matrix (zero if N=0 or N=1)
+
+  -- ALGLIB --
+	 Copyright 28.10.2010 by Bochkanov Sergey
+*************************************************************************/
+func PearsonCorrM(x *[][]float64, n, m int, c *[][]float64) error {
+	if err := checkSample("PearsonCorrM", x, n, m); err != nil {
+		return err
+	}
+	xc := utils.MakeMatrixFloat64(n, m)
+	for i := 0; i <= n-1; i++ {
+		copy(xc[i], (*x)[i][:m])
+	}
+	*c = covariance(xc, n, m)
+	normalize(*c, m)
+	return nil
+}
+
+/*************************************************************************
+Spearman's rank correlation matrix
+
+INPUT PARAMETERS:
+	X   -   array[N,M], sample matrix:
+			* J-th column corresponds to J-th variable
+			* I-th row corresponds to I-th observation
+	N   -   N>=0, number of observations, only leading N rows of X are used
+	M   -   M>0, number of variables, only leading M columns of X are used
+
+OUTPUT PARAMETERS:
+	C   -   array[M,M], correlation matrix (zero if N=0 or N=1)
+
+  -- ALGLIB --
+	 Copyright 28.10.2010 by Bochkanov Sergey
+*************************************************************************/
+func SpearmanCorrM(x *[][]float64, n, m int, c *[][]float64) error {
+	if err := checkSample("SpearmanCorrM", x, n, m); err != nil {
+		return err
+	}
+
+	//
+	// Replace data with ranks
+	//
+	xc := utils.MakeMatrixFloat64(n, m)
+	t := make([]float64, n)
+	for j := 0; j <= m-1; j++ {
+		for i := 0; i <= n-1; i++ {
+			t[i] = (*x)[i][j]
+		}
+		rankx(t, n)
+		for i := 0; i <= n-1; i++ {
+			xc[i][j] = t[i]
+		}
+	}
+	*c = covariance(xc, n, m)
+	normalize(*c, m)
+	return nil
+}
+
+/*************************************************************************
+Pearson's correlation coefficient significance test
+
+This test checks hypotheses about whether X  and  Y  are  samples  of  two
+continuous  distributions  having  zero  correlation  or   whether   their
+correlation is non-zero.
+
+The following tests are performed:
+	* two-tailed test (null hypothesis - X and Y have zero correlation)
+	* left-tailed test (null hypothesis - the correlation  coefficient  is
+	  greater than or equal to 0)
+	* right-tailed test (null hypothesis - the correlation coefficient  is
+	  less than or equal to 0).
+
+Requirements:
+	* the number of elements in each sample is not less than 5
+	* normality of distributions of X and Y.
+
+Input parameters:
+	R   -   Pearson's correlation coefficient for X and Y
+	N   -   number of elements in samples, N>=5.
+
+Output parameters:
+	BothTails   -   p-value for two-tailed test.
+					If BothTails is less than the given significance level
+					the null hypothesis is rejected.
+	LeftTail    -   p-value for left-tailed test.
+					If LeftTail is less than the given significance level,
+					the null hypothesis is rejected.
+	RightTail   -   p-value for right-tailed test.
+					If RightTail is less than the given significance level
+					the null hypothesis is rejected.
+
+  -- ALGLIB --
+	 Copyright 09.04.2007 by Bochkanov Sergey
+*************************************************************************/
+func PearsonCorrelationSignificance(r float64, n int, bothTails, leftTail, rightTail *float64) {
+
+	//
+	// Some special cases
+	//
+	if r >= 1 {
+		*bothTails = 0.0
+		*leftTail = 1.0
+		*rightTail = 0.0
+		return
+	}
+	if r <= -1 {
+		*bothTails = 0.0
+		*leftTail = 0.0
+		*rightTail = 1.0
+		return
+	}
+	if n < 5 {
+		*bothTails = 1.0
+		*leftTail = 1.0
+		*rightTail = 1.0
+		return
+	}
+
+	//
+	// General case
+	//
+	t := r * math.Sqrt(float64(n-2)/(1-utils.SqrFloat64(r)))
+	p := studenttdistribution(n-2, t)
+	*bothTails = 2 * math.Min(p, 1-p)
+	*leftTail = p
+	*rightTail = 1 - p
+}
+
+/*************************************************************************
+Spearman's rank correlation coefficient significance test
+
+This test checks hypotheses about whether X  and  Y  are  samples  of  two
+continuous  distributions  having  zero  correlation  or   whether   their
+correlation is non-zero.
+
+The following tests are performed:
+	* two-tailed test (null hypothesis - X and Y have zero correlation)
+	* left-tailed test (null hypothesis - the correlation  coefficient  is
+	  greater than or equal to 0)
+	* right-tailed test (null hypothesis - the correlation coefficient  is
+	  less than or equal to 0).
+
+Requirements:
+	* the number of elements in each sample is not less than 5.
+
+The test is non-parametric and doesn't require distributions X and Y to be
+normal.
+
+Input parameters:
+	R   -   Spearman's rank correlation coefficient for X and Y
+	N   -   number of elements in samples, N>=5.
+
+Output parameters:
+	BothTails   -   p-value for two-tailed test.
+					If BothTails is less than the given significance level
+					the null hypothesis is rejected.
+	LeftTail    -   p-value for left-tailed test.
+					If LeftTail is less than the given significance level,
+					the null hypothesis is rejected.
+	RightTail   -   p-value for right-tailed test.
+					If RightTail is less than the given significance level
+					the null hypothesis is rejected.
+
+  -- ALGLIB --
+	 Copyright 09.04.2007 by Bochkanov Sergey
+*************************************************************************/
+func SpearmanRankCorrelationSignificance(r float64, n int, bothTails, leftTail, rightTail *float64) {
+	var t float64
+
+	//
+	// Special case
+	//
+	if n < 5 {
+		*bothTails = 1.0
+		*leftTail = 1.0
+		*rightTail = 1.0
+		return
+	}
+
+	//
+	// General case
+	//
+	if r >= 1 {
+		t = 1.0E10
+	} else {
+		if r <= -1 {
+			t = -1.0E10
+		} else {
+			t = r * math.Sqrt(float64(n-2)/(1-utils.SqrFloat64(r)))
+		}
+	}
+	if t < 0 {
+		p := spearmantail(t, n)
+		*bothTails = 2 * p
+		*leftTail = p
+		*rightTail = 1 - p
+	} else {
+		p := spearmantail(-t, n)
+		*bothTails = 2 * p
+		*leftTail = 1 - p
+		*rightTail = p
+	}
+}
+
+// spearmantails - tables of Tail(S, N) for N=5..9: S below the first value is
+// approximated by the Student's t distribution, otherwise the p-value of the
+// first pair {S0, P} with S>=S0 is taken
+var spearmantails = map[int]struct {
+	min   float64
+	pairs [][2]float64
+}{
+	5: {0.000e+00, [][2]float64{
+		{3.580e+00, 8.304e-03}, {2.322e+00, 4.163e-02}, {1.704e+00, 6.641e-02}, {1.303e+00, 1.164e-01},
+		{1.003e+00, 1.748e-01}, {7.584e-01, 2.249e-01}, {5.468e-01, 2.581e-01}, {3.555e-01, 3.413e-01},
+		{1.759e-01, 3.911e-01}, {1.741e-03, 4.747e-01}, {0.000e+00, 5.248e-01}}},
+	6: {1.001e+00, [][2]float64{
+		{5.663e+00, 1.366e-03}, {3.834e+00, 8.350e-03}, {2.968e+00, 1.668e-02}, {2.430e+00, 2.921e-02},
+		{2.045e+00, 5.144e-02}, {1.747e+00, 6.797e-02}, {1.502e+00, 8.752e-02}, {1.295e+00, 1.210e-01},
+		{1.113e+00, 1.487e-01}, {1.001e+00, 1.780e-01}}},
+	7: {1.001e+00, [][2]float64{
+		{8.159e+00, 2.081e-04}, {5.620e+00, 1.393e-03}, {4.445e+00, 3.398e-03}, {3.728e+00, 6.187e-03},
+		{3.226e+00, 1.200e-02}, {2.844e+00, 1.712e-02}, {2.539e+00, 2.408e-02}, {2.285e+00, 3.320e-02},
+		{2.068e+00, 4.406e-02}, {1.879e+00, 5.478e-02}, {1.710e+00, 6.946e-02}, {1.559e+00, 8.331e-02},
+		{1.420e+00, 1.001e-01}, {1.292e+00, 1.180e-01}, {1.173e+00, 1.335e-01}, {1.062e+00, 1.513e-01},
+		{1.001e+00, 1.770e-01}}},
+	8: {2.001e+00, [][2]float64{
+		{1.103e+01, 2.194e-05}, {7.685e+00, 2.008e-04}, {6.143e+00, 5.686e-04}, {5.213e+00, 1.138e-03},
+		{4.567e+00, 2.310e-03}, {4.081e+00, 3.634e-03}, {3.697e+00, 5.369e-03}, {3.381e+00, 7.708e-03},
+		{3.114e+00, 1.087e-02}, {2.884e+00, 1.397e-02}, {2.682e+00, 1.838e-02}, {2.502e+00, 2.288e-02},
+		{2.340e+00, 2.883e-02}, {2.192e+00, 3.469e-02}, {2.057e+00, 4.144e-02}, {2.001e+00, 4.804e-02}}},
+	9: {2.001e+00, [][2]float64{
+		{9.989e+00, 2.306e-05}, {8.069e+00, 8.167e-05}, {6.890e+00, 1.744e-04}, {6.077e+00, 3.625e-04},
+		{5.469e+00, 6.450e-04}, {4.991e+00, 1.001e-03}, {4.600e+00, 1.514e-03}, {4.272e+00, 2.213e-03},
+		{3.991e+00, 2.990e-03}, {3.746e+00, 4.101e-03}, {3.530e+00, 5.355e-03}, {3.336e+00, 6.887e-03},
+		{3.161e+00, 8.598e-03}, {3.002e+00, 1.065e-02}, {2.855e+00, 1.268e-02}, {2.720e+00, 1.552e-02},
+		{2.595e+00, 1.836e-02}, {2.477e+00, 2.158e-02}, {2.368e+00, 2.512e-02}, {2.264e+00, 2.942e-02},
+		{2.166e+00, 3.325e-02}, {2.073e+00, 3.800e-02}, {2.001e+00, 4.285e-02}}},
+}
+
+/*************************************************************************
+Tail(T,N), accepts T<0
+*************************************************************************/
+func spearmantail(t float64, n int) float64 {
+	table, ok := spearmantails[n]
+	if !ok {
+		return studenttdistribution(n-2, t)
+	}
+	s := -t
+	if s < table.min {
+		return studenttdistribution(n-2, -s)
+	}
+	for _, pair := range table.pairs {
+		if s >= pair[0] {
+			return pair[1]
+		}
+	}
+	return 0
+}
+
+// checkSample - input checks of the sample matrix shared by the subroutines
+func checkSample(name string, x *[][]float64, n, m int) error {
+	if n < 0 {
+		return fmt.Errorf("%s: N<0", name)
+	}
+	if m < 1 {
+		return fmt.Errorf("%s: M<1", name)
+	}
+	if len(*x) < n {
+		return fmt.Errorf("%s: Rows(X)<N!", name)
+	}
+	for i := 0; i <= n-1; i++ {
+		if len((*x)[i]) < m {
+			return fmt.Errorf("%s: Cols(X)<M!", name)
+		}
+		if ok, _ := utils.IsFiniteVector((*x)[i], m); !ok {
+			return fmt.Errorf("%s: X contains infinite/NAN elements", name)
+		}
+	}
+	return nil
+}
+
+/*************************************************************************
+Covariance of the columns of X, X is centered in place.
+
+Constant columns are artificially zeroed (they must be zero in exact
+arithmetics, but unfortunately floating point ops are not exact).
+*************************************************************************/
+func covariance(x [][]float64, n, m int) [][]float64 {
+	c := utils.MakeMatrixFloat64(m, m)
+
+	//
+	// N<=1, return zero
+	//
+	if n <= 1 {
+		return c
+	}
+
+	//
+	// Calculate means,
+	// check for constant columns
+	//
+	t := make([]float64, m)
+	same := make([]bool, m)
+	for j := 0; j <= m-1; j++ {
+		same[j] = true
+	}
+	v := 1 / float64(n)
+	for i := 0; i <= n-1; i++ {
+		for j := 0; j <= m-1; j++ {
+			t[j] = t[j] + v*x[i][j]
+			same[j] = same[j] && x[i][j] == x[0][j]
+		}
+	}
+
+	//
+	// center variables and calculate upper half of symmetric covariance matrix
+	//
+	for i := 0; i <= n-1; i++ {
+		for j := 0; j <= m-1; j++ {
+			if same[j] {
+				x[i][j] = 0
+			} else {
+				x[i][j] = x[i][j] - t[j]
+			}
+		}
+	}
+	v = 1 / float64(n-1)
+	for i := 0; i <= m-1; i++ {
+		for j := i; j <= m-1; j++ {
+			s := 0.0
+			for k := 0; k <= n-1; k++ {
+				s += x[k][i] * x[k][j]
+			}
+			c[i][j] = v * s
+		}
+	}
+
+	//
+	// force symmetricity
+	//
+	for i := 0; i <= m-2; i++ {
+		for j := i + 1; j <= m-1; j++ {
+			c[j][i] = c[i][j]
+		}
+	}
+	return c
+}
+
+// normalize - covariance to correlation, zero variance gives zero correlation
+func normalize(c [][]float64, m int) {
+	t := make([]float64, m)
+	for i := 0; i <= m-1; i++ {
+		t[i] = math.Sqrt(c[i][i])
+	}
+	for i := 0; i <= m-1; i++ {
+		for j := 0; j <= m-1; j++ {
+			if t[i] != 0 && t[j] != 0 {
+				c[i][j] = c[i][j] / (t[i] * t[j])
+			} else {
+				c[i][j] = 0.0
+			}
+		}
+	}
+}
+
+// ranks - values sorted with their original positions
+type ranks struct {
+	values []float64
+	tags   []int
+}
+
+func (r ranks) Len() int           { return len(r.values) }
+func (r ranks) Less(i, j int) bool { return r.values[i] < r.values[j] }
+func (r ranks) Swap(i, j int) {
+	r.values[i], r.values[j] = r.values[j], r.values[i]
+	r.tags[i], r.tags[j] = r.tags[j], r.tags[i]
+}
+
+/*************************************************************************
+Internal ranking subroutine, ties get the mean rank of the group
+*************************************************************************/
+func rankx(x []float64, n int) {
+	if n < 1 {
+		return
+	}
+	if n == 1 {
+		x[0] = 1
+		return
+	}
+	r := ranks{values: append([]float64{}, x[:n]...), tags: make([]int, n)}
+	for i := 0; i <= n-1; i++ {
+		r.tags[i] = i
+	}
+	sort.Sort(r)
+
+	//
+	// compute tied ranks
+	//
+	for i := 0; i <= n-1; {
+		j := i + 1
+		for j <= n-1 && r.values[j] == r.values[i] {
+			j++
+		}
+		for k := i; k <= j-1; k++ {
+			r.values[k] = 1 + float64(i+j-1)/2
+		}
+		i = j
+	}
+	for i := 0; i <= n-1; i++ {
+		x[r.tags[i]] = r.values[i]
+	}
+}
@@ -0,0 +1,149 @@
+package correlation
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestStudentTDistribution(t *testing.T) {
+	// Cauchy and two degrees of freedom have closed forms, the third is the table quantile
+	tests := []struct {
+		k        int
+		t        float64
+		expected float64
+	}{
+		{1, 0.7, 0.5 + math.Atan(0.7)/math.Pi},
+		{1, -5, 0.5 + math.Atan(-5)/math.Pi},
+		{2, -3, 0.5 - 3/(2*math.Sqrt(11))},
+		{2, 1.5, 0.5 + 1.5/(2*math.Sqrt(4.25))},
+		{10, 2.228, 0.975},
+		{10, 0, 0.5},
+	}
+	for _, test := range tests {
+		p, err := StudentTDistribution(test.k, test.t)
+		if err != nil || math.Abs(p-test.expected) > 1e-4 {
+			t.Errorf("k: %d, t: %v, p: %v, expected: %v, error: %v", test.k, test.t, p, test.expected, err)
+		}
+	}
+	if _, err := StudentTDistribution(0, 1); err == nil {
+		t.Error("zero degrees of freedom accepted")
+	}
+}
+
+func TestPearsonCorrM(t *testing.T) {
+	x := make([][]float64, 50)
+	for i := range x {
+		v := rand.NormFloat64()
+		x[i] = []float64{v, 2*v + 1, -v, rand.NormFloat64(), 3}
+	}
+	var c [][]float64
+	if err := PearsonCorrM(&x, len(x), 5, &c); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c[0][0]-1) > 1e-12 || math.Abs(c[0][1]-1) > 1e-12 || math.Abs(c[0][2]+1) > 1e-12 {
+		t.Errorf("linear dependency: %v", c[0])
+	}
+	if math.Abs(c[0][3]) > 0.5 || c[0][3] != c[3][0] {
+		t.Errorf("independent variables: %v, %v", c[0][3], c[3][0])
+	}
+	if c[4][4] != 0 || c[0][4] != 0 {
+		t.Errorf("constant variable: %v", c[4])
+	}
+
+	var cov [][]float64
+	if err := CovM(&x, len(x), 2, &cov); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(cov[0][1]-2*cov[0][0]) > 1e-12 || math.Abs(cov[1][1]-4*cov[0][0]) > 1e-12 {
+		t.Errorf("covariance: %v", cov)
+	}
+	if x[0][1] != 2*x[0][0]+1 {
+		t.Error("sample changed")
+	}
+
+	if err := PearsonCorrM(&x, len(x), 6, &c); err == nil {
+		t.Error("short rows accepted")
+	}
+	x[3][2] = math.NaN()
+	if err := PearsonCorrM(&x, len(x), 3, &c); err == nil {
+		t.Error("NaN accepted")
+	}
+}
+
+func TestSpearmanCorrM(t *testing.T) {
+	x := [][]float64{{1, 1, 5}, {2, 8, 4}, {2, 8, 3}, {3, 27, 1}, {4, 64, 2}}
+	var c [][]float64
+	if err := SpearmanCorrM(&x, len(x), 3, &c); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c[0][1]-1) > 1e-12 {
+		t.Errorf("monotonic dependency: %v", c[0][1])
+	}
+	// ranks {1, 2.5, 2.5, 4, 5} and {5, 4, 3, 1, 2}
+	if expected := -8.5 / math.Sqrt(9.5*10); math.Abs(c[0][2]-expected) > 1e-12 {
+		t.Errorf("ties: %v, expected: %v", c[0][2], expected)
+	}
+
+	r := []float64{3, 1, 2, 1}
+	rankx(r, len(r))
+	if r[0] != 4 || r[1] != 1.5 || r[2] != 3 || r[3] != 1.5 {
+		t.Errorf("ranks: %v", r)
+	}
+}
+
+func TestSignificance(t *testing.T) {
+	var both, left, right float64
+	// t = 0.6 * sqrt(10 / 0.64) = 2.371, 10 degrees of freedom
+	PearsonCorrelationSignificance(0.6, 12, &both, &left, &right)
+	if math.Abs(both-0.0393) > 1e-3 || math.Abs(right-both/2) > 1e-12 || math.Abs(left+right-1) > 1e-12 {
+		t.Errorf("pearson p-values: %v, %v, %v", both, left, right)
+	}
+	PearsonCorrelationSignificance(1, 12, &both, &left, &right)
+	if both != 0 || left != 1 || right != 0 {
+		t.Errorf("perfect correlation p-values: %v, %v, %v", both, left, right)
+	}
+	PearsonCorrelationSignificance(0.9, 4, &both, &left, &right)
+	if both != 1 || left != 1 || right != 1 {
+		t.Errorf("small sample p-values: %v, %v, %v", both, left, right)
+	}
+
+	SpearmanRankCorrelationSignificance(-0.6, 12, &both, &left, &right)
+	if math.Abs(both-0.0393) > 1e-3 || math.Abs(left-both/2) > 1e-12 {
+		t.Errorf("spearman p-values: %v, %v, %v", both, left, right)
+	}
+	// table of 5 observations, t = 0.9 * sqrt(3 / 0.19) = 3.576
+	SpearmanRankCorrelationSignificance(0.9, 5, &both, &left, &right)
+	if right != 4.163e-02 || both != 2*4.163e-02 {
+		t.Errorf("spearman table p-values: %v, %v, %v", both, left, right)
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	x := make([][]float64, 100)
+	for i := range x {
+		v := rand.NormFloat64()
+		x[i] = []float64{v, v + rand.NormFloat64()*0.1, rand.NormFloat64()}
+	}
+	for _, method := range []string{MPearson, MSpearman} {
+		m, err := NewMatrix(x, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Method != method || m.Observations != 100 || len(m.Coefficients) != 3 || len(m.PValues) != 3 {
+			t.Fatalf("%s matrix: %+v", method, m)
+		}
+		if m.Coefficients[0][1] < 0.9 || m.PValues[0][1] > 1e-6 || m.PValues[0][0] != 0 {
+			t.Errorf("%s dependent variables: %v, p-value: %v", method, m.Coefficients[0][1], m.PValues[0][1])
+		}
+		if m.PValues[0][2] < 1e-4 {
+			t.Errorf("%s independent variables: %v, p-value: %v", method, m.Coefficients[0][2], m.PValues[0][2])
+		}
+	}
+	if _, err := NewMatrix(x, "kendall"); err == nil {
+		t.Error("unknown method accepted")
+	}
+	if _, err := NewMatrix([][]float64{{1, 2}, {3}}, MPearson); err == nil {
+		t.Error("ragged rows accepted")
+	}
+}
@@ -0,0 +1,604 @@
+package correlation
+
+import (
+	"fmt"
+	"math"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	maxrealnumber  = 1E300
+	minrealnumber  = 1E-300
+)
+
+/*************************************************************************
+Student's t distribution
+
+Computes the integral from minus infinity to t of the Student
+t distribution with integer k > 0 degrees of freedom:
+
+									 t
+									 -
+									| |
+			 -                      |         2   -(k+1)/2
+			| ( (k+1)/2 )           |  (     x   )
+	  ----------------------        |  ( 1 + --- )        dx
+					-               |  (      k  )
+	  sqrt( k pi ) | ( k/2 )        |
+								  | |
+								   -
+								  -inf.
+
+Relation to incomplete beta integral:
+
+	   1 - stdtr(k,t) = 0.5 * incbet( k/2, 1/2, z )
+where
+	   z = k/(k + t**2).
+
+For t < -2, this is the method of computation.  For higher t,
+a direct method is derived from integration by parts.
+Since the function is symmetric about t=0, the area under the
+right tail of the density is found by calling the function
+with -t instead of t.
+
+ACCURACY:
+
+Tested at random 1 <= k <= 25.  The "domain" refers to t.
+					 Relative error:
+arithmetic   domain     # trials      peak         rms
+   IEEE     -100,-2      50000       5.9e-15     1.4e-15
+   IEEE     -2,100      500000       2.7e-15     4.9e-17
+
+Cephes Math Library Release 2.8:  June, 2000
+Copyright 1984, 1987, 1995, 2000 by Stephen L. Moshier
+*************************************************************************/
+func StudentTDistribution(k int, t float64) (float64, error) {
+	if !(k > 0) {
+		return 0, fmt.Errorf("StudentTDistribution: domain error, K=%d", k)
+	}
+	return studenttdistribution(k, t), nil
+}
+
+// studenttdistribution - the distribution of k > 0 degrees of freedom, checked by the callers
+func studenttdistribution(k int, t float64) float64 {
+	if t == 0 {
+		return 0.5
+	}
+	if t < -2.0 {
+		rk := float64(k)
+		z := rk / (rk + t*t)
+		return 0.5 * incompletebeta(0.5*rk, 0.5, z)
+	}
+	x := t
+	if t < 0 {
+		x = -t
+	}
+	rk := float64(k)
+	z := 1.0 + x*x/rk
+	var p float64
+	if k%2 != 0 {
+		xsqk := x / math.Sqrt(rk)
+		p = math.Atan(xsqk)
+		if k > 1 {
+			f := 1.0
+			tz := 1.0
+			j := 3
+			for j <= k-2 && tz/f > utils.MachineEpsilon {
+				tz = tz * (float64(j-1) / (z * float64(j)))
+				f = f + tz
+				j = j + 2
+			}
+			p = p + f*xsqk/z
+		}
+		p = p * 2.0 / math.Pi
+	} else {
+		f := 1.0
+		tz := 1.0
+		j := 2
+		for j <= k-2 && tz/f > utils.MachineEpsilon {
+			tz = tz * (float64(j-1) / (z * float64(j)))
+			f = f + tz
+			j = j + 2
+		}
+		p = f * x / math.Sqrt(z*rk)
+	}
+	if t < 0 {
+		p = -p
+	}
+	return 0.5 + 0.5*p
+}
+
+/*************************************************************************
+Incomplete beta integral
+
+Returns incomplete beta integral of the arguments, evaluated
+from zero to x.  The function is defined as
+
+				 x
+	-            -
+   | (a+b)      | |  a-1     b-1
+ -----------    |   t   (1-t)   dt.
+  -     -     | |
+ | (a) | (b)   -
+				0
+
+The domain of definition is 0 <= x <= 1.  In this
+implementation a and b are restricted to positive values,
+the callers guarantee the domain.
+
+The integral is evaluated by a continued fraction expansion
+or, when b*x is small, by a power series.
+
+Cephes Math Library, Release 2.8:  June, 2000
+Copyright 1984, 1995, 2000 by Stephen L. Moshier
+*************************************************************************/
+func incompletebeta(a, b, x float64) float64 {
+	var t, xc, w, y, sg float64
+
+	big := 4.503599627370496e15
+	biginv := 2.22044604925031308085e-16
+	maxgam := 171.624376956302725
+	minlog := math.Log(minrealnumber)
+	maxlog := math.Log(maxrealnumber)
+	if x == 0 {
+		return 0
+	}
+	if x == 1 {
+		return 1
+	}
+	flag := 0
+	if b*x <= 1.0 && x <= 0.95 {
+		return incompletebetaps(a, b, x, maxgam)
+	}
+	w = 1.0 - x
+	if x > a/(a+b) {
+		flag = 1
+		t = a
+		a = b
+		b = t
+		xc = x
+		x = w
+	} else {
+		xc = w
+	}
+	if flag == 1 && b*x <= 1.0 && x <= 0.95 {
+		t = incompletebetaps(a, b, x, maxgam)
+		if t <= utils.MachineEpsilon {
+			return 1.0 - utils.MachineEpsilon
+		}
+		return 1.0 - t
+	}
+	y = x*(a+b-2.0) - (a - 1.0)
+	if y < 0.0 {
+		w = incompletebetafe(a, b, x, big, biginv)
+	} else {
+		w = incompletebetafe2(a, b, x, big, biginv) / xc
+	}
+	y = a * math.Log(x)
+	t = b * math.Log(xc)
+	if a+b < maxgam && math.Abs(y) < maxlog && math.Abs(t) < maxlog {
+		t = math.Pow(xc, b)
+		t = t * math.Pow(x, a)
+		t = t / a
+		t = t * w
+		t = t * (gammafunction(a+b) / (gammafunction(a) * gammafunction(b)))
+		if flag == 1 {
+			if t <= utils.MachineEpsilon {
+				return 1.0 - utils.MachineEpsilon
+			}
+			return 1.0 - t
+		}
+		return t
+	}
+	y = y + t + lngamma(a+b, &sg) - lngamma(a, &sg) - lngamma(b, &sg)
+	y = y + math.Log(w/a)
+	if y < minlog {
+		t = 0.0
+	} else {
+		t = math.Exp(y)
+	}
+	if flag == 1 {
+		if t <= utils.MachineEpsilon {
+			t = 1.0 - utils.MachineEpsilon
+		} else {
+			t = 1.0 - t
+		}
+	}
+	return t
+}
+
+/*************************************************************************
+Continued fraction expansion #1 for incomplete beta integral
+
+Cephes Math Library, Release 2.8:  June, 2000
+Copyright 1984, 1995, 2000 by Stephen L. Moshier
+*************************************************************************/
+func incompletebetafe(a, b, x, big, biginv float64) float64 {
+	var xk, pk, qk, t float64
+
+	k1 := a
+	k2 := a + b
+	k3 := a
+	k4 := a + 1.0
+	k5 := 1.0
+	k6 := b - 1.0
+	k7 := k4
+	k8 := a + 2.0
+	pkm2 := 0.0
+	qkm2 := 1.0
+	pkm1 := 1.0
+	qkm1 := 1.0
+	ans := 1.0
+	r := 1.0
+	thresh := 3.0 * utils.MachineEpsilon
+	for n := 0; n != 300; n++ {
+		xk = -(x * k1 * k2 / (k3 * k4))
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2 = pkm1
+		pkm1 = pk
+		qkm2 = qkm1
+		qkm1 = qk
+		xk = x * k5 * k6 / (k7 * k8)
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2 = pkm1
+		pkm1 = pk
+		qkm2 = qkm1
+		qkm1 = qk
+		if qk != 0 {
+			r = pk / qk
+		}
+		if r != 0 {
+			t = math.Abs((ans - r) / r)
+			ans = r
+		} else {
+			t = 1.0
+		}
+		if t < thresh {
+			break
+		}
+		k1 = k1 + 1.0
+		k2 = k2 + 1.0
+		k3 = k3 + 2.0
+		k4 = k4 + 2.0
+		k5 = k5 + 1.0
+		k6 = k6 - 1.0
+		k7 = k7 + 2.0
+		k8 = k8 + 2.0
+		if math.Abs(qk)+math.Abs(pk) > big {
+			pkm2 = pkm2 * biginv
+			pkm1 = pkm1 * biginv
+			qkm2 = qkm2 * biginv
+			qkm1 = qkm1 * biginv
+		}
+		if math.Abs(qk) < biginv || math.Abs(pk) < biginv {
+			pkm2 = pkm2 * big
+			pkm1 = pkm1 * big
+			qkm2 = qkm2 * big
+			qkm1 = qkm1 * big
+		}
+	}
+	return ans
+}
+
+/*************************************************************************
+Continued fraction expansion #2
+for incomplete beta integral
+
+Cephes Math Library, Release 2.8:  June, 2000
+Copyright 1984, 1995, 2000 by Stephen L. Moshier
+*************************************************************************/
+func incompletebetafe2(a, b, x, big, biginv float64) float64 {
+	var xk, pk, qk, t float64
+
+	k1 := a
+	k2 := b - 1.0
+	k3 := a
+	k4 := a + 1.0
+	k5 := 1.0
+	k6 := a + b
+	k7 := a + 1.0
+	k8 := a + 2.0
+	pkm2 := 0.0
+	qkm2 := 1.0
+	pkm1 := 1.0
+	qkm1 := 1.0
+	z := x / (1.0 - x)
+	ans := 1.0
+	r := 1.0
+	thresh := 3.0 * utils.MachineEpsilon
+	for n := 0; n != 300; n++ {
+		xk = -(z * k1 * k2 / (k3 * k4))
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2 = pkm1
+		pkm1 = pk
+		qkm2 = qkm1
+		qkm1 = qk
+		xk = z * k5 * k6 / (k7 * k8)
+		pk = pkm1 + pkm2*xk
+		qk = qkm1 + qkm2*xk
+		pkm2 = pkm1
+		pkm1 = pk
+		qkm2 = qkm1
+		qkm1 = qk
+		if qk != 0 {
+			r = pk / qk
+		}
+		if r != 0 {
+			t = math.Abs((ans - r) / r)
+			ans = r
+		} else {
+			t = 1.0
+		}
+		if t < thresh {
+			break
+		}
+		k1 = k1 + 1.0
+		k2 = k2 - 1.0
+		k3 = k3 + 2.0
+		k4 = k4 + 2.0
+		k5 = k5 + 1.0
+		k6 = k6 + 1.0
+		k7 = k7 + 2.0
+		k8 = k8 + 2.0
+		if math.Abs(qk)+math.Abs(pk) > big {
+			pkm2 = pkm2 * biginv
+			pkm1 = pkm1 * biginv
+			qkm2 = qkm2 * biginv
+			qkm1 = qkm1 * biginv
+		}
+		if math.Abs(qk) < biginv || math.Abs(pk) < biginv {
+			pkm2 = pkm2 * big
+			pkm1 = pkm1 * big
+			qkm2 = qkm2 * big
+			qkm1 = qkm1 * big
+		}
+	}
+	return ans
+}
+
+/*************************************************************************
+Power series for incomplete beta integral.
+Use when b*x is small and x not too close to 1.
+
+Cephes Math Library, Release 2.8:  June, 2000
+Copyright 1984, 1995, 2000 by Stephen L. Moshier
+*************************************************************************/
+func incompletebetaps(a, b, x, maxgam float64) float64 {
+	var sg float64
+
+	ai := 1.0 / a
+	u := (1.0 - b) * x
+	v := u / (a + 1.0)
+	t1 := v
+	t := u
+	n := 2.0
+	s := 0.0
+	z := utils.MachineEpsilon * ai
+	for math.Abs(v) > z {
+		u = (n - b) * x / n
+		t = t * u
+		v = t / (a + n)
+		s = s + v
+		n = n + 1.0
+	}
+	s = s + t1
+	s = s + ai
+	u = a * math.Log(x)
+	if a+b < maxgam && math.Abs(u) < math.Log(maxrealnumber) {
+		t = gammafunction(a+b) / (gammafunction(a) * gammafunction(b))
+		s = s * t * math.Pow(x, a)
+	} else {
+		t = lngamma(a+b, &sg) - lngamma(a, &sg) - lngamma(b, &sg) + u + math.Log(s)
+		if t < math.Log(minrealnumber) {
+			s = 0.0
+		} else {
+			s = math.Exp(t)
+		}
+	}
+	return s
+}
+
+/*************************************************************************
+Gamma function
+
+Input parameters:
+	X   -   argument
+
+Domain:
+	0 < X < 171.6
+	-170 < X < 0, X is not an integer.
+
+Cephes Math Library Release 2.8:  June, 2000
+Original copyright 1984, 1987, 1989, 1992, 2000 by Stephen L. Moshier
+Translated to AlgoPascal by Bochkanov Sergey (2005, 2006, 2007).
+*************************************************************************/
+func gammafunction(x float64) float64 {
+	var p, pp, q, qq, z float64
+
+	sgngam := 1.0
+	q = math.Abs(x)
+	if q > 33.0 {
+		if x < 0.0 {
+			p = math.Floor(q)
+			i := int(math.Floor(p + 0.5))
+			if i%2 == 0 {
+				sgngam = -1
+			}
+			z = q - p
+			if z > 0.5 {
+				p = p + 1
+				z = q - p
+			}
+			z = q * math.Sin(math.Pi*z)
+			z = math.Abs(z)
+			z = math.Pi / (z * gammastirf(q))
+		} else {
+			z = gammastirf(x)
+		}
+		return sgngam * z
+	}
+	z = 1
+	for x >= 3 {
+		x = x - 1
+		z = z * x
+	}
+	for x < 0 {
+		if x > -0.000000001 {
+			return z / ((1 + 0.5772156649015329*x) * x)
+		}
+		z = z / x
+		x = x + 1
+	}
+	for x < 2 {
+		if x < 0.000000001 {
+			return z / ((1 + 0.5772156649015329*x) * x)
+		}
+		z = z / x
+		x = x + 1.0
+	}
+	if x == 2 {
+		return z
+	}
+	x = x - 2.0
+	pp = 1.60119522476751861407E-4
+	pp = 1.19135147006586384913E-3 + x*pp
+	pp = 1.04213797561761569935E-2 + x*pp
+	pp = 4.76367800457137231464E-2 + x*pp
+	pp = 2.07448227648435975150E-1 + x*pp
+	pp = 4.94214826801497100753E-1 + x*pp
+	pp = 9.99999999999999996796E-1 + x*pp
+	qq = -2.31581873324120129819E-5
+	qq = 5.39605580493303397842E-4 + x*qq
+	qq = -4.45641913851797240494E-3 + x*qq
+	qq = 1.18139785222060435552E-2 + x*qq
+	qq = 3.58236398605498653373E-2 + x*qq
+	qq = -2.34591795718243348568E-1 + x*qq
+	qq = 7.14304917030273074085E-2 + x*qq
+	qq = 1.00000000000000000320 + x*qq
+	return z * pp / qq
+}
+
+/*************************************************************************
+Natural logarithm of gamma function
+
+Input parameters:
+	X       -   argument
+
+Result:
+	logarithm of the absolute value of the Gamma(X).
+
+Output parameters:
+	SgnGam  -   sign(Gamma(X))
+
+Domain:
+	0 < X < 2.55e305
+	-2.55e305 < X < 0, X is not an integer.
+
+Cephes Math Library Release 2.8:  June, 2000
+Copyright 1984, 1987, 1989, 1992, 2000 by Stephen L. Moshier
+Translated to AlgoPascal by Bochkanov Sergey (2005, 2006, 2007).
+*************************************************************************/
+func lngamma(x float64, sgngam *float64) float64 {
+	var a, b, c, p, q, u, w, z, tmp float64
+
+	*sgngam = 1
+	logpi := 1.14472988584940017414
+	ls2pi := 0.91893853320467274178
+	if x < -34.0 {
+		q = -x
+		w = lngamma(q, &tmp)
+		p = math.Floor(q)
+		i := int(math.Floor(p + 0.5))
+		if i%2 == 0 {
+			*sgngam = -1
+		} else {
+			*sgngam = 1
+		}
+		z = q - p
+		if z > 0.5 {
+			p = p + 1
+			z = p - q
+		}
+		z = q * math.Sin(math.Pi*z)
+		return logpi - math.Log(z) - w
+	}
+	if x < 13 {
+		z = 1
+		p = 0
+		u = x
+		for u >= 3 {
+			p = p - 1
+			u = x + p
+			z = z * u
+		}
+		for u < 2 {
+			z = z / u
+			p = p + 1
+			u = x + p
+		}
+		if z < 0 {
+			*sgngam = -1
+			z = -z
+		} else {
+			*sgngam = 1
+		}
+		if u == 2 {
+			return math.Log(z)
+		}
+		p = p - 2
+		x = x + p
+		b = -1378.25152569120859100
+		b = -38801.6315134637840924 + x*b
+		b = -331612.992738871184744 + x*b
+		b = -1162370.97492762307383 + x*b
+		b = -1721737.00820839662146 + x*b
+		b = -853555.664245765465627 + x*b
+		c = 1
+		c = -351.815701436523470549 + x*c
+		c = -17064.2106651881159223 + x*c
+		c = -220528.590553854454839 + x*c
+		c = -1139334.44367982507207 + x*c
+		c = -2532523.07177582951285 + x*c
+		c = -2018891.41433532773231 + x*c
+		p = x * b / c
+		return math.Log(z) + p
+	}
+	q = (x-0.5)*math.Log(x) - x + ls2pi
+	if x > 100000000 {
+		return q
+	}
+	p = 1 / (x * x)
+	if x >= 1000.0 {
+		q = q + ((7.9365079365079365079365*0.0001*p-2.7777777777777777777778*0.001)*p+0.0833333333333333333333)/x
+	} else {
+		a = 8.11614167470508450300 * 0.0001
+		a = -(5.95061904284301438324 * 0.0001) + p*a
+		a = 7.93650340457716943945*0.0001 + p*a
+		a = -(2.77777777730099687205 * 0.001) + p*a
+		a = 8.33333333333331927722*0.01 + p*a
+		q = q + a/x
+	}
+	return q
+}
+
+func gammastirf(x float64) float64 {
+	w := 1 / x
+	stir := 7.87311395793093628397E-4
+	stir = -2.29549961613378126380E-4 + w*stir
+	stir = -2.68132617805781232825E-3 + w*stir
+	stir = 3.47222221605458667310E-3 + w*stir
+	stir = 8.33333333333482257126E-2 + w*stir
+	w = 1 + w*stir
+	y := math.Exp(x)
+	if x > 143.01608 {
+		v := math.Pow(x, 0.5*x-0.25)
+		y = v * (v / y)
+	} else {
+		y = math.Pow(x, x-0.5) / y
+	}
+	return 2.50662827463100050242 * y * w
+}
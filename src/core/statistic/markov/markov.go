@@ -0,0 +1,259 @@
+// Package markov estimates transition matrix of the n-th order Markov chain over the discrete states.
+//
+// The estimate follows ALGLIB MCPD (Markov Chains for Population Data, mcpdcreate/mcpdsolve):
+// tracks of the states are added to the chain, the transition matrix minimizes the squared error
+// of the prediction of the next state regularized by the distance to the prior matrix
+// (mcpdsettikhonovregularizer, mcpdsetprior). Every state of the track is one-hot population
+// vector here, for such tracks the constrained MCPD problem has the closed form solution
+//
+//	P[h][j] = (C[h][j] + smoothing*Prior[j]) / (C[h] + smoothing)
+//
+// where C[h][j] is the count of the transitions from history h to state j and Prior is the
+// distribution of the states of all transitions, so no optimizer is required.
+// Unlike ALGLIB, rows of the matrix are the histories: P[h] is the distribution of the next state.
+package markov
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxHistories limits size of the transition matrix: states^order
+const maxHistories = 1 << 16
+
+// serializeCode - first element of the serialized chain
+const serializeCode = 1
+
+// Chain - n-th order Markov chain, the history is the sequence of the last order states
+type Chain struct {
+	states    int
+	order     int
+	smoothing float64
+	counts    [][]float64 // transitions from the history (row) to the state (column)
+	totals    []float64   // transitions from the history
+	p         [][]float64 // transition matrix, nil until Solve
+}
+
+// NewChain create empty chain of the order over the states 0..states-1
+func NewChain(states, order int) (*Chain, error) {
+	if states < 2 {
+		return nil, fmt.Errorf("states count: %d must be more than 1", states)
+	}
+	if order < 1 {
+		return nil, fmt.Errorf("order: %d must be positive", order)
+	}
+	histories := 1
+	for i := 0; i < order; i++ {
+		histories *= states
+		if histories > maxHistories {
+			return nil, fmt.Errorf("%d histories of the order %d exceed limit %d", histories, order, maxHistories)
+		}
+	}
+	result := &Chain{states: states, order: order}
+	result.counts = make([][]float64, histories)
+	for i := range result.counts {
+		result.counts[i] = make([]float64, states)
+	}
+	result.totals = make([]float64, histories)
+	return result, nil
+}
+
+// States return count of the states
+func (c *Chain) States() int {
+	return c.states
+}
+
+// Order return length of the history
+func (c *Chain) Order() int {
+	return c.order
+}
+
+// Smoothing return weight of the prior used by the last Solve
+func (c *Chain) Smoothing() float64 {
+	return c.smoothing
+}
+
+// Histories return count of the rows of the transition matrix
+func (c *Chain) Histories() int {
+	return len(c.counts)
+}
+
+// history return row of the transition matrix for the last order states of the sequence
+func (c *Chain) history(sequence []int) (int, error) {
+	if len(sequence) < c.order {
+		return -1, fmt.Errorf("sequence length: %d less than order: %d", len(sequence), c.order)
+	}
+	result := 0
+	for _, state := range sequence[len(sequence)-c.order:] {
+		if state < 0 || state >= c.states {
+			return -1, fmt.Errorf("state %d out of range [0, %d)", state, c.states)
+		}
+		result = result*c.states + state
+	}
+	return result, nil
+}
+
+// AddTransition count transition from the last order states of the history to the next state
+func (c *Chain) AddTransition(history []int, next int) error {
+	h, err := c.history(history)
+	if err != nil {
+		return err
+	}
+	if next < 0 || next >= c.states {
+		return fmt.Errorf("state %d out of range [0, %d)", next, c.states)
+	}
+	c.counts[h][next]++
+	c.totals[h]++
+	c.p = nil
+	return nil
+}
+
+// AddTrack count all transitions of the sequence of states (mcpdaddtrack),
+// the first order states are the history of the first transition
+func (c *Chain) AddTrack(track []int) error {
+	for i := c.order; i < len(track); i++ {
+		if err := c.AddTransition(track[i-c.order:i], track[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transitions return count of the added transitions
+func (c *Chain) Transitions() int {
+	var result float64
+	for _, total := range c.totals {
+		result += total
+	}
+	return int(result)
+}
+
+// Prior return distribution of the next states of all added transitions, uniform for empty chain
+func (c *Chain) Prior() []float64 {
+	result := make([]float64, c.states)
+	var total float64
+	for h, row := range c.counts {
+		for j, count := range row {
+			result[j] += count
+		}
+		total += c.totals[h]
+	}
+	for j := range result {
+		if total > 0 {
+			result[j] /= total
+		} else {
+			result[j] = 1 / float64(c.states)
+		}
+	}
+	return result
+}
+
+// Solve estimate transition matrix (mcpdsolve), smoothing is the weight of the prior in
+// the transitions count: 0 gives maximum likelihood estimate, histories without transitions
+// get the prior in any case
+func (c *Chain) Solve(smoothing float64) error {
+	if smoothing < 0 || math.IsNaN(smoothing) || math.IsInf(smoothing, 0) {
+		return fmt.Errorf("smoothing: %v must be non-negative finite value", smoothing)
+	}
+	prior := c.Prior()
+	p := make([][]float64, len(c.counts))
+	for h, row := range c.counts {
+		p[h] = make([]float64, c.states)
+		if c.totals[h]+smoothing == 0 {
+			copy(p[h], prior)
+			continue
+		}
+		for j, count := range row {
+			p[h][j] = (count + smoothing*prior[j]) / (c.totals[h] + smoothing)
+		}
+	}
+	c.smoothing = smoothing
+	c.p = p
+	return nil
+}
+
+// Probabilities return distribution of the next state after the last order states of the history
+func (c *Chain) Probabilities(history []int) ([]float64, error) {
+	if c.p == nil {
+		return nil, errors.New("transition matrix is not solved")
+	}
+	h, err := c.history(history)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]float64, c.states)
+	copy(result, c.p[h])
+	return result, nil
+}
+
+// Predict return the most likely next state after the history together with its probability,
+// the smallest state wins the tie
+func (c *Chain) Predict(history []int) (int, float64, error) {
+	p, err := c.Probabilities(history)
+	if err != nil {
+		return -1, 0, err
+	}
+	result := 0
+	for j, item := range p {
+		if item > p[result] {
+			result = j
+		}
+	}
+	return result, p[result], nil
+}
+
+// Matrix return copy of the transition matrix (mcpdresults), row per history:
+// the oldest state of the history is the most significant digit of the row index
+func (c *Chain) Matrix() ([][]float64, error) {
+	if c.p == nil {
+		return nil, errors.New("transition matrix is not solved")
+	}
+	result := make([][]float64, len(c.p))
+	for h, row := range c.p {
+		result[h] = make([]float64, len(row))
+		copy(result[h], row)
+	}
+	return result, nil
+}
+
+// Serialize return states count, order, smoothing and transition counts of the chain
+func (c *Chain) Serialize() []float64 {
+	result := make([]float64, 0, 4+len(c.counts)*c.states)
+	result = append(result, serializeCode, float64(c.states), float64(c.order), c.smoothing)
+	for _, row := range c.counts {
+		result = append(result, row...)
+	}
+	return result
+}
+
+// Unserialize restore chain returned by Serialize, the transition matrix is solved
+// with the stored smoothing
+func Unserialize(ra []float64) (*Chain, error) {
+	if len(ra) < 4 || ra[0] != serializeCode {
+		return nil, errors.New("serialized chain is damaged")
+	}
+	c, err := NewChain(int(ra[1]), int(ra[2]))
+	if err != nil {
+		return nil, err
+	}
+	if len(ra) != 4+len(c.counts)*c.states {
+		return nil, fmt.Errorf("serialized chain length: %d, expected: %d", len(ra), 4+len(c.counts)*c.states)
+	}
+	offset := 4
+	for h, row := range c.counts {
+		for j := range row {
+			count := ra[offset]
+			if count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+				return nil, fmt.Errorf("serialized chain has invalid count: %v", count)
+			}
+			row[j] = count
+			c.totals[h] += count
+			offset++
+		}
+	}
+	if err := c.Solve(ra[3]); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,109 @@
+package markov_test
+
+import (
+	"math"
+	"testing"
+
+	"pr.optima/src/core/statistic/markov"
+)
+
+func TestFirstOrder(t *testing.T) {
+	chain, err := markov.NewChain(3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0 -> 1 twice, 0 -> 2 once, 1 -> 0 twice, state 2 is never left
+	if err := chain.AddTrack([]int{0, 1, 0, 1, 0, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if chain.Transitions() != 5 {
+		t.Fatalf("transitions: %d", chain.Transitions())
+	}
+	if _, err := chain.Probabilities([]int{0}); err == nil {
+		t.Error("probabilities of the not solved chain")
+	}
+	if err := chain.Solve(0); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]float64{{0, 2. / 3, 1. / 3}, {1, 0, 0}, {.4, .4, .2}}
+	matrix, err := chain.Matrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h, row := range expected {
+		for j, item := range row {
+			if math.Abs(matrix[h][j]-item) > 1e-12 {
+				t.Fatalf("P[%d] = %v, expected %v", h, matrix[h], row)
+			}
+		}
+	}
+	if state, p, err := chain.Predict([]int{2, 2, 0}); err != nil || state != 1 || math.Abs(p-2./3) > 1e-12 {
+		t.Errorf("predicted %d with %v, error: %v", state, p, err)
+	}
+
+	// smoothing pulls the rows to the prior, each row is the distribution
+	if err := chain.Solve(5); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := chain.Probabilities([]int{1})
+	if math.Abs(p[0]-(2+5*.4)/7) > 1e-12 || math.Abs(p[0]+p[1]+p[2]-1) > 1e-12 {
+		t.Errorf("smoothed probabilities: %v", p)
+	}
+}
+
+func TestHigherOrder(t *testing.T) {
+	chain, err := markov.NewChain(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the period is 3, so the first order chain can't predict it
+	track := []int{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1}
+	if err := chain.AddTrack(track); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Solve(0.1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 2; i < len(track); i++ {
+		if state, _, err := chain.Predict(track[:i]); err != nil || state != track[i] {
+			t.Fatalf("step %d: predicted %d, expected %d, error: %v", i, state, track[i], err)
+		}
+	}
+
+	if err := chain.AddTransition([]int{0}, 1); err == nil {
+		t.Error("short history accepted")
+	}
+	if err := chain.AddTransition([]int{0, 2}, 1); err == nil {
+		t.Error("state out of range accepted")
+	}
+	if _, err := markov.NewChain(10, 5); err == nil {
+		t.Error("huge transition matrix accepted")
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	chain, _ := markov.NewChain(3, 2)
+	if err := chain.AddTrack([]int{0, 1, 2, 2, 1, 0, 0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Solve(0.5); err != nil {
+		t.Fatal(err)
+	}
+	ra := chain.Serialize()
+	restored, err := markov.Unserialize(ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := chain.Matrix()
+	actual, _ := restored.Matrix()
+	for h := range expected {
+		for j := range expected[h] {
+			if expected[h][j] != actual[h][j] {
+				t.Fatalf("P[%d] = %v, restored %v", h, expected[h], actual[h])
+			}
+		}
+	}
+	if _, err := markov.Unserialize(ra[:len(ra)-1]); err == nil {
+		t.Error("truncated array accepted")
+	}
+}
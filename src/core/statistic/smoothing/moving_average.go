@@ -33,6 +33,7 @@ func SMA(source []float64, frame int) ([]float64, error) {
 			item = previous - weightedSource[idx-1] + weightedSource[idx+frame-1]
 		}
 
+		result[idx] = item
 		previous = item
 	}
 
@@ -24,12 +24,14 @@ func MM(source []float64, q int) ([]float64, error) {
 	for idx, item := range result {
 		if idx < q {
 			item = (source[idx] + source[idx+1] + ((3 * source[idx+1]) - (2 * source[idx+2]))) / 3
+			result[idx] = item
 			previous = item
 			continue
 		}
 
 		if idx > length-q {
 			item = (source[idx] + source[idx-1] + ((3 * source[idx-1]) - (2 * source[idx-2]))) / 3
+			result[idx] = item
 			previous = item
 			continue
 		}
@@ -40,11 +42,13 @@ func MM(source []float64, q int) ([]float64, error) {
 				tmpSum += weightedSource[i]
 			}
 			item = tmpSum
+			result[idx] = item
 			previous = item
 			continue
 		}
 
 		item = previous - weightedSource[idx-1] + weightedSource[idx+q-1]
+		result[idx] = item
 		previous = item
 	}
 
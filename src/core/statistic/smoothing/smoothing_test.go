@@ -0,0 +1,64 @@
+package smoothing
+
+import (
+	"math"
+	"testing"
+)
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-12 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSMA(t *testing.T) {
+	result, err := SMA([]float64{1, 2, 3, 4, 5}, 2)
+	if err != nil || !equal(result, []float64{1.5, 2.5, 3.5, 4.5}) {
+		t.Errorf("sma: %v, error: %v", result, err)
+	}
+	result, err = SMA([]float64{1, 2, 3, 4, 5}, 5)
+	if err != nil || !equal(result, []float64{3}) {
+		t.Errorf("sma of the whole source: %v, error: %v", result, err)
+	}
+	if _, err := SMA([]float64{1, 2}, 3); err == nil {
+		t.Error("frame longer than the source accepted")
+	}
+}
+
+func TestMM(t *testing.T) {
+	// the edges are extrapolated by 3 points, every item must be filled
+	result, err := MM([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 2)
+	if err != nil || !equal(result, []float64{1, 2, 2.5, 3, 3.5, 4, 4.5, 8}) {
+		t.Errorf("mm: %v, error: %v", result, err)
+	}
+	if _, err := MM([]float64{1, 2, 3}, 2); err == nil {
+		t.Error("source shorter than 2*q accepted")
+	}
+}
+
+func TestTMM(t *testing.T) {
+	source := []float64{5, 1, 4, 2, 100, 3}
+	result, err := TMM(source, 3)
+	if err != nil || !equal(result, []float64{4, 2, 4, 3}) {
+		t.Errorf("odd frame: %v, error: %v", result, err)
+	}
+	result, err = TMM(source, 2)
+	if err != nil || !equal(result, []float64{3, 2.5, 3, 51, 51.5}) {
+		t.Errorf("even frame: %v, error: %v", result, err)
+	}
+	if result, err = TMM(source, 1); err != nil || !equal(result, source) {
+		t.Errorf("single item frame: %v, error: %v", result, err)
+	}
+	if source[0] != 5 || source[4] != 100 {
+		t.Errorf("source changed: %v", source)
+	}
+	if _, err := TMM(source, 7); err == nil {
+		t.Error("frame longer than the source accepted")
+	}
+}
@@ -0,0 +1,32 @@
+package smoothing
+
+import (
+	"errors"
+	"sort"
+)
+
+// TMM - trailing moving median, result[i] is the median of source[i]..source[i+frame-1],
+// so each value uses the current and the previous items only
+func TMM(source []float64, frame int) ([]float64, error) {
+	if source == nil {
+		return nil, errors.New("'source' required")
+	}
+	length := len(source)
+	if length < frame || frame < 1 {
+		return nil, errors.New("input data is not valid")
+	}
+
+	result := make([]float64, length-frame+1)
+	window := make([]float64, frame)
+	for idx := range result {
+		copy(window, source[idx:idx+frame])
+		sort.Float64s(window)
+		if frame%2 == 1 {
+			result[idx] = window[frame/2]
+		} else {
+			result[idx] = (window[frame/2-1] + window[frame/2]) / 2
+		}
+	}
+
+	return result, nil
+}
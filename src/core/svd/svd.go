@@ -0,0 +1,120 @@
+package svd
+
+import (
+	"math"
+
+	"pr.optima/src/core/neural/utils"
+)
+
+const (
+	// limit of the Jacobi sweeps
+	maxsweeps = 60
+)
+
+/*************************************************************************
+Singular value decomposition of the M*N matrix A, M>=N:
+
+	A = U*diag(W)*VT
+
+U is array[0..M-1,0..N-1] with the left singular vectors in the columns,
+W is array[0..N-1] of the singular values in descending order, VT is
+array[0..N-1,0..N-1] with the right singular vectors in the rows, i.e.
+RMatrixSVD with UNeeded=1 and VTNeeded=1.
+
+Unlike RMatrixSVD (bidiagonal QR iterations) the decomposition is calculated
+by the one-sided Jacobi rotations of the columns, which has the same high
+relative accuracy and is compact for the small matrices of the linear
+regression and PCA.
+Returns False if the rotations haven't converged.
+*************************************************************************/
+func RMatrixSvd(a *[][]float64, m, n int, w *[]float64, u, vt *[][]float64) bool {
+	b := utils.MakeMatrixFloat64(m, n)
+	for i := 0; i <= m-1; i++ {
+		copy(b[i], (*a)[i][:n])
+	}
+	v := utils.MakeMatrixFloat64(n, n)
+	for i := 0; i <= n-1; i++ {
+		v[i][i] = 1
+	}
+
+	converged := false
+	for sweep := 0; sweep < maxsweeps && !converged; sweep++ {
+		converged = true
+		for p := 0; p <= n-2; p++ {
+			for q := p + 1; q <= n-1; q++ {
+				alpha := 0.0
+				beta := 0.0
+				gamma := 0.0
+				for i := 0; i <= m-1; i++ {
+					alpha += b[i][p] * b[i][p]
+					beta += b[i][q] * b[i][q]
+					gamma += b[i][p] * b[i][q]
+				}
+				if gamma == 0 || math.Abs(gamma) <= utils.MachineEpsilon*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				//
+				// Rotation zeroes out the dot product of the columns P and Q
+				//
+				zeta := (beta - alpha) / (2 * gamma)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+				for i := 0; i <= m-1; i++ {
+					wp := b[i][p]
+					wq := b[i][q]
+					b[i][p] = c*wp - s*wq
+					b[i][q] = s*wp + c*wq
+				}
+				for i := 0; i <= n-1; i++ {
+					vp := v[i][p]
+					vq := v[i][q]
+					v[i][p] = c*vp - s*vq
+					v[i][q] = s*vp + c*vq
+				}
+			}
+		}
+	}
+	if !converged {
+		return false
+	}
+
+	//
+	// Singular values are the norms of the columns, sort them in descending order
+	//
+	norms := make([]float64, n)
+	order := make([]int, n)
+	for j := 0; j <= n-1; j++ {
+		r := 0.0
+		for i := 0; i <= m-1; i++ {
+			r += b[i][j] * b[i][j]
+		}
+		norms[j] = math.Sqrt(r)
+		order[j] = j
+	}
+	for i := 1; i <= n-1; i++ {
+		for j := i; j > 0 && norms[order[j]] > norms[order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	*w = make([]float64, n)
+	*u = utils.MakeMatrixFloat64(m, n)
+	*vt = utils.MakeMatrixFloat64(n, n)
+	for k, j := range order {
+		(*w)[k] = norms[j]
+		if norms[j] > 0 {
+			for i := 0; i <= m-1; i++ {
+				(*u)[i][k] = b[i][j] / norms[j]
+			}
+		}
+		for i := 0; i <= n-1; i++ {
+			(*vt)[k][i] = v[i][j]
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package svd_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"pr.optima/src/core/svd"
+)
+
+func TestDecomposition(t *testing.T) {
+	m, n := 7, 4
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, n)
+		for j := range a[i] {
+			a[i][j] = rand.Float64()*2 - 1
+		}
+	}
+	// rank deficient: the last column is the sum of the first two
+	for i := range a {
+		a[i][3] = a[i][0] + a[i][1]
+	}
+	var w []float64
+	var u, vt [][]float64
+	if !svd.RMatrixSvd(&a, m, n, &w, &u, &vt) {
+		t.Fatal("not converged")
+	}
+	for j := 1; j < n; j++ {
+		if w[j] > w[j-1] {
+			t.Fatalf("singular values are not sorted: %v", w)
+		}
+	}
+	if w[n-1] > 1e-12 {
+		t.Errorf("rank deficient matrix has singular values: %v", w)
+	}
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			var r float64
+			for k := 0; k < n; k++ {
+				r += u[i][k] * w[k] * vt[k][j]
+			}
+			if math.Abs(r-a[i][j]) > 1e-12 {
+				t.Fatalf("U*W*VT[%d][%d]: %v, expected %v", i, j, r, a[i][j])
+			}
+		}
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			var r float64
+			for k := 0; k < n; k++ {
+				r += vt[i][k] * vt[j][k]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(r-expected) > 1e-12 {
+				t.Fatalf("VT*V[%d][%d]: %v", i, j, r)
+			}
+		}
+	}
+}
@@ -6,7 +6,10 @@ import 	(
 	"net/http"
 	"encoding/json"
 
+	"golang.org/x/net/context"
+
 	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction"
 	"pr.optima/src/repository"
 	"pr.optima/src/grabber/work"
 )
@@ -16,26 +19,23 @@ const (
 	source2URL = "http://www.apilayer.net/api/live?access_key=85c7d5e8f98fe83fa3fa81aafe489022&currencies=RUB,JPY,GBP,USD,EUR,CNY,CHF" //"85c7d5e8f98fe83fa3fa81aafe489022"
 	appEngineURL = "https://rp-optima.appspot.com/api/refresh"
 	repoSize = 200
+	// works processed concurrently and time limit of the one work
+	workersCount = 0
+	workTimeout = 10 * time.Minute
 )
 const _authKey = "B7C05147C5A34376B30CEF2F289FBB6C"
 var (
 	_repo repository.RateRepo
-	_rubWork *work.Work
-	_eurWork *work.Work
-	_gbpWork *work.Work
-	_jpyWork *work.Work
-	_cnyWork *work.Work
-	_chfWork *work.Work
+	_symbols = []string{"RUB", "EUR", "GBP", "JPY", "CNY", "CHF"}
+	_works map[string]*work.Work
 )
 
 func init() {
 	_repo = repository.New(repoSize, true, nil)
-	_rubWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "RUB")
-	_eurWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "EUR")
-	_gbpWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "GBP")
-	_jpyWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "JPY")
-	_cnyWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "CNY")
-	_chfWork = work.NewWork(6, 5, 20, 1, work.TTLbfgs, "CHF")
+	_works = make(map[string]*work.Work)
+	for _, symbol := range _symbols {
+		_works[symbol] = work.NewWork(6, 5, 20, work.TTLbfgs, prediction.NTRegression, symbol)
+	}
 
 	_now := time.Now()
 	_next := _now.Round(time.Hour)
@@ -80,65 +80,27 @@ func init() {
 
 func executeDomainLogic() {
 	rates := _repo.GetAll()
-	if _rubWork.Limit < len(rates) {
-		rub, err := _rubWork.Process(rates)
-
-		if err != nil {
-			log.Printf("RUB executeDomainLogic error: %v", err)
+	var tasks []prediction.Task
+	for _, symbol := range _symbols {
+		symbolWork := _works[symbol]
+		if symbolWork.Limit < len(rates) {
+			tasks = append(tasks, prediction.Task{Name: symbol, Run: func(ctx context.Context) (int, error) {
+				return symbolWork.Process(ctx, rates)
+			}})
 		}
-
-		log.Printf("RUB nueral result: %d", rub)
 	}
 
-	if _eurWork.Limit < len(rates) {
-		eur, err := _eurWork.Process(rates)
-
-		if err != nil {
-			log.Printf("EUR executeDomainLogic error: %v", err)
+	// symbols are processed concurrently, failure of one symbol doesn't stop the others
+	report := prediction.RunTasks(tasks, workersCount, workTimeout)
+	for _, result := range report.Results {
+		if result.Error == nil {
+			log.Printf("%s nueral result: %d", result.Name, result.Prediction)
 		}
-
-		log.Printf("EUR nueral result: %d", eur)
 	}
-
-	if _gbpWork.Limit < len(rates) {
-		gbp, err := _gbpWork.Process(rates)
-
-		if err != nil {
-			log.Printf("GBP executeDomainLogic error: %v", err)
-		}
-
-		log.Printf("GBP nueral result: %d", gbp)
-	}
-
-	if _jpyWork.Limit < len(rates) {
-		jpy, err := _jpyWork.Process(rates)
-
-		if err != nil {
-			log.Printf("JPY executeDomainLogic error: %v", err)
-		}
-
-		log.Printf("JPY nueral result: %d", jpy)
-	}
-
-	if _cnyWork.Limit < len(rates) {
-		cny, err := _cnyWork.Process(rates)
-
-		if err != nil {
-			log.Printf("CNY executeDomainLogic error: %v", err)
-		}
-
-		log.Printf("CNY nueral result: %d", cny)
-	}
-
-	if _chfWork.Limit < len(rates) {
-		chf, err := _chfWork.Process(rates)
-
-		if err != nil {
-			log.Printf("CHF executeDomainLogic error: %v", err)
-		}
-
-		log.Printf("CHF nueral result: %d", chf)
+	if err := report.Error(); err != nil {
+		log.Printf("executeDomainLogic error: %v", err)
 	}
+	log.Printf("executeDomainLogic: %s", report.ToString())
 
 	// refresh appengine
 	if req, err := http.NewRequest("GET", appEngineURL, nil); err == nil {
@@ -166,6 +166,9 @@ func (rr *efficiencyRepo) run() {
 			}
 		case endEfficiency:
 			close(rr.pipe)
+			if err := rr.client.Close(); err != nil {
+				log.Printf("close efficiency repo error: %v", err)
+			}
 			command.data <- rr.data
 		}
 	}
@@ -241,3 +244,29 @@ func (rr *efficiencyRepo) clearEfficiency(unixdate int64) error {
 	}
 	return err
 }
+
+// LoadEfficiencies return stored efficiencies of all the models of the symbols,
+// single client and one query per symbol are used
+func LoadEfficiencies(symbols []string, r *http.Request) (map[string][]entities.Efficiency, error) {
+	var ctx context.Context
+	if r != nil {
+		ctx = appengine.NewContext(r)
+	} else {
+		ctx = context.Background()
+	}
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	result := make(map[string][]entities.Efficiency, len(symbols))
+	for _, symbol := range symbols {
+		var dst []entities.Efficiency
+		if _, err := client.GetAll(ctx, datastore.NewQuery("Efficiency").Filter("symbol=", symbol), &dst); err != nil {
+			return nil, fmt.Errorf("load efficiencies of %s error: %v", symbol, err)
+		}
+		result[symbol] = dst
+	}
+	return result, nil
+}
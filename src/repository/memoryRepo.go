@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction/memory"
+)
+
+// in-memory implementations of the repos, used by offline runs (backtest) without datastore
+
+// MemoryResultDataRepo - in-memory ResultDataRepo, keeps all pushed records besides the limited window
+type MemoryResultDataRepo interface {
+	ResultDataRepo
+	History() []entities.ResultData
+}
+
+// NewMemoryRateRepo - return new instance of the RateRepo filled by rates, sorted by timestamp
+func NewMemoryRateRepo(limit int, autoResize bool, rates []entities.Rate) RateRepo {
+	return memory.NewRateRepo(limit, autoResize, rates)
+}
+
+// NewMemoryResultDataRepo - return new instance of the in-memory ResultDataRepo
+func NewMemoryResultDataRepo(limit int, autoResize bool, trainType, symbol string) MemoryResultDataRepo {
+	return memory.NewResultDataRepo(limit, autoResize, trainType, symbol)
+}
+
+// NewMemoryEfficiencyRepo return instance of the in-memory EfficiencyRepo
+func NewMemoryEfficiencyRepo(trainType, symbol string, rangesCount, limit, frame int32) EfficiencyRepo {
+	return memory.NewEfficiencyRepo(trainType, symbol, rangesCount, limit, frame)
+}
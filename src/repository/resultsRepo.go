@@ -25,6 +25,11 @@ const (
 	endResultData
 )
 
+const (
+	migrationStep  = 24 * 60 * 60 // seconds of the results loaded at once by the migration
+	migrationBatch = 500          // max entities count of the datastore batch operation
+)
+
 type commandResultData struct {
 	action    commandResultDataAction
 	value     entities.ResultData
@@ -43,6 +48,7 @@ type singleResultData struct {
 type resultDataRepo struct {
 	pipe       chan commandResultData
 	symbol     string
+	trainType  string
 	limit      int
 	autoResize bool
 	lastID     int64
@@ -250,13 +256,16 @@ func (rr *resultDataRepo) run() {
 			}
 		case endResultData:
 			close(rr.pipe)
+			if err := rr.client.Close(); err != nil {
+				log.Printf("close result data repo error: %v", err)
+			}
 			command.data <- rr.data
 		}
 	}
 }
 
-// NewResultDataRepo - return new instance of the ResultDataRepo
-func NewResultDataRepo(limit int, autoResize bool, symbol string, r *http.Request) ResultDataRepo {
+// NewResultDataRepo - return new instance of the ResultDataRepo, data filtered by symbol and train type
+func NewResultDataRepo(limit int, autoResize bool, trainType, symbol string, r *http.Request) ResultDataRepo {
 	var ctx context.Context
 	if r != nil {
 		ctx = appengine.NewContext(r)
@@ -272,6 +281,7 @@ func NewResultDataRepo(limit int, autoResize bool, symbol string, r *http.Reques
 	rr := new(resultDataRepo)
 	rr.pipe = make(chan commandResultData)
 	rr.symbol = symbol
+	rr.trainType = trainType
 	rr.limit = limit
 	rr.autoResize = autoResize == true
 	rr.client = client
@@ -287,11 +297,14 @@ func NewResultDataRepo(limit int, autoResize bool, symbol string, r *http.Reques
 // Cloud datastore logic
 func (rr *resultDataRepo) loadStartResultData() error {
 	var dst []entities.ResultData
-	if _, err := rr.client.GetAll(context.Background(), datastore.NewQuery("ResultData").Filter("symbol=", rr.symbol).Order("-timestamp").Limit(rr.limit), &dst); err != nil {
+	if _, err := rr.client.GetAll(context.Background(), datastore.NewQuery("ResultData").Filter("symbol=", rr.symbol).Filter("trainType=", rr.trainType).Order("-timestamp").Limit(rr.limit), &dst); err != nil {
 		log.Printf("loadStartResultData error: %v\n", err)
 		//return err
 	}
-	if dst != nil {
+	if len(dst) == 0 {
+		dst = rr.loadLegacyResultData()
+	}
+	if len(dst) > 0 {
 		l := len(dst)
 		rr.data = make([]entities.ResultData, l)
 		idx := 0
@@ -304,6 +317,23 @@ func (rr *resultDataRepo) loadStartResultData() error {
 	return nil
 }
 
+// loadLegacyResultData - results stored before trainType was indexed aren't found by the trainType filter
+// until MigrateResultData re-puts them, so they are filtered after the symbol query
+func (rr *resultDataRepo) loadLegacyResultData() []entities.ResultData {
+	var dst []entities.ResultData
+	if _, err := rr.client.GetAll(context.Background(), datastore.NewQuery("ResultData").Filter("symbol=", rr.symbol).Order("-timestamp").Limit(rr.limit), &dst); err != nil {
+		log.Printf("loadLegacyResultData error: %v\n", err)
+		return nil
+	}
+	result := make([]entities.ResultData, 0, len(dst))
+	for _, item := range dst {
+		if item.TrainType == rr.trainType {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func (rr *resultDataRepo) insertNewResultData(data entities.ResultData) (*datastore.Key, error) {
 	ctx := context.Background()
 	return rr.client.Put(ctx, datastore.NewKey(ctx, "ResultData", data.GetCompositeKey(), 0, nil), &data)
@@ -311,14 +341,59 @@ func (rr *resultDataRepo) insertNewResultData(data entities.ResultData) (*datast
 
 func (rr *resultDataRepo) clearDataRepo(unixdate int64) error {
 	ctx := context.Background()
-	var keys []*datastore.Key
-	var err error
-	if keys, err = rr.client.GetAll(ctx, datastore.NewQuery("ResultData").Filter("symbol=", rr.symbol).Filter("timestamp<", unixdate).KeysOnly(), nil); err != nil {
+	// the train type is compared after the query, so the results stored before trainType was indexed are removed too
+	var dst []entities.ResultData
+	keys, err := rr.client.GetAll(ctx, datastore.NewQuery("ResultData").Filter("symbol=", rr.symbol).Filter("timestamp<", unixdate).Order("-timestamp"), &dst)
+	if err != nil {
 		log.Printf("clearDataRepo error: %v", err)
-		//return err
+		return err
+	}
+	var expired []*datastore.Key
+	for i, item := range dst {
+		if item.TrainType == rr.trainType {
+			expired = append(expired, keys[i])
+		}
 	}
-	if keys != nil {
-		return rr.client.DeleteMulti(ctx, keys)
+	if len(expired) > 0 {
+		return rr.client.DeleteMulti(ctx, expired)
+	}
+	return nil
+}
+
+// MigrateResultData re-put the results stored in [since, until) by the day, so the entities written
+// before trainType was indexed get the index entries required by the symbol and train type query.
+// Deploy order: create the indexes of index.yaml and wait until they are serving, deploy the application,
+// then run the migration (/api/migrate) once.
+func MigrateResultData(since, until int64, r *http.Request) (int, error) {
+	var ctx context.Context
+	if r != nil {
+		ctx = appengine.NewContext(r)
+	} else {
+		ctx = context.Background()
+	}
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	count := 0
+	for from := since; from < until; from += migrationStep {
+		var dst []entities.ResultData
+		keys, err := client.GetAll(ctx, datastore.NewQuery("ResultData").Filter("timestamp>=", from).Filter("timestamp<", from+migrationStep), &dst)
+		if err != nil {
+			return count, fmt.Errorf("migrate results from %d error: %v", from, err)
+		}
+		for i := 0; i < len(keys); i += migrationBatch {
+			j := i + migrationBatch
+			if j > len(keys) {
+				j = len(keys)
+			}
+			if _, err := client.PutMulti(ctx, keys[i:j], dst[i:j]); err != nil {
+				return count, fmt.Errorf("migrate results from %d error: %v", from, err)
+			}
+			count += j - i
+		}
 	}
-	return err
+	return count, nil
 }
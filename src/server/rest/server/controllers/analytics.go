@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction"
+	"pr.optima/src/core/statistic/correlation"
+)
+
+// correlationWindow - short window of the correlation, the long one is the cached history
+const correlationWindow = 24
+
+// principal components of the cached rates
+var _pca *entities.PcaResponse
+
+// correlation matrices of the cached rates
+var _correlation *entities.CorrelationResponse
+
+// Pca - return principal components of the cross-currency rate changes in requested format
+func Pca(w http.ResponseWriter, r *http.Request) {
+	format, found := processFormat(w, r)
+	if found == false {
+		return
+	}
+	if _pca != nil {
+		returnResult(w, *_pca, format)
+		return
+	}
+	returnError(w, "Data not exist for principal components.", http.StatusBadRequest, format)
+}
+
+func rebuildPca() error {
+	_pca = nil
+	window := len(_rates) - 1
+	if window > historyLimit {
+		window = historyLimit
+	}
+	components, err := prediction.PanelPca(_rates, prediction.PanelSymbols, window)
+	if err != nil {
+		return err
+	}
+	result := &entities.PcaResponse{
+		Timestamp: _rates[len(_rates)-1].ID,
+		Window:    int32(window),
+		Symbols:   components.Symbols}
+	for i, variance := range components.Variances {
+		result.Components = append(result.Components, entities.PcaComponent{
+			Variance:       variance,
+			ExplainedRatio: components.ExplainedRatio(i),
+			Loadings:       components.Loadings[i]})
+	}
+	_pca = result
+	return nil
+}
+
+// Correlation - return correlation matrices of the cross-currency rate changes in requested format
+func Correlation(w http.ResponseWriter, r *http.Request) {
+	format, found := processFormat(w, r)
+	if found == false {
+		return
+	}
+	if _correlation != nil {
+		returnResult(w, *_correlation, format)
+		return
+	}
+	returnError(w, "Data not exist for correlation.", http.StatusBadRequest, format)
+}
+
+func rebuildCorrelation() error {
+	_correlation = nil
+	window := len(_rates) - 1
+	if window > historyLimit {
+		window = historyLimit
+	}
+	windows := []int{window}
+	if window > correlationWindow {
+		windows = []int{correlationWindow, window}
+	}
+	result := &entities.CorrelationResponse{Symbols: prediction.PanelSymbols}
+	for _, size := range windows {
+		for _, method := range []string{correlation.MPearson, correlation.MSpearman} {
+			matrix, err := prediction.PanelCorrelation(_rates, prediction.PanelSymbols, size, method)
+			if err != nil {
+				return err
+			}
+			result.Matrices = append(result.Matrices, entities.CorrelationMatrix{
+				Window:       int32(size),
+				Method:       method,
+				Coefficients: matrix.Coefficients,
+				PValues:      matrix.PValues})
+		}
+	}
+	result.Timestamp = _rates[len(_rates)-1].ID
+	_correlation = result
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"pr.optima/src/core/entities"
+	"pr.optima/src/repository"
 )
 
 type operationFormat int
@@ -144,10 +145,35 @@ func ClearDB(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Migrate - re-put the results of the cleanup period, required once after trainType of ResultData was indexed
+func Migrate(w http.ResponseWriter, r *http.Request) {
+	authKey := r.Header.Get("Auth")
+	if authKey != _authKey {
+		returnError(w, "Request not authorized", http.StatusUnauthorized, _text)
+		return
+	}
+	now := time.Now().UTC()
+	count, err := repository.MigrateResultData(now.Add(time.Hour*24*(-31)).Unix(), now.Add(time.Hour).Unix(), r)
+	if err != nil {
+		returnError(w, fmt.Sprintf("Migrated: %d, error: %v", count, err), http.StatusInternalServerError, _text)
+		return
+	}
+	returnResult(w, fmt.Sprintf("success, migrated: %d", count), _text)
+}
+
 // ReloadData - update cached data from repo
 func ReloadData(r *http.Request) {
 	initializeRepo(r)
 	rebuildData()
+	if err := rebuildComparisons(r); err != nil {
+		log.Printf("rebuild comparisons error: %v", err)
+	}
+	if err := rebuildPca(); err != nil {
+		log.Printf("rebuild principal components error: %v", err)
+	}
+	if err := rebuildCorrelation(); err != nil {
+		log.Printf("rebuild correlation error: %v", err)
+	}
 }
 
 func returnCurrent(w http.ResponseWriter, format operationFormat, symbol string, set *entities.ResultDataResponse) {
@@ -205,19 +231,11 @@ func returnJSON(w http.ResponseWriter, obj interface{}) {
 }
 
 func processFormatAndSymbol(w http.ResponseWriter, r *http.Request) (operationFormat, string, bool) {
-	var format operationFormat
 	vars := mux.Vars(r)
 	symbol := strings.ToUpper(vars["symbol"])
 
-	switch strings.ToLower(vars["format"]) {
-	case "json":
-		format = _json
-	case "protobuf":
-		format = _protoBuf
-	case "text":
-		format = _text
-	default:
-		returnError(w, "Wrong return format. Required 'json', 'protobuf' or 'text'", http.StatusBadRequest, _text)
+	format, found := processFormat(w, r)
+	if found == false {
 		return _text, symbol, false
 	}
 
@@ -228,6 +246,19 @@ func processFormatAndSymbol(w http.ResponseWriter, r *http.Request) (operationFo
 	return _text, symbol, false
 }
 
+func processFormat(w http.ResponseWriter, r *http.Request) (operationFormat, bool) {
+	switch strings.ToLower(mux.Vars(r)["format"]) {
+	case "json":
+		return _json, true
+	case "protobuf":
+		return _protoBuf, true
+	case "text":
+		return _text, true
+	}
+	returnError(w, "Wrong return format. Required 'json', 'protobuf' or 'text'", http.StatusBadRequest, _text)
+	return _text, false
+}
+
 /*
 func getSymbol(r *http.Request) (string, error) {
 	vars := mux.Vars(r)
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/repository"
+)
+
+// comparedModel - efficiency key of the model processed by the fetch job
+type comparedModel struct {
+	trainType   string
+	rangesCount int32
+	limit       int32
+	frame       int32
+}
+
+var (
+	// scores of the network and the baselines per symbol
+	_comparisons map[string]*entities.CompareResponse
+	// models of the registered works per symbol
+	_comparedModels = make(map[string][]comparedModel)
+)
+
+// RegisterComparison add model of the work to the comparison of the symbol, it is called on the work registration
+func RegisterComparison(symbol, trainType string, rangesCount, limit, frame int32) {
+	_comparedModels[symbol] = append(_comparedModels[symbol], comparedModel{trainType, rangesCount, limit, frame})
+}
+
+// Compare - return efficiency of the MLP and the baseline predictors for requested symbol in requested format
+func Compare(w http.ResponseWriter, r *http.Request) {
+	format, symbol, found := processFormatAndSymbol(w, r)
+	if found == false {
+		return
+	}
+	if compare, ok := _comparisons[symbol]; ok {
+		returnResult(w, *compare, format)
+		return
+	}
+	returnError(w, fmt.Sprintf("Data not exist for symbol: %s.", symbol), http.StatusBadRequest, format)
+}
+
+// matches check that the efficiency is stored by the model
+func (f comparedModel) matches(eff entities.Efficiency) bool {
+	return eff.TrainType == f.trainType && eff.RangesCount == f.rangesCount && eff.Limit == f.limit && eff.Frame == f.frame
+}
+
+// rebuildComparisons load efficiencies of all the models by one query per symbol,
+// the previous comparisons are kept on error
+func rebuildComparisons(r *http.Request) error {
+	efficiencies, err := repository.LoadEfficiencies(_supportedSymbols, r)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]*entities.CompareResponse)
+	for _, symbol := range _supportedSymbols {
+		compare := &entities.CompareResponse{Symbol: symbol}
+		for _, model := range _comparedModels[symbol] {
+			for _, eff := range efficiencies[symbol] {
+				if model.matches(eff) {
+					compare.Models = append(compare.Models, entities.NewModelScore(eff))
+					break
+				}
+			}
+		}
+		result[symbol] = compare
+	}
+	_comparisons = result
+	return nil
+}
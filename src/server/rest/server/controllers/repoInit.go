@@ -13,10 +13,19 @@ import (
 	logAE "google.golang.org/appengine/log"
 
 	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction"
 	"pr.optima/src/repository"
 )
 
-const historyLimit = 100
+const (
+	historyLimit = 100
+	// advisor abstains when probability of the predicted class is less
+	advisorMinConfidence = 0.4
+)
+
+// _advisorModel - classifier network of the fetch job followed by the advisor, regression
+// networks have no class probabilities, so the advisor would never abstain on them
+var _advisorModel = prediction.ModelName(prediction.TTLbfgs, prediction.NTClassifier)
 
 var (
 	_initialized = false
@@ -58,66 +67,88 @@ var (
 	_jpyResultList *entities.ResultDataListResponse
 	_jpyResult     *entities.ResultDataResponse
 	_jpySignal     *entities.Signal
+	// results and efficiency of the advisor model per symbol
+	_advisorResultRepos map[string]repository.ResultDataRepo
+	_advisorEffRepos    map[string]repository.EfficiencyRepo
 )
 
 func initializeRepo(r *http.Request) {
 	_rateRepo = repository.New(historyLimit+5, false, r)
 	_rates = _rateRepo.GetAll()
 
-	_rubResultRepo = repository.NewResultDataRepo(historyLimit, false, "RUB", r)
-	_rubEffRepo = repository.NewEfficiencyRepo("L-BFGS", "RUB", 6, 20, 5, r)
+	_rubResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "RUB", r)
+	_rubEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "RUB", 6, 20, 5, r)
+
+	_eurResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "EUR", r)
+	_eurEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "EUR", 6, 20, 5, r)
 
-	_eurResultRepo = repository.NewResultDataRepo(historyLimit, false, "EUR", r)
-	_eurEffRepo = repository.NewEfficiencyRepo("L-BFGS", "EUR", 6, 20, 5, r)
+	_gbpResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "GBP", r)
+	_gbpEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "GBP", 6, 20, 5, r)
 
-	_gbpResultRepo = repository.NewResultDataRepo(historyLimit, false, "GBP", r)
-	_gbpEffRepo = repository.NewEfficiencyRepo("L-BFGS", "GBP", 6, 20, 5, r)
+	_chfResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "CHF", r)
+	_chfEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "CHF", 6, 20, 5, r)
 
-	_chfResultRepo = repository.NewResultDataRepo(historyLimit, false, "CHF", r)
-	_chfEffRepo = repository.NewEfficiencyRepo("L-BFGS", "CHF", 6, 20, 5, r)
+	_cnyResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "CNY", r)
+	_cnyEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "CNY", 6, 20, 5, r)
 
-	_cnyResultRepo = repository.NewResultDataRepo(historyLimit, false, "CNY", r)
-	_cnyEffRepo = repository.NewEfficiencyRepo("L-BFGS", "CNY", 6, 20, 5, r)
+	_jpyResultRepo = repository.NewResultDataRepo(historyLimit, false, prediction.TTLbfgs, "JPY", r)
+	_jpyEffRepo = repository.NewEfficiencyRepo(prediction.TTLbfgs, "JPY", 6, 20, 5, r)
 
-	_jpyResultRepo = repository.NewResultDataRepo(historyLimit, false, "JPY", r)
-	_jpyEffRepo = repository.NewEfficiencyRepo("L-BFGS", "JPY", 6, 20, 5, r)
+	// the repos of the previous initialization are closed, they hold datastore clients
+	for symbol, repo := range _advisorResultRepos {
+		repo.Close()
+		_advisorEffRepos[symbol].Close()
+	}
+	_advisorResultRepos = make(map[string]repository.ResultDataRepo)
+	_advisorEffRepos = make(map[string]repository.EfficiencyRepo)
+	for _, symbol := range _supportedSymbols {
+		_advisorResultRepos[symbol] = repository.NewResultDataRepo(historyLimit, false, _advisorModel, symbol, r)
+		_advisorEffRepos[symbol] = repository.NewEfficiencyRepo(_advisorModel, symbol, 6, 20, 5, r)
+	}
 
 	_initialized = true
 }
 
 func rebuildData() error {
+	_rubSignal = populateSignal(_advisorResultRepos["RUB"], _advisorEffRepos["RUB"])
+	_eurSignal = populateSignal(_advisorResultRepos["EUR"], _advisorEffRepos["EUR"])
+	_gbpSignal = populateSignal(_advisorResultRepos["GBP"], _advisorEffRepos["GBP"])
+	_chfSignal = populateSignal(_advisorResultRepos["CHF"], _advisorEffRepos["CHF"])
+	_cnySignal = populateSignal(_advisorResultRepos["CNY"], _advisorEffRepos["CNY"])
+	_jpySignal = populateSignal(_advisorResultRepos["JPY"], _advisorEffRepos["JPY"])
+
 	var err error
-	_rubResultList, _rubResult, _rubSignal, err = populateSet(_rubResultRepo, _rubEffRepo)
+	_rubResultList, _rubResult, err = populateSet(_rubResultRepo, _rubEffRepo)
 
 	if err != nil {
 		return err
 	}
 
-	_eurResultList, _eurResult, _eurSignal, err = populateSet(_eurResultRepo, _eurEffRepo)
+	_eurResultList, _eurResult, err = populateSet(_eurResultRepo, _eurEffRepo)
 
 	if err != nil {
 		return err
 	}
 
-	_gbpResultList, _gbpResult, _gbpSignal, err = populateSet(_gbpResultRepo, _gbpEffRepo)
+	_gbpResultList, _gbpResult, err = populateSet(_gbpResultRepo, _gbpEffRepo)
 
 	if err != nil {
 		return err
 	}
 
-	_chfResultList, _chfResult, _chfSignal, err = populateSet(_chfResultRepo, _chfEffRepo)
+	_chfResultList, _chfResult, err = populateSet(_chfResultRepo, _chfEffRepo)
 
 	if err != nil {
 		return err
 	}
 
-	_cnyResultList, _cnyResult, _cnySignal, err = populateSet(_cnyResultRepo, _cnyEffRepo)
+	_cnyResultList, _cnyResult, err = populateSet(_cnyResultRepo, _cnyEffRepo)
 
 	if err != nil {
 		return err
 	}
 
-	_jpyResultList, _jpyResult, _jpySignal, err = populateSet(_jpyResultRepo, _jpyEffRepo)
+	_jpyResultList, _jpyResult, err = populateSet(_jpyResultRepo, _jpyEffRepo)
 
 	if err != nil {
 		return err
@@ -126,10 +157,10 @@ func rebuildData() error {
 	return nil
 }
 
-func populateSet(resultRepo repository.ResultDataRepo, effRepo repository.EfficiencyRepo) (*entities.ResultDataListResponse, *entities.ResultDataResponse, *entities.Signal, error) {
+func populateSet(resultRepo repository.ResultDataRepo, effRepo repository.EfficiencyRepo) (*entities.ResultDataListResponse, *entities.ResultDataResponse, error) {
 	eff, found := effRepo.GetLast()
 	if !found {
-		return nil, nil, nil, errors.New("populateSet error, in get last EFF not found")
+		return nil, nil, errors.New("populateSet error, in get last EFF not found")
 	}
 	score10, score100 := get10_100Score(eff)
 	results := resultRepo.GetAll()
@@ -152,22 +183,12 @@ func populateSet(resultRepo repository.ResultDataRepo, effRepo repository.Effici
 		result.Levels = results[i].RangesCount
 
 		if err := populateDataFor(result.Timestamp, results[i].Step, results[i].Symbol, results, result); err != nil {
-			return nil, nil, nil, fmt.Errorf("PopulateSet error, in populate data: %v.", err)
+			return nil, nil, fmt.Errorf("PopulateSet error, in populate data: %v.", err)
 		}
 		result.Symbol = results[i].Symbol
 		resultSet[limit-counter] = *result
 		counter++
 	}
-	var signal *entities.Signal
-	if last, found := resultRepo.GetLast(); found {
-		signal = new(entities.Signal)
-		signal.RangesCount = last.RangesCount
-		signal.Symbol = last.Symbol
-		signal.Timestamp = last.Timestamp
-		signal.Prediction = last.Prediction
-		signal.Score10 = score10
-		signal.Score100 = score100
-	}
 	retList := new(entities.ResultDataListResponse)
 	retList.Data = resultSet
 	retList.Score10 = score10
@@ -178,7 +199,28 @@ func populateSet(resultRepo repository.ResultDataRepo, effRepo repository.Effici
 	retCurrent.Score10 = score10
 	retCurrent.Score100 = score100
 
-	return retList, retCurrent, signal, nil
+	return retList, retCurrent, nil
+}
+
+// populateSignal return signal of the last result of the advisor model, nil if there are no results,
+// the signal abstains when the confidence of the predicted class is low
+func populateSignal(resultRepo repository.ResultDataRepo, effRepo repository.EfficiencyRepo) *entities.Signal {
+	last, found := resultRepo.GetLast()
+	if !found {
+		return nil
+	}
+	signal := new(entities.Signal)
+	signal.RangesCount = last.RangesCount
+	signal.Symbol = last.Symbol
+	signal.Timestamp = last.Timestamp
+	signal.Prediction = last.Prediction
+	signal.Probabilities = last.Probabilities
+	signal.Confidence = last.Confidence
+	if eff, found := effRepo.GetLast(); found {
+		signal.Score10, signal.Score100 = get10_100Score(eff)
+	}
+	signal.UpdateAbstain(advisorMinConfidence)
+	return signal
 }
 
 func get10_100Score(eff entities.Efficiency) (float32, float32) {
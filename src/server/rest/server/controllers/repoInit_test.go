@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"pr.optima/src/core/entities"
+	"pr.optima/src/repository"
+)
+
+// TestPopulateSignal - the advisor abstains on the classifier result with low confidence
+func TestPopulateSignal(t *testing.T) {
+	effRepo := repository.NewMemoryEfficiencyRepo(_advisorModel, "EUR", 6, 20, 5)
+	resultRepo := repository.NewMemoryResultDataRepo(historyLimit, false, _advisorModel, "EUR")
+	if signal := populateSignal(resultRepo, effRepo); signal != nil {
+		t.Fatalf("signal without results: %v", signal.ToString())
+	}
+
+	cases := []struct {
+		probabilities []float64
+		abstain       bool
+	}{
+		{[]float64{0.3, 0.25, 0.2, 0.1, 0.1, 0.05}, true},
+		{[]float64{0.05, 0.8, 0.1, 0.05, 0, 0}, false},
+	}
+	for i, c := range cases {
+		prediction, confidence := int32(0), 0.0
+		for k, p := range c.probabilities {
+			if p > confidence {
+				prediction, confidence = int32(k), p
+			}
+		}
+		if err := resultRepo.Push(entities.ResultData{RangesCount: 6, Limit: 20, TrainType: _advisorModel, Symbol: "EUR",
+			Timestamp: 1500000000 + int64(i)*3600, Prediction: prediction, Probabilities: c.probabilities, Confidence: confidence, Result: -1}); err != nil {
+			t.Fatal(err)
+		}
+		signal := populateSignal(resultRepo, effRepo)
+		if signal == nil || signal.Prediction != prediction || signal.Confidence != confidence || signal.Abstain != c.abstain {
+			t.Fatalf("case %d, signal: %+v", i, signal)
+		}
+		buf, err := json.Marshal(signal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf(`"abstain":%t`, c.abstain); !strings.Contains(string(buf), expected) {
+			t.Errorf("case %d, json: %s, expected %s", i, buf, expected)
+		}
+	}
+}
@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"time"
 
+	"golang.org/x/net/context"
 	"google.golang.org/appengine"
 	logAE "google.golang.org/appengine/log"
 	"google.golang.org/appengine/urlfetch"
 
 	"pr.optima/src/core/entities"
+	"pr.optima/src/core/prediction"
 	"pr.optima/src/repository"
 	"pr.optima/src/server/rest/server/controllers"
 )
@@ -20,22 +24,108 @@ const (
 	source2Url = "http://www.apilayer.net/api/live?access_key=85c7d5e8f98fe83fa3fa81aafe489022&currencies=RUB,JPY,GBP,USD,EUR,CNY,CHF" //"85c7d5e8f98fe83fa3fa81aafe489022"
 	//appEngineUrl = "https://rp-optima.appspot.com/api/refresh"
 	repoSize = 200
+	// works processed concurrently and time limit of the one work
+	workersCount = 4
+	workTimeout  = 3 * time.Minute
 )
 
 //const authKey = "B7C05147C5A34376B30CEF2F289FBB6C"
 var (
 	//_repo repository.RateRepo
-	works map[string]*fetchRatesWorkItem
+	works   map[string]*fetchRatesWorkItem
+	symbols = []string{"RUB", "EUR", "GBP", "JPY", "CNY", "CHF"}
 )
 
 func init() {
 	works = make(map[string]*fetchRatesWorkItem)
-	works["RUB"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "RUB")
-	works["EUR"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "EUR")
-	works["GBP"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "GBP")
-	works["JPY"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "JPY")
-	works["CNY"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "CNY")
-	works["CHF"] = newFetchRatesWorkItem(6, 5, 20, 1, TTLbfgs, "CHF")
+	// every symbol is processed by each registered train type, efficiency is tracked per train type
+	for _, trainType := range prediction.TrainTypes() {
+		for _, symbol := range symbols {
+			addWork(prediction.NewEngine(6, 5, 20, trainType, prediction.NTRegression, symbol))
+		}
+	}
+	// classifier networks with probabilities per range class
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTLbfgs, prediction.NTClassifier, symbol))
+	}
+	// bagging ensembles, mean of the networks trained on the bootstrap samples
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTEnsembleLbfgs, prediction.NTRegression, symbol))
+	}
+	// Markov chain of the classes, interpretable probabilities of the next class
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTMarkov, prediction.NTClassifier, symbol))
+	}
+	// random decision forests, votes of the trees per range class
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTForest, prediction.NTClassifier, symbol))
+	}
+	// multinomial logit models, cheap calibrated probabilities per range class
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTMnl, prediction.NTClassifier, symbol))
+	}
+	// Fisher LDA classifiers, the class means in the directions which best separate the classes
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTLda, prediction.NTClassifier, symbol))
+	}
+	// analog forecasters, the classes which followed the nearest past windows
+	for _, symbol := range symbols {
+		addWork(prediction.NewEngine(6, 5, 20, prediction.TTAnalog, prediction.NTClassifier, symbol))
+	}
+	// naive and statistical baselines with the same ranges and frame, the networks must beat them
+	for _, trainType := range prediction.Baselines() {
+		for _, symbol := range symbols {
+			addWork(prediction.NewEngine(6, 5, 20, trainType, prediction.NTRegression, symbol))
+		}
+	}
+	// networks with smoothed deltas, volatility, time and correlated symbol classes as inputs
+	for _, symbol := range symbols {
+		engine, err := prediction.NewEngine(6, 5, 50, prediction.TTLbfgs, prediction.NTRegression, symbol)
+		if err == nil {
+			engine, err = engine.WithFeatures("FEAT", featureSpecs(symbol)...)
+		}
+		addWork(engine, err)
+	}
+	// classifier networks with the same features projected by Fisher LDA onto the 3 best separating directions
+	for _, symbol := range symbols {
+		engine, err := prediction.NewEngine(6, 5, 50, prediction.TTLbfgs, prediction.NTClassifier, symbol)
+		if err == nil {
+			engine, err = engine.WithFeatures("FEAT", featureSpecs(symbol)...)
+		}
+		if err == nil {
+			engine, err = engine.WithProjection(3)
+		}
+		addWork(engine, err)
+	}
+}
+
+// featureSpecs return smoothed deltas, volatility, time and correlated symbol classes features of the symbol
+func featureSpecs(symbol string) []prediction.FeatureSpec {
+	correlated := "EUR"
+	if symbol == correlated {
+		correlated = "CHF"
+	}
+	return []prediction.FeatureSpec{
+		{Kind: prediction.FKClasses, Window: 5},
+		{Kind: prediction.FKSma, Window: 5},
+		{Kind: prediction.FKMedian, Window: 3},
+		{Kind: prediction.FKVolatility, Window: 10},
+		{Kind: prediction.FKHour},
+		{Kind: prediction.FKWeekday},
+		{Kind: prediction.FKClasses, Symbol: correlated, Window: 3}}
+}
+
+// addWork add engine with the volatility regimes, so the efficiency of each work is broken down per regime,
+// the model of the work is added to the comparison
+func addWork(engine *prediction.Engine, err error) {
+	if err == nil {
+		engine, err = engine.WithRegimes(prediction.RKVolatility, 3, 5)
+	}
+	if err != nil {
+		log.Fatalf("create work error: %v", err)
+	}
+	works[fmt.Sprintf("%s_%s", engine.Symbol(), engine.Model())] = newFetchRatesWorkItem(engine)
+	controllers.RegisterComparison(engine.Symbol(), engine.Model(), int32(engine.RangeCount()), int32(engine.Limit), int32(engine.Frame()))
 }
 
 // FetchRatesJob - method get rates data from open suorce
@@ -69,20 +159,35 @@ func executeDomainLogic(w http.ResponseWriter, r *http.Request) {
 	repo := repository.New(repoSize, true, r)
 	rates := repo.GetAll()
 
-	for key, work := range works {
-		if work.Limit < len(rates) {
-			_, err := work.Process(rates, r)
-
-			if err != nil {
-				if r != nil {
-					ctx := appengine.NewContext(r)
-					logAE.Warningf(ctx, "Error: %v", fmt.Errorf("%s executeDomainLogic error: %v", key, err))
-				} else {
-					log.Printf("%s executeDomainLogic error: %v", key, err)
-				}
-			}
+	keys := make([]string, 0, len(works))
+	for key := range works {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var tasks []prediction.Task
+	for _, key := range keys {
+		work := works[key]
+		if work.engine.Limit < len(rates) {
+			tasks = append(tasks, prediction.Task{Name: key, Run: func(ctx context.Context) (int, error) {
+				return work.Process(ctx, rates, r)
+			}})
+		}
+	}
+
+	report := prediction.RunTasks(tasks, workersCount, workTimeout)
+	if err := report.Error(); err != nil {
+		if r != nil {
+			ctx := appengine.NewContext(r)
+			logAE.Warningf(ctx, "executeDomainLogic error: %v", err)
+		} else {
+			log.Printf("executeDomainLogic error: %v", err)
 		}
 	}
+	if r != nil {
+		logAE.Infof(appengine.NewContext(r), "executeDomainLogic: %s", report.ToString())
+	} else {
+		log.Printf("executeDomainLogic: %s", report.ToString())
+	}
 
 	controllers.ReloadData(r)
 
@@ -23,7 +23,11 @@ var routes = Routes{
 	Route{"GetCurrentData", "GET", "/api/{format}/{symbol}/current", controllers.Current},
 	Route{"GetAllData", "GET", "/api/{format}/{symbol}/all", controllers.All},
 	Route{"GetAdvisor", "GET", "/api/{format}/{symbol}/advisor", controllers.Advisor},
+	Route{"GetCompare", "GET", "/api/{format}/{symbol}/compare", controllers.Compare},
+	Route{"GetPca", "GET", "/api/{format}/analytics/pca", controllers.Pca},
+	Route{"GetCorrelation", "GET", "/api/{format}/analytics/correlation", controllers.Correlation},
 	Route{"RefreshData", "GET", "/api/refresh", controllers.Refresh},
 	Route{"CleanData", "GET", "/api/clean", controllers.ClearDB},
+	Route{"MigrateData", "GET", "/api/migrate", controllers.Migrate},
 	Route{"FetchRates", "GET", "/jobs/fetch-rates", jobs.FetchRatesJob},
 }